@@ -0,0 +1,237 @@
+// Package archive writes a group's data to a zip archive and reads it back.
+// Every collection is stored as JSON, which imports read, and as CSV for
+// spreadsheets.
+package archive
+
+import (
+	"archive/zip"
+	"cribb-backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Format identifies Cribb group archives in their manifest
+	Format = "cribb-group-export"
+
+	// Version is the archive layout this package writes
+	Version = 1
+
+	// ManifestFile describes the archive and is written first
+	ManifestFile = "manifest.json"
+
+	// GroupFile holds the exported group
+	GroupFile = "group.json"
+
+	// maxEntrySize caps how much one archive entry may expand to
+	maxEntrySize = 64 << 20
+)
+
+// ErrInvalidArchive is returned when an archive can't be imported
+var ErrInvalidArchive = errors.New("invalid group archive")
+
+// Manifest describes an archive
+type Manifest struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	GroupName  string         `json:"group_name"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// Data is everything an archive holds about a group
+type Data struct {
+	Group               models.Group
+	Members             []models.User
+	Chores              []models.Chore
+	RecurringChores     []models.RecurringChore
+	ChoreCompletions    []models.ChoreCompletion
+	PantryItems         []models.PantryItem
+	PantryHistory       []models.PantryHistory
+	Notifications       []models.Notification
+	PantryNotifications []models.PantryNotification
+	ShoppingCart        []models.ShoppingCartItem
+}
+
+// section is one collection in the archive, stored as <name>.json and <name>.csv
+type section struct {
+	name   string
+	value  func(d *Data) interface{} // Pointer to the slice, for decoding
+	count  func(d *Data) int
+	ids    func(d *Data) (ids, groupIDs []primitive.ObjectID) // groupIDs is nil for records without one
+	header []string
+	rows   func(d *Data) [][]string
+}
+
+// Write writes the data as a zip archive
+func Write(w io.Writer, d *Data, now time.Time) error {
+	manifest := Manifest{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: now,
+		GroupName:  d.Group.Name,
+		Counts:     d.Counts(),
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeJSON(zw, ManifestFile, manifest, now); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, GroupFile, d.Group, now); err != nil {
+		return err
+	}
+	for _, s := range sections {
+		if err := writeJSON(zw, s.name+".json", s.value(d), now); err != nil {
+			return err
+		}
+		if err := writeCSV(zw, s.name+".csv", s.header, s.rows(d), now); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Counts returns how many records each collection has
+func (d *Data) Counts() map[string]int {
+	counts := make(map[string]int, len(sections))
+	for _, s := range sections {
+		counts[s.name] = s.count(d)
+	}
+	return counts
+}
+
+func createEntry(zw *zip.Writer, name string, now time.Time) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}, now time.Time) error {
+	entry, err := createEntry(zw, name, now)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeCSV(zw *zip.Writer, name string, header []string, rows [][]string, now time.Time) error {
+	entry, err := createEntry(zw, name, now)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(entry)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Read reads an archive written by Write. CSV files are ignored; collections
+// missing from the archive are left empty.
+func Read(r io.ReaderAt, size int64) (*Data, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var manifest Manifest
+	if err := readJSON(files, ManifestFile, &manifest, true); err != nil {
+		return nil, err
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("%w: not a Cribb group export", ErrInvalidArchive)
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, manifest.Version)
+	}
+
+	d := &Data{}
+	if err := readJSON(files, GroupFile, &d.Group, true); err != nil {
+		return nil, err
+	}
+	for _, s := range sections {
+		if err := readJSON(files, s.name+".json", s.value(d), false); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func readJSON(files map[string]*zip.File, name string, v interface{}, required bool) error {
+	f, ok := files[name]
+	if !ok {
+		if required {
+			return fmt.Errorf("%w: missing %s", ErrInvalidArchive, name)
+		}
+		return nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+	defer rc.Close()
+
+	// Don't trust the sizes in the zip's headers
+	data, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+	if len(data) > maxEntrySize {
+		return fmt.Errorf("%w: %s is too large", ErrInvalidArchive, name)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+	return nil
+}
+
+// validate checks the records belong to the exported group and that no
+// collection repeats an ID, which would collide once remapped
+func (d *Data) validate() error {
+	if d.Group.Name == "" {
+		return fmt.Errorf("%w: the group has no name", ErrInvalidArchive)
+	}
+
+	check := func(collection string, ids []primitive.ObjectID, groupIDs []primitive.ObjectID) error {
+		seen := make(map[primitive.ObjectID]bool, len(ids))
+		for i, id := range ids {
+			if id.IsZero() {
+				return fmt.Errorf("%w: a record in %s has no ID", ErrInvalidArchive, collection)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: %s repeats ID %s", ErrInvalidArchive, collection, id.Hex())
+			}
+			seen[id] = true
+			if groupIDs != nil && groupIDs[i] != d.Group.ID {
+				return fmt.Errorf("%w: %s %s belongs to another group", ErrInvalidArchive, collection, id.Hex())
+			}
+		}
+		return nil
+	}
+
+	for _, s := range sections {
+		ids, groupIDs := s.ids(d)
+		if err := check(s.name, ids, groupIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
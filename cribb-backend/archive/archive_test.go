@@ -0,0 +1,184 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"cribb-backend/archive"
+	"cribb-backend/models"
+	"encoding/csv"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sampleData builds a small group with records that reference each other
+func sampleData() *archive.Data {
+	group := models.NewGroup("Maple House")
+	group.ID = primitive.NewObjectID()
+	alice := models.User{ID: primitive.NewObjectID(), Username: "alice", Name: "Alice", Password: "hash", GroupID: group.ID}
+	bob := models.User{ID: primitive.NewObjectID(), Username: "bob", Name: "Bob", GroupID: group.ID}
+	group.Members = []primitive.ObjectID{alice.ID, bob.ID}
+
+	recurring := models.CreateRecurringChore("Trash", "", group.ID, []primitive.ObjectID{alice.ID, bob.ID}, "weekly", 5)
+	recurring.ID = primitive.NewObjectID()
+	recurring.CurrentIndex = 1
+	chore := models.CreateChoreFromRecurring(recurring)
+	chore.ID = primitive.NewObjectID()
+
+	item := models.CreatePantryItem(group.ID, "Milk", 2, "l", "Dairy", time.Time{}, alice.ID)
+	item.ID = primitive.NewObjectID()
+	item.Batches = []models.PantryBatch{models.CreatePantryBatch(2, time.Time{}, bob.ID)}
+	history := models.CreatePantryHistory(group.ID, item.ID, "Milk", bob.ID, "Bob", models.ActionTypeAdd, 2, "")
+	history.ID = primitive.NewObjectID()
+	removed := models.CreatePantryHistory(group.ID, primitive.NewObjectID(), "Eggs", alice.ID, "Alice", models.ActionTypeRemove, 6, "")
+	removed.ID = primitive.NewObjectID()
+
+	notification := models.CreatePantryNotification(group.ID, item.ID, "Milk", models.NotificationTypeLowStock, "Milk is low")
+	notification.ID = primitive.NewObjectID()
+
+	cartItem := models.CreateShoppingCartItem(bob.ID, group.ID, "Bread, sliced", 1, "Bakery")
+	cartItem.ID = primitive.NewObjectID()
+	cartItem.ListID = primitive.NewObjectID()
+
+	return &archive.Data{
+		Group:           *group,
+		Members:         []models.User{alice, bob},
+		Chores:          []models.Chore{*chore},
+		RecurringChores: []models.RecurringChore{*recurring},
+		ChoreCompletions: []models.ChoreCompletion{
+			{ID: primitive.NewObjectID(), ChoreID: chore.ID, UserID: bob.ID, Points: 5},
+		},
+		PantryItems:         []models.PantryItem{*item},
+		PantryHistory:       []models.PantryHistory{*history, *removed},
+		PantryNotifications: []models.PantryNotification{*notification},
+		ShoppingCart:        []models.ShoppingCartItem{*cartItem},
+	}
+}
+
+func writeArchive(t *testing.T, d *archive.Data) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, d, time.Now()); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteAndRead(t *testing.T) {
+	d := sampleData()
+	data := writeArchive(t, d)
+
+	read, err := archive.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	if read.Group.Name != "Maple House" || len(read.Members) != 2 || len(read.PantryHistory) != 2 {
+		t.Errorf("Expected the archive to round-trip, got %+v", read)
+	}
+	if read.Members[0].Password != "" {
+		t.Error("Expected passwords to be left out of the archive")
+	}
+	if read.PantryItems[0].Batches[0].ID != d.PantryItems[0].Batches[0].ID {
+		t.Error("Expected pantry batches to round-trip")
+	}
+
+	// Every collection also has a CSV with a header row and a row per record
+	zr, _ := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	for _, f := range zr.File {
+		if f.Name != "shopping_cart.csv" {
+			continue
+		}
+		rc, _ := f.Open()
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		if len(rows) != 2 || rows[0][0] != "id" || rows[1][2] != "Bread, sliced" {
+			t.Errorf("Unexpected shopping cart CSV: %v", rows)
+		}
+		return
+	}
+	t.Error("Expected shopping_cart.csv in the archive")
+}
+
+func TestReadRejectsInvalidArchives(t *testing.T) {
+	if _, err := archive.Read(bytes.NewReader([]byte("not a zip")), 9); !errors.Is(err, archive.ErrInvalidArchive) {
+		t.Errorf("Expected ErrInvalidArchive for a non-zip, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	entry, _ := zw.Create(archive.ManifestFile)
+	io.WriteString(entry, `{"format":"something-else","version":1}`)
+	zw.Close()
+	if _, err := archive.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len())); !errors.Is(err, archive.ErrInvalidArchive) {
+		t.Errorf("Expected ErrInvalidArchive for another format, got %v", err)
+	}
+
+	// Records from another group
+	d := sampleData()
+	d.Chores[0].GroupID = primitive.NewObjectID()
+	data := writeArchive(t, d)
+	if _, err := archive.Read(bytes.NewReader(data), int64(len(data))); !errors.Is(err, archive.ErrInvalidArchive) {
+		t.Errorf("Expected ErrInvalidArchive for a chore from another group, got %v", err)
+	}
+
+	// Records without IDs
+	d = sampleData()
+	d.PantryNotifications[0].ID = primitive.NilObjectID
+	data = writeArchive(t, d)
+	if _, err := archive.Read(bytes.NewReader(data), int64(len(data))); !errors.Is(err, archive.ErrInvalidArchive) {
+		t.Errorf("Expected ErrInvalidArchive for a record without an ID, got %v", err)
+	}
+}
+
+func TestRemap(t *testing.T) {
+	d := sampleData()
+	alice := d.Members[0].ID
+	oldChore, oldItem := d.Chores[0], d.PantryItems[0]
+	oldBatch := oldItem.Batches[0]
+
+	groupID := primitive.NewObjectID()
+	importer := primitive.NewObjectID()
+	d.Remap(groupID, archive.UserMap{Users: map[primitive.ObjectID]primitive.ObjectID{alice: importer}, Fallback: importer})
+
+	chore := d.Chores[0]
+	if chore.ID == oldChore.ID || chore.ID.IsZero() || chore.GroupID != groupID {
+		t.Errorf("Expected the chore to get a new ID in the new group, got %+v", chore)
+	}
+	if chore.RecurringID != d.RecurringChores[0].ID {
+		t.Error("Expected the chore to keep pointing at its recurring chore")
+	}
+	if d.ChoreCompletions[0].ChoreID != chore.ID || d.ChoreCompletions[0].UserID != importer {
+		t.Errorf("Expected the completion to point at the remapped chore and user, got %+v", d.ChoreCompletions[0])
+	}
+
+	// Alice and Bob both map to the importer, so the rotation collapses
+	rc := d.RecurringChores[0]
+	if len(rc.MemberRotation) != 1 || rc.MemberRotation[0] != importer || rc.CurrentIndex != 0 {
+		t.Errorf("Expected a rotation of just the importer, got %v at %d", rc.MemberRotation, rc.CurrentIndex)
+	}
+
+	item := d.PantryItems[0]
+	if item.ID == oldItem.ID || item.AddedBy != importer || item.Batches[0].PurchasedBy != importer {
+		t.Errorf("Expected the pantry item to be remapped, got %+v", item)
+	}
+	if item.Batches[0].ID == oldBatch.ID {
+		t.Error("Expected batches to get new IDs")
+	}
+	if d.PantryHistory[0].ItemID != item.ID || d.PantryNotifications[0].ItemID != item.ID {
+		t.Error("Expected history and notifications to point at the remapped item")
+	}
+	if removed := d.PantryHistory[1].ItemID; removed.IsZero() || removed == item.ID {
+		t.Errorf("Expected history of a removed item to get its own new ID, got %s", removed.Hex())
+	}
+
+	cart := d.ShoppingCart[0]
+	if cart.UserID != importer || cart.GroupID != groupID || !cart.ListID.IsZero() {
+		t.Errorf("Expected the cart item on the new group's default list, got %+v", cart)
+	}
+}
@@ -0,0 +1,135 @@
+package archive
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IDMap gives each exported ID a new one, returning the same new ID every
+// time an old one is looked up. Unset IDs stay unset.
+type IDMap map[primitive.ObjectID]primitive.ObjectID
+
+// Get returns the new ID for an exported one
+func (m IDMap) Get(old primitive.ObjectID) primitive.ObjectID {
+	if old.IsZero() {
+		return primitive.NilObjectID
+	}
+	id, ok := m[old]
+	if !ok {
+		id = primitive.NewObjectID()
+		m[old] = id
+	}
+	return id
+}
+
+// UserMap maps exported user IDs to existing accounts. Users it doesn't
+// know, like members who left before the export, map to Fallback.
+type UserMap struct {
+	Users    map[primitive.ObjectID]primitive.ObjectID
+	Fallback primitive.ObjectID
+}
+
+// Get returns the account an exported user ID maps to
+func (m UserMap) Get(old primitive.ObjectID) primitive.ObjectID {
+	if old.IsZero() {
+		return primitive.NilObjectID
+	}
+	if id, ok := m.Users[old]; ok {
+		return id
+	}
+	return m.Fallback
+}
+
+// getAll maps a list of users, dropping duplicates created by the mapping
+func (m UserMap) getAll(old []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(old))
+	mapped := make([]primitive.ObjectID, 0, len(old))
+	for _, id := range old {
+		id = m.Get(id)
+		if !id.IsZero() && !seen[id] {
+			seen[id] = true
+			mapped = append(mapped, id)
+		}
+	}
+	return mapped
+}
+
+// Remap gives every record a new ID and moves it into the group, keeping the
+// references between records intact. User references go through users.
+// Members and the group itself are left for the caller, which decides who
+// belongs to the new group.
+func (d *Data) Remap(groupID primitive.ObjectID, users UserMap) {
+	ids := IDMap{}
+
+	for i := range d.Chores {
+		c := &d.Chores[i]
+		c.ID = ids.Get(c.ID)
+		c.GroupID = groupID
+		c.AssignedTo = users.Get(c.AssignedTo)
+		c.RecurringID = ids.Get(c.RecurringID)
+	}
+
+	for i := range d.RecurringChores {
+		rc := &d.RecurringChores[i]
+		rc.ID = ids.Get(rc.ID)
+		rc.GroupID = groupID
+		rc.MemberRotation = users.getAll(rc.MemberRotation)
+		if len(rc.MemberRotation) > 0 {
+			rc.CurrentIndex %= len(rc.MemberRotation)
+		} else {
+			rc.CurrentIndex = 0
+		}
+	}
+
+	for i := range d.ChoreCompletions {
+		c := &d.ChoreCompletions[i]
+		c.ID = ids.Get(c.ID)
+		c.ChoreID = ids.Get(c.ChoreID)
+		c.UserID = users.Get(c.UserID)
+	}
+
+	for i := range d.PantryItems {
+		p := &d.PantryItems[i]
+		p.ID = ids.Get(p.ID)
+		p.GroupID = groupID
+		p.AddedBy = users.Get(p.AddedBy)
+		for j := range p.Batches {
+			p.Batches[j].ID = ids.Get(p.Batches[j].ID)
+			p.Batches[j].PurchasedBy = users.Get(p.Batches[j].PurchasedBy)
+		}
+	}
+
+	for i := range d.PantryHistory {
+		h := &d.PantryHistory[i]
+		h.ID = ids.Get(h.ID)
+		h.GroupID = groupID
+		h.ItemID = ids.Get(h.ItemID) // Removed items keep a consistent ID across their history
+		h.UserID = users.Get(h.UserID)
+	}
+
+	for i := range d.Notifications {
+		n := &d.Notifications[i]
+		n.ID = ids.Get(n.ID)
+		n.GroupID = groupID
+		n.UserID = users.Get(n.UserID)
+		n.ActorID = users.Get(n.ActorID)
+		n.SubjectID = ids.Get(n.SubjectID)
+		n.ReadBy = users.getAll(n.ReadBy)
+	}
+
+	for i := range d.PantryNotifications {
+		n := &d.PantryNotifications[i]
+		n.ID = ids.Get(n.ID)
+		n.GroupID = groupID
+		n.ItemID = ids.Get(n.ItemID)
+		n.BatchID = ids.Get(n.BatchID)
+		n.ReadBy = users.getAll(n.ReadBy)
+	}
+
+	for i := range d.ShoppingCart {
+		s := &d.ShoppingCart[i]
+		s.ID = ids.Get(s.ID)
+		s.GroupID = groupID
+		s.UserID = users.Get(s.UserID)
+		s.ListID = primitive.NilObjectID // Shopping lists aren't exported, so items go on the default list
+	}
+}
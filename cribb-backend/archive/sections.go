@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"cribb-backend/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sections lists the collections in the order they're written
+var sections = []section{
+	newSection("members",
+		func(d *Data) *[]models.User { return &d.Members },
+		func(u models.User) (primitive.ObjectID, *primitive.ObjectID) { return u.ID, &u.GroupID },
+		[]string{"id", "username", "name", "phone_number", "room_number", "score", "created_at"},
+		func(u models.User) []string {
+			return []string{u.ID.Hex(), u.Username, u.Name, u.PhoneNumber, u.RoomNumber, strconv.Itoa(u.Score), formatTime(u.CreatedAt)}
+		},
+	),
+	newSection("chores",
+		func(d *Data) *[]models.Chore { return &d.Chores },
+		func(c models.Chore) (primitive.ObjectID, *primitive.ObjectID) { return c.ID, &c.GroupID },
+		[]string{"id", "title", "description", "type", "assigned_to", "status", "points", "start_date", "due_date", "recurring_id", "created_at", "updated_at"},
+		func(c models.Chore) []string {
+			return []string{c.ID.Hex(), c.Title, c.Description, string(c.Type), formatID(c.AssignedTo), string(c.Status), strconv.Itoa(c.Points),
+				formatTime(c.StartDate), formatTime(c.DueDate), formatID(c.RecurringID), formatTime(c.CreatedAt), formatTime(c.UpdatedAt)}
+		},
+	),
+	newSection("recurring_chores",
+		func(d *Data) *[]models.RecurringChore { return &d.RecurringChores },
+		func(rc models.RecurringChore) (primitive.ObjectID, *primitive.ObjectID) { return rc.ID, &rc.GroupID },
+		[]string{"id", "title", "description", "frequency", "member_rotation", "current_index", "points", "next_assignment", "is_active", "created_at"},
+		func(rc models.RecurringChore) []string {
+			rotation := make([]string, len(rc.MemberRotation))
+			for i, member := range rc.MemberRotation {
+				rotation[i] = member.Hex()
+			}
+			return []string{rc.ID.Hex(), rc.Title, rc.Description, rc.Frequency, strings.Join(rotation, " "), strconv.Itoa(rc.CurrentIndex),
+				strconv.Itoa(rc.Points), formatTime(rc.NextAssignment), strconv.FormatBool(rc.IsActive), formatTime(rc.CreatedAt)}
+		},
+	),
+	newSection("chore_completions",
+		func(d *Data) *[]models.ChoreCompletion { return &d.ChoreCompletions },
+		func(c models.ChoreCompletion) (primitive.ObjectID, *primitive.ObjectID) { return c.ID, nil },
+		[]string{"id", "chore_id", "user_id", "completed_at", "points"},
+		func(c models.ChoreCompletion) []string {
+			return []string{c.ID.Hex(), c.ChoreID.Hex(), c.UserID.Hex(), formatTime(c.CompletedAt), strconv.Itoa(c.Points)}
+		},
+	),
+	newSection("pantry_items",
+		func(d *Data) *[]models.PantryItem { return &d.PantryItems },
+		func(p models.PantryItem) (primitive.ObjectID, *primitive.ObjectID) { return p.ID, &p.GroupID },
+		[]string{"id", "name", "quantity", "unit", "category", "barcode", "storage_location", "expiration_date", "min_threshold", "par_level", "added_by", "created_at", "updated_at"},
+		func(p models.PantryItem) []string {
+			return []string{p.ID.Hex(), p.Name, formatFloat(p.Quantity), p.Unit, p.Category, p.Barcode, string(p.StorageLocation),
+				formatTime(p.ExpirationDate), formatFloat(p.MinThreshold), formatFloat(p.ParLevel), formatID(p.AddedBy),
+				formatTime(p.CreatedAt), formatTime(p.UpdatedAt)}
+		},
+	),
+	newSection("pantry_history",
+		func(d *Data) *[]models.PantryHistory { return &d.PantryHistory },
+		func(h models.PantryHistory) (primitive.ObjectID, *primitive.ObjectID) { return h.ID, &h.GroupID },
+		[]string{"id", "item_id", "item_name", "user_id", "user_name", "action", "quantity", "details", "created_at"},
+		func(h models.PantryHistory) []string {
+			return []string{h.ID.Hex(), h.ItemID.Hex(), h.ItemName, h.UserID.Hex(), h.UserName, string(h.Action),
+				formatFloat(h.Quantity), h.Details, formatTime(h.CreatedAt)}
+		},
+	),
+	newSection("notifications",
+		func(d *Data) *[]models.Notification { return &d.Notifications },
+		func(n models.Notification) (primitive.ObjectID, *primitive.ObjectID) { return n.ID, &n.GroupID },
+		[]string{"id", "user_id", "actor_id", "domain", "type", "subject_id", "subject_name", "message", "created_at"},
+		func(n models.Notification) []string {
+			return []string{n.ID.Hex(), formatID(n.UserID), formatID(n.ActorID), string(n.Domain), string(n.Type),
+				formatID(n.SubjectID), n.SubjectName, n.Message, formatTime(n.CreatedAt)}
+		},
+	),
+	newSection("pantry_notifications",
+		func(d *Data) *[]models.PantryNotification { return &d.PantryNotifications },
+		func(n models.PantryNotification) (primitive.ObjectID, *primitive.ObjectID) { return n.ID, &n.GroupID },
+		[]string{"id", "item_id", "item_name", "type", "message", "created_at"},
+		func(n models.PantryNotification) []string {
+			return []string{n.ID.Hex(), n.ItemID.Hex(), n.ItemName, string(n.Type), n.Message, formatTime(n.CreatedAt)}
+		},
+	),
+	newSection("shopping_cart",
+		func(d *Data) *[]models.ShoppingCartItem { return &d.ShoppingCart },
+		func(s models.ShoppingCartItem) (primitive.ObjectID, *primitive.ObjectID) { return s.ID, &s.GroupID },
+		[]string{"id", "user_id", "item_name", "quantity", "unit", "category", "added_at"},
+		func(s models.ShoppingCartItem) []string {
+			return []string{s.ID.Hex(), s.UserID.Hex(), s.ItemName, formatFloat(s.Quantity), s.Unit, s.Category, formatTime(s.AddedAt)}
+		},
+	),
+}
+
+// newSection builds a section from a record type. key returns a record's ID
+// and, if it has one, its group ID.
+func newSection[T any](
+	name string,
+	records func(d *Data) *[]T,
+	key func(T) (primitive.ObjectID, *primitive.ObjectID),
+	header []string,
+	row func(T) []string,
+) section {
+	return section{
+		name:  name,
+		value: func(d *Data) interface{} { return records(d) },
+		count: func(d *Data) int { return len(*records(d)) },
+		ids: func(d *Data) ([]primitive.ObjectID, []primitive.ObjectID) {
+			var ids, groupIDs []primitive.ObjectID
+			for _, record := range *records(d) {
+				id, groupID := key(record)
+				ids = append(ids, id)
+				if groupID != nil {
+					groupIDs = append(groupIDs, *groupID)
+				}
+			}
+			return ids, groupIDs
+		},
+		header: header,
+		rows: func(d *Data) [][]string {
+			rows := make([][]string, 0, len(*records(d)))
+			for _, record := range *records(d) {
+				rows = append(rows, row(record))
+			}
+			return rows
+		},
+	}
+}
+
+// formatTime writes a time for CSV, leaving unset times empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatID writes an ID for CSV, leaving unset IDs empty
+func formatID(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
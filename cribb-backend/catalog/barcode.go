@@ -0,0 +1,62 @@
+// Package catalog parses product barcodes and imports product data dumps.
+package catalog
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidBarcode is returned for codes that aren't 8, 12, 13 or 14 digits
+	ErrInvalidBarcode = errors.New("barcode must be an 8, 12, 13 or 14 digit EAN/UPC code")
+
+	// ErrBarcodeChecksum is returned when the check digit doesn't match
+	ErrBarcodeChecksum = errors.New("barcode check digit is invalid")
+)
+
+// NormalizeBarcode validates an EAN-8, UPC-A, EAN-13 or GTIN-14 code and returns
+// its canonical form. UPC-A codes are widened to EAN-13 so both spellings of
+// the same product share one key.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(code))
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+
+	switch len(code) {
+	case 8, 13, 14:
+	case 12:
+		code = "0" + code
+	default:
+		return "", ErrInvalidBarcode
+	}
+
+	if !validCheckDigit(code) {
+		return "", ErrBarcodeChecksum
+	}
+	return code, nil
+}
+
+// validCheckDigit verifies the GS1 mod-10 check digit of a code
+func validCheckDigit(code string) bool {
+	sum := 0
+	// Weights alternate 3, 1, 3... starting from the digit left of the check digit
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	check := (10 - sum%10) % 10
+	return check == int(code[len(code)-1]-'0')
+}
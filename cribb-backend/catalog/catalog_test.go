@@ -0,0 +1,110 @@
+package catalog_test
+
+import (
+	"cribb-backend/catalog"
+	"cribb-backend/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeBarcode(t *testing.T) {
+	cases := map[string]string{
+		"3017620422003":   "3017620422003", // EAN-13
+		"036000291452":    "0036000291452", // UPC-A widened to EAN-13
+		"96385074":        "96385074",      // EAN-8
+		" 3017-620422003": "3017620422003",
+	}
+
+	for input, expected := range cases {
+		code, err := catalog.NormalizeBarcode(input)
+		if err != nil {
+			t.Errorf("NormalizeBarcode(%q) returned error: %v", input, err)
+			continue
+		}
+		if code != expected {
+			t.Errorf("NormalizeBarcode(%q) = %q, expected %q", input, code, expected)
+		}
+	}
+
+	if _, err := catalog.NormalizeBarcode("3017620422004"); !errors.Is(err, catalog.ErrBarcodeChecksum) {
+		t.Errorf("Expected ErrBarcodeChecksum for a wrong check digit, got %v", err)
+	}
+	if _, err := catalog.NormalizeBarcode("12345"); !errors.Is(err, catalog.ErrInvalidBarcode) {
+		t.Errorf("Expected ErrInvalidBarcode for a short code, got %v", err)
+	}
+	if _, err := catalog.NormalizeBarcode("30176204220AB"); !errors.Is(err, catalog.ErrInvalidBarcode) {
+		t.Errorf("Expected ErrInvalidBarcode for letters, got %v", err)
+	}
+}
+
+func TestCategoryFor(t *testing.T) {
+	cases := []struct {
+		categories string
+		expected   string
+	}{
+		{"Dairies,Fermented foods,Fermented milk products,Cheeses", catalog.CategoryDairy},
+		{"en:plant-based-foods-and-beverages,en:plant-based-foods,en:cereals-and-potatoes,en:breads", catalog.CategoryBakery},
+		{"Beverages,Plant-based beverages,Fruit-based beverages,Juices", catalog.CategoryBeverages},
+		{"Plant-based foods and beverages,Plant-based foods,Fruits,Apples", catalog.CategoryProduce},
+		{"Frozen foods,Frozen desserts,Ice creams", catalog.CategoryFrozen},
+		{"Something unusual", catalog.CategoryUncategorized},
+	}
+
+	for _, c := range cases {
+		if category := catalog.CategoryFor(c.categories); category != c.expected {
+			t.Errorf("CategoryFor(%q) = %q, expected %q", c.categories, category, c.expected)
+		}
+	}
+}
+
+func TestReadOpenFoodFacts(t *testing.T) {
+	dump := strings.Join([]string{
+		"code\tproduct_name\tgeneric_name\tbrands\tquantity\tproduct_quantity\tproduct_quantity_unit\tcategories_en",
+		"3017620422003\tNutella\t\tFerrero,Nutella\t400 g\t\t\tBreakfasts,Spreads,Sweet spreads,Cocoa and hazelnuts spreads",
+		"036000291452\t\tWhole milk\tFarm\t1 gal\t\t\tDairies,Milks,Whole milks",
+		"96385074\tCola\t\t\t6 x 330 ml\t\t\tBeverages,Sodas",
+		"5449000000996\tCola Zero\t\t\t1.5l\t1500\tml\tBeverages,Sodas",
+		"1234567890123\tBad checksum\t\t\t\t\t\t",
+		"96385074\t\t\t\t\t\t\t",
+	}, "\n")
+
+	var products []*models.Product
+	stats, err := catalog.ReadOpenFoodFacts(strings.NewReader(dump), func(product *models.Product) error {
+		products = append(products, product)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error reading dump: %v", err)
+	}
+
+	if stats.Rows != 6 || stats.Imported != 4 || stats.Skipped != 2 {
+		t.Fatalf("Unexpected import stats: %+v", stats)
+	}
+
+	nutella := products[0]
+	if nutella.Name != "Nutella" || nutella.Brand != "Ferrero" || nutella.Category != catalog.CategoryCondiments {
+		t.Errorf("Unexpected product: %+v", nutella)
+	}
+	if nutella.DefaultQuantity != 400 || nutella.DefaultUnit != "g" {
+		t.Errorf("Expected 400 g, got %v %s", nutella.DefaultQuantity, nutella.DefaultUnit)
+	}
+	if nutella.Source != models.ProductSourceOpenFoodFacts {
+		t.Errorf("Expected Open Food Facts source, got %s", nutella.Source)
+	}
+
+	milk := products[1]
+	if milk.Barcode != "0036000291452" || milk.Name != "Whole milk" || milk.DefaultUnit != "gal" {
+		t.Errorf("Unexpected product: %+v", milk)
+	}
+	if milk.ShelfLifeDays != catalog.TypicalShelfLifeDays(catalog.CategoryDairy) {
+		t.Errorf("Expected dairy shelf life, got %d", milk.ShelfLifeDays)
+	}
+
+	if products[2].DefaultQuantity != 1 || products[2].DefaultUnit != "pc" {
+		t.Errorf("Expected a multipack to count as one piece, got %v %s", products[2].DefaultQuantity, products[2].DefaultUnit)
+	}
+	if products[3].DefaultQuantity != 1500 || products[3].DefaultUnit != "ml" {
+		t.Errorf("Expected structured quantity 1500 ml, got %v %s", products[3].DefaultQuantity, products[3].DefaultUnit)
+	}
+}
@@ -0,0 +1,100 @@
+package catalog
+
+import (
+	"cribb-backend/models"
+	"strings"
+)
+
+// Pantry categories products are sorted into
+const (
+	CategoryFrozen        = "Frozen"
+	CategoryCanned        = "Canned Goods"
+	CategoryBeverages     = "Beverages"
+	CategorySeafood       = "Seafood"
+	CategoryMeat          = "Meat"
+	CategoryEggs          = "Eggs"
+	CategoryDairy         = "Dairy"
+	CategoryBakery        = "Bakery"
+	CategorySnacks        = "Snacks"
+	CategoryCondiments    = "Condiments"
+	CategoryDryGoods      = "Dry Goods"
+	CategoryProduce       = "Produce"
+	CategoryUncategorized = "Uncategorized"
+)
+
+// categoryRules map category keywords to pantry categories, most specific first.
+// Storage rules win wherever they appear in a product's categories: frozen
+// peas belong with frozen food, not produce.
+var categoryRules = []struct {
+	category string
+	keywords []string
+	storage  bool
+}{
+	{CategoryFrozen, []string{"frozen"}, true},
+	{CategoryCanned, []string{"canned", "conserves", "tinned"}, true},
+	{CategoryBeverages, []string{"beverages", "drinks", "juices", "waters", "sodas", "coffees", "teas", "wines", "beers"}, false},
+	{CategorySeafood, []string{"seafood", "fishes", "fish", "shellfish"}, false},
+	{CategoryMeat, []string{"meats", "meat", "poultry", "sausages", "hams", "beef", "pork", "chicken"}, false},
+	{CategoryEggs, []string{"eggs"}, false},
+	{CategoryDairy, []string{"dairies", "dairy", "milks", "milk", "cheeses", "yogurts", "butters", "creams"}, false},
+	{CategoryBakery, []string{"breads", "bread", "bakery", "pastries", "cakes", "viennoiseries"}, false},
+	{CategorySnacks, []string{"snacks", "sweets", "chocolates", "biscuits", "chips", "confectioneries", "crackers"}, false},
+	{CategoryCondiments, []string{"sauces", "condiments", "spreads", "dressings", "spices", "vinegars", "oils"}, false},
+	{CategoryDryGoods, []string{"cereals", "pastas", "rices", "legumes", "flours", "grains", "sugars", "nuts"}, false},
+	{CategoryProduce, []string{"fruits", "vegetables", "fresh", "herbs", "salads"}, false},
+}
+
+// CategoryFor maps product categories such as Open Food Facts' "Dairies,Cheeses"
+// or "en:dairies,en:cheeses" to a pantry category. The most specific entries
+// (listed last) are tried first.
+func CategoryFor(categories ...string) string {
+	entries := make([][]string, 0)
+	for _, list := range categories {
+		parts := strings.Split(list, ",")
+		for i := len(parts) - 1; i >= 0; i-- {
+			entry := strings.ToLower(strings.TrimSpace(parts[i]))
+			if colon := strings.Index(entry, ":"); colon >= 0 {
+				entry = entry[colon+1:]
+			}
+			words := strings.FieldsFunc(entry, func(r rune) bool {
+				return r == ' ' || r == '-' || r == '_'
+			})
+			if len(words) > 0 {
+				entries = append(entries, words)
+			}
+		}
+	}
+
+	// Storage rules first, then the first rule matching the most specific entry
+	for _, storageOnly := range []bool{true, false} {
+		for _, words := range entries {
+			for _, rule := range categoryRules {
+				if storageOnly && !rule.storage {
+					continue
+				}
+				if matchesAny(words, rule.keywords) {
+					return rule.category
+				}
+			}
+		}
+	}
+	return CategoryUncategorized
+}
+
+// matchesAny checks if any word is one of the keywords
+func matchesAny(words, keywords []string) bool {
+	for _, word := range words {
+		for _, keyword := range keywords {
+			if word == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TypicalShelfLifeDays returns the usual shelf life of a pantry category kept
+// in its usual storage location, or 0 if unknown
+func TypicalShelfLifeDays(category string) int {
+	return models.DefaultShelfLifeDays(category, models.DefaultStorageLocation(category))
+}
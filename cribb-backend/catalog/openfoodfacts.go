@@ -0,0 +1,131 @@
+package catalog
+
+import (
+	"bufio"
+	"cribb-backend/models"
+	"cribb-backend/units"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLineSize bounds a single row of the dump; some products carry very long ingredient lists
+const maxLineSize = 16 * 1024 * 1024
+
+// ImportStats summarizes a dump import
+type ImportStats struct {
+	Rows     int `json:"rows"`
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// ReadOpenFoodFacts reads an Open Food Facts CSV export (tab separated, with a
+// header row) and calls fn for every usable product. Rows without a valid
+// barcode or a name are skipped.
+func ReadOpenFoodFacts(r io.Reader, fn func(*models.Product) error) (ImportStats, error) {
+	var stats ImportStats
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return stats, err
+		}
+		return stats, errors.New("dump is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range strings.Split(strings.TrimPrefix(scanner.Text(), "\ufeff"), "\t") {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["code"]; !ok {
+		return stats, errors.New("dump has no code column")
+	}
+
+	for scanner.Scan() {
+		stats.Rows++
+		fields := strings.Split(scanner.Text(), "\t")
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		product, ok := productFromRow(field)
+		if !ok {
+			stats.Skipped++
+			continue
+		}
+
+		if err := fn(product); err != nil {
+			return stats, fmt.Errorf("row %d: %w", stats.Rows+1, err)
+		}
+		stats.Imported++
+	}
+
+	return stats, scanner.Err()
+}
+
+// productFromRow builds a product from one dump row
+func productFromRow(field func(string) string) (*models.Product, bool) {
+	barcode, err := NormalizeBarcode(field("code"))
+	if err != nil {
+		return nil, false
+	}
+
+	name := field("product_name")
+	if name == "" {
+		name = field("generic_name")
+	}
+	if name == "" {
+		return nil, false
+	}
+
+	brand := field("brands")
+	if comma := strings.Index(brand, ","); comma >= 0 {
+		brand = strings.TrimSpace(brand[:comma])
+	}
+
+	category := CategoryFor(field("main_category_en"), field("categories_en"), field("categories_tags"))
+	quantity, unit := packageSize(field("product_quantity"), field("product_quantity_unit"), field("quantity"))
+
+	return models.CreateProduct(
+		barcode,
+		name,
+		brand,
+		category,
+		unit,
+		quantity,
+		TypicalShelfLifeDays(category),
+		models.ProductSourceOpenFoodFacts,
+	), true
+}
+
+// packageSize works out the default quantity and unit of one package.
+// Structured columns are preferred over the free text quantity ("500 g", "1 l").
+// Anything unparseable, like multipacks, counts as one piece.
+func packageSize(productQuantity, productQuantityUnit, freeText string) (float64, string) {
+	if quantity, err := strconv.ParseFloat(productQuantity, 64); err == nil && quantity > 0 {
+		unit := productQuantityUnit
+		if unit == "" {
+			unit = "g"
+		}
+		if symbol, err := units.Normalize(unit); err == nil {
+			return quantity, symbol
+		}
+	}
+
+	// "12 oz (340 g)" -> "12 oz"
+	if paren := strings.Index(freeText, "("); paren >= 0 {
+		freeText = freeText[:paren]
+	}
+	if quantity, symbol, err := units.Parse(freeText); err == nil && quantity > 0 {
+		return quantity, symbol
+	}
+
+	return 1, "pc"
+}
@@ -0,0 +1,118 @@
+package chatbot_test
+
+import (
+	"context"
+	"cribb-backend/chatbot"
+	"cribb-backend/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cmd, err := chatbot.Parse("  /Done@cribb_bot  the dishes ")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if cmd.Name != "done" || cmd.Text() != "the dishes" {
+		t.Errorf("Unexpected command %+v", cmd)
+	}
+
+	if _, err := chatbot.Parse("done dishes"); !errors.Is(err, chatbot.ErrNotACommand) {
+		t.Errorf("Expected plain chat to be ignored, got %v", err)
+	}
+	if _, err := chatbot.Parse("/ "); !errors.Is(err, chatbot.ErrEmptyCommand) {
+		t.Errorf("Expected a lone slash to be empty, got %v", err)
+	}
+}
+
+func TestItemArgs(t *testing.T) {
+	cases := []struct {
+		text     string
+		name     string
+		quantity float64
+		unit     string
+	}{
+		{"milk", "milk", 1, ""},
+		{"milk 2", "milk", 2, ""},
+		{"oat milk 1,5 L", "oat milk", 1.5, "L"},
+		{"7up 6 cans", "7up", 6, "cans"},
+	}
+	for _, c := range cases {
+		name, quantity, unit, err := chatbot.ItemArgs(strings.Fields(c.text))
+		if err != nil {
+			t.Errorf("ItemArgs(%q) returned error: %v", c.text, err)
+			continue
+		}
+		if name != c.name || quantity != c.quantity || unit != c.unit {
+			t.Errorf("ItemArgs(%q) = %q %v %q, expected %q %v %q", c.text, name, quantity, unit, c.name, c.quantity, c.unit)
+		}
+	}
+
+	if _, _, _, err := chatbot.ItemArgs(nil); err == nil {
+		t.Error("Expected an item name to be required")
+	}
+	if _, _, _, err := chatbot.ItemArgs([]string{"milk", "0"}); err == nil {
+		t.Error("Expected a zero quantity to be rejected")
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	d := chatbot.NewDispatcher()
+	var got chatbot.Command
+	d.Register("done", "/done <chore>", "Complete a chore", func(ctx context.Context, user models.User, cmd chatbot.Command) (string, error) {
+		got = cmd
+		if cmd.Text() == "" {
+			return "", chatbot.Errorf("Which chore?")
+		}
+		return user.Name + " did " + cmd.Text(), nil
+	}, "did")
+	d.Register("broken", "/broken", "Always fails", func(ctx context.Context, user models.User, cmd chatbot.Command) (string, error) {
+		return "", errors.New("database is down")
+	})
+
+	user := models.User{Name: "Alice"}
+	reply, err := d.Dispatch(context.Background(), user, "/did dishes")
+	if err != nil || reply != "Alice did dishes" || got.Name != "did" {
+		t.Errorf("Expected the alias to run /done, got %q %v", reply, err)
+	}
+
+	reply, err = d.Dispatch(context.Background(), user, "/done")
+	if err != nil || reply != "Which chore?" {
+		t.Errorf("Expected command errors to become the reply, got %q %v", reply, err)
+	}
+
+	reply, _ = d.Dispatch(context.Background(), user, "/dance")
+	if !strings.Contains(reply, "Unknown command /dance") {
+		t.Errorf("Unexpected reply to an unknown command %q", reply)
+	}
+
+	reply, _ = d.Dispatch(context.Background(), user, "/help")
+	if !strings.Contains(reply, "/done <chore> - Complete a chore") || strings.Contains(reply, "/did") {
+		t.Errorf("Expected help to list commands once, got %q", reply)
+	}
+
+	if _, err := d.Dispatch(context.Background(), user, "/broken"); err == nil {
+		t.Error("Expected failures to be returned as errors")
+	}
+	if _, err := d.Dispatch(context.Background(), user, "hello"); !errors.Is(err, chatbot.ErrNotACommand) {
+		t.Errorf("Expected plain chat to return ErrNotACommand, got %v", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	names := []string{"Dishes", "Take out trash", "Clean bathroom", "Clean kitchen"}
+
+	if i, _ := chatbot.Match("dishes", names); i != 0 {
+		t.Errorf("Expected an exact match ignoring case, got %d", i)
+	}
+	if i, _ := chatbot.Match("trash", names); i != 1 {
+		t.Errorf("Expected the only partial match, got %d", i)
+	}
+	if i, candidates := chatbot.Match("clean", names); i != -1 || len(candidates) != 2 {
+		t.Errorf("Expected an ambiguous match with 2 candidates, got %d %v", i, candidates)
+	}
+	if i, candidates := chatbot.Match("vacuum", names); i != -1 || len(candidates) != 0 {
+		t.Errorf("Expected no match, got %d %v", i, candidates)
+	}
+}
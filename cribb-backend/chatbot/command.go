@@ -0,0 +1,99 @@
+// Package chatbot parses chat messages like "/add milk 2" into commands and
+// dispatches them to the operations they stand for.
+package chatbot
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotACommand is returned for chat messages that don't start with a slash
+	ErrNotACommand = errors.New("message is not a command")
+
+	// ErrEmptyCommand is returned for a lone slash
+	ErrEmptyCommand = errors.New("command is empty")
+)
+
+// Command is a parsed chat command. Name is lower case without the slash.
+type Command struct {
+	Name string
+	Args []string
+}
+
+// Parse splits a chat message into a command and its arguments. Mentions
+// some platforms append to the name ("/add@cribb_bot") are dropped.
+func Parse(text string) (Command, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return Command{}, ErrNotACommand
+	}
+
+	fields := strings.Fields(text[1:])
+	if len(fields) == 0 {
+		return Command{}, ErrEmptyCommand
+	}
+	name, _, _ := strings.Cut(fields[0], "@")
+	if name == "" {
+		return Command{}, ErrEmptyCommand
+	}
+	return Command{Name: strings.ToLower(name), Args: fields[1:]}, nil
+}
+
+// Text joins the arguments back into the words they were typed as
+func (c Command) Text() string {
+	return strings.Join(c.Args, " ")
+}
+
+// ItemArgs reads "<name> [quantity] [unit]" arguments, as in "/add oat milk 2 L".
+// The last number splits the name from the unit; without one the quantity is 1.
+func ItemArgs(args []string) (name string, quantity float64, unit string, err error) {
+	quantity = 1
+	split := -1
+	for i := len(args) - 1; i > 0; i-- {
+		if parsed, parseErr := strconv.ParseFloat(strings.ReplaceAll(args[i], ",", "."), 64); parseErr == nil {
+			quantity, split = parsed, i
+			break
+		}
+	}
+
+	nameArgs := args
+	if split > 0 {
+		nameArgs = args[:split]
+		unit = strings.Join(args[split+1:], " ")
+	}
+	name = strings.Join(nameArgs, " ")
+
+	switch {
+	case name == "":
+		return "", 0, "", Errorf("Which item?")
+	case quantity <= 0:
+		return "", 0, "", Errorf("The quantity must be more than zero")
+	}
+	return name, quantity, unit, nil
+}
+
+// Match finds what the user typed among names. A case-insensitive exact match
+// wins; otherwise the typed text must appear in exactly one name. It returns
+// -1 with the candidates when there are several, or with none when nothing
+// matches.
+func Match(typed string, names []string) (int, []string) {
+	typed = strings.ToLower(strings.TrimSpace(typed))
+	var candidates []string
+	found := -1
+	for i, name := range names {
+		lower := strings.ToLower(name)
+		if lower == typed {
+			return i, nil
+		}
+		if strings.Contains(lower, typed) {
+			candidates = append(candidates, name)
+			found = i
+		}
+	}
+	if len(candidates) == 1 {
+		return found, nil
+	}
+	return -1, candidates
+}
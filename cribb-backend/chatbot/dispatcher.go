@@ -0,0 +1,102 @@
+// chatbot/dispatcher.go
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cribb-backend/models"
+)
+
+// IntegrationHeader names the chat integration an inbound command came from
+const IntegrationHeader = "X-Cribb-Integration"
+
+// Error is a problem with the command itself, e.g. an unknown chore. Its
+// message is sent back to the chat rather than treated as a failure.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Errorf creates an Error replied to the user
+func Errorf(format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// Handler runs a command for the user who typed it and returns the reply
+type Handler func(ctx context.Context, user models.User, cmd Command) (string, error)
+
+// command is a registered command
+type command struct {
+	name    string
+	usage   string
+	summary string
+	handler Handler
+}
+
+// Dispatcher routes commands by name, including aliases
+type Dispatcher struct {
+	commands map[string]*command
+	primary  []*command // Registered commands without aliases, for help
+}
+
+// NewDispatcher creates a dispatcher that already knows /help
+func NewDispatcher() *Dispatcher {
+	d := &Dispatcher{commands: make(map[string]*command)}
+	d.Register("help", "/help", "Show the commands", func(ctx context.Context, user models.User, cmd Command) (string, error) {
+		return d.Help(), nil
+	})
+	return d
+}
+
+// Register adds a command under its name and any aliases
+func (d *Dispatcher) Register(name, usage, summary string, handler Handler, aliases ...string) {
+	c := &command{name: name, usage: usage, summary: summary, handler: handler}
+	d.primary = append(d.primary, c)
+	d.commands[name] = c
+	for _, alias := range aliases {
+		d.commands[alias] = c
+	}
+}
+
+// Help lists the commands with their usage
+func (d *Dispatcher) Help() string {
+	commands := append([]*command(nil), d.primary...)
+	sort.Slice(commands, func(i, j int) bool { return commands[i].name < commands[j].name })
+
+	var b strings.Builder
+	b.WriteString("Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "\n%s - %s", c.usage, c.summary)
+	}
+	return b.String()
+}
+
+// Dispatch parses the text and runs its command for the user. Problems with
+// the command come back as the reply; only failures return an error.
+// Messages that aren't commands return ErrNotACommand.
+func (d *Dispatcher) Dispatch(ctx context.Context, user models.User, text string) (string, error) {
+	cmd, err := Parse(text)
+	if errors.Is(err, ErrNotACommand) {
+		return "", err
+	}
+	if err != nil {
+		return d.Help(), nil
+	}
+
+	c, ok := d.commands[cmd.Name]
+	if !ok {
+		return fmt.Sprintf("Unknown command /%s. Try /help", cmd.Name), nil
+	}
+
+	reply, err := c.handler(ctx, user, cmd)
+	var commandErr *Error
+	if errors.As(err, &commandErr) {
+		return commandErr.Message, nil
+	}
+	return reply, err
+}
@@ -0,0 +1,105 @@
+// Command catalog-import loads an Open Food Facts CSV dump into the shared product catalog.
+//
+// Usage:
+//
+//	go run ./cmd/catalog-import -file en.openfoodfacts.org.products.csv.gz
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"cribb-backend/catalog"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the Open Food Facts CSV dump (.csv or .csv.gz)")
+	batchSize := flag.Int("batch", 1000, "number of products written per bulk request")
+	dryRun := flag.Bool("dry-run", false, "parse the dump without writing to the database")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open dump: %v", err)
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if strings.HasSuffix(*file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			log.Fatalf("Failed to read gzip dump: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	if !*dryRun {
+		config.ConnectDB()
+	}
+
+	batch := make([]mongo.WriteModel, 0, *batchSize)
+	flush := func() error {
+		if len(batch) == 0 || *dryRun {
+			batch = batch[:0]
+			return nil
+		}
+		_, err := config.DB.Collection("products").BulkWrite(
+			context.Background(),
+			batch,
+			options.BulkWrite().SetOrdered(false),
+		)
+		batch = batch[:0]
+		return err
+	}
+
+	started := time.Now()
+	stats, err := catalog.ReadOpenFoodFacts(reader, func(product *models.Product) error {
+		batch = append(batch, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"barcode": product.Barcode, "group_id": nil}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"name":             product.Name,
+					"brand":            product.Brand,
+					"category":         product.Category,
+					"default_unit":     product.DefaultUnit,
+					"default_quantity": product.DefaultQuantity,
+					"shelf_life_days":  product.ShelfLifeDays,
+					"source":           product.Source,
+					"updated_at":       product.UpdatedAt,
+				},
+				"$setOnInsert": bson.M{"created_at": product.CreatedAt},
+			}).
+			SetUpsert(true))
+
+		if len(batch) >= *batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.Fatalf("Import failed after %d rows: %v", stats.Rows, err)
+	}
+
+	log.Printf("Imported %d products (%d rows read, %d skipped) in %s",
+		stats.Imported, stats.Rows, stats.Skipped, time.Since(started).Round(time.Second))
+}
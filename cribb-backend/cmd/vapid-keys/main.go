@@ -0,0 +1,21 @@
+// Command vapid-keys generates a VAPID key pair for Web Push notifications.
+//
+// Usage:
+//
+//	go run ./cmd/vapid-keys >> .env
+package main
+
+import (
+	"cribb-backend/delivery"
+	"fmt"
+	"log"
+)
+
+func main() {
+	publicKey, privateKey, err := delivery.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatalf("Failed to generate VAPID keys: %v", err)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", publicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", privateKey)
+}
@@ -226,19 +226,398 @@ func initializeDatabase() error {
 			Keys: bson.D{{Key: "item_name", Value: 1}},
 		},
 		{
+			// An item appears once per user on each list
 			Keys: bson.D{
 				{Key: "user_id", Value: 1},
 				{Key: "group_id", Value: 1},
+				{Key: "list_id", Value: 1},
 				{Key: "item_name", Value: 1},
 			},
 			Options: options.Index().SetUnique(true),
 		},
 	}
+	// Replace the old per-user unique index from before named lists
+	if _, err := shoppingCartCollection.Indexes().DropOne(ctx, "user_id_1_group_id_1_item_name_1"); err != nil {
+		log.Printf("Old shopping cart index not dropped: %v", err)
+	}
 	_, err = shoppingCartCollection.Indexes().CreateMany(ctx, shoppingCartIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create shopping cart indexes: %v", err)
 	}
 
+	// Create pantry_history collection with indexes for usage lookups and forecasting
+	pantryHistoryCollection := DB.Collection("pantry_history")
+	pantryHistoryIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "item_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{
+				{Key: "group_id", Value: 1},
+				{Key: "action", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+		},
+	}
+	_, err = pantryHistoryCollection.Indexes().CreateMany(ctx, pantryHistoryIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create pantry history indexes: %v", err)
+	}
+
+	// Create products collection with one entry per barcode, shared or per group
+	productsCollection := DB.Collection("products")
+	productsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "barcode", Value: 1},
+				{Key: "group_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}},
+		},
+	}
+	_, err = productsCollection.Indexes().CreateMany(ctx, productsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create product indexes: %v", err)
+	}
+
+	// Create shelf_life_rules collection with indexes
+	shelfLifeRulesCollection := DB.Collection("shelf_life_rules")
+	shelfLifeRulesIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}},
+		},
+	}
+	_, err = shelfLifeRulesCollection.Indexes().CreateMany(ctx, shelfLifeRulesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create shelf-life rule indexes: %v", err)
+	}
+
+	// Create recipes collection with indexes
+	recipesCollection := DB.Collection("recipes")
+	recipesIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "name", Value: 1}},
+		},
+	}
+	_, err = recipesCollection.Indexes().CreateMany(ctx, recipesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create recipe indexes: %v", err)
+	}
+
+	// Create meal_plan collection with indexes
+	mealPlanCollection := DB.Collection("meal_plan")
+	mealPlanIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "recipe_id", Value: 1}},
+		},
+	}
+	_, err = mealPlanCollection.Indexes().CreateMany(ctx, mealPlanIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create meal plan indexes: %v", err)
+	}
+
+	// Create expenses and settlements collections with indexes
+	expensesCollection := DB.Collection("expenses")
+	expensesIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "date", Value: -1}},
+		},
+	}
+	_, err = expensesCollection.Indexes().CreateMany(ctx, expensesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create expense indexes: %v", err)
+	}
+
+	settlementsCollection := DB.Collection("settlements")
+	settlementsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+	_, err = settlementsCollection.Indexes().CreateMany(ctx, settlementsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create settlement indexes: %v", err)
+	}
+
+	// Create recurring bill collections with indexes
+	recurringBillsCollection := DB.Collection("recurring_bills")
+	recurringBillsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "is_active", Value: 1}, {Key: "next_due_date", Value: 1}},
+		},
+	}
+	_, err = recurringBillsCollection.Indexes().CreateMany(ctx, recurringBillsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create recurring bill indexes: %v", err)
+	}
+
+	billInstancesCollection := DB.Collection("bill_instances")
+	billInstancesIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "due_date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "due_date", Value: 1}},
+		},
+		{
+			// One instance per bill and due date, even if two schedulers race
+			Keys:    bson.D{{Key: "bill_id", Value: 1}, {Key: "due_date", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err = billInstancesCollection.Indexes().CreateMany(ctx, billInstancesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create bill instance indexes: %v", err)
+	}
+
+	billRemindersCollection := DB.Collection("bill_reminders")
+	billRemindersIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "bill_instance_id", Value: 1}},
+		},
+	}
+	_, err = billRemindersCollection.Indexes().CreateMany(ctx, billRemindersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create bill reminder indexes: %v", err)
+	}
+
+	// Create price history and budget collections with indexes
+	priceHistoryCollection := DB.Collection("price_history")
+	priceHistoryIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "product_key", Value: 1}, {Key: "purchased_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "purchased_at", Value: -1}},
+		},
+	}
+	_, err = priceHistoryCollection.Indexes().CreateMany(ctx, priceHistoryIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create price history indexes: %v", err)
+	}
+
+	groceryBudgetsCollection := DB.Collection("grocery_budgets")
+	_, err = groceryBudgetsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "group_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create grocery budget indexes: %v", err)
+	}
+
+	budgetAlertsCollection := DB.Collection("budget_alerts")
+	budgetAlertsIndexes := []mongo.IndexModel{
+		{
+			// One alert per month, category and level
+			Keys:    bson.D{{Key: "group_id", Value: 1}, {Key: "month", Value: 1}, {Key: "category", Value: 1}, {Key: "level", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+	_, err = budgetAlertsCollection.Indexes().CreateMany(ctx, budgetAlertsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create budget alert indexes: %v", err)
+	}
+
+	// Create the shared group list with one entry per item and unit kind
+	groupListCollection := DB.Collection("group_list")
+	groupListIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "group_id", Value: 1},
+				{Key: "item_key", Value: 1},
+				{Key: "unit_key", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "claimed_by", Value: 1}},
+		},
+	}
+	_, err = groupListCollection.Indexes().CreateMany(ctx, groupListIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create group list indexes: %v", err)
+	}
+
+	// Create stores and shopping trips collections with indexes
+	storesCollection := DB.Collection("stores")
+	_, err = storesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "group_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create store indexes: %v", err)
+	}
+
+	shoppingTripsCollection := DB.Collection("shopping_trips")
+	shoppingTripsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "started_at", Value: -1}},
+		},
+		{
+			// A shopper has at most one trip in progress per group
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "shopper_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "active"}),
+		},
+	}
+	_, err = shoppingTripsCollection.Indexes().CreateMany(ctx, shoppingTripsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create shopping trip indexes: %v", err)
+	}
+
+	// Create named shopping lists collection; names are unique per group regardless of case
+	shoppingListsCollection := DB.Collection("shopping_lists")
+	_, err = shoppingListsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create shopping list indexes: %v", err)
+	}
+
+	// Create the notification inbox; the TTL index removes notifications once they expire
+	notificationsCollection := DB.Collection("notifications")
+	notificationsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err = notificationsCollection.Indexes().CreateMany(ctx, notificationsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create notification indexes: %v", err)
+	}
+
+	// Create notification preferences collection; one document per user
+	notificationPreferencesCollection := DB.Collection("notification_preferences")
+	_, err = notificationPreferencesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create notification preference indexes: %v", err)
+	}
+
+	// Create the outbound notification outbox and push subscriptions collections
+	notificationOutboxCollection := DB.Collection("notification_outbox")
+	_, err = notificationOutboxCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create notification outbox indexes: %v", err)
+	}
+
+	pushSubscriptionsCollection := DB.Collection("push_subscriptions")
+	pushSubscriptionsIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "endpoint", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+	}
+	_, err = pushSubscriptionsCollection.Indexes().CreateMany(ctx, pushSubscriptionsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create push subscription indexes: %v", err)
+	}
+
+	groupWebhooksCollection := DB.Collection("group_webhooks")
+	_, err = groupWebhooksCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "events", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create group webhook indexes: %v", err)
+	}
+
+	webhookDeliveriesCollection := DB.Collection("webhook_deliveries")
+	webhookDeliveriesIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err = webhookDeliveriesCollection.Indexes().CreateMany(ctx, webhookDeliveriesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery indexes: %v", err)
+	}
+
+	chatIntegrationsCollection := DB.Collection("chat_integrations")
+	_, err = chatIntegrationsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "group_id", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create chat integration indexes: %v", err)
+	}
+
+	chatCommandReceiptsCollection := DB.Collection("chat_command_receipts")
+	_, err = chatCommandReceiptsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "integration_id", Value: 1}, {Key: "signature", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create chat command receipt indexes: %v", err)
+	}
+
+	calendarFeedsCollection := DB.Collection("calendar_feeds")
+	_, err = calendarFeedsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "scope", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create calendar feed indexes: %v", err)
+	}
+
+	sessionRevocationsCollection := DB.Collection("session_revocations")
+	_, err = sessionRevocationsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "revoked_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session revocation indexes: %v", err)
+	}
+
 	log.Println("Successfully initialized database collections and indexes")
 	return nil
 
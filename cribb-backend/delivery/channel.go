@@ -0,0 +1,112 @@
+// Package delivery sends notifications to users over outbound channels:
+// email, SMS, webhooks and Web Push. It doesn't touch the database; the
+// outbox job loads queued messages and hands them to a Dispatcher.
+package delivery
+
+import (
+	"context"
+	"cribb-backend/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+var (
+	// ErrNoChannel is returned when a message's channel isn't registered
+	ErrNoChannel = errors.New("no delivery channel registered")
+
+	// ErrSubscriptionGone is returned when a push service says the subscription
+	// no longer exists; it should be deleted
+	ErrSubscriptionGone = errors.New("push subscription is gone")
+)
+
+// Message is one notification going to one address
+type Message struct {
+	Channel models.NotificationChannel
+	Target  models.DeliveryTarget
+	Subject string
+	Body    string
+	HTML    string // Optional rich body; channels without HTML use Body
+}
+
+// MessageFromOutbox builds the message for a queued outbox entry
+func MessageFromOutbox(m *models.OutboxMessage) Message {
+	return Message{
+		Channel: m.Channel,
+		Target:  m.Target,
+		Subject: m.Subject,
+		Body:    m.Body,
+		HTML:    m.HTMLBody,
+	}
+}
+
+// Channel delivers messages over one transport. Errors wrapped with Permanent
+// won't be retried.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// permanentError marks a failure retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, e.g. a malformed address
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent checks if err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// statusError turns an unsuccessful HTTP response into an error. Client errors
+// are permanent except for timeouts and rate limiting.
+func statusError(service string, resp *http.Response) error {
+	err := fmt.Errorf("%s responded %s", service, resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return err
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return Permanent(err)
+	}
+	return err
+}
+
+// Dispatcher routes messages to the channel registered for them
+type Dispatcher struct {
+	mu       sync.RWMutex
+	channels map[models.NotificationChannel]Channel
+}
+
+// NewDispatcher creates a dispatcher with no channels
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{channels: make(map[models.NotificationChannel]Channel)}
+}
+
+// Register sets the channel used for a kind of message, replacing any before it
+func (d *Dispatcher) Register(name models.NotificationChannel, channel Channel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels[name] = channel
+}
+
+// Send delivers a message over its channel
+func (d *Dispatcher) Send(ctx context.Context, msg Message) error {
+	d.mu.RLock()
+	channel, ok := d.channels[msg.Channel]
+	d.mu.RUnlock()
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %s", ErrNoChannel, msg.Channel))
+	}
+	return channel.Send(ctx, msg)
+}
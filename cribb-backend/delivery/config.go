@@ -0,0 +1,66 @@
+// delivery/config.go
+package delivery
+
+import (
+	"cribb-backend/models"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// env reads a trimmed environment variable
+func env(key string) string {
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+// VAPIDPublicKey returns the key browsers need to subscribe to push notifications
+func VAPIDPublicKey() string {
+	return env("VAPID_PUBLIC_KEY")
+}
+
+// FromEnv builds a dispatcher from the environment. Channels without
+// credentials fall back to the logging stand-in:
+//
+//	SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+//	SMS_API_URL, SMS_ACCOUNT_SID, SMS_AUTH_TOKEN, SMS_FROM
+//	VAPID_SUBJECT, VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY
+func FromEnv() *Dispatcher {
+	d := NewDispatcher()
+	d.Register(models.NotificationChannelWebhook, NewWebhookChannel())
+
+	if host := env("SMTP_HOST"); host != "" {
+		port, err := strconv.Atoi(env("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		d.Register(models.NotificationChannelEmail,
+			NewSMTPChannel(host, port, env("SMTP_USERNAME"), env("SMTP_PASSWORD"), env("SMTP_FROM")))
+	} else {
+		log.Println("SMTP_HOST not set, logging email notifications instead")
+		d.Register(models.NotificationChannelEmail, LogChannel{})
+	}
+
+	if sid := env("SMS_ACCOUNT_SID"); sid != "" {
+		d.Register(models.NotificationChannelSMS,
+			NewSMSChannel(env("SMS_API_URL"), sid, env("SMS_AUTH_TOKEN"), env("SMS_FROM")))
+	} else {
+		log.Println("SMS_ACCOUNT_SID not set, logging SMS notifications instead")
+		d.Register(models.NotificationChannelSMS, LogChannel{})
+	}
+
+	if privateKey := env("VAPID_PRIVATE_KEY"); privateKey != "" {
+		push, err := NewWebPushChannel(env("VAPID_SUBJECT"), VAPIDPublicKey(), privateKey)
+		if err != nil {
+			log.Printf("Web Push disabled: %v", err)
+			d.Register(models.NotificationChannelPush, LogChannel{})
+		} else {
+			d.Register(models.NotificationChannelPush, push)
+		}
+	} else {
+		log.Println("VAPID_PRIVATE_KEY not set, logging push notifications instead")
+		d.Register(models.NotificationChannelPush, LogChannel{})
+	}
+
+	return d
+}
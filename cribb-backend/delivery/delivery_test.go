@@ -0,0 +1,422 @@
+package delivery_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cribb-backend/delivery"
+	"cribb-backend/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestDispatcherRoutesByChannel(t *testing.T) {
+	d := delivery.NewDispatcher()
+	fake := delivery.NewFake()
+	d.Register(models.NotificationChannelEmail, fake)
+
+	msg := delivery.Message{
+		Channel: models.NotificationChannelEmail,
+		Target:  models.DeliveryTarget{Address: "alice@example.com"},
+		Subject: "Low stock",
+		Body:    "Milk is running low",
+	}
+	if err := d.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected the fake to accept the message, got %v", err)
+	}
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].Subject != "Low stock" {
+		t.Errorf("Unexpected messages %+v", sent)
+	}
+
+	msg.Channel = models.NotificationChannelSMS
+	err := d.Send(context.Background(), msg)
+	if !errors.Is(err, delivery.ErrNoChannel) || !delivery.IsPermanent(err) {
+		t.Errorf("Expected a permanent missing channel error, got %v", err)
+	}
+
+	fake.Err = errors.New("provider down")
+	msg.Channel = models.NotificationChannelEmail
+	if err := d.Send(context.Background(), msg); err == nil || delivery.IsPermanent(err) {
+		t.Errorf("Expected a retryable failure, got %v", err)
+	}
+}
+
+func TestWebhookChannel(t *testing.T) {
+	status := http.StatusOK
+	var received delivery.WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON body, got %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	// The test server is on loopback, which the default client refuses
+	channel := delivery.NewWebhookChannel()
+	msg := delivery.Message{Target: models.DeliveryTarget{Address: server.URL}, Subject: "Chore assigned", Body: "Dishes"}
+	if err := channel.Send(context.Background(), msg); !errors.Is(err, models.ErrPrivateHost) || !delivery.IsPermanent(err) {
+		t.Fatalf("Expected a permanent failure posting to loopback, got %v", err)
+	}
+
+	channel.Client = server.Client()
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected the webhook to succeed, got %v", err)
+	}
+	if received.Subject != "Chore assigned" || received.Body != "Dishes" {
+		t.Errorf("Unexpected payload %+v", received)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := channel.Send(context.Background(), msg); err == nil || delivery.IsPermanent(err) {
+		t.Errorf("Expected server errors to be retried, got %v", err)
+	}
+	status = http.StatusBadRequest
+	if err := channel.Send(context.Background(), msg); !delivery.IsPermanent(err) {
+		t.Errorf("Expected client errors to be permanent, got %v", err)
+	}
+}
+
+func TestPublicClient(t *testing.T) {
+	client := delivery.PublicClient(time.Second)
+	for _, target := range []string{"http://127.0.0.1:27017/", "http://[::1]/", "http://10.0.0.8/", "http://169.254.169.254/latest/meta-data/", "http://0.0.0.0/"} {
+		if _, err := client.Get(target); !errors.Is(err, models.ErrPrivateHost) {
+			t.Errorf("Expected %s to be refused, got %v", target, err)
+		}
+	}
+}
+
+func TestSignedWebhook(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"type":"chore.completed"}`)
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var timestamp int64
+		fmt.Sscan(r.Header.Get(delivery.TimestampHeader), &timestamp)
+		if !delivery.VerifySignature(secret, timestamp, body, r.Header.Get(delivery.SignatureHeader)) {
+			t.Errorf("Expected a valid signature, got %q", r.Header.Get(delivery.SignatureHeader))
+		}
+		if r.Header.Get(delivery.EventHeader) != "chore.completed" || r.Header.Get(delivery.DeliveryHeader) != "d1" {
+			t.Errorf("Unexpected event headers %v", r.Header)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	// The test server is on loopback, which the default client refuses
+	sender := delivery.NewSignedWebhook()
+	request := delivery.SignedRequest{URL: server.URL, Secret: secret, Event: "chore.completed", DeliveryID: "d1", Payload: payload}
+	if _, err := sender.Post(context.Background(), request); !errors.Is(err, models.ErrPrivateHost) || !delivery.IsPermanent(err) {
+		t.Fatalf("Expected a permanent failure posting to loopback, got %v", err)
+	}
+
+	sender.Client = server.Client()
+	code, err := sender.Post(context.Background(), request)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("Expected the webhook to succeed, got %d %v", code, err)
+	}
+
+	status = http.StatusBadGateway
+	if code, err := sender.Post(context.Background(), request); code != http.StatusBadGateway || err == nil || delivery.IsPermanent(err) {
+		t.Errorf("Expected a retryable failure with its status, got %d %v", code, err)
+	}
+
+	if delivery.VerifySignature("other", 1, payload, delivery.Sign(secret, 1, payload)) {
+		t.Error("Expected a signature from another secret to be rejected")
+	}
+	if delivery.VerifySignature(secret, 2, payload, delivery.Sign(secret, 1, payload)) {
+		t.Error("Expected a signature for another timestamp to be rejected")
+	}
+}
+
+func TestSMSChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Accounts/AC123/Messages.json" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "AC123" || pass != "secret" {
+			t.Errorf("Expected account credentials, got %q %q", user, pass)
+		}
+		r.ParseForm()
+		if r.Form.Get("To") != "+15551234567" || r.Form.Get("From") != "+15550000000" {
+			t.Errorf("Unexpected numbers %v", r.Form)
+		}
+		if r.Form.Get("Body") != "Overdue: Take out trash is overdue" {
+			t.Errorf("Unexpected body %q", r.Form.Get("Body"))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	channel := delivery.NewSMSChannel(server.URL, "AC123", "secret", "+15550000000")
+	msg := delivery.Message{
+		Target:  models.DeliveryTarget{Address: "+15551234567"},
+		Subject: "Overdue",
+		Body:    "Take out trash is overdue",
+	}
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected the text to send, got %v", err)
+	}
+
+	msg.Target.Address = ""
+	if err := channel.Send(context.Background(), msg); !delivery.IsPermanent(err) {
+		t.Errorf("Expected a missing number to be permanent, got %v", err)
+	}
+}
+
+// hkdf mirrors RFC 5869 for a single output block
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+// decryptPush decrypts an aes128gcm push body the way a browser would
+func decryptPush(body []byte, subscriber *ecdh.PrivateKey, authSecret []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("body too short for the aes128gcm header")
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != 4096 {
+		return nil, fmt.Errorf("unexpected record size %d", rs)
+	}
+	keyLen := int(body[20])
+	asPublicBytes := body[21 : 21+keyLen]
+	ciphertext := body[21+keyLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := subscriber.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), subscriber.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm := hkdf(authSecret, shared, keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		return nil, errors.New("missing the last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1], nil
+}
+
+func TestWebPushChannel(t *testing.T) {
+	publicKey, privateKey, err := delivery.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel, err := delivery.NewWebPushChannel("mailto:admin@example.com", publicKey, privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create push channel: %v", err)
+	}
+	channel.Client = http.DefaultClient // The test server is on loopback
+
+	subscriber, _ := ecdh.P256().GenerateKey(rand.Reader)
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+			t.Errorf("Missing push headers: %v", r.Header)
+		}
+
+		// The VAPID token must verify against the advertised public key
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "vapid t=")
+		token, key, _ := strings.Cut(auth, ", k=")
+		if key != publicKey {
+			t.Errorf("Expected k=%s, got %s", publicKey, key)
+		}
+		parsed, err := jwt.Parse(token, func(tok *jwt.Token) (interface{}, error) {
+			raw, _ := base64.RawURLEncoding.DecodeString(key)
+			pub, err := ecdh.P256().NewPublicKey(raw)
+			if err != nil {
+				return nil, err
+			}
+			return ecdhToECDSA(pub), nil
+		})
+		if err != nil || !parsed.Valid {
+			t.Errorf("Invalid VAPID token: %v", err)
+		} else if claims := parsed.Claims.(jwt.MapClaims); claims["aud"] != "http://"+r.Host {
+			t.Errorf("Unexpected audience %v", claims["aud"])
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var payload delivery.WebPushPayload
+		plaintext, err := decryptPush(body, subscriber, authSecret)
+		if err != nil {
+			t.Errorf("Failed to decrypt push payload: %v", err)
+		} else if err := json.Unmarshal(plaintext, &payload); err != nil {
+			t.Errorf("Invalid payload: %v", err)
+		}
+		if payload.Title != "Expiring soon" || payload.Body != "Yogurt expires tomorrow" {
+			t.Errorf("Unexpected payload %+v", payload)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	msg := delivery.Message{
+		Target: models.DeliveryTarget{
+			Address: server.URL + "/push/abc",
+			P256dh:  base64.RawURLEncoding.EncodeToString(subscriber.PublicKey().Bytes()),
+			Auth:    base64.RawURLEncoding.EncodeToString(authSecret),
+		},
+		Subject: "Expiring soon",
+		Body:    "Yogurt expires tomorrow",
+	}
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected the push to send, got %v", err)
+	}
+
+	status = http.StatusGone
+	err = channel.Send(context.Background(), msg)
+	if !errors.Is(err, delivery.ErrSubscriptionGone) || !delivery.IsPermanent(err) {
+		t.Errorf("Expected an expired subscription error, got %v", err)
+	}
+
+	if _, err := delivery.NewWebPushChannel("mailto:admin@example.com", "bogus", privateKey); err == nil {
+		t.Error("Expected a mismatched public key to be rejected")
+	}
+}
+
+func TestSMTPChannel(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// A minimal SMTP server that accepts messages one connection at a time
+	received := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			serveSMTP(conn, received)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	channel := delivery.NewSMTPChannel("127.0.0.1", addr.Port, "", "", "Cribb <noreply@example.com>")
+	msg := delivery.Message{
+		Target:  models.DeliveryTarget{Address: "alice@example.com"},
+		Subject: "Your weekly digest",
+		Body:    "3 chores due",
+	}
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected the email to send, got %v", err)
+	}
+
+	email := <-received
+	for _, want := range []string{"To: <alice@example.com>", "Subject: Your weekly digest", "3 chores due"} {
+		if !strings.Contains(email, want) {
+			t.Errorf("Expected the email to contain %q:\n%s", want, email)
+		}
+	}
+
+	msg.HTML = "<p><b>3</b> chores due</p>"
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected the HTML email to send, got %v", err)
+	}
+	email = <-received
+	for _, want := range []string{"multipart/alternative", "text/plain", "text/html", "<b>3</b> chores due"} {
+		if !strings.Contains(email, want) {
+			t.Errorf("Expected the HTML email to contain %q:\n%s", want, email)
+		}
+	}
+
+	msg.Target.Address = "not an address"
+	if err := channel.Send(context.Background(), msg); !delivery.IsPermanent(err) {
+		t.Errorf("Expected an invalid address to be permanent, got %v", err)
+	}
+}
+
+// serveSMTP speaks just enough SMTP to accept messages on one connection
+func serveSMTP(conn net.Conn, received chan<- string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	var data strings.Builder
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				received <- data.String()
+				data.Reset()
+				reply("250 OK")
+				continue
+			}
+			data.WriteString(line)
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			inData = true
+			reply("354 Go ahead")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// ecdhToECDSA converts a P-256 public key for JWT verification
+func ecdhToECDSA(pub *ecdh.PublicKey) *ecdsa.PublicKey {
+	point := pub.Bytes()
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(point[1:33]),
+		Y:     new(big.Int).SetBytes(point[33:]),
+	}
+}
@@ -0,0 +1,49 @@
+// delivery/fake.go
+package delivery
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Fake records messages instead of sending them. Tests register it in place
+// of a real channel; set Err to simulate failures.
+type Fake struct {
+	mu   sync.Mutex
+	sent []Message
+	Err  error
+}
+
+// NewFake creates a fake channel that accepts every message
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Send records the message, or returns Err if it's set
+func (f *Fake) Send(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// Sent returns the messages accepted so far
+func (f *Fake) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
+
+// LogChannel is the local stand-in for channels that aren't configured:
+// it logs each message so development setups work without provider accounts
+type LogChannel struct{}
+
+// Send logs the message
+func (LogChannel) Send(ctx context.Context, msg Message) error {
+	log.Printf("[%s] to %s: %s - %s", msg.Channel, msg.Target.Address, msg.Subject, msg.Body)
+	return nil
+}
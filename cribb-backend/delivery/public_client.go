@@ -0,0 +1,50 @@
+// delivery/public_client.go
+package delivery
+
+import (
+	"cribb-backend/models"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// PublicClient returns an HTTP client for posting to URLs users give us. It
+// refuses to connect to loopback, private, link-local and other internal
+// addresses, checking the address actually dialed so hostnames that resolve
+// inside the network are caught too. Redirects aren't followed.
+func PublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   refusePrivateAddresses,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // A proxy would make the dialed address meaningless
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   timeout,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivateAddresses runs before each connection with the resolved address
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !models.IsPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("%w: %s", models.ErrPrivateHost, host)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// delivery/sms.go
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultSMSBaseURL is the Twilio REST API
+const DefaultSMSBaseURL = "https://api.twilio.com/2010-04-01"
+
+// smsMaxLength keeps texts to a few segments
+const smsMaxLength = 320
+
+// SMSChannel sends text messages through a Twilio-compatible REST API
+type SMSChannel struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+	Client     *http.Client
+}
+
+// NewSMSChannel creates an SMS channel for a provider account
+func NewSMSChannel(baseURL, accountSID, authToken, from string) *SMSChannel {
+	if baseURL == "" {
+		baseURL = DefaultSMSBaseURL
+	}
+	return &SMSChannel{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// smsText combines the subject and body and trims it to a sensible length
+func smsText(msg Message) string {
+	text := msg.Body
+	if msg.Subject != "" {
+		text = msg.Subject + ": " + msg.Body
+	}
+	if runes := []rune(text); len(runes) > smsMaxLength {
+		text = string(runes[:smsMaxLength-1]) + "…"
+	}
+	return text
+}
+
+// Send texts the message to the target phone number
+func (c *SMSChannel) Send(ctx context.Context, msg Message) error {
+	to := strings.TrimSpace(msg.Target.Address)
+	if to == "" {
+		return Permanent(fmt.Errorf("no phone number to text"))
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", c.From)
+	form.Set("Body", smsText(msg))
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", c.BaseURL, url.PathEscape(c.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError("SMS provider", resp)
+	}
+	return nil
+}
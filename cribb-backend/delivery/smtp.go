@@ -0,0 +1,100 @@
+// delivery/smtp.go
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// SMTPChannel sends email through an SMTP server
+type SMTPChannel struct {
+	Addr string // host:port
+	Auth smtp.Auth
+	From string
+}
+
+// NewSMTPChannel creates an email channel using PLAIN auth when a username is given
+func NewSMTPChannel(host string, port int, username, password, from string) *SMTPChannel {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPChannel{
+		Addr: fmt.Sprintf("%s:%d", host, port),
+		Auth: auth,
+		From: from,
+	}
+}
+
+// buildEmail renders an email with its headers. With an HTML body the email
+// is multipart/alternative so clients without HTML show the plain text.
+func buildEmail(from, to *mail.Address, subject, body, html string, now time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if html == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+		buf.WriteString("\r\n")
+		buf.WriteString(body)
+		buf.WriteString("\r\n")
+		return buf.Bytes()
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n", parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", body},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.content))
+		qp.Close()
+	}
+	parts.Close()
+	return buf.Bytes()
+}
+
+// Send emails the message to the target address
+func (c *SMTPChannel) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid sender address %q", c.From))
+	}
+	to, err := mail.ParseAddress(msg.Target.Address)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid email address %q", msg.Target.Address))
+	}
+
+	email := buildEmail(from, to, msg.Subject, msg.Body, msg.HTML, time.Now())
+	err = smtp.SendMail(c.Addr, c.Auth, from.Address, []string{to.Address}, email)
+
+	// 5xx replies mean the server rejected the mail for good
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+// delivery/webhook.go
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookPayload is the JSON body posted to webhook URLs
+type WebhookPayload struct {
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	HTML    string    `json:"html,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// WebhookChannel posts messages as JSON to the target URL
+type WebhookChannel struct {
+	Client *http.Client
+}
+
+// NewWebhookChannel creates a webhook channel with a short timeout that only
+// posts to public addresses
+func NewWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{Client: PublicClient(10 * time.Second)}
+}
+
+// Send posts the message to the target URL
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(WebhookPayload{
+		Subject: msg.Subject,
+		Body:    msg.Body,
+		HTML:    msg.HTML,
+		SentAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Target.Address, bytes.NewReader(payload))
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid webhook URL: %v", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Cribb-Webhooks/1.0")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		if errors.Is(err, models.ErrPrivateHost) {
+			return Permanent(err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError("webhook", resp)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+// delivery/webpush.go
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"cribb-backend/models"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// webPushTTL is how long a push service holds a message for an offline device
+	webPushTTL = 24 * time.Hour
+
+	// webPushRecordSize is the aes128gcm record size; payloads fit in one record
+	webPushRecordSize = 4096
+
+	// vapidTokenLifetime is how long the signed VAPID token is valid
+	vapidTokenLifetime = 12 * time.Hour
+)
+
+// WebPushPayload is the JSON the service worker receives
+type WebPushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// WebPushChannel sends Web Push messages (RFC 8030) with payloads encrypted
+// per RFC 8291 and VAPID authentication (RFC 8292)
+type WebPushChannel struct {
+	Subject    string // mailto: or https: contact for the push service
+	PublicKey  string // VAPID public key, base64url uncompressed P-256 point
+	privateKey *ecdsa.PrivateKey
+	Client     *http.Client
+}
+
+// b64 is the unpadded base64url encoding Web Push uses for keys
+var b64 = base64.RawURLEncoding
+
+// decodeKey accepts base64url keys with or without padding
+func decodeKey(key string) ([]byte, error) {
+	return b64.DecodeString(trimPadding(key))
+}
+
+func trimPadding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// NewWebPushChannel creates a push channel from a VAPID key pair. The private
+// key is the base64url encoded 32 byte P-256 scalar.
+func NewWebPushChannel(subject, publicKey, privateKey string) (*WebPushChannel, error) {
+	raw, err := decodeKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+
+	// The uncompressed point is 0x04 || X || Y
+	point := ecdhKey.PublicKey().Bytes()
+	if publicKey != "" {
+		given, err := decodeKey(publicKey)
+		if err != nil || !bytes.Equal(given, point) {
+			return nil, errors.New("VAPID public key doesn't match the private key")
+		}
+	}
+
+	signingKey := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+
+	return &WebPushChannel{
+		Subject:    subject,
+		PublicKey:  b64.EncodeToString(point),
+		privateKey: signingKey,
+		Client:     PublicClient(10 * time.Second),
+	}, nil
+}
+
+// GenerateVAPIDKeys creates a new VAPID key pair, base64url encoded
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return b64.EncodeToString(key.PublicKey().Bytes()), b64.EncodeToString(key.Bytes()), nil
+}
+
+// hkdf derives length bytes from ikm (RFC 5869); Web Push only needs one block
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+// encryptPayload encrypts plaintext for a subscription with the aes128gcm
+// content coding from RFC 8291. salt and the ephemeral key are taken as
+// arguments so tests can reproduce the RFC's example.
+func encryptPayload(plaintext []byte, userPublic, authSecret, salt []byte, ephemeral *ecdh.PrivateKey) ([]byte, error) {
+	uaPublic, err := ecdh.P256().NewPublicKey(userPublic)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid subscription key: %v", err))
+	}
+	sharedSecret, err := ephemeral.ECDH(uaPublic)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+	asPublic := ephemeral.PublicKey().Bytes()
+
+	// Mix the auth secret and both public keys into the input keying material
+	keyInfo := append([]byte("WebPush: info\x00"), userPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, sharedSecret, keyInfo, 32)
+
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record, ended with the last-record delimiter
+	record := append(append([]byte{}, plaintext...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// vapidAuthorization signs the VAPID token for the push service at endpoint
+func (c *WebPushChannel) vapidAuthorization(endpoint string, now time.Time) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", Permanent(err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+		"sub": c.Subject,
+	})
+	signed, err := token.SignedString(c.privateKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, c.PublicKey), nil
+}
+
+// Send pushes the message to the subscription's endpoint
+func (c *WebPushChannel) Send(ctx context.Context, msg Message) error {
+	userPublic, err := decodeKey(msg.Target.P256dh)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid subscription key: %v", err))
+	}
+	authSecret, err := decodeKey(msg.Target.Auth)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid subscription auth secret: %v", err))
+	}
+
+	payload, err := json.Marshal(WebPushPayload{Title: msg.Subject, Body: msg.Body})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	body, err := encryptPayload(payload, userPublic, authSecret, salt, ephemeral)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := c.vapidAuthorization(msg.Target.Address, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Target.Address, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid push endpoint: %v", err))
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		if errors.Is(err, models.ErrPrivateHost) {
+			return Permanent(err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return Permanent(ErrSubscriptionGone)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return statusError("push service", resp)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package digest renders household digests to HTML and plain text.
+package digest
+
+import (
+	"bytes"
+	"cribb-backend/models"
+	"embed"
+	htmltemplate "html/template"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*
+var templateFiles embed.FS
+
+// Rendered is a digest ready to send
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// funcs formats values for both templates. Dates are shown in loc.
+func funcs(loc *time.Location) map[string]any {
+	return map[string]any{
+		"date": func(t time.Time) string {
+			return t.In(loc).Format("Mon Jan 2")
+		},
+		"datetime": func(t time.Time) string {
+			return t.In(loc).Format("Mon Jan 2 15:04")
+		},
+		"quantity": func(q float64) string {
+			return strconv.FormatFloat(q, 'f', -1, 64)
+		},
+	}
+}
+
+// Render fills the HTML and plain text templates with the digest
+func Render(d *models.Digest) (*Rendered, error) {
+	loc := d.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	text, err := texttemplate.New("digest.txt").Funcs(funcs(loc)).ParseFS(templateFiles, "templates/digest.txt")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New("digest.html").Funcs(funcs(loc)).ParseFS(templateFiles, "templates/digest.html")
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &Rendered{Subject: d.Subject()}
+	var buf bytes.Buffer
+	if err := text.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	rendered.Text = buf.String()
+
+	buf.Reset()
+	if err := html.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	rendered.HTML = buf.String()
+	return rendered, nil
+}
@@ -0,0 +1,69 @@
+package digest_test
+
+import (
+	"cribb-backend/digest"
+	"cribb-backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2025, 3, 12, 8, 0, 0, 0, time.UTC)
+	d := &models.Digest{
+		UserName:      "Alice",
+		GroupName:     "Maple House",
+		Frequency:     models.DigestDaily,
+		Since:         now.AddDate(0, 0, -1),
+		GeneratedAt:   now,
+		ChoresDue:     []models.DigestChore{{Title: "Take out trash", DueDate: now.AddDate(0, 0, 2), Points: 5}},
+		ExpiringItems: []models.DigestPantryItem{{Name: "Milk", Quantity: 1.5, Unit: "L", ExpirationDate: now.AddDate(0, 0, 1)}},
+		CartActivity:  []models.DigestActivity{{UserName: "Bob", ItemName: "Eggs", Details: "Added <Eggs> to the cart"}},
+	}
+
+	rendered, err := digest.Render(d)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if rendered.Subject != "Your daily Maple House digest" {
+		t.Errorf("Unexpected subject %q", rendered.Subject)
+	}
+
+	for _, want := range []string{"Hi Alice", "CHORES DUE THIS WEEK", "Take out trash, due Fri Mar 14 (5 pts)", "Milk: 1.5 L, expires Thu Mar 13", "Bob: Added <Eggs> to the cart"} {
+		if !strings.Contains(rendered.Text, want) {
+			t.Errorf("Expected the text digest to contain %q:\n%s", want, rendered.Text)
+		}
+	}
+	if strings.Contains(rendered.Text, "OVERDUE") || strings.Contains(rendered.Text, "RUNNING LOW") {
+		t.Errorf("Expected empty sections to be left out:\n%s", rendered.Text)
+	}
+
+	if !strings.Contains(rendered.HTML, "Added &lt;Eggs&gt; to the cart") {
+		t.Errorf("Expected cart details to be escaped in HTML:\n%s", rendered.HTML)
+	}
+	if strings.Contains(rendered.HTML, "Overdue chores") {
+		t.Errorf("Expected empty sections to be left out of HTML:\n%s", rendered.HTML)
+	}
+}
+
+func TestRenderUsesTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	due := time.Date(2025, 3, 12, 20, 0, 0, 0, time.UTC) // Already Thursday in Tokyo
+	d := &models.Digest{
+		UserName:      "Alice",
+		GroupName:     "Maple House",
+		Location:      loc,
+		OverdueChores: []models.DigestChore{{Title: "Dishes", DueDate: due}},
+	}
+
+	rendered, err := digest.Render(d)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(rendered.Text, "Dishes (was due Thu Mar 13)") {
+		t.Errorf("Expected the due date in the user's timezone:\n%s", rendered.Text)
+	}
+}
@@ -0,0 +1,249 @@
+// events/bus.go
+package events
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Type names a kind of change roommates can be told about
+type Type string
+
+const (
+	CartItemAdded   Type = "cart.item_added"
+	CartItemUpdated Type = "cart.item_updated"
+	CartItemDeleted Type = "cart.item_deleted"
+
+	PantryItemAdded   Type = "pantry.item_added"
+	PantryItemUsed    Type = "pantry.item_used"
+	PantryItemRemoved Type = "pantry.item_removed"
+
+	PantryLowStock   Type = "pantry.low_stock"
+	PantryOutOfStock Type = "pantry.out_of_stock"
+
+	ChoreCompleted Type = "chore.completed"
+	ChoreAssigned  Type = "chore.assigned"
+	ChoreOverdue   Type = "chore.overdue"
+
+	// WebhookTest is only sent to a webhook to check it's reachable
+	WebhookTest Type = "webhook.test"
+
+	// Reset tells a resuming client that events were missed and it should refetch
+	Reset Type = "reset"
+)
+
+const (
+	// DefaultHistorySize is how many recent events each group keeps for resuming
+	DefaultHistorySize = 256
+
+	// subscriberBuffer is how many events a subscriber can fall behind before it's dropped
+	subscriberBuffer = 64
+)
+
+// Event is a change within a group. IDs increase within a group, including
+// across restarts, so clients can resume from the last one they saw.
+type Event struct {
+	ID      uint64             `json:"id"`
+	Type    Type               `json:"type"`
+	GroupID primitive.ObjectID `json:"group_id"`
+	ActorID primitive.ObjectID `json:"actor_id,omitempty"`
+	Data    interface{}        `json:"data,omitempty"`
+	Time    time.Time          `json:"time"`
+}
+
+// Types lists the event types external listeners can subscribe to
+var Types = []Type{
+	CartItemAdded, CartItemUpdated, CartItemDeleted,
+	PantryItemAdded, PantryItemUsed, PantryItemRemoved, PantryLowStock, PantryOutOfStock,
+	ChoreCompleted, ChoreAssigned, ChoreOverdue,
+}
+
+// IsKnown checks if the type is one listeners can subscribe to
+func (t Type) IsKnown() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Hook is called with every event published on a bus. Hooks run on the
+// publisher's goroutine, so they must not block.
+type Hook func(Event)
+
+// Subscription receives a group's events until it's closed. The channel is
+// closed if the subscriber falls too far behind; it should reconnect and resume.
+type Subscription struct {
+	Events <-chan Event
+
+	bus     *Bus
+	groupID primitive.ObjectID
+	ch      chan Event
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s.groupID, s.ch)
+}
+
+// groupStream holds a group's recent events and subscribers
+type groupStream struct {
+	history     []Event // Ring buffer of the most recent events
+	start       int     // Index of the oldest event in history
+	count       int
+	lastID      uint64
+	subscribers map[chan Event]struct{}
+}
+
+// Bus fans events out to the subscribers of each group
+type Bus struct {
+	mu          sync.Mutex
+	groups      map[primitive.ObjectID]*groupStream
+	historySize int
+	firstID     uint64
+	now         func() time.Time
+	hooks       []Hook
+}
+
+// NewBus creates a bus that keeps historySize events per group for resuming
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		groups:      make(map[primitive.ObjectID]*groupStream),
+		historySize: historySize,
+		// Start above any ID handed out before a restart
+		firstID: uint64(time.Now().UnixMilli()) * 1000,
+		now:     time.Now,
+	}
+}
+
+// Default is the bus handlers publish to
+var Default = NewBus(DefaultHistorySize)
+
+// Publish sends an event to the default bus
+func Publish(groupID primitive.ObjectID, eventType Type, actorID primitive.ObjectID, data interface{}) Event {
+	return Default.Publish(groupID, eventType, actorID, data)
+}
+
+// AddHook registers a hook on the default bus
+func AddHook(hook Hook) {
+	Default.AddHook(hook)
+}
+
+// AddHook registers a hook called with every event published on the bus
+func (b *Bus) AddHook(hook Hook) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hooks = append(b.hooks, hook)
+}
+
+// stream returns the group's stream, creating it if needed. Callers hold the lock.
+func (b *Bus) stream(groupID primitive.ObjectID) *groupStream {
+	stream, exists := b.groups[groupID]
+	if !exists {
+		stream = &groupStream{
+			history:     make([]Event, b.historySize),
+			lastID:      b.firstID,
+			subscribers: make(map[chan Event]struct{}),
+		}
+		b.groups[groupID] = stream
+	}
+	return stream
+}
+
+// Publish records an event and sends it to the group's subscribers
+func (b *Bus) Publish(groupID primitive.ObjectID, eventType Type, actorID primitive.ObjectID, data interface{}) Event {
+	b.mu.Lock()
+
+	stream := b.stream(groupID)
+	stream.lastID++
+	event := Event{
+		ID:      stream.lastID,
+		Type:    eventType,
+		GroupID: groupID,
+		ActorID: actorID,
+		Data:    data,
+		Time:    b.now(),
+	}
+
+	if stream.count < b.historySize {
+		stream.history[(stream.start+stream.count)%b.historySize] = event
+		stream.count++
+	} else {
+		stream.history[stream.start] = event
+		stream.start = (stream.start + 1) % b.historySize
+	}
+
+	for ch := range stream.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Too far behind; the client resumes from its last event ID
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+	}
+	hooks := b.hooks
+	b.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(event)
+	}
+	return event
+}
+
+// Subscribe starts receiving a group's events. With a last event ID, the events
+// published since then are returned to be sent first; if some of them are no
+// longer kept, a single reset event is returned instead.
+func (b *Bus) Subscribe(groupID primitive.ObjectID, lastEventID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream := b.stream(groupID)
+	ch := make(chan Event, subscriberBuffer)
+	stream.subscribers[ch] = struct{}{}
+	subscription := &Subscription{Events: ch, bus: b, groupID: groupID, ch: ch}
+
+	if lastEventID == 0 || lastEventID >= stream.lastID {
+		return subscription, nil
+	}
+
+	oldestKept := stream.lastID - uint64(stream.count) + 1
+	if lastEventID+1 < oldestKept || lastEventID < b.firstID {
+		return subscription, []Event{{
+			ID:      stream.lastID,
+			Type:    Reset,
+			GroupID: groupID,
+			Time:    b.now(),
+		}}
+	}
+
+	missed := make([]Event, 0, stream.lastID-lastEventID)
+	for i := 0; i < stream.count; i++ {
+		event := stream.history[(stream.start+i)%b.historySize]
+		if event.ID > lastEventID {
+			missed = append(missed, event)
+		}
+	}
+	return subscription, missed
+}
+
+// unsubscribe removes a subscriber if it's still registered
+func (b *Bus) unsubscribe(groupID primitive.ObjectID, ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream, exists := b.groups[groupID]
+	if !exists {
+		return
+	}
+	if _, subscribed := stream.subscribers[ch]; subscribed {
+		delete(stream.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPublishReachesGroupSubscribers(t *testing.T) {
+	bus := NewBus(8)
+	group, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+	sub, missed := bus.Subscribe(group, 0)
+	defer sub.Close()
+	if len(missed) != 0 {
+		t.Fatalf("Expected no backlog for a new subscriber, got %d", len(missed))
+	}
+
+	bus.Publish(other, CartItemAdded, primitive.NilObjectID, nil)
+	published := bus.Publish(group, PantryItemUsed, primitive.NewObjectID(), map[string]float64{"quantity": 2})
+
+	select {
+	case event := <-sub.Events:
+		if event.ID != published.ID || event.Type != PantryItemUsed {
+			t.Errorf("Unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("Expected the group's event to be delivered")
+	}
+
+	select {
+	case event := <-sub.Events:
+		t.Errorf("Expected no events from other groups, got %+v", event)
+	default:
+	}
+}
+
+func TestSubscribeResumesFromLastEventID(t *testing.T) {
+	bus := NewBus(4)
+	group := primitive.NewObjectID()
+
+	first := bus.Publish(group, CartItemAdded, primitive.NilObjectID, nil)
+	bus.Publish(group, CartItemUpdated, primitive.NilObjectID, nil)
+	last := bus.Publish(group, CartItemDeleted, primitive.NilObjectID, nil)
+
+	sub, missed := bus.Subscribe(group, first.ID)
+	sub.Close()
+	if len(missed) != 2 || missed[0].Type != CartItemUpdated || missed[1].ID != last.ID {
+		t.Errorf("Expected the two events after the first, got %+v", missed)
+	}
+
+	sub, missed = bus.Subscribe(group, last.ID)
+	sub.Close()
+	if len(missed) != 0 {
+		t.Errorf("Expected nothing missed when up to date, got %+v", missed)
+	}
+
+	// Push the first events out of the history
+	for i := 0; i < 4; i++ {
+		bus.Publish(group, ChoreAssigned, primitive.NilObjectID, nil)
+	}
+	sub, missed = bus.Subscribe(group, first.ID)
+	sub.Close()
+	if len(missed) != 1 || missed[0].Type != Reset {
+		t.Errorf("Expected a reset when events were dropped, got %+v", missed)
+	}
+
+	// IDs from before a restart are older than anything this bus handed out
+	sub, missed = bus.Subscribe(primitive.NewObjectID(), 42)
+	sub.Close()
+	if len(missed) != 1 || missed[0].Type != Reset {
+		t.Errorf("Expected a reset for an unknown event ID, got %+v", missed)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	bus := NewBus(8)
+	group := primitive.NewObjectID()
+
+	sub, _ := bus.Subscribe(group, 0)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		bus.Publish(group, CartItemAdded, primitive.NilObjectID, i)
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("Expected the buffered events before the channel closed, got %d", received)
+	}
+
+	// Closing after being dropped is harmless
+	sub.Close()
+}
+
+func TestHooksSeeEveryEvent(t *testing.T) {
+	bus := NewBus(4)
+	var seen []Type
+	bus.AddHook(func(event Event) { seen = append(seen, event.Type) })
+
+	bus.Publish(primitive.NewObjectID(), ChoreOverdue, primitive.NilObjectID, nil)
+	bus.Publish(primitive.NewObjectID(), PantryLowStock, primitive.NilObjectID, nil)
+
+	if len(seen) != 2 || seen[0] != ChoreOverdue || seen[1] != PantryLowStock {
+		t.Errorf("Expected the hook to see both events in order, got %v", seen)
+	}
+	if !PantryLowStock.IsKnown() || Reset.IsKnown() || WebhookTest.IsKnown() {
+		t.Error("Expected only subscribable types to be known")
+	}
+}
@@ -0,0 +1,309 @@
+// handlers/account.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DeleteAccountRequest confirms an account deletion with the user's password
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// PersonalDataExport is everything stored about a user
+type PersonalDataExport struct {
+	ExportedAt              time.Time                       `json:"exported_at"`
+	Profile                 models.User                     `json:"profile"`
+	NotificationPreferences *models.NotificationPreferences `json:"notification_preferences,omitempty"`
+	PushSubscriptions       []models.PushSubscription       `json:"push_subscriptions"`
+	CalendarFeeds           []models.CalendarFeed           `json:"calendar_feeds"`
+	Chores                  []models.Chore                  `json:"chores"`
+	RecurringChores         []models.RecurringChore         `json:"recurring_chores"`
+	ChoreCompletions        []models.ChoreCompletion        `json:"chore_completions"`
+	PantryItems             []models.PantryItem             `json:"pantry_items"`
+	PantryHistory           []models.PantryHistory          `json:"pantry_history"`
+	ShoppingCart            []models.ShoppingCartItem       `json:"shopping_cart"`
+	ShoppingCartActivity    []models.ShoppingCartActivity   `json:"shopping_cart_activity"`
+	GroupList               []models.GroupListItem          `json:"group_list"`
+	ShoppingTrips           []models.ShoppingTrip           `json:"shopping_trips"`
+	Notifications           []models.Notification           `json:"notifications"`
+	Expenses                []models.Expense                `json:"expenses"`
+	Settlements             []models.Settlement             `json:"settlements"`
+	BillInstances           []models.BillInstance           `json:"bill_instances"`
+	BillReminders           []models.BillReminder           `json:"bill_reminders"`
+	MealPlan                []models.MealPlanEntry          `json:"meal_plan"`
+	Recipes                 []models.Recipe                 `json:"recipes"`
+}
+
+// IsSessionRevoked checks tokens against the user's latest session revocation.
+// It's installed as middleware.SessionRevoked at startup.
+func IsSessionRevoked(userID string, issuedAt time.Time) bool {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false
+	}
+
+	var revocation models.SessionRevocation
+	err = config.DB.Collection("session_revocations").FindOne(
+		context.Background(),
+		bson.M{"user_id": id},
+		options.FindOne().SetSort(bson.D{{Key: "revoked_at", Value: -1}}),
+	).Decode(&revocation)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Failed to check session revocation: %v", err)
+		}
+		return false
+	}
+	return revocation.Revokes(issuedAt)
+}
+
+// ExportPersonalDataHandler downloads everything stored about the user as JSON
+func ExportPersonalDataHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	export := PersonalDataExport{
+		ExportedAt:           time.Now(),
+		Profile:              user,
+		PushSubscriptions:    make([]models.PushSubscription, 0),
+		CalendarFeeds:        make([]models.CalendarFeed, 0),
+		Chores:               make([]models.Chore, 0),
+		RecurringChores:      make([]models.RecurringChore, 0),
+		ChoreCompletions:     make([]models.ChoreCompletion, 0),
+		PantryItems:          make([]models.PantryItem, 0),
+		PantryHistory:        make([]models.PantryHistory, 0),
+		ShoppingCart:         make([]models.ShoppingCartItem, 0),
+		ShoppingCartActivity: make([]models.ShoppingCartActivity, 0),
+		GroupList:            make([]models.GroupListItem, 0),
+		ShoppingTrips:        make([]models.ShoppingTrip, 0),
+		Notifications:        make([]models.Notification, 0),
+		Expenses:             make([]models.Expense, 0),
+		Settlements:          make([]models.Settlement, 0),
+		BillInstances:        make([]models.BillInstance, 0),
+		BillReminders:        make([]models.BillReminder, 0),
+		MealPlan:             make([]models.MealPlanEntry, 0),
+		Recipes:              make([]models.Recipe, 0),
+	}
+
+	var prefs models.NotificationPreferences
+	err := config.DB.Collection(notifications.PreferencesCollection).FindOne(ctx, bson.M{"user_id": user.ID}).Decode(&prefs)
+	if err == nil {
+		export.NotificationPreferences = &prefs
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Failed to export notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	byUser := bson.M{"user_id": user.ID}
+	loads := []struct {
+		collection string
+		filter     bson.M
+		out        interface{}
+	}{
+		{notifications.PushSubscriptionsCollection, byUser, &export.PushSubscriptions},
+		{"calendar_feeds", byUser, &export.CalendarFeeds},
+		{"chores", bson.M{"assigned_to": user.ID}, &export.Chores},
+		{"recurring_chores", bson.M{"member_rotation": user.ID}, &export.RecurringChores},
+		{"chore_completions", byUser, &export.ChoreCompletions},
+		{"pantry_items", bson.M{"$or": []bson.M{{"added_by": user.ID}, {"batches.purchased_by": user.ID}}}, &export.PantryItems},
+		{"pantry_history", byUser, &export.PantryHistory},
+		{"shopping_cart", byUser, &export.ShoppingCart},
+		{"shopping_cart_activity", byUser, &export.ShoppingCartActivity},
+		{"group_list", bson.M{"$or": []bson.M{{"requests.user_id": user.ID}, {"claimed_by": user.ID}}}, &export.GroupList},
+		{"shopping_trips", bson.M{"shopper_id": user.ID}, &export.ShoppingTrips},
+		{notifications.Collection, bson.M{"$or": []bson.M{
+			{"user_id": user.ID},
+			{"actor_id": user.ID},
+			{"subject_id": user.ID, "type": bson.M{"$in": models.UserSubjectTypes}},
+		}}, &export.Notifications},
+		{"expenses", bson.M{"$or": []bson.M{{"paid_by": user.ID}, {"created_by": user.ID}, {"splits.user_id": user.ID}}}, &export.Expenses},
+		{"settlements", bson.M{"$or": []bson.M{{"from_user": user.ID}, {"to_user": user.ID}}}, &export.Settlements},
+		{"bill_instances", bson.M{"shares.user_id": user.ID}, &export.BillInstances},
+		{"bill_reminders", byUser, &export.BillReminders},
+		{"meal_plan", bson.M{"$or": []bson.M{{"created_by": user.ID}, {"cooked_by": user.ID}}}, &export.MealPlan},
+		{"recipes", bson.M{"created_by": user.ID}, &export.Recipes},
+	}
+	for _, load := range loads {
+		if err := loadRecords(ctx, load.collection, load.filter, load.out); err != nil {
+			log.Printf("Failed to export %s of user %s: %v", load.collection, user.ID.Hex(), err)
+			http.Error(w, "Failed to export personal data", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="cribb-personal-data-%s.json"`, export.ExportedAt.Format("2006-01-02")))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(export)
+}
+
+// anonymizeUser removes the user from the group and their personal data,
+// keeping the group's history with their name replaced
+func anonymizeUser(sc mongo.SessionContext, user models.User) error {
+	anonymized := bson.M{"user_id": primitive.NilObjectID, "user_name": models.DeletedUserName}
+	updates := []struct {
+		collection string
+		filter     bson.M
+		update     bson.M
+	}{
+		// History the group keeps, without saying who it was
+		{"pantry_history", bson.M{"user_id": user.ID}, bson.M{"$set": anonymized}},
+		{"shopping_cart_activity", bson.M{"user_id": user.ID}, bson.M{"$set": anonymized}},
+		{"shopping_cart_activity", bson.M{"read_by": user.ID}, bson.M{"$pull": bson.M{"read_by": user.ID}}},
+		{"chore_completions", bson.M{"user_id": user.ID}, bson.M{"$set": bson.M{"user_id": primitive.NilObjectID}}},
+		{"shopping_cart", bson.M{"user_id": user.ID}, bson.M{"$set": bson.M{"user_id": primitive.NilObjectID}}},
+		{"shopping_trips", bson.M{"shopper_id": user.ID}, bson.M{"$set": bson.M{"shopper_id": primitive.NilObjectID, "shopper_name": models.DeletedUserName}}},
+		{"pantry_items", bson.M{"added_by": user.ID}, bson.M{"$set": bson.M{"added_by": primitive.NilObjectID}}},
+		{"group_list", bson.M{"claimed_by": user.ID}, bson.M{"$unset": bson.M{"claimed_by": "", "claimed_by_name": "", "claimed_at": ""}}},
+		{notifications.Collection, bson.M{"actor_id": user.ID}, bson.M{"$unset": bson.M{"actor_id": ""}}},
+		{notifications.Collection, bson.M{"$or": []bson.M{{"read_by": user.ID}, {"hidden_from": user.ID}}}, bson.M{"$pull": bson.M{"read_by": user.ID, "hidden_from": user.ID}}},
+		{"pantry_notifications", bson.M{"read_by": user.ID}, bson.M{"$pull": bson.M{"read_by": user.ID}}},
+
+		// Their chores go back to being unassigned
+		{"chores", bson.M{"assigned_to": user.ID}, bson.M{"$unset": bson.M{"assigned_to": ""}}},
+
+		// Leave the group
+		{"groups", bson.M{"members": user.ID}, bson.M{"$pull": bson.M{"members": user.ID}}},
+		{"groups", bson.M{"admins": user.ID}, bson.M{"$pull": bson.M{"admins": user.ID}}},
+	}
+	for _, u := range updates {
+		if _, err := config.DB.Collection(u.collection).UpdateMany(sc, u.filter, u.update); err != nil {
+			return fmt.Errorf("%s: %w", u.collection, err)
+		}
+	}
+
+	// Requests on the group list keep their quantity but lose the name
+	_, err := config.DB.Collection("group_list").UpdateMany(
+		sc,
+		bson.M{"requests.user_id": user.ID},
+		bson.M{"$set": bson.M{
+			"requests.$[request].user_id":   primitive.NilObjectID,
+			"requests.$[request].user_name": models.DeletedUserName,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"request.user_id": user.ID}},
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("group_list: %w", err)
+	}
+
+	// Personal data that means nothing without them
+	deletes := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{notifications.PreferencesCollection, bson.M{"user_id": user.ID}},
+		{notifications.PushSubscriptionsCollection, bson.M{"user_id": user.ID}},
+		{notifications.OutboxCollection, bson.M{"user_id": user.ID}},
+		{notifications.Collection, bson.M{"user_id": user.ID}},
+		{notifications.Collection, bson.M{"subject_id": user.ID, "type": bson.M{"$in": models.UserSubjectTypes}}}, // e.g. "<name> joined the group"
+		{"calendar_feeds", bson.M{"user_id": user.ID}},
+		{"bill_reminders", bson.M{"user_id": user.ID}},
+	}
+	for _, d := range deletes {
+		if _, err := config.DB.Collection(d.collection).DeleteMany(sc, d.filter); err != nil {
+			return fmt.Errorf("%s: %w", d.collection, err)
+		}
+	}
+
+	// Take them out of rotations, keeping whoever is up next
+	var recurringChores []models.RecurringChore
+	if err := loadRecords(sc, "recurring_chores", bson.M{"member_rotation": user.ID}, &recurringChores); err != nil {
+		return fmt.Errorf("recurring_chores: %w", err)
+	}
+	for _, rc := range recurringChores {
+		rc.RemoveMember(user.ID)
+		_, err := config.DB.Collection("recurring_chores").UpdateByID(sc, rc.ID, bson.M{
+			"$set": bson.M{
+				"member_rotation": rc.MemberRotation,
+				"current_index":   rc.CurrentIndex,
+				"is_active":       rc.IsActive,
+				"updated_at":      time.Now(),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("recurring_chores: %w", err)
+		}
+	}
+
+	if _, err := config.DB.Collection("session_revocations").InsertOne(sc, models.CreateSessionRevocation(user.ID, time.Now())); err != nil {
+		return fmt.Errorf("session_revocations: %w", err)
+	}
+
+	_, err = config.DB.Collection("users").DeleteOne(sc, bson.M{"_id": user.ID})
+	return err
+}
+
+// DeleteAccountHandler deletes the user's account after checking their
+// password. Their entries in the group's history are anonymized, they're
+// removed from their group and chore rotations, and their sessions are revoked.
+// Shared expenses and bills are kept so the other members' balances still add up.
+func DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) != nil {
+		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+		return
+	}
+
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		log.Printf("Session start error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(r.Context(), func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, anonymizeUser(sc, user)
+	})
+	if err != nil {
+		log.Printf("Failed to delete account %s: %v", user.ID.Hex(), err)
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Account deleted successfully",
+	})
+}
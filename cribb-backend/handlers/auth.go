@@ -209,7 +209,8 @@ func GenerateJWTToken(userID, username string) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":       userID,
 		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24 * 7).Unix(), // Token expires in 7 days
+		"iat":      time.Now().Unix(),
+		"exp":      time.Now().Add(models.SessionLifetime).Unix(), // Token expires in 7 days
 	})
 
 	// Sign the token with our secret from config
@@ -0,0 +1,452 @@
+// handlers/bills.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveRecurringBillRequest defines the request structure for creating or updating a recurring bill.
+// Amounts are decimals in the bill's currency.
+type SaveRecurringBillRequest struct {
+	BillID         string             `json:"bill_id,omitempty"` // Set to update an existing bill
+	Name           string             `json:"name"`
+	Category       string             `json:"category,omitempty"`
+	Amount         float64            `json:"amount"`
+	Currency       string             `json:"currency,omitempty"`
+	DueDay         int                `json:"due_day"`
+	IntervalMonths int                `json:"interval_months,omitempty"` // Defaults to monthly
+	ReminderDays   int                `json:"reminder_days,omitempty"`
+	SplitType      string             `json:"split_type,omitempty"`
+	Participants   []string           `json:"participants,omitempty"` // Equal split; defaults to every member
+	Shares         map[string]float64 `json:"shares,omitempty"`
+	Exact          map[string]float64 `json:"exact,omitempty"`
+}
+
+// PayBillRequest defines the request structure for marking a share of a bill paid
+type PayBillRequest struct {
+	InstanceID string `json:"instance_id"`
+	UserID     string `json:"user_id,omitempty"` // Defaults to the current user
+	Paid       *bool  `json:"paid,omitempty"`    // Defaults to true; false undoes a payment
+}
+
+// SaveRecurringBillHandler creates a recurring bill, or updates one when bill_id is given.
+// Changes apply to instances generated from then on.
+func SaveRecurringBillHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveRecurringBillRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	bill := models.CreateRecurringBill(
+		group.ID,
+		request.Name,
+		request.Category,
+		models.ToCents(request.Amount),
+		request.Currency,
+		request.DueDay,
+		request.IntervalMonths,
+		request.ReminderDays,
+		user.ID,
+	)
+	if err := bill.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input, err := splitInputFromRequest(CreateExpenseRequest{
+		Participants: request.Participants,
+		Shares:       request.Shares,
+		Exact:        request.Exact,
+	}, group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := bill.Split(models.SplitType(strings.ToLower(request.SplitType)), input, groupMemberSet(group)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.BillID == "" {
+		bill.NextDueDate = bill.FirstDueDate(time.Now())
+
+		result, err := config.DB.Collection("recurring_bills").InsertOne(context.Background(), bill)
+		if err != nil {
+			log.Printf("Recurring bill creation error: %v", err)
+			http.Error(w, "Failed to create recurring bill", http.StatusInternalServerError)
+			return
+		}
+		bill.ID = result.InsertedID.(primitive.ObjectID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(bill)
+		return
+	}
+
+	billID, err := primitive.ObjectIDFromHex(request.BillID)
+	if err != nil {
+		http.Error(w, "Invalid bill ID format", http.StatusBadRequest)
+		return
+	}
+
+	var existing models.RecurringBill
+	err = config.DB.Collection("recurring_bills").FindOne(
+		context.Background(),
+		bson.M{"_id": billID, "group_id": group.ID},
+	).Decode(&existing)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Recurring bill not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch recurring bill", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	bill.ID = existing.ID
+	bill.IsActive = existing.IsActive
+	bill.CreatedBy = existing.CreatedBy
+	bill.CreatedAt = existing.CreatedAt
+	bill.NextDueDate = existing.NextDueDate
+	if bill.DueDay != existing.DueDay {
+		// Move the upcoming due date within its month rather than skipping one
+		bill.NextDueDate = models.DueDateIn(existing.NextDueDate.Year(), existing.NextDueDate.Month(), bill.DueDay)
+	}
+
+	_, err = config.DB.Collection("recurring_bills").ReplaceOne(
+		context.Background(),
+		bson.M{"_id": bill.ID},
+		bill,
+	)
+	if err != nil {
+		log.Printf("Recurring bill update error: %v", err)
+		http.Error(w, "Failed to update recurring bill", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bill)
+}
+
+// GetRecurringBillsHandler lists the group's recurring bills
+func GetRecurringBillsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "next_due_date", Value: 1}})
+	cursor, err := config.DB.Collection("recurring_bills").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		opts,
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch recurring bills", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	bills := make([]models.RecurringBill, 0)
+	if err = cursor.All(context.Background(), &bills); err != nil {
+		http.Error(w, "Failed to decode recurring bills", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bills)
+}
+
+// DeactivateRecurringBillHandler stops a recurring bill; instances already generated are kept
+func DeactivateRecurringBillHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get bill ID from URL path
+	billIDStr := strings.TrimPrefix(r.URL.Path, "/api/bills/remove/")
+	billID, err := primitive.ObjectIDFromHex(billIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bill ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("recurring_bills").UpdateOne(
+		context.Background(),
+		bson.M{"_id": billID, "group_id": user.GroupID},
+		bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		http.Error(w, "Failed to deactivate recurring bill", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Recurring bill not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Recurring bill deactivated successfully",
+	})
+}
+
+// GetBillInstancesHandler lists the group's bills, optionally filtered by status
+func GetBillInstancesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"group_id": group.ID}
+	switch status := models.BillStatus(r.URL.Query().Get("status")); status {
+	case "":
+	case models.BillStatusPending, models.BillStatusOverdue, models.BillStatusPaid:
+		filter["status"] = status
+	case "unpaid":
+		filter["status"] = bson.M{"$in": []models.BillStatus{models.BillStatusPending, models.BillStatusOverdue}}
+	default:
+		http.Error(w, "Status must be pending, overdue, paid or unpaid", http.StatusBadRequest)
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}})
+	cursor, err := config.DB.Collection("bill_instances").Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch bills", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	instances := make([]models.BillInstance, 0)
+	if err = cursor.All(context.Background(), &instances); err != nil {
+		http.Error(w, "Failed to decode bills", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(instances)
+}
+
+// PayBillHandler marks a member's share of a bill as paid or unpaid.
+// Members mark their own share; the bill's creator can mark anyone's.
+func PayBillHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request PayBillRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	instanceID, err := primitive.ObjectIDFromHex(request.InstanceID)
+	if err != nil {
+		http.Error(w, "Invalid bill ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	payerID := user.ID
+	if request.UserID != "" {
+		if payerID, err = parseUserID(request.UserID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	paid := request.Paid == nil || *request.Paid
+
+	var instance models.BillInstance
+	err = config.DB.Collection("bill_instances").FindOne(
+		context.Background(),
+		bson.M{"_id": instanceID, "group_id": user.GroupID},
+	).Decode(&instance)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Bill not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch bill", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if payerID != user.ID {
+		var bill models.RecurringBill
+		err = config.DB.Collection("recurring_bills").FindOne(
+			context.Background(),
+			bson.M{"_id": instance.BillID},
+		).Decode(&bill)
+		if err != nil || bill.CreatedBy != user.ID {
+			http.Error(w, "Only the bill's creator can mark other members' shares", http.StatusForbidden)
+			return
+		}
+	}
+
+	previousUpdate := instance.UpdatedAt
+	if err := instance.MarkPaid(payerID, paid, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only update if the bill hasn't changed since it was read
+	result, err := config.DB.Collection("bill_instances").UpdateOne(
+		context.Background(),
+		bson.M{"_id": instance.ID, "updated_at": previousUpdate},
+		bson.M{"$set": bson.M{
+			"shares":     instance.Shares,
+			"status":     instance.Status,
+			"updated_at": instance.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		log.Printf("Failed to record bill payment: %v", err)
+		http.Error(w, "Failed to record payment", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Bill was modified, please retry", http.StatusConflict)
+		return
+	}
+
+	// Reminders for a paid share no longer apply
+	if paid {
+		_, err = config.DB.Collection("bill_reminders").DeleteMany(
+			context.Background(),
+			bson.M{"bill_instance_id": instance.ID, "user_id": payerID},
+		)
+		if err != nil {
+			log.Printf("Failed to delete bill reminders: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(instance)
+}
+
+// GetBillRemindersHandler lists the current user's bill reminders
+func GetBillRemindersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"user_id": user.ID}
+	if r.URL.Query().Get("unread") == "true" {
+		filter["is_read"] = false
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("bill_reminders").Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch bill reminders", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	reminders := make([]models.BillReminder, 0)
+	if err = cursor.All(context.Background(), &reminders); err != nil {
+		http.Error(w, "Failed to decode bill reminders", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reminders)
+}
+
+// MarkBillReminderReadHandler marks one of the current user's bill reminders as read
+func MarkBillReminderReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ReminderID string `json:"reminder_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reminderID, err := primitive.ObjectIDFromHex(request.ReminderID)
+	if err != nil {
+		http.Error(w, "Invalid reminder ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("bill_reminders").UpdateOne(
+		context.Background(),
+		bson.M{"_id": reminderID, "user_id": user.ID},
+		bson.M{"$set": bson.M{"is_read": true}},
+	)
+	if err != nil {
+		http.Error(w, "Failed to update bill reminder", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Bill reminder not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Bill reminder marked as read",
+	})
+}
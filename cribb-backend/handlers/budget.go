@@ -0,0 +1,444 @@
+// handlers/budget.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetBudgetRequest defines the request structure for setting a group's monthly grocery budget.
+// Amounts are decimals in the budget's currency.
+type SetBudgetRequest struct {
+	Amount         float64            `json:"amount"`
+	Currency       string             `json:"currency,omitempty"`
+	CategoryLimits map[string]float64 `json:"category_limits,omitempty"`
+	AlertThreshold int                `json:"alert_threshold,omitempty"` // Percent; defaults to 90
+}
+
+// BudgetResponse is a group's budget together with its spending for a month
+type BudgetResponse struct {
+	Budget *models.GroceryBudget `json:"budget"`
+	Status models.BudgetStatus   `json:"status"`
+}
+
+// PriceHistoryResponse is the price history of one product
+type PriceHistoryResponse struct {
+	ItemName string                     `json:"item_name"`
+	Stores   []models.StorePriceSummary `json:"stores"`
+	Records  []models.PriceRecord       `json:"records"`
+}
+
+// findGroupBudget returns the group's budget, or nil if it hasn't set one
+func findGroupBudget(groupID primitive.ObjectID) (*models.GroceryBudget, error) {
+	var budget models.GroceryBudget
+	err := config.DB.Collection("grocery_budgets").FindOne(
+		context.Background(),
+		bson.M{"group_id": groupID},
+	).Decode(&budget)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// groupCurrency returns the currency of the group's budget, or the default currency
+func groupCurrency(groupID primitive.ObjectID) string {
+	budget, err := findGroupBudget(groupID)
+	if err != nil || budget == nil {
+		return models.DefaultCurrency
+	}
+	return budget.Currency
+}
+
+// recordPurchasePrice adds a priced purchase to the group's price history
+func recordPurchasePrice(user models.User, group models.Group, purchase CartPurchase, category, store, currency string) {
+	record := models.CreatePriceRecord(
+		group.ID,
+		purchase.ItemName,
+		store,
+		category,
+		purchase.Quantity,
+		purchase.Unit,
+		models.ToCents(purchase.Price),
+		currency,
+		user.ID,
+		time.Now(),
+	)
+
+	_, err := config.DB.Collection("price_history").InsertOne(context.Background(), record)
+	if err != nil {
+		log.Printf("Failed to record purchase price: %v", err)
+	}
+}
+
+// monthSpending totals the group's recorded purchases in a month by category
+func monthSpending(groupID primitive.ObjectID, currency string, month time.Time) (map[string]int64, error) {
+	start, end := models.BudgetMonth(month)
+	cursor, err := config.DB.Collection("price_history").Find(
+		context.Background(),
+		bson.M{
+			"group_id":     groupID,
+			"currency":     currency,
+			"purchased_at": bson.M{"$gte": start, "$lt": end},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var records []models.PriceRecord
+	if err = cursor.All(context.Background(), &records); err != nil {
+		return nil, err
+	}
+
+	spent := make(map[string]int64)
+	for _, record := range records {
+		spent[record.Category] += record.Total
+	}
+	return spent, nil
+}
+
+// cartSpending estimates what everything in the group's shopping carts will cost,
+// by category, from the latest price paid for each item. It also returns the
+// names of items that couldn't be priced.
+func cartSpending(groupID primitive.ObjectID, currency string) (map[string]int64, []string, error) {
+	cursor, err := config.DB.Collection("shopping_cart").Find(
+		context.Background(),
+		bson.M{"group_id": groupID},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cartItems []models.ShoppingCartItem
+	err = cursor.All(context.Background(), &cartItems)
+	cursor.Close(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inCart := make(map[string]int64)
+	unpriced := make([]string, 0)
+	if len(cartItems) == 0 {
+		return inCart, unpriced, nil
+	}
+
+	keys := make([]string, 0, len(cartItems))
+	for _, item := range cartItems {
+		keys = append(keys, models.IngredientKey(item.ItemName))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "purchased_at", Value: -1}})
+	cursor, err = config.DB.Collection("price_history").Find(
+		context.Background(),
+		bson.M{"group_id": groupID, "currency": currency, "product_key": bson.M{"$in": keys}},
+		opts,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []models.PriceRecord
+	err = cursor.All(context.Background(), &records)
+	cursor.Close(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byProduct := make(map[string][]models.PriceRecord)
+	for _, record := range records {
+		byProduct[record.ProductKey] = append(byProduct[record.ProductKey], record)
+	}
+
+	for _, item := range cartItems {
+		unit := item.Unit
+		if unit == "" {
+			unit = "pc"
+		}
+		cost, ok := models.EstimateCost(byProduct[models.IngredientKey(item.ItemName)], item.Quantity, unit)
+		if !ok {
+			unpriced = append(unpriced, item.ItemName)
+			continue
+		}
+		inCart[item.Category] += cost
+	}
+	return inCart, unpriced, nil
+}
+
+// budgetStatus works out the group's spending against its budget for a month.
+// The cart only counts towards the current month.
+func budgetStatus(budget *models.GroceryBudget, month time.Time) (models.BudgetStatus, error) {
+	spent, err := monthSpending(budget.GroupID, budget.Currency, month)
+	if err != nil {
+		return models.BudgetStatus{}, err
+	}
+
+	inCart := make(map[string]int64)
+	var unpriced []string
+	currentMonth, _ := models.BudgetMonth(time.Now())
+	if requested, _ := models.BudgetMonth(month); requested.Equal(currentMonth) {
+		if inCart, unpriced, err = cartSpending(budget.GroupID, budget.Currency); err != nil {
+			return models.BudgetStatus{}, err
+		}
+	}
+
+	status := models.ComputeBudgetStatus(budget, month, spent, inCart)
+	status.Unpriced = unpriced
+	return status, nil
+}
+
+// checkBudgetAlerts records an alert for each limit the group's spending has
+// newly reached this month
+func checkBudgetAlerts(groupID primitive.ObjectID) {
+	budget, err := findGroupBudget(groupID)
+	if err != nil {
+		log.Printf("Failed to fetch budget for alerts: %v", err)
+		return
+	}
+	if budget == nil {
+		return
+	}
+
+	status, err := budgetStatus(budget, time.Now())
+	if err != nil {
+		log.Printf("Failed to compute budget status for alerts: %v", err)
+		return
+	}
+
+	for _, alert := range status.Alerts(groupID, budget.AlertThreshold) {
+		_, err := config.DB.Collection("budget_alerts").InsertOne(context.Background(), alert)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			log.Printf("Failed to create budget alert: %v", err)
+		}
+	}
+}
+
+// parseBudgetMonth reads the month query parameter, defaulting to the current month
+func parseBudgetMonth(r *http.Request) (time.Time, error) {
+	monthStr := r.URL.Query().Get("month")
+	if monthStr == "" {
+		return time.Now(), nil
+	}
+	month, err := time.Parse(models.BudgetMonthLayout, monthStr)
+	if err != nil {
+		return time.Time{}, errors.New("invalid month format. Use YYYY-MM")
+	}
+	return month, nil
+}
+
+// SetBudgetHandler sets the group's monthly grocery budget
+func SetBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SetBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	limits := make(map[string]int64, len(request.CategoryLimits))
+	for category, limit := range request.CategoryLimits {
+		limits[category] = models.ToCents(limit)
+	}
+
+	budget := models.CreateGroceryBudget(
+		group.ID,
+		models.ToCents(request.Amount),
+		request.Currency,
+		limits,
+		request.AlertThreshold,
+		user.ID,
+	)
+	if err := budget.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := findGroupBudget(group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch budget", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		budget.ID = existing.ID
+		budget.CreatedAt = existing.CreatedAt
+	} else {
+		budget.ID = primitive.NewObjectID()
+	}
+
+	_, err = config.DB.Collection("grocery_budgets").ReplaceOne(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		budget,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Budget update error: %v", err)
+		http.Error(w, "Failed to save budget", http.StatusInternalServerError)
+		return
+	}
+
+	// A lower budget may already be reached
+	go checkBudgetAlerts(group.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budget)
+}
+
+// GetBudgetHandler returns the group's budget and its spending for a month (?month=YYYY-MM)
+func GetBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	month, err := parseBudgetMonth(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	budget, err := findGroupBudget(group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch budget", http.StatusInternalServerError)
+		return
+	}
+	if budget == nil {
+		http.Error(w, "Group has no budget", http.StatusNotFound)
+		return
+	}
+
+	status, err := budgetStatus(budget, month)
+	if err != nil {
+		log.Printf("Budget status error: %v", err)
+		http.Error(w, "Failed to compute budget status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BudgetResponse{Budget: budget, Status: status})
+}
+
+// GetBudgetAlertsHandler lists the group's budget alerts, newest first (?month=YYYY-MM to filter)
+func GetBudgetAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"group_id": group.ID}
+	if r.URL.Query().Get("month") != "" {
+		month, err := parseBudgetMonth(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter["month"] = month.Format(models.BudgetMonthLayout)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("budget_alerts").Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch budget alerts", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	alerts := make([]models.BudgetAlert, 0)
+	if err = cursor.All(context.Background(), &alerts); err != nil {
+		http.Error(w, "Failed to decode budget alerts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// GetPriceHistoryHandler returns what the group has paid for a product (?item_name=),
+// optionally at one store (?store=)
+func GetPriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	itemName := strings.TrimSpace(r.URL.Query().Get("item_name"))
+	if itemName == "" {
+		http.Error(w, "Item name is required", http.StatusBadRequest)
+		return
+	}
+
+	filter := bson.M{"group_id": group.ID, "product_key": models.IngredientKey(itemName)}
+	if store := strings.TrimSpace(r.URL.Query().Get("store")); store != "" {
+		filter["store"] = store
+	}
+
+	limit := int64(100)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "purchased_at", Value: -1}}).SetLimit(limit)
+	cursor, err := config.DB.Collection("price_history").Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch price history", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	records := make([]models.PriceRecord, 0)
+	if err = cursor.All(context.Background(), &records); err != nil {
+		http.Error(w, "Failed to decode price history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PriceHistoryResponse{
+		ItemName: itemName,
+		Stores:   models.SummarizePrices(records),
+		Records:  records,
+	})
+}
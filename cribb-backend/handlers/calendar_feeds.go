@@ -0,0 +1,395 @@
+// handlers/calendar_feeds.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/ical"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// calendarFeedPath is where feeds are served, followed by "<token>.ics"
+const calendarFeedPath = "/api/calendar/feed/"
+
+// CreateCalendarFeedRequest picks which chores the feed includes
+type CreateCalendarFeedRequest struct {
+	Scope models.CalendarFeedScope `json:"scope"`
+}
+
+// CalendarFeedCreatedResponse includes the feed URLs, which are only shown once
+type CalendarFeedCreatedResponse struct {
+	models.CalendarFeed
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcal_url"`
+}
+
+// calendarFeedURL builds the absolute feed URL for a token from the request
+func calendarFeedURL(r *http.Request, token string) *url.URL {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return &url.URL{Scheme: scheme, Host: r.Host, Path: calendarFeedPath + token + ".ics"}
+}
+
+// GetCalendarFeedsHandler lists the user's calendar feeds
+func GetCalendarFeedsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	cursor, err := config.DB.Collection("calendar_feeds").Find(
+		context.Background(),
+		bson.M{"user_id": user.ID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch calendar feeds", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	feeds := make([]models.CalendarFeed, 0)
+	if err = cursor.All(context.Background(), &feeds); err != nil {
+		http.Error(w, "Failed to decode calendar feeds", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feeds)
+}
+
+// CreateCalendarFeedHandler creates a feed URL for the user's chores or the
+// group's chores. A user has one feed per scope, so creating another rotates
+// the URL and the old one stops working.
+func CreateCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request CreateCalendarFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Scope == "" {
+		request.Scope = models.CalendarFeedMine
+	}
+	if !request.Scope.IsValid() {
+		http.Error(w, fmt.Sprintf("Scope must be %q or %q", models.CalendarFeedMine, models.CalendarFeedGroup), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	feed, token, err := models.CreateCalendarFeed(user.ID, request.Scope)
+	if err != nil {
+		log.Printf("Calendar feed token generation error: %v", err)
+		http.Error(w, "Failed to create calendar feed", http.StatusInternalServerError)
+		return
+	}
+
+	// Replace any existing feed of the same scope
+	_, err = config.DB.Collection("calendar_feeds").DeleteMany(
+		context.Background(),
+		bson.M{"user_id": user.ID, "scope": request.Scope},
+	)
+	if err != nil {
+		http.Error(w, "Failed to rotate calendar feed", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := config.DB.Collection("calendar_feeds").InsertOne(context.Background(), feed)
+	if err != nil {
+		log.Printf("Calendar feed creation error: %v", err)
+		http.Error(w, "Failed to create calendar feed", http.StatusInternalServerError)
+		return
+	}
+	feed.ID = result.InsertedID.(primitive.ObjectID)
+
+	feedURL := calendarFeedURL(r, token)
+	webcalURL := *feedURL
+	webcalURL.Scheme = "webcal"
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CalendarFeedCreatedResponse{
+		CalendarFeed: *feed,
+		URL:          feedURL.String(),
+		WebcalURL:    webcalURL.String(),
+	})
+}
+
+// DeleteCalendarFeedHandler revokes one of the user's calendar feeds
+func DeleteCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get feed ID from URL path
+	feedID, err := primitive.ObjectIDFromHex(strings.TrimPrefix(r.URL.Path, "/api/calendar/feeds/remove/"))
+	if err != nil {
+		http.Error(w, "Invalid feed ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("calendar_feeds").DeleteOne(
+		context.Background(),
+		bson.M{"_id": feedID, "user_id": user.ID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete calendar feed", http.StatusInternalServerError)
+		return
+	}
+	if result.DeletedCount == 0 {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Calendar feed deleted successfully",
+	})
+}
+
+// CalendarFeedHandler serves a feed as an .ics file. The token in the URL is
+// the only credential, since calendar apps can't send an Authorization header.
+// Chores are events by default; ?type=todo writes them as tasks instead.
+func CalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, found := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, calendarFeedPath), ".ics")
+	if !found || token == "" {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+
+	kind := ical.Event
+	switch r.URL.Query().Get("type") {
+	case "", "event":
+	case "todo":
+		kind = ical.Todo
+	default:
+		http.Error(w, "Type must be event or todo", http.StatusBadRequest)
+		return
+	}
+
+	var feed models.CalendarFeed
+	err := config.DB.Collection("calendar_feeds").FindOne(
+		context.Background(),
+		bson.M{"token_hash": models.HashCalendarToken(token)},
+	).Decode(&feed)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch calendar feed", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var user models.User
+	err = config.DB.Collection("users").FindOne(
+		context.Background(),
+		bson.M{"_id": feed.UserID},
+	).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	now := time.Now()
+	cal, err := buildChoreCalendar(&feed, user, now)
+	if err != nil {
+		log.Printf("Failed to build calendar feed %s: %v", feed.ID.Hex(), err)
+		http.Error(w, "Failed to build calendar", http.StatusInternalServerError)
+		return
+	}
+	cal.Kind = kind
+
+	_, err = config.DB.Collection("calendar_feeds").UpdateByID(
+		context.Background(),
+		feed.ID,
+		bson.M{"$set": bson.M{"last_fetched_at": now}},
+	)
+	if err != nil {
+		log.Printf("Failed to update calendar feed: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="chores.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write([]byte(cal.Render(now)))
+}
+
+// buildChoreCalendar collects the feed's chores, recently completed ones and
+// projected future instances of recurring chores. A user without a group gets
+// an empty calendar.
+func buildChoreCalendar(feed *models.CalendarFeed, user models.User, now time.Time) (*ical.Calendar, error) {
+	cal := &ical.Calendar{Name: "My chores"}
+	if user.GroupID.IsZero() {
+		return cal, nil
+	}
+
+	var group models.Group
+	err := config.DB.Collection("groups").FindOne(
+		context.Background(),
+		bson.M{"_id": user.GroupID},
+	).Decode(&group)
+	if err != nil {
+		return nil, err
+	}
+
+	mine := feed.Scope == models.CalendarFeedMine
+	if mine {
+		cal.Name = group.Name + ": my chores"
+	} else {
+		cal.Name = group.Name + " chores"
+	}
+
+	// Member names label group chores with who they're assigned to
+	names := make(map[primitive.ObjectID]string)
+	if !mine {
+		cursor, err := config.DB.Collection("users").Find(
+			context.Background(),
+			bson.M{"group_id": group.ID},
+			options.Find().SetProjection(bson.M{"name": 1, "username": 1}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var members []models.User
+		if err = cursor.All(context.Background(), &members); err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			names[member.ID] = member.Name
+		}
+	}
+	summary := func(title string, assignee primitive.ObjectID) string {
+		if name := names[assignee]; name != "" {
+			return title + " (" + name + ")"
+		}
+		return title
+	}
+
+	choreFilter := bson.M{
+		"group_id": group.ID,
+		"$or": []bson.M{
+			{"status": bson.M{"$ne": models.ChoreStatusCompleted}},
+			{"updated_at": bson.M{"$gte": now.Add(-models.CalendarFeedCompletedWindow)}},
+		},
+	}
+	if mine {
+		choreFilter["assigned_to"] = user.ID
+	}
+	cursor, err := config.DB.Collection("chores").Find(context.Background(), choreFilter)
+	if err != nil {
+		return nil, err
+	}
+	var chores []models.Chore
+	if err = cursor.All(context.Background(), &chores); err != nil {
+		return nil, err
+	}
+
+	for _, chore := range chores {
+		due := chore.DueDate
+		if due.IsZero() {
+			due = chore.StartDate
+		}
+		item := ical.Item{
+			UID:          "chore-" + chore.ID.Hex() + "@cribb",
+			Summary:      summary(chore.Title, chore.AssignedTo),
+			Description:  choreDescription(chore.Description, chore.Points),
+			Due:          due,
+			Created:      chore.CreatedAt,
+			LastModified: chore.UpdatedAt,
+		}
+		if chore.Status == models.ChoreStatusCompleted {
+			completed := chore.UpdatedAt
+			item.Completed = &completed
+		}
+		cal.Items = append(cal.Items, item)
+	}
+
+	cursor, err = config.DB.Collection("recurring_chores").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID, "is_active": true},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var recurringChores []models.RecurringChore
+	if err = cursor.All(context.Background(), &recurringChores); err != nil {
+		return nil, err
+	}
+
+	until := now.Add(models.CalendarFeedHorizon)
+	for _, rc := range recurringChores {
+		for _, occurrence := range rc.ProjectOccurrences(now, until, models.CalendarFeedMaxOccurrences) {
+			if mine && occurrence.AssignedTo != user.ID {
+				continue
+			}
+			// Keyed by the day it's assigned, so refreshes replace the same entry
+			cal.Items = append(cal.Items, ical.Item{
+				UID:          fmt.Sprintf("recurring-%s-%s@cribb", rc.ID.Hex(), occurrence.AssignedAt.UTC().Format("20060102")),
+				Summary:      summary(occurrence.Title, occurrence.AssignedTo),
+				Description:  choreDescription(occurrence.Description, occurrence.Points) + "\nUpcoming " + rc.Frequency + " chore",
+				Due:          occurrence.DueDate,
+				Created:      rc.CreatedAt,
+				LastModified: rc.UpdatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(cal.Items, func(i, j int) bool { return cal.Items[i].Due.Before(cal.Items[j].Due) })
+	return cal, nil
+}
+
+// choreDescription describes a chore in a calendar entry
+func choreDescription(description string, points int) string {
+	if description == "" {
+		return fmt.Sprintf("%d points", points)
+	}
+	return fmt.Sprintf("%s\n%d points", description, points)
+}
@@ -0,0 +1,179 @@
+// handlers/chat_commands.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/chatbot"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chatCommands maps chat commands onto the existing handlers
+var chatCommands = newChatCommands()
+
+func newChatCommands() *chatbot.Dispatcher {
+	d := chatbot.NewDispatcher()
+	d.Register("done", "/done <chore>", "Mark one of your chores as done", chatDoneCommand, "did", "complete")
+	d.Register("add", "/add <item> [quantity] [unit]", "Add an item to the shopping cart", chatAddCommand, "buy")
+	d.Register("use", "/use <item> [quantity] [unit]", "Use an item from the pantry", chatUseCommand, "used")
+	d.Register("chores", "/chores", "List your chores", chatChoresCommand, "list")
+	return d
+}
+
+// chatFailure turns an error caused by the command into the chat reply,
+// leaving other errors to be logged
+func chatFailure(err error) error {
+	if isPantryRequestError(err) {
+		return chatbot.Errorf("%s", err.Error())
+	}
+	return err
+}
+
+// chatQuantity formats a quantity without trailing zeros
+func chatQuantity(quantity float64, unit string) string {
+	formatted := strconv.FormatFloat(quantity, 'f', -1, 64)
+	if unit != "" {
+		formatted += " " + unit
+	}
+	return formatted
+}
+
+// chatMatchError explains why typed text didn't pick out one thing
+func chatMatchError(kind, typed string, candidates []string) error {
+	if len(candidates) == 0 {
+		return chatbot.Errorf("I couldn't find a %s called %q", kind, typed)
+	}
+	return chatbot.Errorf("Which %s did you mean: %s?", kind, strings.Join(candidates, ", "))
+}
+
+// chatDoneCommand completes one of the user's chores by title
+func chatDoneCommand(ctx context.Context, user models.User, cmd chatbot.Command) (string, error) {
+	typed := cmd.Text()
+	if typed == "" {
+		return "", chatbot.Errorf("Usage: /done <chore>")
+	}
+
+	cursor, err := config.DB.Collection("chores").Find(ctx, bson.M{
+		"assigned_to": user.ID,
+		"status":      bson.M{"$ne": models.ChoreStatusCompleted},
+	})
+	if err != nil {
+		return "", err
+	}
+	var chores []models.Chore
+	if err = cursor.All(ctx, &chores); err != nil {
+		return "", err
+	}
+
+	titles := make([]string, len(chores))
+	for i, chore := range chores {
+		titles[i] = chore.Title
+	}
+	i, candidates := chatbot.Match(typed, titles)
+	if i < 0 {
+		return "", chatMatchError("chore of yours", typed, candidates)
+	}
+	chore := chores[i]
+
+	result, err := completeChore(user.ID, chore.ID)
+	if err != nil {
+		return "", chatFailure(err)
+	}
+	return fmt.Sprintf("Nice work, %s! %q is done: +%d points (%d total)", user.Name, chore.Title, result.PointsEarned, result.NewScore), nil
+}
+
+// chatAddCommand adds an item to the group's shopping cart
+func chatAddCommand(ctx context.Context, user models.User, cmd chatbot.Command) (string, error) {
+	if len(cmd.Args) == 0 {
+		return "", chatbot.Errorf("Usage: /add <item> [quantity] [unit]")
+	}
+	name, quantity, unit, err := chatbot.ItemArgs(cmd.Args)
+	if err != nil {
+		return "", err
+	}
+
+	if unit != "" {
+		if unit, err = normalizeUnitOrError(unit); err != nil {
+			return "", chatbot.Errorf("%s", err.Error())
+		}
+	}
+
+	item, err := addToShoppingCart(user, primitive.NilObjectID, name, quantity, unit, "", "Added item to shopping cart")
+	if err != nil {
+		return "", chatFailure(err)
+	}
+	if item.Quantity == quantity || item.Quantity == 0 {
+		return fmt.Sprintf("Added %s %s to the shopping cart", chatQuantity(quantity, unit), name), nil
+	}
+	return fmt.Sprintf("Added %s %s to the shopping cart (%s in total)",
+		chatQuantity(quantity, unit), name, chatQuantity(item.Quantity, item.Unit)), nil
+}
+
+// chatUseCommand uses a pantry item found by name
+func chatUseCommand(ctx context.Context, user models.User, cmd chatbot.Command) (string, error) {
+	if len(cmd.Args) == 0 {
+		return "", chatbot.Errorf("Usage: /use <item> [quantity] [unit]")
+	}
+	name, quantity, unit, err := chatbot.ItemArgs(cmd.Args)
+	if err != nil {
+		return "", err
+	}
+
+	cursor, err := config.DB.Collection("pantry_items").Find(ctx, bson.M{"group_id": user.GroupID})
+	if err != nil {
+		return "", err
+	}
+	var items []models.PantryItem
+	if err = cursor.All(ctx, &items); err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	i, candidates := chatbot.Match(name, names)
+	if i < 0 {
+		return "", chatMatchError("pantry item", name, candidates)
+	}
+	item := items[i]
+
+	result, err := usePantryItem(user, item.ID, quantity, unit)
+	if err != nil {
+		return "", chatFailure(err)
+	}
+	return fmt.Sprintf("Used %s of %s, %s left", chatQuantity(result.UsedQty, result.Unit), item.Name,
+		chatQuantity(result.RemainingQty, result.Unit)), nil
+}
+
+// chatChoresCommand lists the user's open chores, soonest first
+func chatChoresCommand(ctx context.Context, user models.User, cmd chatbot.Command) (string, error) {
+	chores, err := openChores(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(chores) == 0 {
+		return "You have no chores right now", nil
+	}
+
+	sort.Slice(chores, func(i, j int) bool { return chores[i].DueDate.Before(chores[j].DueDate) })
+	var b strings.Builder
+	b.WriteString("Your chores:")
+	for _, chore := range chores {
+		fmt.Fprintf(&b, "\n- %s", chore.Title)
+		if !chore.DueDate.IsZero() {
+			fmt.Fprintf(&b, ", due %s", chore.DueDate.Format("Mon Jan 2"))
+		}
+		if chore.Status == models.ChoreStatusOverdue {
+			b.WriteString(" (overdue)")
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,282 @@
+// handlers/chat_integrations.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/chatbot"
+	"cribb-backend/config"
+	"cribb-backend/delivery"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxChatCommandBody caps the size of an inbound chat command
+const maxChatCommandBody = 64 << 10
+
+// CreateChatIntegrationRequest names a new chat integration
+type CreateChatIntegrationRequest struct {
+	Name string `json:"name"`
+}
+
+// ChatIntegrationCreatedResponse includes the signing secret, which is only shown once
+type ChatIntegrationCreatedResponse struct {
+	models.ChatIntegration
+	Secret string `json:"secret"`
+}
+
+// ChatCommandRequest is the generic format chat platform adapters post.
+// Username is the Cribb user who typed the message.
+type ChatCommandRequest struct {
+	Username string `json:"username"`
+	Text     string `json:"text"`
+}
+
+// ChatCommandResponse is the reply to post back to the chat. Handled is false
+// for messages that weren't commands.
+type ChatCommandResponse struct {
+	Handled bool   `json:"handled"`
+	Reply   string `json:"reply,omitempty"`
+}
+
+// GetChatIntegrationsHandler lists the group's chat integrations for its admins
+func GetChatIntegrationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	cursor, err := config.DB.Collection("chat_integrations").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch chat integrations", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	integrations := make([]models.ChatIntegration, 0)
+	if err = cursor.All(context.Background(), &integrations); err != nil {
+		http.Error(w, "Failed to decode chat integrations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(integrations)
+}
+
+// CreateChatIntegrationHandler creates a chat integration and returns its signing secret
+func CreateChatIntegrationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request CreateChatIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	integration, err := models.CreateChatIntegration(group.ID, request.Name, user.ID)
+	if err != nil {
+		log.Printf("Chat integration secret generation error: %v", err)
+		http.Error(w, "Failed to create chat integration", http.StatusInternalServerError)
+		return
+	}
+	if err := integration.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.Collection("chat_integrations").InsertOne(context.Background(), integration)
+	if err != nil {
+		log.Printf("Chat integration creation error: %v", err)
+		http.Error(w, "Failed to create chat integration", http.StatusInternalServerError)
+		return
+	}
+	integration.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ChatIntegrationCreatedResponse{ChatIntegration: *integration, Secret: integration.Secret})
+}
+
+// DeleteChatIntegrationHandler removes a chat integration, revoking its secret
+func DeleteChatIntegrationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get integration ID from URL path
+	integrationID, err := primitive.ObjectIDFromHex(strings.TrimPrefix(r.URL.Path, "/api/groups/chat-integrations/remove/"))
+	if err != nil {
+		http.Error(w, "Invalid integration ID format", http.StatusBadRequest)
+		return
+	}
+
+	_, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("chat_integrations").DeleteOne(
+		context.Background(),
+		bson.M{"_id": integrationID, "group_id": group.ID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete chat integration", http.StatusInternalServerError)
+		return
+	}
+	if result.DeletedCount == 0 {
+		http.Error(w, "Chat integration not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Chat integration deleted successfully",
+	})
+}
+
+// verifyChatCommand checks the integration header, timestamp and signature of
+// an inbound command and that it wasn't seen before, returning the
+// integration it came from
+func verifyChatCommand(r *http.Request, body []byte) (*models.ChatIntegration, bool) {
+	integrationID, err := primitive.ObjectIDFromHex(r.Header.Get(chatbot.IntegrationHeader))
+	if err != nil {
+		return nil, false
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(delivery.TimestampHeader), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > models.ChatCommandMaxAge || age < -models.ChatCommandMaxAge {
+		return nil, false
+	}
+
+	var integration models.ChatIntegration
+	err = config.DB.Collection("chat_integrations").FindOne(
+		context.Background(),
+		bson.M{"_id": integrationID},
+	).Decode(&integration)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Failed to fetch chat integration: %v", err)
+		}
+		return nil, false
+	}
+
+	signature := r.Header.Get(delivery.SignatureHeader)
+	if !delivery.VerifySignature(integration.Secret, timestamp, body, signature) {
+		return nil, false
+	}
+
+	// Each signed request is only accepted once
+	receipt := models.CreateChatCommandReceipt(integration.ID, signature, time.Unix(timestamp, 0))
+	if _, err := config.DB.Collection("chat_command_receipts").InsertOne(context.Background(), receipt); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Printf("Failed to record chat command: %v", err)
+		}
+		return nil, false
+	}
+	return &integration, true
+}
+
+// ChatCommandHandler runs a command typed in a group chat. Requests are signed
+// like outgoing webhooks: X-Cribb-Signature holds the HMAC-SHA256 of
+// "<X-Cribb-Timestamp>.<body>" keyed with the integration's secret, and
+// X-Cribb-Integration names the integration.
+func ChatCommandHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxChatCommandBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	integration, ok := verifyChatCommand(r, body)
+	if !ok {
+		http.Error(w, "Invalid or expired signature", http.StatusUnauthorized)
+		return
+	}
+
+	var request ChatCommandRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// The sender must belong to the integration's group
+	var user models.User
+	err = config.DB.Collection("users").FindOne(
+		context.Background(),
+		bson.M{"username": request.Username, "group_id": integration.GroupID},
+	).Decode(&user)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(ChatCommandResponse{
+			Handled: true,
+			Reply:   "I don't know " + request.Username + " in this group. Use your Cribb username.",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	reply, err := chatCommands.Dispatch(ctx, user, request.Text)
+	if errors.Is(err, chatbot.ErrNotACommand) {
+		json.NewEncoder(w).Encode(ChatCommandResponse{Handled: false})
+		return
+	}
+	if err != nil {
+		log.Printf("Chat command %q failed: %v", request.Text, err)
+		json.NewEncoder(w).Encode(ChatCommandResponse{Handled: true, Reply: "Something went wrong, please try again"})
+		return
+	}
+
+	_, err = config.DB.Collection("chat_integrations").UpdateByID(
+		context.Background(),
+		integration.ID,
+		bson.M{"$set": bson.M{"last_used_at": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Failed to update chat integration: %v", err)
+	}
+
+	json.NewEncoder(w).Encode(ChatCommandResponse{Handled: true, Reply: reply})
+}
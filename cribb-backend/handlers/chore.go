@@ -4,7 +4,9 @@ package handlers
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
 	"encoding/json"
 	"errors"
 	"log"
@@ -106,6 +108,9 @@ func CreateIndividualChoreHandler(w http.ResponseWriter, r *http.Request) {
 	// Set the inserted ID
 	chore.ID = result.InsertedID.(primitive.ObjectID)
 
+	events.Publish(chore.GroupID, events.ChoreAssigned, primitive.NilObjectID, chore)
+	notifications.Notify(models.CreateChoreAssignedNotification(chore))
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(chore)
@@ -238,10 +243,14 @@ func CreateRecurringChoreHandler(w http.ResponseWriter, r *http.Request) {
 	firstChore := models.CreateChoreFromRecurring(recurringChore)
 
 	// Insert the first chore instance
-	_, err = config.DB.Collection("chores").InsertOne(context.Background(), firstChore)
+	insertResult, err := config.DB.Collection("chores").InsertOne(context.Background(), firstChore)
 	if err != nil {
 		log.Printf("Failed to create first chore instance: %v", err)
 		// Continue anyway since the recurring definition was created successfully
+	} else {
+		firstChore.ID = insertResult.InsertedID.(primitive.ObjectID)
+		events.Publish(group.ID, events.ChoreAssigned, primitive.NilObjectID, firstChore)
+		notifications.Notify(models.CreateChoreAssignedNotification(firstChore))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -277,24 +286,34 @@ func GetUserChoresHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get all active chores for the user
+	chores, err := openChores(context.Background(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch chores", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chores)
+}
+
+// openChores returns the chores assigned to a user that aren't completed,
+// marking the ones past their due date as overdue
+func openChores(ctx context.Context, userID primitive.ObjectID) ([]models.Chore, error) {
 	cursor, err := config.DB.Collection("chores").Find(
-		context.Background(),
+		ctx,
 		bson.M{
-			"assigned_to": user.ID,
+			"assigned_to": userID,
 			"status":      bson.M{"$ne": models.ChoreStatusCompleted},
 		},
 	)
 	if err != nil {
-		http.Error(w, "Failed to fetch chores", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var chores []models.Chore
-	if err = cursor.All(context.Background(), &chores); err != nil {
-		http.Error(w, "Failed to decode chores", http.StatusInternalServerError)
-		return
+	if err = cursor.All(ctx, &chores); err != nil {
+		return nil, err
 	}
 
 	// Check for overdue chores and update their status
@@ -304,14 +323,8 @@ func GetUserChoresHandler(w http.ResponseWriter, r *http.Request) {
 			chores[i].Status = models.ChoreStatusOverdue
 
 			// Update in database
-			_, _ = config.DB.Collection("chores").UpdateOne(
-				context.Background(),
-				bson.M{"_id": chore.ID},
-				bson.M{"$set": bson.M{"status": models.ChoreStatusOverdue}},
-			)
+			markChoreOverdue(chore)
 		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(chores)
+	return chores, nil
 }
@@ -4,7 +4,9 @@ package handlers
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
 	"encoding/json"
 	"errors"
 	"log"
@@ -54,17 +56,43 @@ func CompleteChoreHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result, err := completeChore(userID, choreID)
+	if err != nil {
+		log.Printf("Transaction failed: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return success response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ChoreCompletionResult is what completing a chore earned
+type ChoreCompletionResult struct {
+	PointsEarned int `json:"points_earned"`
+	NewScore     int `json:"new_score"`
+}
+
+// completeChore marks a chore assigned to the user as completed, credits the
+// points and creates the next instance of a recurring chore. Errors caused by
+// the request itself are returned as pantryRequestError.
+func completeChore(userID, choreID primitive.ObjectID) (ChoreCompletionResult, error) {
+	var result ChoreCompletionResult
+
 	// Start a MongoDB session for transaction
 	session, err := config.DB.Client().StartSession()
 	if err != nil {
-		log.Printf("Failed to start MongoDB session: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return result, err
 	}
 	defer session.EndSession(context.Background())
 
+	// Set by the transaction, published once it commits
+	var completedChore models.Chore
+	var nextChore *models.Chore
+
 	// Define the transaction
-	result, err := session.WithTransaction(context.Background(), func(sessionContext mongo.SessionContext) (interface{}, error) {
+	_, err = session.WithTransaction(context.Background(), func(sessionContext mongo.SessionContext) (interface{}, error) {
 		// 1. Get the user by ID
 		var user models.User
 		err := config.DB.Collection("users").FindOne(
@@ -74,7 +102,7 @@ func CompleteChoreHandler(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			if errors.Is(err, mongo.ErrNoDocuments) {
-				return nil, errors.New("user not found")
+				return nil, pantryRequestError{"user not found"}
 			}
 			return nil, err
 		}
@@ -88,20 +116,22 @@ func CompleteChoreHandler(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			if errors.Is(err, mongo.ErrNoDocuments) {
-				return nil, errors.New("chore not found")
+				return nil, pantryRequestError{"chore not found"}
 			}
 			return nil, err
 		}
 
 		// 3. Verify the chore is assigned to the user
 		if chore.AssignedTo != userID {
-			return nil, errors.New("chore is not assigned to this user")
+			return nil, pantryRequestError{"chore is not assigned to this user"}
 		}
 
 		// 4. Verify the chore is not already completed
 		if chore.Status == models.ChoreStatusCompleted {
-			return nil, errors.New("chore is already completed")
+			return nil, pantryRequestError{"chore is already completed"}
 		}
+		completedChore = chore
+		nextChore = nil
 
 		now := time.Now()
 
@@ -189,29 +219,34 @@ func CompleteChoreHandler(w http.ResponseWriter, r *http.Request) {
 				}
 
 				// Create next chore instance
-				nextChore := models.CreateChoreFromRecurring(&recurringChore)
-				_, err = config.DB.Collection("chores").InsertOne(sessionContext, nextChore)
+				next := models.CreateChoreFromRecurring(&recurringChore)
+				insertResult, err := config.DB.Collection("chores").InsertOne(sessionContext, next)
 				if err != nil {
 					return nil, err
 				}
+				next.ID = insertResult.InsertedID.(primitive.ObjectID)
+				nextChore = next
 			}
 		}
 
-		return map[string]interface{}{
-			"points_earned": chore.Points,
-			"new_score":     user.Score + chore.Points,
-		}, nil
+		result = ChoreCompletionResult{
+			PointsEarned: chore.Points,
+			NewScore:     user.Score + chore.Points,
+		}
+		return nil, nil
 	})
-
 	if err != nil {
-		log.Printf("Transaction failed: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return result, err
 	}
 
-	// Return success response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	completedChore.Status = models.ChoreStatusCompleted
+	events.Publish(completedChore.GroupID, events.ChoreCompleted, userID, completedChore)
+	if nextChore != nil {
+		events.Publish(nextChore.GroupID, events.ChoreAssigned, primitive.NilObjectID, nextChore)
+		notifications.Notify(models.CreateChoreAssignedNotification(nextChore))
+	}
+
+	return result, nil
 }
 
 // GetGroupChoresHandler retrieves all active chores for a group
@@ -289,16 +324,7 @@ func GetGroupChoresHandler(w http.ResponseWriter, r *http.Request) {
 				chores[i].Status = models.ChoreStatusOverdue
 
 				// Update in database (don't wait for the result)
-				go func(choreID primitive.ObjectID) {
-					_, err := config.DB.Collection("chores").UpdateOne(
-						context.Background(),
-						bson.M{"_id": choreID},
-						bson.M{"$set": bson.M{"status": models.ChoreStatusOverdue}},
-					)
-					if err != nil {
-						log.Printf("Failed to update chore status to overdue: %v", err)
-					}
-				}(chore.ID)
+				go markChoreOverdue(chore)
 			}
 		}
 	}
@@ -336,6 +362,30 @@ func GetGroupChoresHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(choresWithAssignees)
 }
 
+// markChoreOverdue flags a chore as overdue and tells its assignee.
+// The status check makes sure the assignee is only told once.
+func markChoreOverdue(chore models.Chore) {
+	result, err := config.DB.Collection("chores").UpdateOne(
+		context.Background(),
+		bson.M{
+			"_id":    chore.ID,
+			"status": bson.M{"$nin": []models.ChoreStatus{models.ChoreStatusOverdue, models.ChoreStatusCompleted}},
+		},
+		bson.M{"$set": bson.M{"status": models.ChoreStatusOverdue}},
+	)
+	if err != nil {
+		log.Printf("Failed to update chore status to overdue: %v", err)
+		return
+	}
+	if result.ModifiedCount == 0 {
+		return
+	}
+
+	chore.Status = models.ChoreStatusOverdue
+	notifications.Notify(models.CreateChoreOverdueNotification(&chore))
+	events.Publish(chore.GroupID, events.ChoreOverdue, primitive.NilObjectID, chore)
+}
+
 // GetGroupRecurringChoresHandler retrieves all recurring chores for a group
 func GetGroupRecurringChoresHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"cribb-backend/config"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
 	"encoding/json"
 	"errors"
 	"log"
@@ -156,6 +157,11 @@ func UpdateChoreHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Let the new assignee know the chore is theirs
+	if updatedChore.AssignedTo != chore.AssignedTo {
+		notifications.Notify(models.CreateChoreAssignedNotification(&updatedChore))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedChore)
@@ -0,0 +1,103 @@
+// handlers/events.go
+package handlers
+
+import (
+	"cribb-backend/events"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// eventStreamHeartbeat is how often a comment is sent to keep idle connections open
+const eventStreamHeartbeat = 25 * time.Second
+
+// writeServerSentEvent writes an event in the text/event-stream format
+func writeServerSentEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// GroupEventStreamHandler streams the group's changes as Server-Sent Events.
+// Clients resume after a reconnect with the Last-Event-ID header or ?last_event_id=;
+// a reset event means some changes were missed and the client should refetch.
+func GroupEventStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	lastEventIDStr := r.Header.Get("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = r.URL.Query().Get("last_event_id")
+	}
+	var lastEventID uint64
+	if lastEventIDStr != "" {
+		parsed, err := strconv.ParseUint(lastEventIDStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid last event ID", http.StatusBadRequest)
+			return
+		}
+		lastEventID = parsed
+	}
+
+	subscription, missed := events.Default.Subscribe(group.ID, lastEventID)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Ask clients to reconnect quickly if the stream drops
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, event := range missed {
+		if err := writeServerSentEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-subscription.Events:
+			if !open {
+				// Dropped for falling behind; the client reconnects and resumes
+				return
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				log.Printf("Failed to write event to stream: %v", err)
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
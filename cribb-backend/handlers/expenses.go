@@ -0,0 +1,481 @@
+// handlers/expenses.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExpenseItemRequest is a line of a by-item expense
+type ExpenseItemRequest struct {
+	Name    string   `json:"name"`
+	Amount  float64  `json:"amount"`
+	UserIDs []string `json:"user_ids"`
+}
+
+// CreateExpenseRequest defines the request structure for recording an expense.
+// Amounts are decimals in the expense's currency.
+type CreateExpenseRequest struct {
+	Description  string               `json:"description"`
+	Amount       float64              `json:"amount"`
+	Currency     string               `json:"currency,omitempty"` // Defaults to USD
+	PaidBy       string               `json:"paid_by,omitempty"`  // Defaults to the current user
+	Date         string               `json:"date,omitempty"`     // YYYY-MM-DD, defaults to today
+	SplitType    string               `json:"split_type,omitempty"`
+	Participants []string             `json:"participants,omitempty"` // Equal split; defaults to every member
+	Shares       map[string]float64   `json:"shares,omitempty"`
+	Exact        map[string]float64   `json:"exact,omitempty"`
+	Items        []ExpenseItemRequest `json:"items,omitempty"`
+}
+
+// SettleUpRequest defines the request structure for recording a settlement
+type SettleUpRequest struct {
+	FromUserID string  `json:"from_user_id,omitempty"` // Defaults to the current user
+	ToUserID   string  `json:"to_user_id"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency,omitempty"`
+	Note       string  `json:"note,omitempty"`
+}
+
+// CurrencyBalances is the state of one currency's balances in a group
+type CurrencyBalances struct {
+	Currency  string                 `json:"currency"`
+	Balances  []models.MemberBalance `json:"balances"`
+	Transfers []models.Transfer      `json:"transfers"` // Suggested payments to settle up
+}
+
+// groupMemberSet returns the group's members as a set
+func groupMemberSet(group models.Group) map[primitive.ObjectID]bool {
+	members := make(map[primitive.ObjectID]bool, len(group.Members))
+	for _, memberID := range group.Members {
+		members[memberID] = true
+	}
+	return members
+}
+
+// parseUserID parses a user ID from a request
+func parseUserID(value string) (primitive.ObjectID, error) {
+	userID, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return userID, fmt.Errorf("invalid user ID %q", value)
+	}
+	return userID, nil
+}
+
+// splitInputFromRequest converts the request's split fields, which use string IDs and decimal amounts
+func splitInputFromRequest(request CreateExpenseRequest, group models.Group) (models.SplitInput, error) {
+	var input models.SplitInput
+
+	for _, participant := range request.Participants {
+		userID, err := parseUserID(participant)
+		if err != nil {
+			return input, err
+		}
+		input.Participants = append(input.Participants, userID)
+	}
+	if len(input.Participants) == 0 {
+		input.Participants = group.Members
+	}
+
+	if len(request.Shares) > 0 {
+		input.Shares = make(map[primitive.ObjectID]float64, len(request.Shares))
+		for participant, shares := range request.Shares {
+			userID, err := parseUserID(participant)
+			if err != nil {
+				return input, err
+			}
+			input.Shares[userID] = shares
+		}
+	}
+
+	if len(request.Exact) > 0 {
+		input.Exact = make(map[primitive.ObjectID]int64, len(request.Exact))
+		for participant, amount := range request.Exact {
+			userID, err := parseUserID(participant)
+			if err != nil {
+				return input, err
+			}
+			input.Exact[userID] = models.ToCents(amount)
+		}
+	}
+
+	for _, item := range request.Items {
+		expenseItem := models.ExpenseItem{
+			Name:   strings.TrimSpace(item.Name),
+			Amount: models.ToCents(item.Amount),
+		}
+		for _, participant := range item.UserIDs {
+			userID, err := parseUserID(participant)
+			if err != nil {
+				return input, err
+			}
+			expenseItem.UserIDs = append(expenseItem.UserIDs, userID)
+		}
+		input.Items = append(input.Items, expenseItem)
+	}
+
+	return input, nil
+}
+
+// CreateExpenseHandler records an expense paid by a member and splits it
+func CreateExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request CreateExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+	members := groupMemberSet(group)
+
+	paidBy := user.ID
+	if request.PaidBy != "" {
+		var err error
+		if paidBy, err = parseUserID(request.PaidBy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !members[paidBy] {
+			http.Error(w, "Payer is not a member of this group", http.StatusBadRequest)
+			return
+		}
+	}
+
+	expense := models.CreateExpense(
+		group.ID,
+		request.Description,
+		paidBy,
+		models.ToCents(request.Amount),
+		request.Currency,
+		models.SplitType(strings.ToLower(request.SplitType)),
+		models.ExpenseSourceManual,
+		user.ID,
+	)
+	if request.Date != "" {
+		date, err := time.Parse(planDateLayout, request.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		expense.Date = date
+	}
+	if err := expense.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input, err := splitInputFromRequest(request, group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := expense.Split(input, members); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.Collection("expenses").InsertOne(context.Background(), expense)
+	if err != nil {
+		log.Printf("Expense creation error: %v", err)
+		http.Error(w, "Failed to record expense", http.StatusInternalServerError)
+		return
+	}
+	expense.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(expense)
+}
+
+// GetExpensesHandler lists the group's expenses, newest first
+func GetExpensesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"group_id": group.ID}
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		filter["currency"] = strings.ToUpper(currency)
+	}
+
+	limit := int64(50)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.ParseInt(limitStr, 10, 64); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "date", Value: -1}, {Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := config.DB.Collection("expenses").Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch expenses", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	expenses := make([]models.Expense, 0)
+	if err = cursor.All(context.Background(), &expenses); err != nil {
+		http.Error(w, "Failed to decode expenses", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(expenses)
+}
+
+// DeleteExpenseHandler deletes an expense; only its creator or payer may do so
+func DeleteExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get expense ID from URL path
+	expenseIDStr := strings.TrimPrefix(r.URL.Path, "/api/expenses/remove/")
+	expenseID, err := primitive.ObjectIDFromHex(expenseIDStr)
+	if err != nil {
+		http.Error(w, "Invalid expense ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var expense models.Expense
+	err = config.DB.Collection("expenses").FindOne(
+		context.Background(),
+		bson.M{"_id": expenseID, "group_id": user.GroupID},
+	).Decode(&expense)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Expense not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch expense", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if expense.CreatedBy != user.ID && expense.PaidBy != user.ID {
+		http.Error(w, "Only the payer or the member who recorded the expense can delete it", http.StatusForbidden)
+		return
+	}
+
+	_, err = config.DB.Collection("expenses").DeleteOne(context.Background(), bson.M{"_id": expense.ID})
+	if err != nil {
+		http.Error(w, "Failed to delete expense", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Expense deleted successfully",
+	})
+}
+
+// loadGroupLedger fetches all of a group's expenses and settlements
+func loadGroupLedger(groupID primitive.ObjectID) ([]models.Expense, []models.Settlement, error) {
+	expenses := make([]models.Expense, 0)
+	cursor, err := config.DB.Collection("expenses").Find(context.Background(), bson.M{"group_id": groupID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = cursor.All(context.Background(), &expenses); err != nil {
+		return nil, nil, err
+	}
+
+	settlements := make([]models.Settlement, 0)
+	cursor, err = config.DB.Collection("settlements").Find(context.Background(), bson.M{"group_id": groupID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = cursor.All(context.Background(), &settlements); err != nil {
+		return nil, nil, err
+	}
+
+	return expenses, settlements, nil
+}
+
+// GetExpenseBalancesHandler returns each member's balance per currency and the
+// transfers that would settle the group up
+func GetExpenseBalancesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	expenses, settlements, err := loadGroupLedger(group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch expenses", http.StatusInternalServerError)
+		return
+	}
+
+	ledgers := models.ComputeBalances(expenses, settlements)
+
+	currencies := make([]string, 0, len(ledgers))
+	for currency := range ledgers {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	balances := make([]CurrencyBalances, 0, len(currencies))
+	for _, currency := range currencies {
+		balances = append(balances, CurrencyBalances{
+			Currency:  currency,
+			Balances:  models.SortedBalances(ledgers[currency]),
+			Transfers: models.SettleUp(ledgers[currency], currency),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+// SettleUpHandler records a payment between two members
+func SettleUpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SettleUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+	members := groupMemberSet(group)
+
+	fromUser := user.ID
+	if request.FromUserID != "" {
+		var err error
+		if fromUser, err = parseUserID(request.FromUserID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	toUser, err := parseUserID(request.ToUserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if fromUser == toUser {
+		http.Error(w, "A member can't settle up with themselves", http.StatusBadRequest)
+		return
+	}
+	if !members[fromUser] || !members[toUser] {
+		http.Error(w, "Both members must belong to this group", http.StatusBadRequest)
+		return
+	}
+	// Members record payments they made or received
+	if user.ID != fromUser && user.ID != toUser {
+		http.Error(w, "You can only record settlements you are part of", http.StatusForbidden)
+		return
+	}
+
+	settlement := models.CreateSettlement(
+		group.ID,
+		fromUser,
+		toUser,
+		models.ToCents(request.Amount),
+		request.Currency,
+		strings.TrimSpace(request.Note),
+		user.ID,
+	)
+	if settlement.Amount <= 0 {
+		http.Error(w, "Amount must be positive", http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateCurrency(settlement.Currency); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.Collection("settlements").InsertOne(context.Background(), settlement)
+	if err != nil {
+		log.Printf("Settlement creation error: %v", err)
+		http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+		return
+	}
+	settlement.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(settlement)
+}
+
+// GetSettlementsHandler lists the group's settlements, newest first
+func GetSettlementsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("settlements").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		opts,
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch settlements", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	settlements := make([]models.Settlement, 0)
+	if err = cursor.All(context.Background(), &settlements); err != nil {
+		http.Error(w, "Failed to decode settlements", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settlements)
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/middleware"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
 	"encoding/json"
 	"errors"
 	"log"
@@ -35,6 +37,13 @@ func CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
 	// Initialize with proper defaults (including group_code generation)
 	group = *models.NewGroup(group.Name)
 
+	// Whoever creates the group administers it
+	if userClaims, ok := middleware.GetUserFromContext(r.Context()); ok {
+		if creatorID, err := primitive.ObjectIDFromHex(userClaims.ID); err == nil {
+			group.Admins = []primitive.ObjectID{creatorID}
+		}
+	}
+
 	// Insert and get generated ID
 	result, err := config.DB.Collection("groups").InsertOne(context.Background(), group)
 	if err != nil {
@@ -94,6 +103,10 @@ func JoinGroupHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer session.EndSession(context.Background())
 
+	// Set by the transaction, used to notify the group once it commits
+	var joinedUser models.User
+	var joinedGroupID primitive.ObjectID
+
 	// Transaction handling
 	err = mongo.WithSession(context.Background(), session, func(sc mongo.SessionContext) error {
 		// 1. Fetch group with essential fields
@@ -117,7 +130,7 @@ func JoinGroupHandler(w http.ResponseWriter, r *http.Request) {
 		err = config.DB.Collection("users").FindOne(
 			sc,
 			bson.M{"username": request.Username},
-			options.FindOne().SetProjection(bson.M{"_id": 1}),
+			options.FindOne().SetProjection(bson.M{"_id": 1, "name": 1}),
 		).Decode(&user)
 
 		if err != nil {
@@ -176,6 +189,8 @@ func JoinGroupHandler(w http.ResponseWriter, r *http.Request) {
 			return fmt.Errorf("group document not found")
 		}
 
+		joinedUser = user
+		joinedGroupID = group.ID
 		return nil
 	})
 
@@ -197,6 +212,8 @@ func JoinGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	notifications.Notify(models.CreateMemberJoinedNotification(joinedGroupID, &joinedUser))
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Successfully joined group",
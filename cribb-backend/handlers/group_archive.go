@@ -0,0 +1,373 @@
+// handlers/group_archive.go
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"cribb-backend/archive"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxGroupArchiveSize caps the size of an uploaded archive
+const maxGroupArchiveSize = 32 << 20
+
+// maxGroupNameAttempts caps how many numbered names are tried when renaming an import
+const maxGroupNameAttempts = 100
+
+// archiveFileName keeps group names safe to use in a file name
+var archiveFileName = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ImportedMember reports who an exported member's records were given to.
+// Matched members keep their records and can join with the group code.
+type ImportedMember struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	UserID   string `json:"user_id"`
+	Matched  bool   `json:"matched"`
+}
+
+// GroupImportResponse describes the group created from an archive
+type GroupImportResponse struct {
+	Group    models.Group     `json:"group"`
+	Renamed  bool             `json:"renamed"`
+	Imported map[string]int   `json:"imported"`
+	Members  []ImportedMember `json:"members"`
+}
+
+// errGroupImportConflict is returned from the import transaction when the
+// group name was taken in the meantime
+var errGroupImportConflict = errors.New("group name already exists")
+
+// loadRecords decodes every document matching the filter into out
+func loadRecords(ctx context.Context, collection string, filter bson.M, out interface{}) error {
+	cursor, err := config.DB.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, out)
+}
+
+// loadGroupArchive collects the group's data for an export
+func loadGroupArchive(ctx context.Context, group models.Group) (*archive.Data, error) {
+	d := &archive.Data{
+		Group:               group,
+		Members:             make([]models.User, 0),
+		Chores:              make([]models.Chore, 0),
+		RecurringChores:     make([]models.RecurringChore, 0),
+		ChoreCompletions:    make([]models.ChoreCompletion, 0),
+		PantryItems:         make([]models.PantryItem, 0),
+		PantryHistory:       make([]models.PantryHistory, 0),
+		Notifications:       make([]models.Notification, 0),
+		PantryNotifications: make([]models.PantryNotification, 0),
+		ShoppingCart:        make([]models.ShoppingCartItem, 0),
+	}
+
+	byGroup := bson.M{"group_id": group.ID}
+	loads := []struct {
+		collection string
+		out        interface{}
+	}{
+		{"users", &d.Members},
+		{"chores", &d.Chores},
+		{"recurring_chores", &d.RecurringChores},
+		{"pantry_items", &d.PantryItems},
+		{"pantry_history", &d.PantryHistory},
+		{"notifications", &d.Notifications},
+		{"pantry_notifications", &d.PantryNotifications},
+		{"shopping_cart", &d.ShoppingCart},
+	}
+	for _, load := range loads {
+		if err := loadRecords(ctx, load.collection, byGroup, load.out); err != nil {
+			return nil, fmt.Errorf("%s: %w", load.collection, err)
+		}
+	}
+
+	// Completions don't record the group, so they're found through the chores
+	choreIDs := make([]primitive.ObjectID, len(d.Chores))
+	for i, chore := range d.Chores {
+		choreIDs[i] = chore.ID
+	}
+	if len(choreIDs) > 0 {
+		err := loadRecords(ctx, "chore_completions", bson.M{"chore_id": bson.M{"$in": choreIDs}}, &d.ChoreCompletions)
+		if err != nil {
+			return nil, fmt.Errorf("chore_completions: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// ExportGroupHandler downloads the group's data as a zip of JSON and CSV files
+func ExportGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := loadGroupArchive(r.Context(), group)
+	if err != nil {
+		log.Printf("Failed to load group %s for export: %v", group.ID.Hex(), err)
+		http.Error(w, "Failed to export group", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	fileName := fmt.Sprintf("cribb-%s-%s.zip", strings.Trim(archiveFileName.ReplaceAllString(group.Name, "-"), "-"), now.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	// Headers are already sent, so a failure can only cut the download short
+	if err := archive.Write(w, data, now); err != nil {
+		log.Printf("Failed to write export of group %s: %v", group.ID.Hex(), err)
+	}
+}
+
+// readGroupArchive reads the uploaded archive, sent either as the request body
+// or as the "archive" field of a multipart form
+func readGroupArchive(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxGroupArchiveSize)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("archive")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	return io.ReadAll(r.Body)
+}
+
+// availableGroupName returns name, or the first free "name (n)" when
+// rename is set. It returns an empty name if no free name was found.
+func availableGroupName(ctx context.Context, name string, rename bool) (string, error) {
+	for n := 1; n <= maxGroupNameAttempts; n++ {
+		candidate := name
+		if n > 1 {
+			candidate = fmt.Sprintf("%s (%d)", name, n)
+		}
+		count, err := config.DB.Collection("groups").CountDocuments(ctx, bson.M{"name": candidate})
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		if !rename {
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+// importMembers maps the archive's members to accounts. The importer's own
+// account is matched by username; with matchOthers so are the importer's
+// current housemates. Archives can be edited, so accounts outside the
+// importer's group are never matched. Records of unmatched members go to the
+// importer.
+func importMembers(ctx context.Context, data *archive.Data, importer models.User, matchOthers bool) (archive.UserMap, []ImportedMember, error) {
+	users := archive.UserMap{Users: make(map[primitive.ObjectID]primitive.ObjectID), Fallback: importer.ID}
+
+	accounts := make(map[string]primitive.ObjectID)
+	if matchOthers && !importer.GroupID.IsZero() {
+		usernames := make([]string, len(data.Members))
+		for i, member := range data.Members {
+			usernames[i] = member.Username
+		}
+		var existing []models.User
+		err := loadRecords(ctx, "users", bson.M{"username": bson.M{"$in": usernames}, "group_id": importer.GroupID}, &existing)
+		if err != nil {
+			return users, nil, err
+		}
+		for _, user := range existing {
+			accounts[user.Username] = user.ID
+		}
+	}
+	accounts[importer.Username] = importer.ID
+
+	members := make([]ImportedMember, 0, len(data.Members))
+	for _, member := range data.Members {
+		reported := ImportedMember{Username: member.Username, Name: member.Name, UserID: importer.ID.Hex()}
+		if account, ok := accounts[member.Username]; ok {
+			users.Users[member.ID] = account
+			reported.UserID = account.Hex()
+			reported.Matched = true
+		}
+		members = append(members, reported)
+	}
+	return users, members, nil
+}
+
+// insertGroupRecords inserts a collection's records, if there are any
+func insertGroupRecords[T any](ctx context.Context, collection string, records []T) error {
+	if len(records) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(records))
+	for i := range records {
+		documents[i] = records[i]
+	}
+	_, err := config.DB.Collection(collection).InsertMany(ctx, documents)
+	return err
+}
+
+// ImportGroupHandler creates a new group from an export archive, with the
+// importer as its only member and admin. Every record gets a new ID. Supports:
+//   - ?name= to use a different group name
+//   - ?on_conflict=rename to add a number when the name is taken (default fail)
+//   - ?match_members=true to give members' records to the importer's current
+//     housemates with their usernames
+//   - ?leave_group=true to leave the importer's current group
+func ImportGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	onConflict := query.Get("on_conflict")
+	if onConflict != "" && onConflict != "fail" && onConflict != "rename" {
+		http.Error(w, "on_conflict must be fail or rename", http.StatusBadRequest)
+		return
+	}
+
+	body, err := readGroupArchive(w, r)
+	if err != nil {
+		http.Error(w, "Invalid archive upload", http.StatusBadRequest)
+		return
+	}
+	data, err := archive.Read(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !user.GroupID.IsZero() && query.Get("leave_group") != "true" {
+		http.Error(w, "You're already in a group; set leave_group=true to leave it", http.StatusConflict)
+		return
+	}
+
+	ctx := r.Context()
+	requestedName := strings.TrimSpace(query.Get("name"))
+	if requestedName == "" {
+		requestedName = data.Group.Name
+	}
+	name, err := availableGroupName(ctx, requestedName, onConflict == "rename")
+	if err != nil {
+		http.Error(w, "Failed to check group name", http.StatusInternalServerError)
+		return
+	}
+	if name == "" {
+		http.Error(w, "Group name already exists", http.StatusConflict)
+		return
+	}
+
+	users, members, err := importMembers(ctx, data, user, query.Get("match_members") == "true")
+	if err != nil {
+		http.Error(w, "Failed to match members", http.StatusInternalServerError)
+		return
+	}
+
+	group := models.NewGroup(name)
+	group.ID = primitive.NewObjectID()
+	group.Members = []primitive.ObjectID{user.ID}
+	group.Admins = []primitive.ObjectID{user.ID}
+	data.Remap(group.ID, users)
+
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		log.Printf("Session start error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := config.DB.Collection("groups").InsertOne(sc, group); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, errGroupImportConflict
+			}
+			return nil, err
+		}
+
+		inserts := []func() error{
+			func() error { return insertGroupRecords(sc, "chores", data.Chores) },
+			func() error { return insertGroupRecords(sc, "recurring_chores", data.RecurringChores) },
+			func() error { return insertGroupRecords(sc, "chore_completions", data.ChoreCompletions) },
+			func() error { return insertGroupRecords(sc, "pantry_items", data.PantryItems) },
+			func() error { return insertGroupRecords(sc, "pantry_history", data.PantryHistory) },
+			func() error { return insertGroupRecords(sc, "notifications", data.Notifications) },
+			func() error { return insertGroupRecords(sc, "pantry_notifications", data.PantryNotifications) },
+			func() error { return insertGroupRecords(sc, "shopping_cart", data.ShoppingCart) },
+		}
+		for _, insert := range inserts {
+			if err := insert(); err != nil {
+				return nil, err
+			}
+		}
+
+		if !user.GroupID.IsZero() {
+			_, err := config.DB.Collection("groups").UpdateByID(sc, user.GroupID, bson.M{
+				"$pull": bson.M{"members": user.ID, "admins": user.ID},
+				"$set":  bson.M{"updated_at": time.Now()},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		_, err := config.DB.Collection("users").UpdateByID(sc, user.ID, bson.M{
+			"$set": bson.M{
+				"group":      group.Name,
+				"group_id":   group.ID,
+				"group_code": group.GroupCode,
+				"updated_at": time.Now(),
+			},
+		})
+		return nil, err
+	})
+	if err != nil {
+		if errors.Is(err, errGroupImportConflict) {
+			http.Error(w, "Group name already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Group import failed: %v", err)
+		http.Error(w, "Failed to import group", http.StatusInternalServerError)
+		return
+	}
+
+	// Members are reported separately since their accounts aren't imported
+	imported := data.Counts()
+	delete(imported, "members")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(GroupImportResponse{
+		Group:    *group,
+		Renamed:  name != requestedName,
+		Imported: imported,
+		Members:  members,
+	})
+}
@@ -0,0 +1,406 @@
+// handlers/group_list.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/units"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// groupListRetries is how often a list update is retried after losing a race
+const groupListRetries = 3
+
+// errGroupListItemChanged is returned when the item changed since the client last saw it
+var errGroupListItemChanged = errors.New("item was changed by another member, please refresh")
+
+// AddGroupListItemRequest defines the request structure for asking for an item on the group list
+type AddGroupListItemRequest struct {
+	ItemName string  `json:"item_name" validate:"required,min=1"`
+	Quantity float64 `json:"quantity" validate:"required,min=0.1"`
+	Unit     string  `json:"unit,omitempty"`
+	Category string  `json:"category"`
+}
+
+// GroupListItemActionRequest defines the request structure for acting on a group list item
+type GroupListItemActionRequest struct {
+	ItemID  string `json:"item_id"`
+	Version *int64 `json:"version,omitempty"` // The version the client last saw; rejects stale changes
+}
+
+// updateGroupListItem applies a change to a list item, retrying on a fresh copy
+// if another member changed it first. Items left without requests are deleted.
+func updateGroupListItem(
+	groupID primitive.ObjectID,
+	request GroupListItemActionRequest,
+	change func(item *models.GroupListItem) error,
+) (models.GroupListItem, error) {
+	var item models.GroupListItem
+
+	itemID, err := primitive.ObjectIDFromHex(request.ItemID)
+	if err != nil {
+		return item, pantryRequestError{"Invalid item ID format"}
+	}
+
+	collection := config.DB.Collection("group_list")
+	for attempt := 0; attempt < groupListRetries; attempt++ {
+		err = collection.FindOne(
+			context.Background(),
+			bson.M{"_id": itemID, "group_id": groupID},
+		).Decode(&item)
+		if err != nil {
+			return item, err
+		}
+		if request.Version != nil && item.Version != *request.Version {
+			return item, errGroupListItemChanged
+		}
+
+		seen := item.Version
+		if err = change(&item); err != nil {
+			return item, err
+		}
+
+		var matched int64
+		if len(item.Requests) == 0 {
+			result, err := collection.DeleteOne(
+				context.Background(),
+				bson.M{"_id": item.ID, "version": seen},
+			)
+			if err != nil {
+				return item, err
+			}
+			matched = result.DeletedCount
+		} else {
+			result, err := collection.ReplaceOne(
+				context.Background(),
+				bson.M{"_id": item.ID, "version": seen},
+				item,
+			)
+			if err != nil {
+				return item, err
+			}
+			matched = result.MatchedCount
+		}
+		if matched > 0 {
+			return item, nil
+		}
+	}
+	return item, errGroupListItemChanged
+}
+
+// addToGroupList adds the user's request to the group list, merging it into an
+// existing entry for the same item when the units are compatible
+func addToGroupList(user models.User, group models.Group, itemName string, quantity float64, unit, category string) (models.GroupListItem, error) {
+	key := models.IngredientKey(itemName)
+	unitKey := models.ListUnitKey(unit)
+	collection := config.DB.Collection("group_list")
+
+	for attempt := 0; attempt < groupListRetries; attempt++ {
+		var item models.GroupListItem
+		err := collection.FindOne(
+			context.Background(),
+			bson.M{"group_id": group.ID, "item_key": key, "unit_key": unitKey},
+		).Decode(&item)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			newItem := models.CreateGroupListItem(group.ID, itemName, unit, category)
+			if err := newItem.AddRequest(user.ID, user.Name, quantity, unit, time.Now()); err != nil {
+				return item, err
+			}
+
+			result, err := collection.InsertOne(context.Background(), newItem)
+			if err != nil {
+				if mongo.IsDuplicateKeyError(err) {
+					// Someone else asked for it at the same moment; merge into theirs
+					continue
+				}
+				return item, err
+			}
+			newItem.ID = result.InsertedID.(primitive.ObjectID)
+			return *newItem, nil
+		}
+		if err != nil {
+			return item, err
+		}
+
+		// Adds merge into whatever the item has become, so no version is checked
+		merged, err := updateGroupListItem(
+			group.ID,
+			GroupListItemActionRequest{ItemID: item.ID.Hex()},
+			func(item *models.GroupListItem) error {
+				if item.Category == "" {
+					item.Category = category
+				}
+				return item.AddRequest(user.ID, user.Name, quantity, unit, time.Now())
+			},
+		)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Bought or removed in the meantime; start a new entry
+			continue
+		}
+		return merged, err
+	}
+	return models.GroupListItem{}, errGroupListItemChanged
+}
+
+// writeGroupListError maps a group list error to a response
+func writeGroupListError(w http.ResponseWriter, err error) {
+	var requestErr pantryRequestError
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		http.Error(w, "Group list item not found", http.StatusNotFound)
+	case errors.Is(err, models.ErrListItemClaimed),
+		errors.Is(err, models.ErrListItemNotClaimed),
+		errors.Is(err, errGroupListItemChanged):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &requestErr), errors.Is(err, units.ErrIncompatibleUnits):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Group list update failed: %v", err)
+		http.Error(w, "Failed to update group list", http.StatusInternalServerError)
+	}
+}
+
+// decodeGroupListAction reads a group list action request
+func decodeGroupListAction(w http.ResponseWriter, r *http.Request) (GroupListItemActionRequest, bool) {
+	var request GroupListItemActionRequest
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return request, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return request, false
+	}
+	return request, true
+}
+
+// AddGroupListItemHandler asks for an item on the group's shared list
+func AddGroupListItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request AddGroupListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(request.ItemName) == "" || request.Quantity <= 0 {
+		http.Error(w, "Item name and quantity are required. Quantity must be positive.", http.StatusBadRequest)
+		return
+	}
+
+	if request.Unit != "" {
+		unit, err := normalizeUnitOrError(request.Unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request.Unit = unit
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := addToGroupList(user, group, request.ItemName, request.Quantity, request.Unit, request.Category)
+	if err != nil {
+		writeGroupListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Item added to group list",
+		Data:    item,
+	})
+}
+
+// ListGroupListItemsHandler returns the group's shared list. Use ?claimed=mine,
+// ?claimed=true or ?claimed=false to filter by claim.
+func ListGroupListItemsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"group_id": group.ID}
+	switch r.URL.Query().Get("claimed") {
+	case "":
+	case "mine":
+		filter["claimed_by"] = user.ID
+	case "true":
+		filter["claimed_by"] = bson.M{"$exists": true}
+	case "false":
+		filter["claimed_by"] = bson.M{"$exists": false}
+	default:
+		http.Error(w, "Claimed must be mine, true or false", http.StatusBadRequest)
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "item_key", Value: 1}})
+	cursor, err := config.DB.Collection("group_list").Find(context.Background(), filter, opts)
+	if err != nil {
+		log.Printf("Failed to fetch group list items: %v", err)
+		http.Error(w, "Failed to fetch group list items", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	items := make([]models.GroupListItem, 0)
+	if err = cursor.All(context.Background(), &items); err != nil {
+		http.Error(w, "Failed to decode group list items", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Group list retrieved successfully",
+		Data:    items,
+	})
+}
+
+// ClaimGroupListItemHandler marks the current user as the one buying an item
+func ClaimGroupListItemHandler(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeGroupListAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := updateGroupListItem(group.ID, request, func(item *models.GroupListItem) error {
+		return item.Claim(user.ID, user.Name, time.Now())
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrListItemClaimed) {
+			http.Error(w, "Item is already claimed by "+item.ClaimedByName, http.StatusConflict)
+			return
+		}
+		writeGroupListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Item claimed",
+		Data:    item,
+	})
+}
+
+// UnclaimGroupListItemHandler releases the current user's claim on an item
+func UnclaimGroupListItemHandler(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeGroupListAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := updateGroupListItem(group.ID, request, func(item *models.GroupListItem) error {
+		return item.Unclaim(user.ID, time.Now())
+	})
+	if err != nil {
+		writeGroupListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Item released",
+		Data:    item,
+	})
+}
+
+// RemoveGroupListRequestHandler takes back the current user's request for an item.
+// The item leaves the list once nobody wants it.
+func RemoveGroupListRequestHandler(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeGroupListAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := updateGroupListItem(group.ID, request, func(item *models.GroupListItem) error {
+		if !item.RemoveRequest(user.ID, time.Now()) {
+			return pantryRequestError{"You have not asked for this item"}
+		}
+		return nil
+	})
+	if err != nil {
+		writeGroupListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Request removed from group list",
+		Data:    item,
+	})
+}
+
+// CompleteGroupListItemHandler takes an item off the list once the member who claimed it has bought it
+func CompleteGroupListItemHandler(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeGroupListAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := updateGroupListItem(group.ID, request, func(item *models.GroupListItem) error {
+		if !item.IsClaimed() || *item.ClaimedBy != user.ID {
+			return models.ErrListItemNotClaimed
+		}
+		item.Requests = item.Requests[:0]
+		return nil
+	})
+	if err != nil {
+		writeGroupListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: item.ItemName + " bought and removed from group list",
+	})
+}
@@ -0,0 +1,358 @@
+// handlers/group_webhooks.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/delivery"
+	"cribb-backend/events"
+	"cribb-backend/models"
+	"cribb-backend/webhooks"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxWebhookDeliveryPage caps how many deliveries one request returns
+const maxWebhookDeliveryPage = 100
+
+// SaveGroupWebhookRequest registers a webhook, or updates one when webhook_id is given
+type SaveGroupWebhookRequest struct {
+	WebhookID   string   `json:"webhook_id,omitempty"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	Active      *bool    `json:"active,omitempty"` // Defaults to true
+}
+
+// GroupWebhookCreatedResponse includes the signing secret, which is only shown once
+type GroupWebhookCreatedResponse struct {
+	models.GroupWebhook
+	Secret string `json:"secret"`
+}
+
+// TestGroupWebhookRequest names the webhook to send a test delivery to
+type TestGroupWebhookRequest struct {
+	WebhookID string `json:"webhook_id"`
+}
+
+// findGroupWebhook loads one of the group's webhooks by its hex ID
+func findGroupWebhook(w http.ResponseWriter, groupID primitive.ObjectID, idStr string) (models.GroupWebhook, bool) {
+	var webhook models.GroupWebhook
+
+	webhookID, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Invalid webhook ID format", http.StatusBadRequest)
+		return webhook, false
+	}
+
+	err = config.DB.Collection(webhooks.Collection).FindOne(
+		context.Background(),
+		bson.M{"_id": webhookID, "group_id": groupID},
+	).Decode(&webhook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch webhook", http.StatusInternalServerError)
+		}
+		return webhook, false
+	}
+	return webhook, true
+}
+
+// webhookEvents checks the requested event types and removes duplicates
+func webhookEvents(requested []string) ([]string, error) {
+	seen := make(map[string]bool, len(requested))
+	eventTypes := make([]string, 0, len(requested))
+	for _, eventType := range requested {
+		eventType = strings.TrimSpace(eventType)
+		if !events.Type(eventType).IsKnown() {
+			return nil, pantryRequestError{fmt.Sprintf("Unknown event type %q", eventType)}
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
+
+// GetGroupWebhooksHandler lists the group's webhooks for its admins
+func GetGroupWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	cursor, err := config.DB.Collection(webhooks.Collection).Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	hooks := make([]models.GroupWebhook, 0)
+	if err = cursor.All(context.Background(), &hooks); err != nil {
+		http.Error(w, "Failed to decode webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks":    hooks,
+		"event_types": events.Types,
+	})
+}
+
+// SaveGroupWebhookHandler registers a webhook, or updates one when webhook_id is given.
+// A new webhook's signing secret is returned only in the response that creates it.
+func SaveGroupWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveGroupWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	eventTypes, err := webhookEvents(request.Events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := models.CreateGroupWebhook(group.ID, strings.TrimSpace(request.URL), strings.TrimSpace(request.Description), eventTypes, user.ID)
+	if err != nil {
+		log.Printf("Webhook secret generation error: %v", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	if request.Active != nil {
+		webhook.Active = *request.Active
+	}
+	if err := webhook.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.WebhookID == "" {
+		count, err := config.DB.Collection(webhooks.Collection).CountDocuments(
+			context.Background(),
+			bson.M{"group_id": group.ID},
+		)
+		if err != nil {
+			http.Error(w, "Failed to count webhooks", http.StatusInternalServerError)
+			return
+		}
+		if count >= models.MaxGroupWebhooks {
+			http.Error(w, fmt.Sprintf("A group can have at most %d webhooks", models.MaxGroupWebhooks), http.StatusBadRequest)
+			return
+		}
+
+		result, err := config.DB.Collection(webhooks.Collection).InsertOne(context.Background(), webhook)
+		if err != nil {
+			log.Printf("Webhook creation error: %v", err)
+			http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+			return
+		}
+		webhook.ID = result.InsertedID.(primitive.ObjectID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(GroupWebhookCreatedResponse{GroupWebhook: *webhook, Secret: webhook.Secret})
+		return
+	}
+
+	existing, ok := findGroupWebhook(w, group.ID, request.WebhookID)
+	if !ok {
+		return
+	}
+	webhook.ID = existing.ID
+	webhook.Secret = existing.Secret
+	webhook.CreatedBy = existing.CreatedBy
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now()
+
+	_, err = config.DB.Collection(webhooks.Collection).ReplaceOne(
+		context.Background(),
+		bson.M{"_id": webhook.ID},
+		webhook,
+	)
+	if err != nil {
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// DeleteGroupWebhookHandler removes a webhook and its delivery log
+func DeleteGroupWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get webhook ID from URL path
+	webhookIDStr := strings.TrimPrefix(r.URL.Path, "/api/groups/webhooks/remove/")
+
+	_, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, ok := findGroupWebhook(w, group.ID, webhookIDStr)
+	if !ok {
+		return
+	}
+
+	_, err := config.DB.Collection(webhooks.Collection).DeleteOne(
+		context.Background(),
+		bson.M{"_id": webhook.ID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = config.DB.Collection(webhooks.DeliveriesCollection).DeleteMany(
+		context.Background(),
+		bson.M{"webhook_id": webhook.ID},
+	)
+	if err != nil {
+		log.Printf("Failed to delete deliveries of webhook %s: %v", webhook.ID.Hex(), err)
+		// Continue anyway, they expire on their own
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetWebhookDeliveriesHandler returns a webhook's recent deliveries, newest first,
+// with the log of each attempt. Supports ?webhook_id= (required) and ?limit=.
+func GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := int64(20)
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, maxWebhookDeliveryPage)
+	}
+
+	_, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, ok := findGroupWebhook(w, group.ID, query.Get("webhook_id"))
+	if !ok {
+		return
+	}
+
+	cursor, err := config.DB.Collection(webhooks.DeliveriesCollection).Find(
+		context.Background(),
+		bson.M{"webhook_id": webhook.ID},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch deliveries", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	if err = cursor.All(context.Background(), &deliveries); err != nil {
+		http.Error(w, "Failed to decode deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// TestGroupWebhookHandler sends a webhook.test event right away and returns
+// the logged delivery. Test deliveries aren't retried.
+func TestGroupWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request TestGroupWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentGroupAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, ok := findGroupWebhook(w, group.ID, request.WebhookID)
+	if !ok {
+		return
+	}
+
+	payload, err := webhooks.TestPayload(&webhook, user)
+	if err != nil {
+		http.Error(w, "Failed to build test payload", http.StatusInternalServerError)
+		return
+	}
+	testDelivery := models.CreateWebhookDelivery(&webhook, string(events.WebhookTest), payload)
+	testDelivery.ID = primitive.NewObjectID()
+	testDelivery.MaxAttempts = 1
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	webhooks.Attempt(ctx, delivery.NewSignedWebhook(), &webhook, testDelivery)
+
+	_, err = config.DB.Collection(webhooks.DeliveriesCollection).InsertOne(context.Background(), testDelivery)
+	if err != nil {
+		log.Printf("Failed to log test delivery: %v", err)
+		// Continue anyway, the result is still returned
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(testDelivery)
+}
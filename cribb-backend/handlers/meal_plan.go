@@ -0,0 +1,361 @@
+// handlers/meal_plan.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/units"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// planDateLayout is the day format used by meal plan requests
+const planDateLayout = "2006-01-02"
+
+// AddMealPlanEntryRequest defines the request structure for planning a meal
+type AddMealPlanEntryRequest struct {
+	RecipeID string `json:"recipe_id"`
+	Date     string `json:"date"`                // YYYY-MM-DD
+	MealType string `json:"meal_type,omitempty"` // Defaults to dinner
+	Servings int    `json:"servings,omitempty"`  // Defaults to the recipe's servings
+}
+
+// AddMealPlanEntryHandler plans a recipe for a day
+func AddMealPlanEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request AddMealPlanEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse(planDateLayout, request.Date)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	mealType, err := models.ParseMealType(request.MealType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Servings < 0 {
+		http.Error(w, "Servings cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	recipe, ok := findGroupRecipe(w, group.ID, request.RecipeID)
+	if !ok {
+		return
+	}
+
+	entry := models.CreateMealPlanEntry(group.ID, &recipe, date, mealType, request.Servings, user.ID)
+
+	result, err := config.DB.Collection("meal_plan").InsertOne(context.Background(), entry)
+	if err != nil {
+		log.Printf("Meal plan entry creation error: %v", err)
+		http.Error(w, "Failed to add meal to plan", http.StatusInternalServerError)
+		return
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetMealPlanWeekHandler lists the meals planned for a week (Monday to Sunday)
+func GetMealPlanWeekHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Any day in the week can be given; defaults to the current week
+	day := time.Now()
+	if week := r.URL.Query().Get("week"); week != "" {
+		parsed, err := time.Parse(planDateLayout, week)
+		if err != nil {
+			http.Error(w, "Invalid week format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		day = parsed
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	start := models.WeekStart(day)
+	end := start.AddDate(0, 0, 7)
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := config.DB.Collection("meal_plan").Find(
+		context.Background(),
+		bson.M{
+			"group_id": group.ID,
+			"date":     bson.M{"$gte": start, "$lt": end},
+		},
+		opts,
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch meal plan", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	entries := make([]models.MealPlanEntry, 0)
+	if err = cursor.All(context.Background(), &entries); err != nil {
+		http.Error(w, "Failed to decode meal plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"week_start": start.Format(planDateLayout),
+		"week_end":   end.AddDate(0, 0, -1).Format(planDateLayout),
+		"entries":    entries,
+	})
+}
+
+// DeleteMealPlanEntryHandler removes a meal from the plan
+func DeleteMealPlanEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get entry ID from URL path
+	entryIDStr := strings.TrimPrefix(r.URL.Path, "/api/meal-plan/remove/")
+	entryID, err := primitive.ObjectIDFromHex(entryIDStr)
+	if err != nil {
+		http.Error(w, "Invalid meal plan entry ID format", http.StatusBadRequest)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("meal_plan").DeleteOne(
+		context.Background(),
+		bson.M{"_id": entryID, "group_id": group.ID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete meal plan entry", http.StatusInternalServerError)
+		return
+	}
+	if result.DeletedCount == 0 {
+		http.Error(w, "Meal plan entry not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Meal plan entry deleted successfully",
+	})
+}
+
+// PlannedRecipeRequest names a recipe and servings to shop for
+type PlannedRecipeRequest struct {
+	RecipeID string `json:"recipe_id"`
+	Servings int    `json:"servings,omitempty"` // Defaults to the recipe's servings
+}
+
+// MealPlanShoppingListRequest defines the request structure for the meal plan shopping list.
+// Either recipes are listed, or the uncooked meals planned between the dates are used.
+type MealPlanShoppingListRequest struct {
+	StartDate       string                 `json:"start_date,omitempty"` // YYYY-MM-DD, defaults to today
+	EndDate         string                 `json:"end_date,omitempty"`   // YYYY-MM-DD inclusive, defaults to a week from the start
+	Recipes         []PlannedRecipeRequest `json:"recipes,omitempty"`
+	IncludeOptional bool                   `json:"include_optional,omitempty"`
+	PushToCart      bool                   `json:"push_to_cart,omitempty"`
+}
+
+// MealPlanShoppingListHandler works out what to buy for planned meals after
+// subtracting pantry stock, optionally adding it to the user's shopping cart
+func MealPlanShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request MealPlanShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	start := models.PlanDay(time.Now())
+	if request.StartDate != "" {
+		parsed, err := time.Parse(planDateLayout, request.StartDate)
+		if err != nil {
+			http.Error(w, "Invalid start date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		start = parsed
+	}
+	end := start.AddDate(0, 0, 6)
+	if request.EndDate != "" {
+		parsed, err := time.Parse(planDateLayout, request.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid end date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		http.Error(w, "End date cannot be before start date", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	var planned []models.PlannedRecipe
+	if len(request.Recipes) > 0 {
+		for _, requested := range request.Recipes {
+			if requested.Servings < 0 {
+				http.Error(w, "Servings cannot be negative", http.StatusBadRequest)
+				return
+			}
+			recipe, ok := findGroupRecipe(w, group.ID, requested.RecipeID)
+			if !ok {
+				return
+			}
+			planned = append(planned, models.PlannedRecipe{Recipe: &recipe, Servings: requested.Servings})
+		}
+	} else {
+		var err error
+		planned, err = plannedRecipesBetween(group.ID, start, end)
+		if err != nil {
+			log.Printf("Failed to load planned meals: %v", err)
+			http.Error(w, "Failed to fetch meal plan", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	items, err := loadPantryItems(context.Background(), group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch pantry items", http.StatusInternalServerError)
+		return
+	}
+
+	needs := models.AggregateIngredients(planned, request.IncludeOptional)
+	gaps := models.ComputeShoppingGap(needs, items)
+
+	response := map[string]interface{}{
+		"recipe_count": len(planned),
+		"items":        gaps,
+	}
+	if len(request.Recipes) == 0 {
+		response["start_date"] = start.Format(planDateLayout)
+		response["end_date"] = end.Format(planDateLayout)
+	}
+
+	if request.PushToCart {
+		// Items are added one by one, so a failure is reported with the rest
+		// of the results instead of hiding the items already added
+		added := make([]models.ShoppingCartItem, 0, len(gaps))
+		skipped := make([]string, 0)
+		failed := make([]string, 0)
+		for _, gap := range gaps {
+			cartItem, err := addToShoppingCart(user, primitive.NilObjectID, gap.Name, gap.ToBuy, gap.Unit, gap.Category, "Added for planned meals")
+			if err != nil {
+				if errors.Is(err, units.ErrIncompatibleUnits) {
+					skipped = append(skipped, gap.Name)
+					continue
+				}
+				log.Printf("Failed to add planned meal ingredient to cart: %v", err)
+				failed = append(failed, gap.Name)
+				continue
+			}
+			added = append(added, cartItem)
+		}
+		response["cart_items"] = added
+		response["skipped"] = skipped
+		response["failed"] = failed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// plannedRecipesBetween loads the uncooked meals planned between two days, inclusive
+func plannedRecipesBetween(groupID primitive.ObjectID, start, end time.Time) ([]models.PlannedRecipe, error) {
+	cursor, err := config.DB.Collection("meal_plan").Find(
+		context.Background(),
+		bson.M{
+			"group_id":  groupID,
+			"date":      bson.M{"$gte": models.PlanDay(start), "$lt": models.PlanDay(end).AddDate(0, 0, 1)},
+			"cooked_at": bson.M{"$exists": false},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	entries := make([]models.MealPlanEntry, 0)
+	if err = cursor.All(context.Background(), &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	recipeIDs := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		recipeIDs = append(recipeIDs, entry.RecipeID)
+	}
+
+	recipeCursor, err := config.DB.Collection("recipes").Find(
+		context.Background(),
+		bson.M{"_id": bson.M{"$in": recipeIDs}, "group_id": groupID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer recipeCursor.Close(context.Background())
+
+	recipes := make([]models.Recipe, 0)
+	if err = recipeCursor.All(context.Background(), &recipes); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Recipe, len(recipes))
+	for i := range recipes {
+		byID[recipes[i].ID] = &recipes[i]
+	}
+
+	planned := make([]models.PlannedRecipe, 0, len(entries))
+	for _, entry := range entries {
+		if recipe, ok := byID[entry.RecipeID]; ok {
+			planned = append(planned, models.PlannedRecipe{Recipe: recipe, Servings: entry.Servings})
+		}
+	}
+	return planned, nil
+}
@@ -0,0 +1,117 @@
+// handlers/notification_preferences.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveNotificationPreferencesRequest replaces the user's notification preferences.
+// Types maps a notification type to a delivery mode per channel; anything left
+// out uses the default for its channel. Leaving out the digest keeps the
+// current schedule.
+type SaveNotificationPreferencesRequest struct {
+	Timezone   string                                                                         `json:"timezone"`
+	QuietHours *models.QuietHours                                                             `json:"quiet_hours,omitempty"`
+	Email      string                                                                         `json:"email,omitempty"`
+	WebhookURL string                                                                         `json:"webhook_url,omitempty"`
+	Types      map[models.NotificationType]map[models.NotificationChannel]models.DeliveryMode `json:"types"`
+	Digest     *models.DigestSettings                                                         `json:"digest,omitempty"`
+}
+
+// GetNotificationPreferencesHandler returns the user's notification preferences
+func GetNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := notifications.LoadPreferences(context.Background(), []primitive.ObjectID{user.ID})
+	if err != nil {
+		http.Error(w, "Failed to fetch notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferences[user.ID])
+}
+
+// SaveNotificationPreferencesHandler saves the user's notification preferences
+func SaveNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	saved, err := notifications.LoadPreferences(context.Background(), []primitive.ObjectID{user.ID})
+	if err != nil {
+		http.Error(w, "Failed to fetch notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	preferences := models.DefaultNotificationPreferences(user.ID)
+	preferences.Digest = saved[user.ID].Digest
+	if request.Digest != nil {
+		// When the last digest went out isn't up to the client
+		lastSentAt := preferences.Digest.LastSentAt
+		preferences.Digest = *request.Digest
+		preferences.Digest.LastSentAt = lastSentAt
+	}
+	if request.Timezone != "" {
+		preferences.Timezone = request.Timezone
+	}
+	preferences.QuietHours = request.QuietHours
+	preferences.Email = strings.TrimSpace(request.Email)
+	preferences.WebhookURL = strings.TrimSpace(request.WebhookURL)
+	if request.Types != nil {
+		preferences.Types = request.Types
+	}
+	preferences.UpdatedAt = time.Now()
+
+	if err := preferences.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = config.DB.Collection(notifications.PreferencesCollection).ReplaceOne(
+		context.Background(),
+		bson.M{"user_id": user.ID},
+		preferences,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Failed to save notification preferences: %v", err)
+		http.Error(w, "Failed to save notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferences)
+}
@@ -0,0 +1,212 @@
+// handlers/notifications.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxNotificationPage caps how many notifications are returned at once
+const maxNotificationPage = 100
+
+// NotificationResponse is a notification with the caller's read state
+type NotificationResponse struct {
+	models.Notification
+	IsRead bool `json:"is_read"`
+}
+
+// NotificationInboxResponse is a page of the caller's inbox.
+// NextBefore is passed as ?before= to fetch the following page.
+type NotificationInboxResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+	NextBefore    string                 `json:"next_before,omitempty"`
+}
+
+// MarkNotificationsReadRequest lists the notifications to mark as read
+type MarkNotificationsReadRequest struct {
+	NotificationIDs []string `json:"notification_ids"`
+}
+
+// MarkAllNotificationsReadRequest optionally limits mark-all-read to one domain
+type MarkAllNotificationsReadRequest struct {
+	Domain models.NotificationDomain `json:"domain,omitempty"`
+}
+
+// GetNotificationsHandler returns a page of the user's inbox, newest first.
+// Supports ?limit=, ?before=<notification id>, ?unread=true and ?domain=.
+func GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := int64(20)
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, maxNotificationPage)
+	}
+
+	filter := notifications.InboxFilter(group.ID, user.ID)
+	if query.Get("unread") == "true" {
+		filter = notifications.UnreadFilter(group.ID, user.ID)
+	}
+	if domain := query.Get("domain"); domain != "" {
+		filter["domain"] = domain
+	}
+	if before := query.Get("before"); before != "" {
+		beforeID, err := primitive.ObjectIDFromHex(before)
+		if err != nil {
+			http.Error(w, "Invalid before cursor", http.StatusBadRequest)
+			return
+		}
+		filter["_id"] = bson.M{"$lt": beforeID}
+	}
+
+	// Object IDs grow with creation time, so they order the inbox and page it
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+	cursor, err := config.DB.Collection(notifications.Collection).Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch notifications", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var page []models.Notification
+	if err = cursor.All(context.Background(), &page); err != nil {
+		http.Error(w, "Failed to decode notifications", http.StatusInternalServerError)
+		return
+	}
+
+	unreadCount, err := config.DB.Collection(notifications.Collection).CountDocuments(
+		context.Background(),
+		notifications.UnreadFilter(group.ID, user.ID),
+	)
+	if err != nil {
+		http.Error(w, "Failed to count unread notifications", http.StatusInternalServerError)
+		return
+	}
+
+	response := NotificationInboxResponse{
+		Notifications: make([]NotificationResponse, 0, len(page)),
+		UnreadCount:   unreadCount,
+	}
+	for _, notification := range page {
+		response.Notifications = append(response.Notifications, NotificationResponse{
+			Notification: notification,
+			IsRead:       notification.HasBeenReadBy(user.ID),
+		})
+	}
+	if int64(len(page)) == limit {
+		response.NextBefore = page[len(page)-1].ID.Hex()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// MarkNotificationsReadHandler marks some of the user's notifications as read
+func MarkNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request MarkNotificationsReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(request.NotificationIDs) == 0 {
+		http.Error(w, "At least one notification ID is required", http.StatusBadRequest)
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(request.NotificationIDs))
+	for _, idStr := range request.NotificationIDs {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			http.Error(w, "Invalid notification ID format", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	// Only notifications in the user's own inbox can be marked
+	filter := notifications.InboxFilter(group.ID, user.ID)
+	filter["_id"] = bson.M{"$in": ids}
+
+	markNotificationsRead(w, filter, user.ID)
+}
+
+// MarkAllNotificationsReadHandler marks the user's whole inbox, or one domain of it, as read
+func MarkAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request MarkAllNotificationsReadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	filter := notifications.UnreadFilter(group.ID, user.ID)
+	if request.Domain != "" {
+		filter["domain"] = request.Domain
+	}
+
+	markNotificationsRead(w, filter, user.ID)
+}
+
+// markNotificationsRead adds the user to read_by on the matching notifications
+func markNotificationsRead(w http.ResponseWriter, filter bson.M, userID primitive.ObjectID) {
+	result, err := config.DB.Collection(notifications.Collection).UpdateMany(
+		context.Background(),
+		filter,
+		bson.M{"$addToSet": bson.M{"read_by": userID}},
+	)
+	if err != nil {
+		http.Error(w, "Failed to mark notifications as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Notifications marked as read",
+		"updated": result.ModifiedCount,
+	})
+}
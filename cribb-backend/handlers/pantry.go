@@ -3,12 +3,17 @@ package handlers
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/middleware"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"cribb-backend/units"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -20,18 +25,65 @@ import (
 
 // AddPantryItemRequest defines the request structure for adding a pantry item
 type AddPantryItemRequest struct {
-	Name           string  `json:"name"`
-	Quantity       float64 `json:"quantity"`
-	Unit           string  `json:"unit"`
-	Category       string  `json:"category"`
-	ExpirationDate *string `json:"expiration_date,omitempty"`
-	GroupName      string  `json:"group_name"`
+	Name            string   `json:"name"`
+	Quantity        float64  `json:"quantity"`
+	Unit            string   `json:"unit"`
+	Category        string   `json:"category"`
+	ExpirationDate  *string  `json:"expiration_date,omitempty"`
+	GroupName       string   `json:"group_name"`
+	MinThreshold    *float64 `json:"min_threshold,omitempty"`
+	ParLevel        *float64 `json:"par_level,omitempty"`
+	StorageLocation string   `json:"storage_location,omitempty"` // pantry, fridge or freezer
 }
 
 // UsePantryItemRequest defines the request structure for using a pantry item
 type UsePantryItemRequest struct {
 	ItemID   string  `json:"item_id"`
 	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit,omitempty"` // Defaults to the item's own unit
+}
+
+// pantryRequestError marks transaction errors caused by the request rather than the database
+type pantryRequestError struct {
+	message string
+}
+
+func (e pantryRequestError) Error() string {
+	return e.message
+}
+
+// isPantryRequestError checks if a pantry error was caused by the request: a
+// pantryRequestError, too little stock or a unit that doesn't fit the item
+func isPantryRequestError(err error) bool {
+	var requestErr pantryRequestError
+	return errors.As(err, &requestErr) || errors.Is(err, models.ErrInsufficientQuantity) ||
+		errors.Is(err, units.ErrIncompatibleUnits) || errors.Is(err, units.ErrUnknownUnit)
+}
+
+// normalizeUnitOrError returns the canonical unit symbol, or an error message listing the supported units
+func normalizeUnitOrError(unit string) (string, error) {
+	symbol, err := units.Normalize(unit)
+	if err != nil {
+		return "", fmt.Errorf("Unknown unit %q. Supported units: %s", unit, strings.Join(units.Symbols(), ", "))
+	}
+	return symbol, nil
+}
+
+// pantryBatchUpdate builds the update that stores an item's batches and the totals derived from them
+func pantryBatchUpdate(item models.PantryItem) bson.M {
+	set := bson.M{
+		"quantity":   item.Quantity,
+		"batches":    item.Batches,
+		"updated_at": item.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+
+	if item.ExpirationDate.IsZero() {
+		update["$unset"] = bson.M{"expiration_date": ""}
+	} else {
+		set["expiration_date"] = item.ExpirationDate
+	}
+	return update
 }
 
 // UsePantryItemHandler handles consuming an item from the pantry
@@ -90,23 +142,47 @@ func UsePantryItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, err := usePantryItem(user, itemID, request.Quantity, request.Unit)
+	if err != nil {
+		if isPantryRequestError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Transaction failed: %v", err)
+		http.Error(w, "Failed to use pantry item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UsePantryItemResponse reports what using a pantry item took from it
+type UsePantryItemResponse struct {
+	Success      bool                      `json:"success"`
+	Message      string                    `json:"message"`
+	UsedQty      float64                   `json:"used_quantity"`
+	RemainingQty float64                   `json:"remaining_quantity"`
+	Unit         string                    `json:"unit"`
+	Batches      []models.BatchConsumption `json:"batches"`
+}
+
+// usePantryItem takes a quantity of one of the user's group's pantry items
+// and records it in the pantry history. Errors caused by the request itself
+// satisfy isPantryRequestError.
+func usePantryItem(user models.User, itemID primitive.ObjectID, quantity float64, unit string) (UsePantryItemResponse, error) {
+	var response UsePantryItemResponse
+
 	// Start a transaction
 	session, err := config.DB.Client().StartSession()
 	if err != nil {
-		log.Printf("Failed to start MongoDB session: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return response, err
 	}
 	defer session.EndSession(context.Background())
 
-	// Response data structure
-	type UsePantryItemResponse struct {
-		Success      bool    `json:"success"`
-		Message      string  `json:"message"`
-		RemainingQty float64 `json:"remaining_quantity"`
-		Unit         string  `json:"unit"`
-	}
-	var response UsePantryItemResponse
+	var usedQuantity float64
+	var alerts []stockAlert
 
 	// Start transaction
 	err = mongo.WithSession(context.Background(), session, func(sc mongo.SessionContext) error {
@@ -119,101 +195,41 @@ func UsePantryItemHandler(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			if errors.Is(err, mongo.ErrNoDocuments) {
-				return errors.New("pantry item not found")
+				return pantryRequestError{"pantry item not found"}
 			}
 			return err
 		}
 
 		// Verify the item belongs to the user's group
 		if pantryItem.GroupID != user.GroupID {
-			return errors.New("pantry item does not belong to user's group")
+			return pantryRequestError{"pantry item does not belong to user's group"}
 		}
 
-		// Check if there's enough quantity
-		if pantryItem.Quantity < request.Quantity {
-			return errors.New("not enough quantity available")
-		}
-
-		// Update the quantity
-		newQuantity := pantryItem.Quantity - request.Quantity
-		pantryItem.UpdateQuantity(newQuantity)
-
-		_, err = config.DB.Collection("pantry_items").UpdateOne(
-			sc,
-			bson.M{"_id": pantryItem.ID},
-			bson.M{"$set": bson.M{
-				"quantity":   newQuantity,
-				"updated_at": pantryItem.UpdatedAt,
-			}},
-		)
+		// Take the quantity from the first-expiring batches
+		used, consumed, raised, err := usePantryStock(sc, &pantryItem, quantity, unit)
 		if err != nil {
 			return err
 		}
+		usedQuantity = used
+		alerts = raised
 
 		// Set response values
 		response.Success = true
 		response.Message = "Item used successfully"
-		response.RemainingQty = newQuantity
+		response.UsedQty = usedQuantity
+		response.RemainingQty = pantryItem.Quantity
 		response.Unit = pantryItem.Unit
-
-		// Check if low-stock notification is needed (if quantity is below 20%)
-		// This threshold could be made configurable in the future
-		if newQuantity > 0 && newQuantity <= 1 {
-			notification := models.CreatePantryNotification(
-				pantryItem.GroupID,
-				pantryItem.ID,
-				pantryItem.Name,
-				models.NotificationTypeLowStock,
-				"Item is running low",
-			)
-			_, err = config.DB.Collection("pantry_notifications").InsertOne(sc, notification)
-			if err != nil {
-				log.Printf("Failed to create low-stock notification: %v", err)
-				// Continue anyway, as this is not critical
-			}
-		}
-
-		if newQuantity == 0 {
-			// Remove any existing low_stock notifications
-			_, err = config.DB.Collection("pantry_notifications").DeleteMany(
-				sc,
-				bson.M{
-					"item_id": pantryItem.ID,
-					"type":    models.NotificationTypeLowStock,
-				},
-			)
-			if err != nil {
-				log.Printf("Failed to delete low_stock notifications: %v", err)
-				// Continue anyway as this is not critical
-			}
-
-			// Create out_of_stock notification
-			notification := models.CreatePantryNotification(
-				pantryItem.GroupID,
-				pantryItem.ID,
-				pantryItem.Name,
-				models.NotificationTypeOutOfStock,
-				"Item is out of stock",
-			)
-
-			_, err = config.DB.Collection("pantry_notifications").InsertOne(sc, notification)
-			if err != nil {
-				log.Printf("Failed to create out_of_stock notification: %v", err)
-				// Continue anyway as this is not critical
-			}
-		}
+		response.Batches = consumed
 
 		return nil
 	})
 
 	if err != nil {
-		log.Printf("Transaction failed: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return response, err
 	}
+	sendStockAlerts(alerts)
 
 	// Create history record for using an item
-	itemID, _ = primitive.ObjectIDFromHex(request.ItemID)
 	var pantryItem models.PantryItem
 	err = config.DB.Collection("pantry_items").FindOne(
 		context.Background(),
@@ -225,15 +241,142 @@ func UsePantryItemHandler(w http.ResponseWriter, r *http.Request) {
 			user.GroupID,
 			itemID,
 			pantryItem.Name,
-			userID,
+			user.ID,
 			user.Name,
-			request.Quantity,
+			usedQuantity,
 		)
 	}
+	return response, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+// stockAlert is a low or out of stock alert raised while using an item. It's
+// only sent once the transaction that used the item commits.
+type stockAlert struct {
+	event        events.Type
+	item         models.PantryItem
+	notification *models.PantryNotification // Nil if it couldn't be stored
+}
+
+// sendStockAlerts publishes the alerts of a committed transaction and adds
+// them to the group's inboxes
+func sendStockAlerts(alerts []stockAlert) {
+	for _, alert := range alerts {
+		if alert.notification != nil {
+			notifications.Notify(models.NotificationFromPantry(alert.notification))
+		}
+		events.Publish(alert.item.GroupID, alert.event, primitive.NilObjectID, alert.item)
+	}
+}
+
+// usePantryStock takes a quantity, in the item's unit or any compatible one, from
+// the item's first-expiring batches within a transaction and stores the matching
+// stock notifications. It returns the quantity used in the item's own unit, the
+// batches it came from and the alerts to send with sendStockAlerts once the
+// transaction commits.
+func usePantryStock(sc mongo.SessionContext, pantryItem *models.PantryItem, quantity float64, unit string) (float64, []models.BatchConsumption, []stockAlert, error) {
+	// Convert the requested quantity into the item's unit
+	usedQuantity := quantity
+	if unit != "" && !strings.EqualFold(unit, pantryItem.Unit) {
+		converted, err := units.Convert(quantity, unit, pantryItem.Unit)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		usedQuantity = converted
+	}
+
+	// Take the quantity from the first-expiring batches
+	consumed, err := pantryItem.ConsumeFIFO(usedQuantity)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	_, err = config.DB.Collection("pantry_items").UpdateOne(
+		sc,
+		bson.M{"_id": pantryItem.ID},
+		pantryBatchUpdate(*pantryItem),
+	)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Expiration notifications for emptied batches no longer apply
+	emptiedBatchIDs := make([]primitive.ObjectID, 0)
+	for _, consumption := range consumed {
+		if consumption.Emptied {
+			emptiedBatchIDs = append(emptiedBatchIDs, consumption.BatchID)
+		}
+	}
+	if len(emptiedBatchIDs) > 0 {
+		_, err = config.DB.Collection("pantry_notifications").DeleteMany(
+			sc,
+			bson.M{
+				"item_id":  pantryItem.ID,
+				"batch_id": bson.M{"$in": emptiedBatchIDs},
+			},
+		)
+		if err != nil {
+			log.Printf("Failed to delete notifications for emptied batches: %v", err)
+			// Continue anyway as this is not critical
+		}
+	}
+
+	var alerts []stockAlert
+
+	// Check if low-stock notification is needed (at or below the item's min threshold)
+	if pantryItem.IsLowStock() {
+		notification := models.CreatePantryNotification(
+			pantryItem.GroupID,
+			pantryItem.ID,
+			pantryItem.Name,
+			models.NotificationTypeLowStock,
+			"Item is running low",
+		)
+		alert := stockAlert{event: events.PantryLowStock, item: *pantryItem}
+		_, err = config.DB.Collection("pantry_notifications").InsertOne(sc, notification)
+		if err != nil {
+			log.Printf("Failed to create low-stock notification: %v", err)
+			// Continue anyway, as this is not critical
+		} else {
+			alert.notification = notification
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if pantryItem.IsOutOfStock() {
+		// Remove any existing low_stock notifications
+		_, err = config.DB.Collection("pantry_notifications").DeleteMany(
+			sc,
+			bson.M{
+				"item_id": pantryItem.ID,
+				"type":    models.NotificationTypeLowStock,
+			},
+		)
+		if err != nil {
+			log.Printf("Failed to delete low_stock notifications: %v", err)
+			// Continue anyway as this is not critical
+		}
+
+		// Create out_of_stock notification
+		notification := models.CreatePantryNotification(
+			pantryItem.GroupID,
+			pantryItem.ID,
+			pantryItem.Name,
+			models.NotificationTypeOutOfStock,
+			"Item is out of stock",
+		)
+
+		alert := stockAlert{event: events.PantryOutOfStock, item: *pantryItem}
+		_, err = config.DB.Collection("pantry_notifications").InsertOne(sc, notification)
+		if err != nil {
+			log.Printf("Failed to create out_of_stock notification: %v", err)
+			// Continue anyway as this is not critical
+		} else {
+			alert.notification = notification
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return usedQuantity, consumed, alerts, nil
 }
 
 func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
@@ -316,17 +459,101 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Validate the storage location if provided
+	location, err := models.ParseStorageLocation(request.StorageLocation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pantryItem, _, err := addToPantry(pantryAddition{
+		Group:           group,
+		User:            user,
+		Name:            request.Name,
+		Quantity:        request.Quantity,
+		Unit:            request.Unit,
+		Category:        request.Category,
+		ExpirationDate:  expirationDate,
+		MinThreshold:    request.MinThreshold,
+		ParLevel:        request.ParLevel,
+		StorageLocation: location,
+	})
+	if err != nil {
+		var requestErr pantryRequestError
+		if errors.As(err, &requestErr) {
+			http.Error(w, requestErr.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Transaction failed: %v", err)
+		http.Error(w, "Failed to add/update pantry item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pantryItem)
+}
+
+// pantryAddition describes stock being added to a group's pantry
+type pantryAddition struct {
+	Group          models.Group
+	User           models.User
+	Name           string
+	Quantity       float64
+	Unit           string
+	Category       string
+	Barcode        string
+	ExpirationDate time.Time // Estimated from shelf-life rules when zero
+	MinThreshold   *float64
+	ParLevel       *float64
+
+	StorageLocation models.StorageLocation // Defaults to the category's usual location
+	ShelfLifeDays   int                    // Catalog shelf life of the product, if known
+	Price           float64                // Paid for this purchase, if known
+}
+
+// addToPantry adds stock to the group's pantry as a new batch, creating the item
+// if needed, and records the change in the pantry history. It returns the item
+// and the batch that was added.
+// Errors caused by the request itself are returned as pantryRequestError.
+func addToPantry(addition pantryAddition) (models.PantryItem, models.PantryBatch, error) {
+	var pantryItem models.PantryItem
+	var addedBatch models.PantryBatch
+	group := addition.Group
+	userID := addition.User.ID
+
+	// Validate the unit and store it in canonical form
+	unit, err := normalizeUnitOrError(addition.Unit)
+	if err != nil {
+		return pantryItem, addedBatch, pantryRequestError{err.Error()}
+	}
+
+	// Validate stock levels if provided
+	var minThreshold, parLevel float64
+	if addition.MinThreshold != nil {
+		minThreshold = *addition.MinThreshold
+	}
+	if addition.ParLevel != nil {
+		parLevel = *addition.ParLevel
+	}
+	if err := models.ValidateStockLevels(minThreshold, parLevel); err != nil {
+		return pantryItem, addedBatch, pantryRequestError{err.Error()}
+	}
+
+	// Group shelf-life rules fill in missing expiration dates
+	rules, err := loadShelfLifeRules(group.ID)
+	if err != nil {
+		return pantryItem, addedBatch, err
+	}
+
 	// Start a transaction
 	session, err := config.DB.Client().StartSession()
 	if err != nil {
 		log.Printf("Failed to start MongoDB session: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return pantryItem, addedBatch, err
 	}
 	defer session.EndSession(context.Background())
 
-	// Start transaction
-	var pantryItem models.PantryItem
 	var isNewItem bool = true
 	var oldQuantity float64 = 0
 
@@ -336,7 +563,7 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 			sc,
 			bson.M{
 				"group_id": group.ID,
-				"name":     bson.M{"$regex": primitive.Regex{Pattern: "^" + strings.TrimSpace(request.Name) + "$", Options: "i"}},
+				"name":     bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(addition.Name)) + "$", Options: "i"}},
 			},
 		)
 
@@ -349,41 +576,99 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 			isNewItem = false
 			oldQuantity = pantryItem.Quantity
 
-			// Update the item
-			pantryItem.Quantity = request.Quantity
-			pantryItem.Unit = request.Unit
-			if request.Category != "" {
-				pantryItem.Category = request.Category
+			// Keep the item's unit when the request uses a compatible one,
+			// adopting the new unit only for legacy items with unknown units
+			quantity := addition.Quantity
+			if _, known := units.Lookup(pantryItem.Unit); known {
+				converted, err := units.Convert(addition.Quantity, unit, pantryItem.Unit)
+				if err != nil {
+					return pantryRequestError{fmt.Sprintf("Unit %s is not compatible with the existing item's unit %s", unit, pantryItem.Unit)}
+				}
+				quantity = converted
+			} else {
+				pantryItem.Unit = unit
+			}
+
+			if addition.Category != "" {
+				pantryItem.Category = addition.Category
+			}
+			if addition.StorageLocation != "" && addition.StorageLocation != pantryItem.Location() {
+				pantryItem.MoveTo(addition.StorageLocation, rules, addition.ShelfLifeDays, time.Now())
+			}
+
+			// Record the purchase as a new batch so each keeps its own expiration date
+			if quantity > 0 {
+				expirationDate, estimated := addition.ExpirationDate, false
+				if expirationDate.IsZero() {
+					expirationDate, estimated = shelfLifeExpiration(rules, pantryItem.Name, pantryItem.Category, pantryItem.Location(), addition.ShelfLifeDays)
+				}
+				addedBatch = models.CreatePantryBatch(quantity, expirationDate, userID)
+				addedBatch.ExpirationEstimated = estimated
+				addedBatch.Price = addition.Price
+				pantryItem.AddBatch(addedBatch)
+			}
+			if addition.Barcode != "" && pantryItem.Barcode == "" {
+				pantryItem.Barcode = addition.Barcode
 			}
-			if !expirationDate.IsZero() {
-				pantryItem.ExpirationDate = expirationDate
+			if addition.MinThreshold != nil {
+				pantryItem.MinThreshold = minThreshold
+			}
+			if addition.ParLevel != nil {
+				pantryItem.ParLevel = parLevel
+			}
+			if err := models.ValidateStockLevels(pantryItem.MinThreshold, pantryItem.ParLevel); err != nil {
+				return pantryRequestError{err.Error()}
 			}
 			pantryItem.UpdatedAt = time.Now()
 
+			update := bson.M{"$set": pantryItem}
+			if pantryItem.ExpirationDate.IsZero() {
+				update["$unset"] = bson.M{"expiration_date": ""}
+			}
 			_, err = config.DB.Collection("pantry_items").UpdateOne(
 				sc,
 				bson.M{"_id": pantryItem.ID},
-				bson.M{"$set": pantryItem},
+				update,
 			)
 			if err != nil {
 				return err
 			}
 		} else if errors.Is(existingItem.Err(), mongo.ErrNoDocuments) {
 			// Item doesn't exist, create new one
-			category := request.Category
+			category := addition.Category
 			if category == "" {
 				category = "Uncategorized"
 			}
 
+			location := addition.StorageLocation
+			if location == "" {
+				location = models.DefaultStorageLocation(category)
+			}
+			expirationDate, estimated := addition.ExpirationDate, false
+			if expirationDate.IsZero() {
+				expirationDate, estimated = shelfLifeExpiration(rules, addition.Name, category, location, addition.ShelfLifeDays)
+			}
+
 			pantryItem = *models.CreatePantryItem(
 				group.ID,
-				request.Name,
-				request.Quantity,
-				request.Unit,
+				addition.Name,
+				addition.Quantity,
+				unit,
 				category,
 				expirationDate,
 				userID,
 			)
+			pantryItem.Barcode = addition.Barcode
+			pantryItem.StorageLocation = location
+			if len(pantryItem.Batches) > 0 {
+				pantryItem.Batches[0].ExpirationEstimated = estimated
+				pantryItem.Batches[0].Price = addition.Price
+			}
+			pantryItem.MinThreshold = minThreshold
+			pantryItem.ParLevel = parLevel
+			if len(pantryItem.Batches) > 0 {
+				addedBatch = pantryItem.Batches[0]
+			}
 
 			result, err := config.DB.Collection("pantry_items").InsertOne(sc, pantryItem)
 			if err != nil {
@@ -395,8 +680,8 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 			return existingItem.Err()
 		}
 
-		// Check if we need to create expiration notification
-		if !expirationDate.IsZero() && pantryItem.IsExpiringSoon(3) {
+		// Check if we need to create expiration notification for the new batch
+		if !addedBatch.ID.IsZero() && addedBatch.IsExpiringSoon(3) {
 			notification := models.CreatePantryNotification(
 				group.ID,
 				pantryItem.ID,
@@ -404,10 +689,13 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 				models.NotificationTypeExpiringSoon,
 				"Item will expire in 3 days or less",
 			)
+			notification.BatchID = addedBatch.ID
 			_, err = config.DB.Collection("pantry_notifications").InsertOne(sc, notification)
 			if err != nil {
 				log.Printf("Failed to create expiration notification: %v", err)
 				// Continue anyway, as this is not critical
+			} else if err := notifications.Send(sc, models.NotificationFromPantry(notification)); err != nil {
+				log.Printf("Failed to add %s notification to inbox: %v", notification.Type, err)
 			}
 		}
 
@@ -415,9 +703,7 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		log.Printf("Transaction failed: %v", err)
-		http.Error(w, "Failed to add/update pantry item", http.StatusInternalServerError)
-		return
+		return pantryItem, addedBatch, err
 	}
 
 	// Create history record for adding a new item or updating an existing one
@@ -427,26 +713,22 @@ func AddPantryItemHandler(w http.ResponseWriter, r *http.Request) {
 			pantryItem.ID,
 			pantryItem.Name,
 			userID,
-			user.Name,
-			request.Quantity,
+			addition.User.Name,
+			addition.Quantity,
 		)
-	} else {
+	} else if oldQuantity != pantryItem.Quantity {
 		// If quantity changed, record it as an update
-		if oldQuantity != request.Quantity {
-			UpdatePantryHistoryForAdd(
-				group.ID,
-				pantryItem.ID,
-				pantryItem.Name,
-				userID,
-				user.Name,
-				request.Quantity-oldQuantity,
-			)
-		}
+		UpdatePantryHistoryForAdd(
+			group.ID,
+			pantryItem.ID,
+			pantryItem.Name,
+			userID,
+			addition.User.Name,
+			units.Round(pantryItem.Quantity-oldQuantity),
+		)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(pantryItem)
+	return pantryItem, addedBatch, nil
 }
 
 // GetPantryItemsHandler retrieves all pantry items for a group
@@ -557,6 +839,7 @@ func GetPantryItemsHandler(w http.ResponseWriter, r *http.Request) {
 
 	response := make([]PantryItemResponse, 0, len(pantryItems))
 	for _, item := range pantryItems {
+		item.EnsureBatches()
 		extendedItem := PantryItemResponse{
 			PantryItem:     item,
 			IsExpiringSoon: item.IsExpiringSoon(3),
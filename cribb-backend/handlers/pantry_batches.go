@@ -0,0 +1,117 @@
+// handlers/pantry_batches.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/units"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DeletePantryBatchHandler discards a single batch of a pantry item, e.g. an expired carton
+func DeletePantryBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get item and batch IDs from URL path: /api/pantry/batches/remove/{item_id}/{batch_id}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/pantry/batches/remove/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "Item ID and batch ID are required", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid item ID format", http.StatusBadRequest)
+		return
+	}
+	batchID, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		http.Error(w, "Invalid batch ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var pantryItem models.PantryItem
+	err = config.DB.Collection("pantry_items").FindOne(
+		context.Background(),
+		bson.M{"_id": itemID},
+	).Decode(&pantryItem)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Pantry item not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch pantry item", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Verify the item belongs to the user's group
+	if pantryItem.GroupID != user.GroupID {
+		http.Error(w, "Pantry item does not belong to user's group", http.StatusForbidden)
+		return
+	}
+
+	batch, found := pantryItem.RemoveBatch(batchID)
+	if !found {
+		http.Error(w, "Batch not found", http.StatusNotFound)
+		return
+	}
+
+	// Only update if the item hasn't changed since it was read
+	result, err := config.DB.Collection("pantry_items").UpdateOne(
+		context.Background(),
+		bson.M{"_id": pantryItem.ID, "quantity": units.Round(pantryItem.Quantity + batch.Quantity)},
+		pantryBatchUpdate(pantryItem),
+	)
+	if err != nil {
+		log.Printf("Failed to remove pantry batch: %v", err)
+		http.Error(w, "Failed to remove batch", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Pantry item was modified, please retry", http.StatusConflict)
+		return
+	}
+
+	// Notifications about the discarded batch no longer apply
+	_, err = config.DB.Collection("pantry_notifications").DeleteMany(
+		context.Background(),
+		bson.M{"item_id": pantryItem.ID, "batch_id": batch.ID},
+	)
+	if err != nil {
+		log.Printf("Failed to delete batch notifications: %v", err)
+	}
+
+	UpdatePantryHistoryForRemove(
+		pantryItem.GroupID,
+		pantryItem.ID,
+		pantryItem.Name,
+		user.ID,
+		user.Name,
+		batch.Quantity,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Batch removed successfully",
+		"batch":   batch,
+		"item":    pantryItem,
+	})
+}
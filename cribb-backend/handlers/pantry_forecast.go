@@ -0,0 +1,47 @@
+// handlers/pantry_forecast.go
+package handlers
+
+import (
+	"cribb-backend/jobs"
+	"cribb-backend/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// GetPantryForecastHandler returns predicted consumption and run-out dates for a group's pantry
+func GetPantryForecastHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	forecasts, err := jobs.ForecastGroupPantry(group.ID)
+	if err != nil {
+		log.Printf("Failed to forecast pantry for group %s: %v", group.ID.Hex(), err)
+		http.Error(w, "Failed to generate pantry forecast", http.StatusInternalServerError)
+		return
+	}
+
+	// Soonest run-outs first, items without enough history last
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		if forecasts[i].HasEnoughData != forecasts[j].HasEnoughData {
+			return forecasts[i].HasEnoughData
+		}
+		return forecasts[i].PredictedRunOut.Before(forecasts[j].PredictedRunOut)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"forecasts":           forecasts,
+		"warning_days":        jobs.RunOutWarningDays,
+		"shopping_horizon":    jobs.ShoppingHorizonDays,
+		"history_window_days": models.ForecastWindowDays,
+	})
+}
@@ -4,6 +4,7 @@ package handlers
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/jobs"
 	"cribb-backend/middleware"
 	"cribb-backend/models"
@@ -96,7 +97,7 @@ func GetPantryWarningsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find all low-stock and out-of-stock notifications for this group
+	// Find all low-stock, out-of-stock and running-out notifications for this group
 	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(50)
 	cursor, err := config.DB.Collection("pantry_notifications").Find(
 		context.Background(),
@@ -106,6 +107,7 @@ func GetPantryWarningsHandler(w http.ResponseWriter, r *http.Request) {
 				"$in": []models.NotificationType{
 					models.NotificationTypeLowStock,
 					models.NotificationTypeOutOfStock,
+					models.NotificationTypeRunningOutSoon,
 				},
 			},
 		},
@@ -294,6 +296,14 @@ func GetPantryExpiringHandler(w http.ResponseWriter, r *http.Request) {
 			expiringResponse.Quantity = item.Quantity
 			expiringResponse.Unit = item.Unit
 			expiringResponse.IsExpired = item.IsExpired()
+
+			// Report the batch the notification is about when it still exists
+			item.EnsureBatches()
+			if batch, found := item.FindBatch(notification.BatchID); found {
+				expiringResponse.ExpirationDate = batch.ExpirationDate
+				expiringResponse.Quantity = batch.Quantity
+				expiringResponse.IsExpired = batch.IsExpired()
+			}
 		}
 
 		response = append(response, expiringResponse)
@@ -492,6 +502,135 @@ func GetPantryShoppingListHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// UpdatePantryStockLevelsHandler sets the min threshold and par level of a pantry item
+func UpdatePantryStockLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get user from context (set by AuthMiddleware)
+	userClaims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		ItemID       string  `json:"item_id"`
+		MinThreshold float64 `json:"min_threshold"`
+		ParLevel     float64 `json:"par_level"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if request.ItemID == "" {
+		http.Error(w, "Item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.ValidateStockLevels(request.MinThreshold, request.ParLevel); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Convert item ID to ObjectID
+	itemID, err := primitive.ObjectIDFromHex(request.ItemID)
+	if err != nil {
+		http.Error(w, "Invalid item ID format", http.StatusBadRequest)
+		return
+	}
+
+	// Get user ID
+	userID, err := primitive.ObjectIDFromHex(userClaims.ID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Find user to get their group
+	var user models.User
+	err = config.DB.Collection("users").FindOne(
+		context.Background(),
+		bson.M{"_id": userID},
+	).Decode(&user)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "User not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Find the item and verify it belongs to the user's group
+	var item models.PantryItem
+	err = config.DB.Collection("pantry_items").FindOne(
+		context.Background(),
+		bson.M{"_id": itemID},
+	).Decode(&item)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Pantry item not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch pantry item", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if item.GroupID != user.GroupID {
+		http.Error(w, "Pantry item does not belong to user's group", http.StatusForbidden)
+		return
+	}
+
+	// A zero value clears the field so the defaults apply again
+	update := bson.M{
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	unset := bson.M{}
+	if request.MinThreshold > 0 {
+		update["$set"].(bson.M)["min_threshold"] = request.MinThreshold
+	} else {
+		unset["min_threshold"] = ""
+	}
+	if request.ParLevel > 0 {
+		update["$set"].(bson.M)["par_level"] = request.ParLevel
+	} else {
+		unset["par_level"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err = config.DB.Collection("pantry_items").UpdateOne(
+		context.Background(),
+		bson.M{"_id": itemID},
+		update,
+	)
+	if err != nil {
+		http.Error(w, "Failed to update stock levels", http.StatusInternalServerError)
+		return
+	}
+
+	item.MinThreshold = request.MinThreshold
+	item.ParLevel = request.ParLevel
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"item_id":            item.ID.Hex(),
+		"min_threshold":      item.LowStockThreshold(),
+		"par_level":          item.TargetLevel(),
+		"is_low_stock":       item.IsLowStock(),
+		"suggested_quantity": item.SuggestedPurchaseQuantity(),
+	})
+}
+
 // GetPantryHistoryHandler retrieves the history of pantry actions
 func GetPantryHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -710,7 +849,8 @@ func DeleteNotificationHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdatePantryHistoryForAdd creates a history record for adding an item
+// UpdatePantryHistoryForAdd creates a history record for adding an item.
+// Like the other history helpers, it also publishes the change to the group's event stream.
 func UpdatePantryHistoryForAdd(groupID, itemID primitive.ObjectID, itemName string, userID primitive.ObjectID, userName string, quantity float64) {
 	history := models.CreatePantryHistory(
 		groupID,
@@ -723,6 +863,8 @@ func UpdatePantryHistoryForAdd(groupID, itemID primitive.ObjectID, itemName stri
 		"Item added to pantry",
 	)
 
+	events.Publish(groupID, events.PantryItemAdded, userID, history)
+
 	_, err := config.DB.Collection("pantry_history").InsertOne(
 		context.Background(),
 		history,
@@ -746,6 +888,33 @@ func UpdatePantryHistoryForUse(groupID, itemID primitive.ObjectID, itemName stri
 		"Item used from pantry",
 	)
 
+	events.Publish(groupID, events.PantryItemUsed, userID, history)
+
+	_, err := config.DB.Collection("pantry_history").InsertOne(
+		context.Background(),
+		history,
+	)
+
+	if err != nil {
+		log.Printf("Failed to create pantry history record: %v", err)
+	}
+}
+
+// UpdatePantryHistoryForCook creates a history record for an ingredient used by a recipe
+func UpdatePantryHistoryForCook(groupID, itemID primitive.ObjectID, itemName string, userID primitive.ObjectID, userName string, quantity float64, recipeName string) {
+	history := models.CreatePantryHistory(
+		groupID,
+		itemID,
+		itemName,
+		userID,
+		userName,
+		models.ActionTypeUse,
+		quantity,
+		"Used cooking "+recipeName,
+	)
+
+	events.Publish(groupID, events.PantryItemUsed, userID, history)
+
 	_, err := config.DB.Collection("pantry_history").InsertOne(
 		context.Background(),
 		history,
@@ -769,6 +938,8 @@ func UpdatePantryHistoryForRemove(groupID, itemID primitive.ObjectID, itemName s
 		"Item removed from pantry",
 	)
 
+	events.Publish(groupID, events.PantryItemRemoved, userID, history)
+
 	_, err := config.DB.Collection("pantry_history").InsertOne(
 		context.Background(),
 		history,
@@ -0,0 +1,356 @@
+// handlers/products.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/catalog"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveCustomProductRequest defines the request structure for a group's own product entry
+type SaveCustomProductRequest struct {
+	Barcode         string  `json:"barcode"`
+	Name            string  `json:"name"`
+	Brand           string  `json:"brand"`
+	Category        string  `json:"category"`
+	DefaultUnit     string  `json:"default_unit"`
+	DefaultQuantity float64 `json:"default_quantity"`
+	ShelfLifeDays   int     `json:"shelf_life_days"`
+}
+
+// AddPantryItemByBarcodeRequest defines the request structure for adding a pantry item by barcode
+type AddPantryItemByBarcodeRequest struct {
+	Barcode         string   `json:"barcode"`
+	Quantity        *float64 `json:"quantity,omitempty"`        // Defaults to one package
+	Unit            string   `json:"unit,omitempty"`            // Defaults to the product's unit
+	ExpirationDate  *string  `json:"expiration_date,omitempty"` // Estimated from shelf-life rules when omitted
+	StorageLocation string   `json:"storage_location,omitempty"`
+	GroupName       string   `json:"group_name"`
+}
+
+// findProduct looks up a barcode, preferring the group's own entry over the shared catalog
+func findProduct(groupID primitive.ObjectID, barcode string) (models.Product, error) {
+	var product models.Product
+	err := config.DB.Collection("products").FindOne(
+		context.Background(),
+		bson.M{
+			"barcode": barcode,
+			"$or": []bson.M{
+				{"group_id": groupID},
+				{"group_id": nil},
+			},
+		},
+		// Sorting descending puts the group's entry ahead of the shared one without a group_id
+		options.FindOne().SetSort(bson.D{{Key: "group_id", Value: -1}}),
+	).Decode(&product)
+	return product, err
+}
+
+// LookupProductHandler finds a product by barcode
+func LookupProductHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	barcode, err := catalog.NormalizeBarcode(r.URL.Query().Get("barcode"))
+	if err != nil {
+		http.Error(w, "Invalid barcode: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := findProduct(group.ID, barcode)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Product not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch product", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetCustomProductsHandler lists the group's own product entries
+func GetCustomProductsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := config.DB.Collection("products").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		opts,
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	products := make([]models.Product, 0)
+	if err = cursor.All(context.Background(), &products); err != nil {
+		http.Error(w, "Failed to decode products", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// SaveCustomProductHandler creates or updates a product entry for the user's group
+func SaveCustomProductHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveCustomProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if request.Barcode == "" || strings.TrimSpace(request.Name) == "" || request.DefaultUnit == "" {
+		http.Error(w, "Barcode, name and default unit are required", http.StatusBadRequest)
+		return
+	}
+	if request.DefaultQuantity < 0 || request.ShelfLifeDays < 0 {
+		http.Error(w, "Default quantity and shelf life cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	barcode, err := catalog.NormalizeBarcode(request.Barcode)
+	if err != nil {
+		http.Error(w, "Invalid barcode: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	unit, err := normalizeUnitOrError(request.DefaultUnit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	if request.DefaultQuantity == 0 {
+		request.DefaultQuantity = 1
+	}
+	if request.Category == "" {
+		request.Category = catalog.CategoryUncategorized
+	}
+
+	product := models.CreateCustomProduct(
+		group.ID,
+		user.ID,
+		barcode,
+		strings.TrimSpace(request.Name),
+		request.Brand,
+		request.Category,
+		unit,
+		request.DefaultQuantity,
+		request.ShelfLifeDays,
+	)
+
+	err = config.DB.Collection("products").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"barcode": barcode, "group_id": group.ID},
+		bson.M{
+			"$set": bson.M{
+				"name":             product.Name,
+				"brand":            product.Brand,
+				"category":         product.Category,
+				"default_unit":     product.DefaultUnit,
+				"default_quantity": product.DefaultQuantity,
+				"shelf_life_days":  product.ShelfLifeDays,
+				"source":           product.Source,
+				"updated_at":       product.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{
+				"created_by": product.CreatedBy,
+				"created_at": product.CreatedAt,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(product)
+	if err != nil {
+		log.Printf("Custom product save error: %v", err)
+		http.Error(w, "Failed to save product", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// DeleteCustomProductHandler removes one of the group's own product entries
+func DeleteCustomProductHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get product ID from URL path
+	productIDStr := strings.TrimPrefix(r.URL.Path, "/api/products/custom/remove/")
+	productID, err := primitive.ObjectIDFromHex(productIDStr)
+	if err != nil {
+		http.Error(w, "Invalid product ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("products").DeleteOne(
+		context.Background(),
+		bson.M{"_id": productID, "group_id": user.GroupID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete product", http.StatusInternalServerError)
+		return
+	}
+	if result.DeletedCount == 0 {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Product deleted successfully",
+	})
+}
+
+// AddPantryItemByBarcodeHandler adds a pantry item using the catalog entry for a barcode
+func AddPantryItemByBarcodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request AddPantryItemByBarcodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	barcode, err := catalog.NormalizeBarcode(request.Barcode)
+	if err != nil {
+		http.Error(w, "Invalid barcode: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Quantity != nil && *request.Quantity <= 0 {
+		http.Error(w, "Quantity must be positive", http.StatusBadRequest)
+		return
+	}
+
+	// The group can be named in the body like the regular add endpoint
+	if request.GroupName != "" {
+		query := r.URL.Query()
+		query.Set("group_name", request.GroupName)
+		r.URL.RawQuery = query.Encode()
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := findProduct(group.ID, barcode)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Product not found. Add it as a custom product first", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch product", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Fill in the purchase from the product's defaults
+	quantity := product.DefaultQuantity
+	if request.Quantity != nil {
+		quantity = *request.Quantity
+	}
+	unit := product.DefaultUnit
+	if request.Unit != "" {
+		unit = request.Unit
+	}
+
+	// Without a date the shelf-life rules estimate one, using the product's typical shelf life
+	var expirationDate time.Time
+	if request.ExpirationDate != nil && *request.ExpirationDate != "" {
+		expirationDate, err = time.Parse(time.RFC3339, *request.ExpirationDate)
+		if err != nil {
+			http.Error(w, "Invalid expiration date format. Use ISO 8601/RFC3339 format (YYYY-MM-DDTHH:MM:SSZ)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	location, err := models.ParseStorageLocation(request.StorageLocation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pantryItem, addedBatch, err := addToPantry(pantryAddition{
+		Group:           group,
+		User:            user,
+		Name:            product.Name,
+		Quantity:        quantity,
+		Unit:            unit,
+		Category:        product.Category,
+		Barcode:         product.Barcode,
+		ExpirationDate:  expirationDate,
+		StorageLocation: location,
+		ShelfLifeDays:   product.ShelfLifeDays,
+	})
+	if err != nil {
+		var requestErr pantryRequestError
+		if errors.As(err, &requestErr) {
+			http.Error(w, requestErr.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Transaction failed: %v", err)
+		http.Error(w, "Failed to add/update pantry item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"item":                 pantryItem,
+		"product":              product,
+		"expiration_estimated": addedBatch.ExpirationEstimated,
+	})
+}
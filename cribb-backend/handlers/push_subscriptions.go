@@ -0,0 +1,123 @@
+// handlers/push_subscriptions.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/delivery"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PushSubscriptionRequest is the PushSubscription a browser hands out, as JSON
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// GetPushPublicKeyHandler returns the VAPID key browsers subscribe with
+func GetPushPublicKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	publicKey := delivery.VAPIDPublicKey()
+	if publicKey == "" {
+		http.Error(w, "Push notifications are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"public_key": publicKey})
+}
+
+// SubscribePushHandler registers the user's browser for push notifications.
+// Subscribing the same endpoint again moves it to the current user.
+func SubscribePushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request PushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	subscription := models.CreatePushSubscription(user.ID, request.Endpoint, request.Keys.P256dh, request.Keys.Auth, r.UserAgent())
+	if err := subscription.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err := config.DB.Collection(notifications.PushSubscriptionsCollection).ReplaceOne(
+		context.Background(),
+		bson.M{"endpoint": subscription.Endpoint},
+		subscription,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Failed to save push subscription: %v", err)
+		http.Error(w, "Failed to save push subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// UnsubscribePushHandler removes one of the user's push subscriptions
+func UnsubscribePushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request PushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Endpoint == "" {
+		http.Error(w, "Endpoint is required", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection(notifications.PushSubscriptionsCollection).DeleteOne(
+		context.Background(),
+		bson.M{"endpoint": request.Endpoint, "user_id": user.ID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to remove push subscription", http.StatusInternalServerError)
+		return
+	}
+	if result.DeletedCount == 0 {
+		http.Error(w, "Push subscription not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Push subscription removed"})
+}
@@ -0,0 +1,464 @@
+// handlers/recipes.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveRecipeRequest defines the request structure for creating or updating a recipe
+type SaveRecipeRequest struct {
+	RecipeID     string                    `json:"recipe_id,omitempty"` // Set to update an existing recipe
+	Name         string                    `json:"name"`
+	Description  string                    `json:"description"`
+	Servings     int                       `json:"servings"`
+	Ingredients  []models.RecipeIngredient `json:"ingredients"`
+	Instructions string                    `json:"instructions"`
+}
+
+// CookRecipeRequest defines the request structure for cooking a recipe
+type CookRecipeRequest struct {
+	RecipeID        string `json:"recipe_id"`
+	Servings        int    `json:"servings,omitempty"`           // Defaults to the recipe's or plan entry's servings
+	MealPlanEntryID string `json:"meal_plan_entry_id,omitempty"` // Marks the planned meal as cooked
+}
+
+// CookedIngredient reports what cooking took from one pantry item
+type CookedIngredient struct {
+	ItemID    primitive.ObjectID `json:"item_id"`
+	Name      string             `json:"name"`
+	Used      float64            `json:"used_quantity"`
+	Remaining float64            `json:"remaining_quantity"`
+	Unit      string             `json:"unit"`
+}
+
+// loadPantryItems fetches all pantry items of a group
+func loadPantryItems(ctx context.Context, groupID primitive.ObjectID) ([]models.PantryItem, error) {
+	cursor, err := config.DB.Collection("pantry_items").Find(ctx, bson.M{"group_id": groupID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := make([]models.PantryItem, 0)
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// loadRecipes fetches all recipes of a group, sorted by name
+func loadRecipes(groupID primitive.ObjectID) ([]models.Recipe, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := config.DB.Collection("recipes").Find(
+		context.Background(),
+		bson.M{"group_id": groupID},
+		opts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	recipes := make([]models.Recipe, 0)
+	if err = cursor.All(context.Background(), &recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// findGroupRecipe loads a recipe by ID, writing an error response if it isn't in the group
+func findGroupRecipe(w http.ResponseWriter, groupID primitive.ObjectID, recipeIDStr string) (models.Recipe, bool) {
+	var recipe models.Recipe
+
+	recipeID, err := primitive.ObjectIDFromHex(recipeIDStr)
+	if err != nil {
+		http.Error(w, "Invalid recipe ID format", http.StatusBadRequest)
+		return recipe, false
+	}
+
+	err = config.DB.Collection("recipes").FindOne(
+		context.Background(),
+		bson.M{"_id": recipeID, "group_id": groupID},
+	).Decode(&recipe)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Recipe not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch recipe", http.StatusInternalServerError)
+		}
+		return recipe, false
+	}
+	return recipe, true
+}
+
+// SaveRecipeHandler creates a recipe, or updates one when recipe_id is given
+func SaveRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	if request.Servings == 0 {
+		request.Servings = 1
+	}
+
+	recipe := models.CreateRecipe(
+		group.ID,
+		request.Name,
+		request.Description,
+		request.Servings,
+		request.Ingredients,
+		request.Instructions,
+		user.ID,
+	)
+	if err := recipe.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.RecipeID == "" {
+		result, err := config.DB.Collection("recipes").InsertOne(context.Background(), recipe)
+		if err != nil {
+			log.Printf("Recipe creation error: %v", err)
+			http.Error(w, "Failed to create recipe", http.StatusInternalServerError)
+			return
+		}
+		recipe.ID = result.InsertedID.(primitive.ObjectID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(recipe)
+		return
+	}
+
+	existing, ok := findGroupRecipe(w, group.ID, request.RecipeID)
+	if !ok {
+		return
+	}
+
+	recipe.ID = existing.ID
+	recipe.CreatedBy = existing.CreatedBy
+	recipe.CreatedAt = existing.CreatedAt
+
+	_, err := config.DB.Collection("recipes").ReplaceOne(
+		context.Background(),
+		bson.M{"_id": existing.ID},
+		recipe,
+	)
+	if err != nil {
+		log.Printf("Recipe update error: %v", err)
+		http.Error(w, "Failed to update recipe", http.StatusInternalServerError)
+		return
+	}
+
+	// Keep planned meals showing the current name
+	if existing.Name != recipe.Name {
+		_, err = config.DB.Collection("meal_plan").UpdateMany(
+			context.Background(),
+			bson.M{"recipe_id": recipe.ID},
+			bson.M{"$set": bson.M{"recipe_name": recipe.Name}},
+		)
+		if err != nil {
+			log.Printf("Failed to rename planned meals: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipe)
+}
+
+// GetRecipesHandler lists the group's recipes
+func GetRecipesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	recipes, err := loadRecipes(group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch recipes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipes)
+}
+
+// DeleteRecipeHandler deletes a recipe and its uncooked meal plan entries
+func DeleteRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	recipe, ok := findGroupRecipe(w, group.ID, strings.TrimPrefix(r.URL.Path, "/api/recipes/remove/"))
+	if !ok {
+		return
+	}
+
+	_, err := config.DB.Collection("recipes").DeleteOne(context.Background(), bson.M{"_id": recipe.ID})
+	if err != nil {
+		http.Error(w, "Failed to delete recipe", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = config.DB.Collection("meal_plan").DeleteMany(
+		context.Background(),
+		bson.M{"recipe_id": recipe.ID, "cooked_at": bson.M{"$exists": false}},
+	)
+	if err != nil {
+		log.Printf("Failed to delete planned meals for recipe: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Recipe deleted successfully",
+	})
+}
+
+// GetCookableRecipesHandler matches the group's recipes against the current pantry
+func GetCookableRecipesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	recipes, err := loadRecipes(group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch recipes", http.StatusInternalServerError)
+		return
+	}
+
+	items, err := loadPantryItems(context.Background(), group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch pantry items", http.StatusInternalServerError)
+		return
+	}
+
+	matches := models.MatchRecipes(recipes, items)
+
+	// Only recipes that can be cooked right now unless all are requested
+	if r.URL.Query().Get("all") != "true" {
+		cookable := make([]models.RecipeMatch, 0, len(matches))
+		for _, match := range matches {
+			if match.CanCook {
+				cookable = append(cookable, match)
+			}
+		}
+		matches = cookable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matches)
+}
+
+// CookRecipeHandler deducts a recipe's ingredients from the pantry
+func CookRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request CookRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.RecipeID == "" && request.MealPlanEntryID == "" {
+		http.Error(w, "Recipe ID or meal plan entry ID is required", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	// A planned meal names the recipe and servings
+	var entry *models.MealPlanEntry
+	if request.MealPlanEntryID != "" {
+		entryID, err := primitive.ObjectIDFromHex(request.MealPlanEntryID)
+		if err != nil {
+			http.Error(w, "Invalid meal plan entry ID format", http.StatusBadRequest)
+			return
+		}
+
+		entry = &models.MealPlanEntry{}
+		err = config.DB.Collection("meal_plan").FindOne(
+			context.Background(),
+			bson.M{"_id": entryID, "group_id": group.ID},
+		).Decode(entry)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				http.Error(w, "Meal plan entry not found", http.StatusNotFound)
+			} else {
+				http.Error(w, "Failed to fetch meal plan entry", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		request.RecipeID = entry.RecipeID.Hex()
+		if request.Servings <= 0 {
+			request.Servings = entry.Servings
+		}
+	}
+
+	recipe, ok := findGroupRecipe(w, group.ID, request.RecipeID)
+	if !ok {
+		return
+	}
+	servings := request.Servings
+	if servings <= 0 {
+		servings = recipe.Servings
+	}
+
+	// Start a transaction
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		log.Printf("Failed to start MongoDB session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer session.EndSession(context.Background())
+
+	var cooked []CookedIngredient
+	var alerts []stockAlert
+
+	// Deductions are all-or-nothing, so a failure partway leaves the pantry as it was
+	_, err = session.WithTransaction(context.Background(), func(sc mongo.SessionContext) (interface{}, error) {
+		cooked = make([]CookedIngredient, 0, len(recipe.Ingredients))
+		alerts = nil
+
+		// Marking the planned meal first means a meal is only ever cooked once,
+		// even when two requests to cook it race
+		if entry != nil {
+			result, err := config.DB.Collection("meal_plan").UpdateOne(
+				sc,
+				bson.M{"_id": entry.ID, "cooked_at": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"cooked_at": time.Now(), "cooked_by": user.ID}},
+			)
+			if err != nil {
+				return nil, err
+			}
+			if result.MatchedCount == 0 {
+				return nil, pantryRequestError{"This meal has already been cooked"}
+			}
+		}
+
+		items, err := loadPantryItems(sc, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		pantry := models.IndexPantryItems(items)
+
+		// Check everything first so nothing is deducted for a meal that can't be made
+		match := models.MatchRecipe(&recipe, servings, pantry)
+		if !match.CanCook {
+			return nil, pantryRequestError{"Missing ingredients: " + match.MissingSummary()}
+		}
+
+		for _, ingredient := range match.Ingredients {
+			// Optional ingredients the pantry lacks are skipped
+			if ingredient.ItemID.IsZero() || ingredient.Missing > 0 {
+				continue
+			}
+
+			key := models.IngredientKey(ingredient.Name)
+			item := pantry[key]
+			used, _, raised, err := usePantryStock(sc, &item, ingredient.Required, ingredient.Unit)
+			if err != nil {
+				if errors.Is(err, models.ErrInsufficientQuantity) {
+					return nil, pantryRequestError{fmt.Sprintf("Not enough %s for this recipe", item.Name)}
+				}
+				return nil, err
+			}
+			pantry[key] = item
+			alerts = append(alerts, raised...)
+
+			cooked = append(cooked, CookedIngredient{
+				ItemID:    item.ID,
+				Name:      item.Name,
+				Used:      used,
+				Remaining: item.Quantity,
+				Unit:      item.Unit,
+			})
+		}
+
+		return nil, nil
+	})
+
+	if err != nil {
+		var requestErr pantryRequestError
+		if errors.As(err, &requestErr) {
+			http.Error(w, requestErr.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Transaction failed: %v", err)
+		http.Error(w, "Failed to cook recipe", http.StatusInternalServerError)
+		return
+	}
+	sendStockAlerts(alerts)
+
+	// Create history records for the ingredients used
+	for _, ingredient := range cooked {
+		UpdatePantryHistoryForCook(
+			group.ID,
+			ingredient.ItemID,
+			ingredient.Name,
+			user.ID,
+			user.Name,
+			ingredient.Used,
+			recipe.Name,
+		)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Recipe cooked successfully",
+		"recipe_id":   recipe.ID,
+		"recipe_name": recipe.Name,
+		"servings":    servings,
+		"ingredients": cooked,
+	})
+}
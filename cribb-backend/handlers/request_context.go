@@ -0,0 +1,113 @@
+// handlers/request_context.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/middleware"
+	"cribb-backend/models"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// currentUser loads the authenticated user from the database.
+// It writes an error response and returns false if the user can't be loaded.
+func currentUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	var user models.User
+
+	// Get user from context (set by AuthMiddleware)
+	userClaims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return user, false
+	}
+
+	// Get user ID
+	userID, err := primitive.ObjectIDFromHex(userClaims.ID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return user, false
+	}
+
+	err = config.DB.Collection("users").FindOne(
+		context.Background(),
+		bson.M{"_id": userID},
+	).Decode(&user)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "User not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+		}
+		return user, false
+	}
+
+	return user, true
+}
+
+// currentUserGroup loads the authenticated user and the group given by the
+// group_name or group_code query parameter, verifying the user is a member.
+func currentUserGroup(w http.ResponseWriter, r *http.Request) (models.User, models.Group, bool) {
+	var group models.Group
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return user, group, false
+	}
+
+	// Find the group
+	groupName := r.URL.Query().Get("group_name")
+	groupCode := r.URL.Query().Get("group_code")
+
+	// Need either group name or group code
+	if groupName == "" && groupCode == "" {
+		http.Error(w, "Group name or group code is required", http.StatusBadRequest)
+		return user, group, false
+	}
+
+	groupFilter := bson.M{"group_code": groupCode}
+	if groupName != "" {
+		groupFilter = bson.M{"name": groupName}
+	}
+
+	err := config.DB.Collection("groups").FindOne(
+		context.Background(),
+		groupFilter,
+	).Decode(&group)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Group not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch group", http.StatusInternalServerError)
+		}
+		return user, group, false
+	}
+
+	// Verify user belongs to the group
+	if user.GroupID != group.ID {
+		http.Error(w, "User is not a member of this group", http.StatusForbidden)
+		return user, group, false
+	}
+
+	return user, group, true
+}
+
+// currentGroupAdmin loads the authenticated user and their group like
+// currentUserGroup, verifying the user is one of the group's admins
+func currentGroupAdmin(w http.ResponseWriter, r *http.Request) (models.User, models.Group, bool) {
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return user, group, false
+	}
+	if !group.IsAdmin(user.ID) {
+		http.Error(w, "Only group admins can do this", http.StatusForbidden)
+		return user, group, false
+	}
+	return user, group, true
+}
@@ -0,0 +1,307 @@
+// handlers/shelf_life.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveShelfLifeRuleRequest defines the request structure for a group shelf-life rule
+type SaveShelfLifeRuleRequest struct {
+	Category        string `json:"category,omitempty"`
+	ProductName     string `json:"product_name,omitempty"`
+	StorageLocation string `json:"storage_location,omitempty"` // Empty applies to every location
+	Days            int    `json:"days"`
+}
+
+// MovePantryItemRequest defines the request structure for moving an item to another storage location
+type MovePantryItemRequest struct {
+	ItemID          string `json:"item_id"`
+	StorageLocation string `json:"storage_location"`
+}
+
+// loadShelfLifeRules fetches a group's shelf-life rules
+func loadShelfLifeRules(groupID primitive.ObjectID) ([]models.ShelfLifeRule, error) {
+	cursor, err := config.DB.Collection("shelf_life_rules").Find(
+		context.Background(),
+		bson.M{"group_id": groupID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	rules := make([]models.ShelfLifeRule, 0)
+	if err = cursor.All(context.Background(), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// shelfLifeExpiration estimates the expiration date of stock added now without one
+func shelfLifeExpiration(rules []models.ShelfLifeRule, name, category string, location models.StorageLocation, productDays int) (time.Time, bool) {
+	estimate := models.ResolveShelfLife(rules, name, category, location, productDays)
+	if estimate.Days <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, estimate.Days), true
+}
+
+// GetShelfLifeRulesHandler lists the group's shelf-life rules
+func GetShelfLifeRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := loadShelfLifeRules(group.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch shelf-life rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// SaveShelfLifeRuleHandler creates or updates a shelf-life rule for the user's group
+func SaveShelfLifeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveShelfLifeRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	location, err := models.ParseStorageLocation(request.StorageLocation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	rule := models.CreateShelfLifeRule(group.ID, request.Category, request.ProductName, location, request.Days, user.ID)
+	if err := rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// One rule per product or category and location; saving again replaces the days
+	filter := bson.M{
+		"group_id":         group.ID,
+		"category":         matchOptional(rule.Category),
+		"product_name":     matchOptional(rule.ProductName),
+		"storage_location": matchOptional(string(rule.StorageLocation)),
+	}
+	err = config.DB.Collection("shelf_life_rules").FindOneAndUpdate(
+		context.Background(),
+		filter,
+		bson.M{
+			"$set": bson.M{
+				"days":       rule.Days,
+				"updated_at": rule.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{
+				"group_id":         rule.GroupID,
+				"category":         rule.Category,
+				"product_name":     rule.ProductName,
+				"storage_location": rule.StorageLocation,
+				"created_by":       rule.CreatedBy,
+				"created_at":       rule.CreatedAt,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(rule)
+	if err != nil {
+		log.Printf("Shelf-life rule save error: %v", err)
+		http.Error(w, "Failed to save shelf-life rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// matchOptional builds a filter value where an empty string also matches a missing field
+func matchOptional(value string) interface{} {
+	if value == "" {
+		return bson.M{"$in": []interface{}{nil, ""}}
+	}
+	return value
+}
+
+// DeleteShelfLifeRuleHandler removes one of the group's shelf-life rules
+func DeleteShelfLifeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get rule ID from URL path
+	ruleIDStr := strings.TrimPrefix(r.URL.Path, "/api/pantry/shelf-life/rules/remove/")
+	ruleID, err := primitive.ObjectIDFromHex(ruleIDStr)
+	if err != nil {
+		http.Error(w, "Invalid rule ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := config.DB.Collection("shelf_life_rules").DeleteOne(
+		context.Background(),
+		bson.M{"_id": ruleID, "group_id": user.GroupID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete shelf-life rule", http.StatusInternalServerError)
+		return
+	}
+	if result.DeletedCount == 0 {
+		http.Error(w, "Shelf-life rule not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Shelf-life rule deleted successfully",
+	})
+}
+
+// MovePantryItemHandler moves an item to another storage location, adjusting estimated expiration dates
+func MovePantryItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request MovePantryItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(request.ItemID)
+	if err != nil {
+		http.Error(w, "Invalid item ID format", http.StatusBadRequest)
+		return
+	}
+
+	location, err := models.ParseStorageLocation(request.StorageLocation)
+	if err != nil || location == "" {
+		http.Error(w, "Storage location must be pantry, fridge or freezer", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var pantryItem models.PantryItem
+	err = config.DB.Collection("pantry_items").FindOne(
+		context.Background(),
+		bson.M{"_id": itemID},
+	).Decode(&pantryItem)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Pantry item not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch pantry item", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Verify the item belongs to the user's group
+	if pantryItem.GroupID != user.GroupID {
+		http.Error(w, "Pantry item does not belong to user's group", http.StatusForbidden)
+		return
+	}
+
+	rules, err := loadShelfLifeRules(pantryItem.GroupID)
+	if err != nil {
+		http.Error(w, "Failed to fetch shelf-life rules", http.StatusInternalServerError)
+		return
+	}
+
+	// Items added by barcode estimated their expiry from the catalog entry
+	productDays := 0
+	if pantryItem.Barcode != "" {
+		if product, err := findProduct(pantryItem.GroupID, pantryItem.Barcode); err == nil {
+			productDays = product.ShelfLifeDays
+		}
+	}
+
+	previousUpdate := pantryItem.UpdatedAt
+	pantryItem.MoveTo(location, rules, productDays, time.Now())
+
+	update := pantryBatchUpdate(pantryItem)
+	update["$set"].(bson.M)["storage_location"] = pantryItem.StorageLocation
+
+	// Only update if the item hasn't changed since it was read
+	result, err := config.DB.Collection("pantry_items").UpdateOne(
+		context.Background(),
+		bson.M{"_id": pantryItem.ID, "updated_at": previousUpdate},
+		update,
+	)
+	if err != nil {
+		log.Printf("Failed to move pantry item: %v", err)
+		http.Error(w, "Failed to move pantry item", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "Pantry item was modified, please retry", http.StatusConflict)
+		return
+	}
+
+	// Expiring-soon notifications no longer apply to batches the move pushed out
+	extendedBatchIDs := make([]primitive.ObjectID, 0)
+	for _, batch := range pantryItem.Batches {
+		if batch.ExpirationEstimated && !batch.IsExpiringSoon(3) && !batch.IsExpired() {
+			extendedBatchIDs = append(extendedBatchIDs, batch.ID)
+		}
+	}
+	if len(extendedBatchIDs) > 0 {
+		_, err = config.DB.Collection("pantry_notifications").DeleteMany(
+			context.Background(),
+			bson.M{
+				"item_id":  pantryItem.ID,
+				"batch_id": bson.M{"$in": extendedBatchIDs},
+				"type":     models.NotificationTypeExpiringSoon,
+			},
+		)
+		if err != nil {
+			log.Printf("Failed to delete expiring notifications: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pantryItem)
+}
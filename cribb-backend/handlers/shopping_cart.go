@@ -4,8 +4,11 @@ package handlers
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/middleware"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"cribb-backend/units"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,7 +27,9 @@ import (
 type AddShoppingCartItemRequest struct {
 	ItemName string  `json:"item_name" validate:"required,min=1"`
 	Quantity float64 `json:"quantity" validate:"required,min=0.1"`
+	Unit     string  `json:"unit,omitempty"`
 	Category string  `json:"category"`
+	ListID   string  `json:"list_id,omitempty"` // Defaults to the group's default list
 }
 
 // UpdateShoppingCartItemRequest defines the request structure for updating a shopping cart item
@@ -32,6 +37,7 @@ type UpdateShoppingCartItemRequest struct {
 	ItemID   string  `json:"item_id" validate:"required"`
 	ItemName string  `json:"item_name,omitempty" validate:"min=1"`
 	Quantity float64 `json:"quantity,omitempty" validate:"min=0.1"`
+	Unit     string  `json:"unit,omitempty"`
 	Category string  `json:"category,omitempty"`
 }
 
@@ -68,6 +74,16 @@ func AddShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate the unit if one was given
+	if request.Unit != "" {
+		unit, err := normalizeUnitOrError(request.Unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request.Unit = unit
+	}
+
 	// Get user ID
 	userID, err := primitive.ObjectIDFromHex(userClaims.ID)
 	if err != nil {
@@ -91,11 +107,47 @@ func AddShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	listID, err := activeShoppingListID(user.GroupID, request.ListID)
+	if err != nil {
+		var requestErr pantryRequestError
+		if errors.As(err, &requestErr) {
+			http.Error(w, requestErr.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to fetch shopping list", http.StatusInternalServerError)
+		return
+	}
+
+	finalShoppingCartItem, err := addToShoppingCart(user, listID, request.ItemName, request.Quantity, request.Unit, request.Category, "Added item to shopping cart")
+	if err != nil {
+		if errors.Is(err, units.ErrIncompatibleUnits) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to add shopping cart item: %v", err)
+		http.Error(w, "Failed to add item to shopping cart", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK) // 200 OK for both add and increment
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Item processed successfully", // Updated generic message
+		Data:    finalShoppingCartItem,
+	})
+}
+
+// addToShoppingCart adds an item to one of the user's lists, increasing the quantity
+// if the item is already on it, and logs the activity. A zero list ID is the default list. The quantity is converted into the
+// unit the cart item is already tracked in; incompatible units return ErrIncompatibleUnits.
+func addToShoppingCart(user models.User, listID primitive.ObjectID, itemName string, quantity float64, unit, category, details string) (models.ShoppingCartItem, error) {
 	// Define filter to find the item
 	filter := bson.M{
-		"user_id":   userID,
+		"user_id":   user.ID,
 		"group_id":  user.GroupID,
-		"item_name": request.ItemName,
+		"list_id":   shoppingListFilter(listID),
+		"item_name": itemName,
 	}
 
 	// Variable to hold the final item state
@@ -104,87 +156,98 @@ func AddShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Attempt to find the existing item first
 	var existingItem models.ShoppingCartItem
-	err = config.DB.Collection("shopping_cart").FindOne(context.Background(), filter).Decode(&existingItem)
+	err := config.DB.Collection("shopping_cart").FindOne(context.Background(), filter).Decode(&existingItem)
 
 	if err == nil {
 		// Item found - Increment quantity and update timestamp/category
 		itemWasUpdated = true
+
+		// Convert the added quantity into the unit the item is already tracked in
+		if unit != "" && existingItem.Unit != "" && unit != existingItem.Unit {
+			converted, convErr := units.Convert(quantity, unit, existingItem.Unit)
+			if convErr != nil {
+				return finalShoppingCartItem, fmt.Errorf("%w: unit %s is not compatible with the cart item's unit %s", units.ErrIncompatibleUnits, unit, existingItem.Unit)
+			}
+			quantity = converted
+		}
+
 		update := bson.M{
-			"$inc": bson.M{"quantity": request.Quantity}, // Increment quantity
+			"$inc": bson.M{"quantity": quantity}, // Increment quantity
 			"$set": bson.M{
 				"added_at": time.Now(), // Update timestamp
-				// Optionally update category if provided?
-				// "category": request.Category, // Keep existing category or update? Decide policy.
 			},
 		}
 		// If category is provided in the request, update it as well
-		if request.Category != "" {
-			update["$set"].(bson.M)["category"] = request.Category
+		if category != "" {
+			update["$set"].(bson.M)["category"] = category
+		}
+		if existingItem.Unit == "" && unit != "" {
+			update["$set"].(bson.M)["unit"] = unit
 		}
 
-		_, updateErr := config.DB.Collection("shopping_cart").UpdateOne(
+		_, err = config.DB.Collection("shopping_cart").UpdateOne(
 			context.Background(),
 			filter,
 			update,
 		)
-		if updateErr != nil {
-			log.Printf("Failed to increment shopping cart item quantity: %v", updateErr)
-			http.Error(w, "Failed to update item quantity in shopping cart", http.StatusInternalServerError)
-			return
+		if err != nil {
+			return finalShoppingCartItem, fmt.Errorf("failed to increment shopping cart item quantity: %w", err)
 		}
 		// Fetch the updated item to return it
-		fetchErr := config.DB.Collection("shopping_cart").FindOne(context.Background(), filter).Decode(&finalShoppingCartItem)
-		if fetchErr != nil {
-			log.Printf("Failed to fetch updated shopping cart item: %v", fetchErr)
-			http.Error(w, "Failed to fetch updated item", http.StatusInternalServerError)
-			return
+		err = config.DB.Collection("shopping_cart").FindOne(context.Background(), filter).Decode(&finalShoppingCartItem)
+		if err != nil {
+			return finalShoppingCartItem, fmt.Errorf("failed to fetch updated shopping cart item: %w", err)
 		}
 
 	} else if errors.Is(err, mongo.ErrNoDocuments) {
 		// Item not found - Insert new item
-		itemWasUpdated = false
 		newItem := models.CreateShoppingCartItem(
-			userID,
+			user.ID,
 			user.GroupID,
-			request.ItemName,
-			request.Quantity,
-			request.Category,
+			itemName,
+			quantity,
+			category,
 		)
+		newItem.Unit = unit
+		newItem.ListID = listID
 		insertResult, insertErr := config.DB.Collection("shopping_cart").InsertOne(context.Background(), newItem)
 		if insertErr != nil {
-			log.Printf("Failed to insert new shopping cart item: %v", insertErr)
-			http.Error(w, "Failed to add item to shopping cart", http.StatusInternalServerError)
-			return
+			return finalShoppingCartItem, fmt.Errorf("failed to insert new shopping cart item: %w", insertErr)
 		}
 		newItem.ID = insertResult.InsertedID.(primitive.ObjectID)
 		finalShoppingCartItem = *newItem // Use the newly inserted item data (Dereference the pointer)
 
 	} else {
 		// Other database error during FindOne
-		log.Printf("Error checking for existing shopping cart item: %v", err)
-		http.Error(w, "Database error checking for item", http.StatusInternalServerError)
-		return
+		return finalShoppingCartItem, fmt.Errorf("error checking for existing shopping cart item: %w", err)
+	}
+
+	eventType := events.CartItemAdded
+	if itemWasUpdated {
+		eventType = events.CartItemUpdated
 	}
+	events.Publish(user.GroupID, eventType, user.ID, finalShoppingCartItem)
 
 	// Log the activity
 	go func() {
 		activityAction := models.CartActivityTypeAdd
-		activityDetails := "Added item to shopping cart"
+		activityDetails := details
 		if itemWasUpdated {
 			activityAction = models.CartActivityTypeUpdate // Using Update type for increment as well
-			activityDetails = fmt.Sprintf("Increased quantity of %s by %.2f (New total: %.2f)", finalShoppingCartItem.ItemName, request.Quantity, finalShoppingCartItem.Quantity)
+			activityDetails = fmt.Sprintf("Increased quantity of %s by %.2f (New total: %.2f)", finalShoppingCartItem.ItemName, quantity, finalShoppingCartItem.Quantity)
 		}
 
 		activity := models.CreateShoppingCartActivity(
 			user.GroupID,
 			finalShoppingCartItem.ID, // Use the ID from the final item state
 			finalShoppingCartItem.ItemName,
-			userID,
+			user.ID,
 			user.Name,
 			activityAction,                 // Use the determined action
 			finalShoppingCartItem.Quantity, // Log the *new* total quantity
 			activityDetails,                // Use the determined details
 		)
+		activity.ListID = listID
 
 		_, insertErr := config.DB.Collection("shopping_cart_activity").InsertOne(
 			context.Background(),
@@ -193,16 +256,15 @@ func AddShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 
 		if insertErr != nil {
 			log.Printf("Failed to create shopping cart activity record: %v", insertErr)
+		} else {
+			notifications.Notify(models.NotificationFromCartActivity(activity))
 		}
+
+		// More in the cart raises the projected spend
+		checkBudgetAlerts(user.GroupID)
 	}()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK) // 200 OK for both add and increment
-	json.NewEncoder(w).Encode(ShoppingCartResponse{
-		Status:  "success",
-		Message: "Item processed successfully", // Updated generic message
-		Data:    finalShoppingCartItem,
-	})
+	return finalShoppingCartItem, nil
 }
 
 // UpdateShoppingCartItemHandler handles updating an item in the shopping cart
@@ -296,6 +358,25 @@ func UpdateShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 		updateFields["category"] = request.Category
 	}
 
+	if request.Unit != "" {
+		unit, err := normalizeUnitOrError(request.Unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Changing only the unit converts the existing quantity
+		if request.Quantity <= 0 && shoppingCartItem.Unit != "" && shoppingCartItem.Unit != unit {
+			converted, err := units.Convert(shoppingCartItem.Quantity, shoppingCartItem.Unit, unit)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Unit %s is not compatible with the cart item's unit %s", unit, shoppingCartItem.Unit), http.StatusBadRequest)
+				return
+			}
+			updateFields["quantity"] = converted
+		}
+		updateFields["unit"] = unit
+	}
+
 	// If no fields to update, return early
 	if len(updateFields) == 0 {
 		http.Error(w, "No valid fields to update", http.StatusBadRequest)
@@ -331,6 +412,8 @@ func UpdateShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	events.Publish(user.GroupID, events.CartItemUpdated, userID, shoppingCartItem)
+
 	// Log the activity
 	go func() {
 		// Create details message
@@ -366,6 +449,8 @@ func UpdateShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			log.Printf("Failed to create shopping cart activity record: %v", err)
+		} else {
+			notifications.Notify(models.NotificationFromCartActivity(activity))
 		}
 	}()
 
@@ -468,6 +553,8 @@ func DeleteShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	events.Publish(user.GroupID, events.CartItemDeleted, userID, shoppingCartItem)
+
 	// Log the activity
 	go func() {
 		// Create activity log
@@ -489,6 +576,8 @@ func DeleteShoppingCartItemHandler(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			log.Printf("Failed to create shopping cart activity record: %v", err)
+		} else {
+			notifications.Notify(models.NotificationFromCartActivity(activity))
 		}
 	}()
 
@@ -566,6 +655,21 @@ func ListShoppingCartItemsHandler(w http.ResponseWriter, r *http.Request) {
 		filter["user_id"] = filterUserID
 	}
 
+	// Filter by list; without one, items on archived lists are left out
+	listFilter, err := shoppingListQueryFilter(user.GroupID, r.URL.Query().Get("list_id"))
+	if err != nil {
+		var requestErr pantryRequestError
+		if errors.As(err, &requestErr) {
+			http.Error(w, requestErr.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to fetch shopping lists", http.StatusInternalServerError)
+		return
+	}
+	if listFilter != nil {
+		filter["list_id"] = listFilter
+	}
+
 	// Get all items in the shopping cart for the group
 	opts := options.Find().SetSort(bson.D{{Key: "added_at", Value: -1}})
 	cursor, err := config.DB.Collection("shopping_cart").Find(
@@ -0,0 +1,469 @@
+// handlers/shopping_cart_checkout.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/events"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PurchaseCartItemRequest defines the request structure for checking a cart item off into the pantry
+type PurchaseCartItemRequest struct {
+	ItemID          string   `json:"item_id"`
+	Quantity        *float64 `json:"quantity,omitempty"`        // In the cart item's unit; defaults to the whole cart quantity
+	Price           *float64 `json:"price,omitempty"`           // Total paid
+	Currency        string   `json:"currency,omitempty"`        // Defaults to the group budget's currency
+	Store           string   `json:"store,omitempty"`           // Where it was bought, for price history
+	ExpirationDate  *string  `json:"expiration_date,omitempty"` // Estimated from shelf-life rules when omitted
+	StorageLocation string   `json:"storage_location,omitempty"`
+}
+
+// CheckoutCartRequest defines the request structure for checking off several cart items
+type CheckoutCartRequest struct {
+	Items  []PurchaseCartItemRequest `json:"items"`
+	All    bool                      `json:"all,omitempty"`     // Check off the whole cart when no items are listed
+	Store  string                    `json:"store,omitempty"`   // Default store for items that don't name one
+	ListID string                    `json:"list_id,omitempty"` // With all, only check off this list ("default" for the default list)
+
+	// Record the priced items as one expense paid by the current user, split equally
+	RecordExpense bool     `json:"record_expense,omitempty"`
+	Currency      string   `json:"currency,omitempty"`
+	Participants  []string `json:"participants,omitempty"` // Defaults to every member
+}
+
+// CheckoutCartResponse is the response to a bulk checkout. Data lists the
+// purchases; the expense recorded for them, if any, sits beside it.
+type CheckoutCartResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    []CartPurchase  `json:"data"`
+	Expense *models.Expense `json:"expense,omitempty"`
+}
+
+// CartPurchase is the result of checking off one cart item
+type CartPurchase struct {
+	CartItemID      primitive.ObjectID  `json:"cart_item_id"`
+	ItemName        string              `json:"item_name"`
+	Quantity        float64             `json:"quantity"`
+	Unit            string              `json:"unit"`
+	Price           float64             `json:"price,omitempty"`
+	RemainingInCart float64             `json:"remaining_in_cart"`
+	PantryItem      *models.PantryItem  `json:"pantry_item,omitempty"`
+	Batch           *models.PantryBatch `json:"batch,omitempty"`
+	Error           string              `json:"error,omitempty"`
+}
+
+// purchaseCartItem moves a cart item, or part of it, into the group's pantry.
+// Errors caused by the request itself are returned as pantryRequestError.
+func purchaseCartItem(user models.User, group models.Group, request PurchaseCartItemRequest) (CartPurchase, error) {
+	var purchase CartPurchase
+
+	itemID, err := primitive.ObjectIDFromHex(request.ItemID)
+	if err != nil {
+		return purchase, pantryRequestError{"Invalid item ID format"}
+	}
+	purchase.CartItemID = itemID
+
+	var cartItem models.ShoppingCartItem
+	err = config.DB.Collection("shopping_cart").FindOne(
+		context.Background(),
+		bson.M{"_id": itemID, "user_id": user.ID},
+	).Decode(&cartItem)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return purchase, pantryRequestError{"Shopping cart item not found or does not belong to user"}
+		}
+		return purchase, err
+	}
+	purchase.ItemName = cartItem.ItemName
+
+	quantity := cartItem.Quantity
+	if request.Quantity != nil {
+		if *request.Quantity <= 0 {
+			return purchase, pantryRequestError{"Quantity must be positive"}
+		}
+		quantity = *request.Quantity
+	}
+	purchase.Quantity = quantity
+
+	var price float64
+	if request.Price != nil {
+		if *request.Price < 0 {
+			return purchase, pantryRequestError{"Price cannot be negative"}
+		}
+		price = *request.Price
+	}
+	purchase.Price = price
+
+	var expirationDate time.Time
+	if request.ExpirationDate != nil && *request.ExpirationDate != "" {
+		expirationDate, err = time.Parse(time.RFC3339, *request.ExpirationDate)
+		if err != nil {
+			return purchase, pantryRequestError{"Invalid expiration date format. Use ISO 8601/RFC3339 format (YYYY-MM-DDTHH:MM:SSZ)"}
+		}
+	}
+
+	location, err := models.ParseStorageLocation(request.StorageLocation)
+	if err != nil {
+		return purchase, pantryRequestError{err.Error()}
+	}
+
+	// Cart items added without a unit are counted in the pantry item's unit
+	unit := cartItem.Unit
+	if unit == "" {
+		unit = pantryUnitFor(group.ID, cartItem.ItemName)
+	}
+	purchase.Unit = unit
+
+	// Take what was bought off the cart before stocking the pantry, so the
+	// same cart item can't be checked off twice at once
+	remaining, err := claimCartQuantity(user.ID, cartItem.ID, quantity)
+	if err != nil {
+		return purchase, err
+	}
+	purchase.RemainingInCart = remaining
+
+	pantryItem, batch, err := addToPantry(pantryAddition{
+		Group:           group,
+		User:            user,
+		Name:            cartItem.ItemName,
+		Quantity:        quantity,
+		Unit:            unit,
+		Category:        cartItem.Category,
+		ExpirationDate:  expirationDate,
+		StorageLocation: location,
+		Price:           price,
+	})
+	if err != nil {
+		releaseCartQuantity(cartItem, quantity, remaining)
+		return purchase, err
+	}
+	purchase.PantryItem = &pantryItem
+	purchase.Batch = &batch
+
+	if price > 0 {
+		currency := request.Currency
+		if currency == "" {
+			currency = groupCurrency(group.ID)
+		}
+		recordPurchasePrice(user, group, purchase, cartItem.Category, request.Store, currency)
+	}
+
+	if remaining > 0 {
+		cartItem.Quantity = remaining
+		events.Publish(group.ID, events.CartItemUpdated, user.ID, cartItem)
+	} else {
+		events.Publish(group.ID, events.CartItemDeleted, user.ID, cartItem)
+	}
+
+	// Log the activity
+	go func() {
+		details := fmt.Sprintf("Purchased %g %s of %s into the pantry", quantity, unit, cartItem.ItemName)
+		if price > 0 {
+			details += fmt.Sprintf(" for %.2f", price)
+		}
+
+		activity := models.CreateShoppingCartActivity(
+			group.ID,
+			cartItem.ID,
+			cartItem.ItemName,
+			user.ID,
+			user.Name,
+			models.CartActivityTypePurchase,
+			quantity,
+			details,
+		)
+
+		_, err := config.DB.Collection("shopping_cart_activity").InsertOne(
+			context.Background(),
+			activity,
+		)
+
+		if err != nil {
+			log.Printf("Failed to create shopping cart activity record: %v", err)
+		} else {
+			notifications.Notify(models.NotificationFromCartActivity(activity))
+		}
+	}()
+
+	return purchase, nil
+}
+
+// claimCartQuantity takes quantity off a cart item, deleting the item once
+// all of it is taken. It fails if the item is gone, so two purchases of the
+// same item can't both go through. It returns what's left in the cart.
+func claimCartQuantity(userID, cartItemID primitive.ObjectID, quantity float64) (float64, error) {
+	var updated models.ShoppingCartItem
+	err := config.DB.Collection("shopping_cart").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": cartItemID, "user_id": userID, "quantity": bson.M{"$gt": quantity}},
+		bson.M{"$inc": bson.M{"quantity": -quantity}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == nil {
+		return updated.Quantity, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+
+	result, err := config.DB.Collection("shopping_cart").DeleteOne(
+		context.Background(),
+		bson.M{"_id": cartItemID, "user_id": userID, "quantity": bson.M{"$lte": quantity}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if result.DeletedCount == 0 {
+		return 0, pantryRequestError{"Shopping cart item was already checked off or changed; refresh and try again"}
+	}
+	return 0, nil
+}
+
+// releaseCartQuantity puts a claimed quantity back on the cart after the
+// pantry couldn't be stocked
+func releaseCartQuantity(cartItem models.ShoppingCartItem, quantity, remaining float64) {
+	var err error
+	if remaining > 0 {
+		_, err = config.DB.Collection("shopping_cart").UpdateOne(
+			context.Background(),
+			bson.M{"_id": cartItem.ID},
+			bson.M{"$inc": bson.M{"quantity": quantity}},
+		)
+	} else {
+		_, err = config.DB.Collection("shopping_cart").InsertOne(context.Background(), cartItem)
+	}
+	if err != nil {
+		log.Printf("Failed to return %s to the shopping cart: %v", cartItem.ItemName, err)
+	}
+}
+
+// pantryUnitFor returns the unit a pantry item with the given name is tracked in, or pieces
+func pantryUnitFor(groupID primitive.ObjectID, name string) string {
+	var pantryItem models.PantryItem
+	err := config.DB.Collection("pantry_items").FindOne(
+		context.Background(),
+		bson.M{
+			"group_id": groupID,
+			"name":     bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(name)) + "$", Options: "i"}},
+		},
+	).Decode(&pantryItem)
+	if err != nil || pantryItem.Unit == "" {
+		return "pc"
+	}
+	return pantryItem.Unit
+}
+
+// PurchaseCartItemHandler checks a single cart item off into the pantry
+func PurchaseCartItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request PurchaseCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	purchase, err := purchaseCartItem(user, group, request)
+	if err != nil {
+		var requestErr pantryRequestError
+		if errors.As(err, &requestErr) {
+			http.Error(w, requestErr.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Cart purchase failed: %v", err)
+		http.Error(w, "Failed to move item into pantry", http.StatusInternalServerError)
+		return
+	}
+
+	if purchase.Price > 0 {
+		go checkBudgetAlerts(group.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Item moved into pantry",
+		Data:    purchase,
+	})
+}
+
+// CheckoutCartHandler checks several cart items off into the pantry. Each item
+// is handled on its own, so one failure doesn't stop the rest.
+func CheckoutCartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request CheckoutCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	if len(request.Items) == 0 {
+		if !request.All {
+			http.Error(w, "No items to check out", http.StatusBadRequest)
+			return
+		}
+
+		filter := bson.M{"user_id": user.ID, "group_id": group.ID}
+		listFilter, err := shoppingListQueryFilter(group.ID, request.ListID)
+		if err != nil {
+			var requestErr pantryRequestError
+			if errors.As(err, &requestErr) {
+				http.Error(w, requestErr.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to fetch shopping lists", http.StatusInternalServerError)
+			return
+		}
+		if listFilter != nil {
+			filter["list_id"] = listFilter
+		}
+
+		cursor, err := config.DB.Collection("shopping_cart").Find(context.Background(), filter)
+		if err != nil {
+			http.Error(w, "Failed to fetch shopping cart items", http.StatusInternalServerError)
+			return
+		}
+		var cartItems []models.ShoppingCartItem
+		err = cursor.All(context.Background(), &cartItems)
+		cursor.Close(context.Background())
+		if err != nil {
+			http.Error(w, "Failed to decode shopping cart items", http.StatusInternalServerError)
+			return
+		}
+
+		for _, cartItem := range cartItems {
+			request.Items = append(request.Items, PurchaseCartItemRequest{ItemID: cartItem.ID.Hex()})
+		}
+	}
+
+	if request.Currency == "" {
+		request.Currency = groupCurrency(group.ID)
+	}
+
+	purchases := make([]CartPurchase, 0, len(request.Items))
+	failed := 0
+	for _, itemRequest := range request.Items {
+		if itemRequest.Store == "" {
+			itemRequest.Store = request.Store
+		}
+		if itemRequest.Currency == "" {
+			itemRequest.Currency = request.Currency
+		}
+		purchase, err := purchaseCartItem(user, group, itemRequest)
+		if err != nil {
+			var requestErr pantryRequestError
+			if errors.As(err, &requestErr) {
+				purchase.Error = requestErr.Error()
+			} else {
+				log.Printf("Cart purchase failed: %v", err)
+				purchase.Error = "Failed to move item into pantry"
+			}
+			failed++
+		}
+		purchases = append(purchases, purchase)
+	}
+
+	status := "success"
+	message := fmt.Sprintf("%d item(s) moved into pantry", len(purchases)-failed)
+	if failed > 0 {
+		status = "partial"
+		message += fmt.Sprintf(", %d failed", failed)
+	}
+
+	go checkBudgetAlerts(group.ID)
+
+	response := CheckoutCartResponse{Data: purchases}
+	if request.RecordExpense {
+		expense, err := checkoutExpense(user, group, purchases, request)
+		if err != nil {
+			// The purchases went through; report the expense problem alongside them
+			log.Printf("Failed to record checkout expense: %v", err)
+			status = "partial"
+			message += ". Expense not recorded: " + err.Error()
+		}
+		response.Expense = expense
+	}
+	response.Status = status
+	response.Message = message
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkoutExpense records the priced purchases of a checkout as one expense
+// paid by the user and split equally. It returns nil if nothing had a price.
+func checkoutExpense(user models.User, group models.Group, purchases []CartPurchase, request CheckoutCartRequest) (*models.Expense, error) {
+	var total float64
+	names := make([]string, 0, len(purchases))
+	for _, purchase := range purchases {
+		if purchase.Error == "" && purchase.Price > 0 {
+			total += purchase.Price
+			names = append(names, purchase.ItemName)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	expense := models.CreateExpense(
+		group.ID,
+		"Groceries: "+strings.Join(names, ", "),
+		user.ID,
+		models.ToCents(total),
+		request.Currency,
+		models.SplitTypeEqual,
+		models.ExpenseSourceCartCheckout,
+		user.ID,
+	)
+	if err := expense.Validate(); err != nil {
+		return nil, err
+	}
+
+	input, err := splitInputFromRequest(CreateExpenseRequest{Participants: request.Participants}, group)
+	if err != nil {
+		return nil, err
+	}
+	if err := expense.Split(input, groupMemberSet(group)); err != nil {
+		return nil, err
+	}
+
+	result, err := config.DB.Collection("expenses").InsertOne(context.Background(), expense)
+	if err != nil {
+		return nil, err
+	}
+	expense.ID = result.InsertedID.(primitive.ObjectID)
+	return expense, nil
+}
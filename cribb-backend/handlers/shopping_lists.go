@@ -0,0 +1,558 @@
+// handlers/shopping_lists.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"cribb-backend/units"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultListParam selects the group's default list in requests
+const defaultListParam = "default"
+
+// ShoppingListRequest defines the request structure for creating, renaming, archiving or restoring a list
+type ShoppingListRequest struct {
+	ListID string `json:"list_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// MoveCartItemsRequest defines the request structure for moving cart items to another list
+type MoveCartItemsRequest struct {
+	ItemIDs  []string `json:"item_ids"`
+	ToListID string   `json:"to_list_id"` // "default" or empty for the default list
+}
+
+// ShoppingListSummary is a list with the number of items on it
+type ShoppingListSummary struct {
+	models.ShoppingList
+	ItemCount int64 `json:"item_count"`
+}
+
+// shoppingListFilter matches cart items on a list; a zero ID is the default list
+func shoppingListFilter(listID primitive.ObjectID) interface{} {
+	if listID.IsZero() {
+		return bson.M{"$exists": false}
+	}
+	return listID
+}
+
+// findGroupShoppingList looks up one of the group's lists.
+// A missing or malformed ID is returned as pantryRequestError.
+func findGroupShoppingList(groupID primitive.ObjectID, idStr string) (models.ShoppingList, error) {
+	var list models.ShoppingList
+
+	listID, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return list, pantryRequestError{"Invalid list ID format"}
+	}
+
+	err = config.DB.Collection("shopping_lists").FindOne(
+		context.Background(),
+		bson.M{"_id": listID, "group_id": groupID},
+	).Decode(&list)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return list, pantryRequestError{"Shopping list not found"}
+	}
+	return list, err
+}
+
+// activeShoppingListID resolves the list items are being added to. Archived lists
+// can't take new items.
+func activeShoppingListID(groupID primitive.ObjectID, idStr string) (primitive.ObjectID, error) {
+	if idStr == "" || idStr == defaultListParam {
+		return primitive.NilObjectID, nil
+	}
+
+	list, err := findGroupShoppingList(groupID, idStr)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if list.IsArchived {
+		return primitive.NilObjectID, pantryRequestError{"Shopping list is archived"}
+	}
+	return list.ID, nil
+}
+
+// shoppingListQueryFilter returns the list_id filter for a list query parameter,
+// or nil for no filter. Without a list, items on archived lists are left out.
+func shoppingListQueryFilter(groupID primitive.ObjectID, idStr string) (interface{}, error) {
+	switch idStr {
+	case defaultListParam:
+		return shoppingListFilter(primitive.NilObjectID), nil
+	case "":
+		cursor, err := config.DB.Collection("shopping_lists").Find(
+			context.Background(),
+			bson.M{"group_id": groupID, "is_archived": true},
+			options.Find().SetProjection(bson.M{"_id": 1}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var archived []models.ShoppingList
+		err = cursor.All(context.Background(), &archived)
+		cursor.Close(context.Background())
+		if err != nil || len(archived) == 0 {
+			return nil, err
+		}
+
+		ids := make([]primitive.ObjectID, 0, len(archived))
+		for _, list := range archived {
+			ids = append(ids, list.ID)
+		}
+		return bson.M{"$nin": ids}, nil
+	default:
+		list, err := findGroupShoppingList(groupID, idStr)
+		if err != nil {
+			return nil, err
+		}
+		return list.ID, nil
+	}
+}
+
+// logShoppingListActivity records a list-level event in the cart activity feed
+func logShoppingListActivity(list models.ShoppingList, user models.User, action models.CartActivityType, details string) {
+	go func() {
+		activity := models.CreateShoppingListActivity(&list, user.ID, user.Name, action, details)
+		_, err := config.DB.Collection("shopping_cart_activity").InsertOne(context.Background(), activity)
+		if err != nil {
+			log.Printf("Failed to create shopping cart activity record: %v", err)
+		} else {
+			notifications.Notify(models.NotificationFromCartActivity(activity))
+		}
+	}()
+}
+
+// writeShoppingListError maps a list lookup error to a response
+func writeShoppingListError(w http.ResponseWriter, err error) {
+	var requestErr pantryRequestError
+	if errors.As(err, &requestErr) {
+		status := http.StatusBadRequest
+		if requestErr.Error() == "Shopping list not found" {
+			status = http.StatusNotFound
+		}
+		http.Error(w, requestErr.Error(), status)
+		return
+	}
+	http.Error(w, "Failed to fetch shopping list", http.StatusInternalServerError)
+}
+
+// CreateShoppingListHandler creates a named shopping list for the group
+func CreateShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request ShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.ValidateShoppingListName(request.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	list := models.CreateShoppingList(group.ID, request.Name, user.ID)
+	result, err := config.DB.Collection("shopping_lists").InsertOne(context.Background(), list)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			http.Error(w, "A list with this name already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Shopping list creation error: %v", err)
+		http.Error(w, "Failed to create shopping list", http.StatusInternalServerError)
+		return
+	}
+	list.ID = result.InsertedID.(primitive.ObjectID)
+
+	logShoppingListActivity(*list, user, models.CartActivityTypeListCreate, fmt.Sprintf("Created list %s", list.Name))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Shopping list created",
+		Data:    list,
+	})
+}
+
+// GetShoppingListsHandler lists the group's shopping lists with their item counts,
+// starting with the default list. Archived lists are included with ?archived=true.
+func GetShoppingListsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"group_id": group.ID}
+	if r.URL.Query().Get("archived") != "true" {
+		filter["is_archived"] = false
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "is_archived", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := config.DB.Collection("shopping_lists").Find(context.Background(), filter, opts)
+	if err != nil {
+		http.Error(w, "Failed to fetch shopping lists", http.StatusInternalServerError)
+		return
+	}
+	var lists []models.ShoppingList
+	err = cursor.All(context.Background(), &lists)
+	cursor.Close(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to decode shopping lists", http.StatusInternalServerError)
+		return
+	}
+
+	defaultList := models.ShoppingList{GroupID: group.ID, Name: models.DefaultShoppingListName}
+	lists = append([]models.ShoppingList{defaultList}, lists...)
+
+	summaries := make([]ShoppingListSummary, 0, len(lists))
+	for _, list := range lists {
+		count, err := config.DB.Collection("shopping_cart").CountDocuments(
+			context.Background(),
+			bson.M{"group_id": group.ID, "list_id": shoppingListFilter(list.ID)},
+		)
+		if err != nil {
+			http.Error(w, "Failed to count shopping list items", http.StatusInternalServerError)
+			return
+		}
+		summaries = append(summaries, ShoppingListSummary{ShoppingList: list, ItemCount: count})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Shopping lists retrieved successfully",
+		Data:    summaries,
+	})
+}
+
+// RenameShoppingListHandler renames one of the group's lists
+func RenameShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request ShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.ValidateShoppingListName(request.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := findGroupShoppingList(group.ID, request.ListID)
+	if err != nil {
+		writeShoppingListError(w, err)
+		return
+	}
+
+	oldName := list.Name
+	list.Name = strings.TrimSpace(request.Name)
+	list.UpdatedAt = time.Now()
+
+	_, err = config.DB.Collection("shopping_lists").UpdateOne(
+		context.Background(),
+		bson.M{"_id": list.ID},
+		bson.M{"$set": bson.M{"name": list.Name, "updated_at": list.UpdatedAt}},
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			http.Error(w, "A list with this name already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to rename shopping list", http.StatusInternalServerError)
+		return
+	}
+
+	logShoppingListActivity(list, user, models.CartActivityTypeListRename, fmt.Sprintf("Renamed list %s to %s", oldName, list.Name))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: "Shopping list renamed",
+		Data:    list,
+	})
+}
+
+// ArchiveShoppingListHandler archives a list; its items are kept but hidden from the cart
+func ArchiveShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	setShoppingListArchived(w, r, true)
+}
+
+// RestoreShoppingListHandler brings an archived list back
+func RestoreShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	setShoppingListArchived(w, r, false)
+}
+
+// setShoppingListArchived archives or restores a list
+func setShoppingListArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request ShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := findGroupShoppingList(group.ID, request.ListID)
+	if err != nil {
+		writeShoppingListError(w, err)
+		return
+	}
+	if list.IsArchived == archived {
+		state := "active"
+		if archived {
+			state = "archived"
+		}
+		http.Error(w, "Shopping list is already "+state, http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"is_archived": archived, "updated_at": now}}
+	action := models.CartActivityTypeListRestore
+	details := fmt.Sprintf("Restored list %s", list.Name)
+	if archived {
+		update["$set"].(bson.M)["archived_at"] = now
+		action = models.CartActivityTypeListArchive
+		details = fmt.Sprintf("Archived list %s", list.Name)
+	} else {
+		update["$unset"] = bson.M{"archived_at": ""}
+	}
+
+	_, err = config.DB.Collection("shopping_lists").UpdateOne(
+		context.Background(),
+		bson.M{"_id": list.ID},
+		update,
+	)
+	if err != nil {
+		http.Error(w, "Failed to update shopping list", http.StatusInternalServerError)
+		return
+	}
+
+	list.IsArchived = archived
+	list.UpdatedAt = now
+	list.ArchivedAt = nil
+	if archived {
+		list.ArchivedAt = &now
+	}
+
+	logShoppingListActivity(list, user, action, details)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: details,
+		Data:    list,
+	})
+}
+
+// moveCartItem moves one of the user's cart items to another list. If the user
+// already has the item there, the quantities are merged into that entry.
+func moveCartItem(user models.User, itemIDStr string, toListID primitive.ObjectID) (models.ShoppingCartItem, error) {
+	var item models.ShoppingCartItem
+
+	itemID, err := primitive.ObjectIDFromHex(itemIDStr)
+	if err != nil {
+		return item, pantryRequestError{"Invalid item ID format"}
+	}
+
+	err = config.DB.Collection("shopping_cart").FindOne(
+		context.Background(),
+		bson.M{"_id": itemID, "user_id": user.ID},
+	).Decode(&item)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return item, pantryRequestError{"Shopping cart item not found or does not belong to user"}
+		}
+		return item, err
+	}
+	if item.ListID == toListID {
+		return item, nil
+	}
+
+	update := bson.M{"$set": bson.M{"list_id": toListID}}
+	if toListID.IsZero() {
+		update = bson.M{"$unset": bson.M{"list_id": ""}}
+	}
+	_, err = config.DB.Collection("shopping_cart").UpdateOne(
+		context.Background(),
+		bson.M{"_id": item.ID},
+		update,
+	)
+	if err == nil {
+		item.ListID = toListID
+		return item, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return item, err
+	}
+
+	// The item is already on the target list; add this quantity to it
+	var target models.ShoppingCartItem
+	err = config.DB.Collection("shopping_cart").FindOne(
+		context.Background(),
+		bson.M{
+			"user_id":   user.ID,
+			"group_id":  item.GroupID,
+			"list_id":   shoppingListFilter(toListID),
+			"item_name": item.ItemName,
+		},
+	).Decode(&target)
+	if err != nil {
+		return item, err
+	}
+
+	quantity := item.Quantity
+	if item.Unit != "" && target.Unit != "" && item.Unit != target.Unit {
+		if quantity, err = units.Convert(quantity, item.Unit, target.Unit); err != nil {
+			return item, pantryRequestError{fmt.Sprintf("%s is already on that list in %s, which %s can't be converted to", item.ItemName, target.Unit, item.Unit)}
+		}
+	}
+
+	_, err = config.DB.Collection("shopping_cart").UpdateOne(
+		context.Background(),
+		bson.M{"_id": target.ID},
+		bson.M{"$inc": bson.M{"quantity": quantity}},
+	)
+	if err != nil {
+		return item, err
+	}
+	_, err = config.DB.Collection("shopping_cart").DeleteOne(
+		context.Background(),
+		bson.M{"_id": item.ID},
+	)
+	if err != nil {
+		return item, err
+	}
+
+	target.Quantity += quantity
+	return target, nil
+}
+
+// MoveCartItemsHandler moves the user's cart items to another list
+func MoveCartItemsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request MoveCartItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(request.ItemIDs) == 0 {
+		http.Error(w, "No items to move", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	toListID, err := activeShoppingListID(group.ID, request.ToListID)
+	if err != nil {
+		writeShoppingListError(w, err)
+		return
+	}
+	listName := models.DefaultShoppingListName
+	if !toListID.IsZero() {
+		list, err := findGroupShoppingList(group.ID, toListID.Hex())
+		if err != nil {
+			writeShoppingListError(w, err)
+			return
+		}
+		listName = list.Name
+	}
+
+	moved := make([]models.ShoppingCartItem, 0, len(request.ItemIDs))
+	for _, itemID := range request.ItemIDs {
+		item, err := moveCartItem(user, itemID, toListID)
+		if err != nil {
+			var requestErr pantryRequestError
+			if errors.As(err, &requestErr) {
+				http.Error(w, requestErr.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Failed to move shopping cart item: %v", err)
+			http.Error(w, "Failed to move shopping cart item", http.StatusInternalServerError)
+			return
+		}
+		moved = append(moved, item)
+
+		go func(item models.ShoppingCartItem) {
+			activity := models.CreateShoppingCartActivity(
+				group.ID,
+				item.ID,
+				item.ItemName,
+				user.ID,
+				user.Name,
+				models.CartActivityTypeMove,
+				item.Quantity,
+				fmt.Sprintf("Moved %s to %s", item.ItemName, listName),
+			)
+			activity.ListID = toListID
+			activity.ListName = listName
+
+			_, err := config.DB.Collection("shopping_cart_activity").InsertOne(context.Background(), activity)
+			if err != nil {
+				log.Printf("Failed to create shopping cart activity record: %v", err)
+			} else {
+				notifications.Notify(models.NotificationFromCartActivity(activity))
+			}
+		}(item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShoppingCartResponse{
+		Status:  "success",
+		Message: fmt.Sprintf("%d item(s) moved to %s", len(moved), listName),
+		Data:    moved,
+	})
+}
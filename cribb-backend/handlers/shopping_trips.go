@@ -0,0 +1,410 @@
+// handlers/shopping_trips.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StartTripRequest defines the request structure for starting a shopping trip
+type StartTripRequest struct {
+	StoreID    string `json:"store_id,omitempty"`    // Sort the list by this store's aisles
+	ClaimItems *bool  `json:"claim_items,omitempty"` // Claim unclaimed list items for the shopper; defaults to true
+}
+
+// CheckTripItemRequest defines the request structure for checking off an item during a trip
+type CheckTripItemRequest struct {
+	TripID     string  `json:"trip_id"`
+	ListItemID string  `json:"list_item_id"`
+	Status     string  `json:"status"`               // bought, skipped, substituted or pending
+	Quantity   float64 `json:"quantity,omitempty"`   // Defaults to the listed quantity
+	Substitute string  `json:"substitute,omitempty"` // What was bought instead
+}
+
+// TripActionRequest defines the request structure for ending a trip
+type TripActionRequest struct {
+	TripID string `json:"trip_id"`
+}
+
+// updateShoppingTrip applies a change to a trip in the group, retrying on a fresh
+// copy if it was changed at the same time. Errors from the change are returned as is.
+func updateShoppingTrip(groupID primitive.ObjectID, tripIDStr string, change func(trip *models.ShoppingTrip) error) (models.ShoppingTrip, error) {
+	var trip models.ShoppingTrip
+
+	tripID, err := primitive.ObjectIDFromHex(tripIDStr)
+	if err != nil {
+		return trip, pantryRequestError{"Invalid trip ID format"}
+	}
+
+	for attempt := 0; attempt < groupListRetries; attempt++ {
+		err = config.DB.Collection("shopping_trips").FindOne(
+			context.Background(),
+			bson.M{"_id": tripID, "group_id": groupID},
+		).Decode(&trip)
+		if err != nil {
+			return trip, err
+		}
+
+		seen := trip.Version
+		if err = change(&trip); err != nil {
+			return trip, err
+		}
+
+		result, err := config.DB.Collection("shopping_trips").ReplaceOne(
+			context.Background(),
+			bson.M{"_id": trip.ID, "version": seen},
+			trip,
+		)
+		if err != nil {
+			return trip, err
+		}
+		if result.MatchedCount > 0 {
+			return trip, nil
+		}
+	}
+	return trip, errors.New("trip is being updated by someone else, please retry")
+}
+
+// writeTripError maps a trip error to a response
+func writeTripError(w http.ResponseWriter, err error) {
+	var requestErr pantryRequestError
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		http.Error(w, "Shopping trip not found", http.StatusNotFound)
+	case errors.As(err, &requestErr):
+		http.Error(w, requestErr.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Shopping trip update failed: %v", err)
+		http.Error(w, "Failed to update shopping trip", http.StatusInternalServerError)
+	}
+}
+
+// releaseTripItems updates the group list once a trip ends: bought and substituted
+// quantities come off the list and every claim the trip made is released.
+// Nothing comes off the list for a cancelled trip.
+func releaseTripItems(trip models.ShoppingTrip) {
+	for _, item := range trip.Items {
+		_, err := updateGroupListItem(
+			trip.GroupID,
+			GroupListItemActionRequest{ItemID: item.ListItemID.Hex()},
+			func(listItem *models.GroupListItem) error {
+				if trip.Status == models.TripStatusCompleted &&
+					(item.Status == models.TripItemStatusBought || item.Status == models.TripItemStatusSubstituted) {
+					bought := item.BoughtQuantity
+					if item.Status == models.TripItemStatusSubstituted {
+						// The substitute covers what was asked for
+						bought = item.Quantity
+					}
+					listItem.Fulfil(bought, time.Now())
+					return nil
+				}
+				return listItem.Unclaim(trip.ShopperID, time.Now())
+			},
+		)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) && !errors.Is(err, models.ErrListItemNotClaimed) {
+			log.Printf("Failed to update group list item %s after trip: %v", item.ListItemID.Hex(), err)
+		}
+	}
+}
+
+// StartTripHandler starts a shopping trip with a snapshot of the group list.
+// Items claimed by other members are left for them.
+func StartTripHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request StartTripRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	var store *models.Store
+	if request.StoreID != "" {
+		found, ok := findGroupStore(w, group.ID, request.StoreID)
+		if !ok {
+			return
+		}
+		store = &found
+	}
+
+	count, err := config.DB.Collection("shopping_trips").CountDocuments(
+		context.Background(),
+		bson.M{"group_id": group.ID, "shopper_id": user.ID, "status": models.TripStatusActive},
+	)
+	if err != nil {
+		http.Error(w, "Failed to check for active trips", http.StatusInternalServerError)
+		return
+	}
+	if count > 0 {
+		http.Error(w, "You already have a shopping trip in progress", http.StatusConflict)
+		return
+	}
+
+	cursor, err := config.DB.Collection("group_list").Find(
+		context.Background(),
+		bson.M{
+			"group_id": group.ID,
+			"$or": []bson.M{
+				{"claimed_by": bson.M{"$exists": false}},
+				{"claimed_by": user.ID},
+			},
+		},
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch group list items", http.StatusInternalServerError)
+		return
+	}
+	var listItems []models.GroupListItem
+	err = cursor.All(context.Background(), &listItems)
+	cursor.Close(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to decode group list items", http.StatusInternalServerError)
+		return
+	}
+
+	if request.ClaimItems == nil || *request.ClaimItems {
+		claimed := make([]models.GroupListItem, 0, len(listItems))
+		for _, listItem := range listItems {
+			updated, err := updateGroupListItem(
+				group.ID,
+				GroupListItemActionRequest{ItemID: listItem.ID.Hex()},
+				func(item *models.GroupListItem) error {
+					return item.Claim(user.ID, user.Name, time.Now())
+				},
+			)
+			if err != nil {
+				// Claimed or removed by someone else since the list was read
+				if !errors.Is(err, models.ErrListItemClaimed) && !errors.Is(err, mongo.ErrNoDocuments) {
+					log.Printf("Failed to claim group list item for trip: %v", err)
+				}
+				continue
+			}
+			claimed = append(claimed, updated)
+		}
+		listItems = claimed
+	}
+
+	if len(listItems) == 0 {
+		http.Error(w, "There is nothing on the group list to shop for", http.StatusBadRequest)
+		return
+	}
+
+	trip := models.CreateShoppingTrip(group.ID, user.ID, user.Name, store, listItems)
+	result, err := config.DB.Collection("shopping_trips").InsertOne(context.Background(), trip)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			http.Error(w, "You already have a shopping trip in progress", http.StatusConflict)
+			return
+		}
+		log.Printf("Shopping trip creation error: %v", err)
+		http.Error(w, "Failed to start shopping trip", http.StatusInternalServerError)
+		return
+	}
+	trip.ID = result.InsertedID.(primitive.ObjectID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(trip)
+}
+
+// GetActiveTripHandler returns the current user's trip in progress
+func GetActiveTripHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	var trip models.ShoppingTrip
+	err := config.DB.Collection("shopping_trips").FindOne(
+		context.Background(),
+		bson.M{"group_id": group.ID, "shopper_id": user.ID, "status": models.TripStatusActive},
+	).Decode(&trip)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "No shopping trip in progress", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch shopping trip", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trip)
+}
+
+// GetTripsHandler lists the group's shopping trips, newest first
+func GetTripsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	limit := int64(20)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit)
+	cursor, err := config.DB.Collection("shopping_trips").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		opts,
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch shopping trips", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	trips := make([]models.ShoppingTrip, 0)
+	if err = cursor.All(context.Background(), &trips); err != nil {
+		http.Error(w, "Failed to decode shopping trips", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trips)
+}
+
+// CheckTripItemHandler records what happened to an item during a trip.
+// Any group member can check items off, e.g. while on the phone with the shopper.
+func CheckTripItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request CheckTripItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	status, err := models.ParseTripItemStatus(request.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listItemID, err := primitive.ObjectIDFromHex(request.ListItemID)
+	if err != nil {
+		http.Error(w, "Invalid item ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	var checked models.TripItem
+	trip, err := updateShoppingTrip(group.ID, request.TripID, func(trip *models.ShoppingTrip) error {
+		item, err := trip.CheckOff(listItemID, status, request.Quantity, request.Substitute, user.ID, time.Now())
+		if err != nil {
+			return pantryRequestError{err.Error()}
+		}
+		checked = *item
+		return nil
+	})
+	if err != nil {
+		writeTripError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"item":    checked,
+		"version": trip.Version,
+	})
+}
+
+// CompleteTripHandler ends the shopper's trip, takes what was bought off the
+// group list and returns the trip summary
+func CompleteTripHandler(w http.ResponseWriter, r *http.Request) {
+	endTrip(w, r, false)
+}
+
+// CancelTripHandler abandons the shopper's trip and releases its claims on the group list
+func CancelTripHandler(w http.ResponseWriter, r *http.Request) {
+	endTrip(w, r, true)
+}
+
+// endTrip completes or cancels a trip; only the shopper can end it
+func endTrip(w http.ResponseWriter, r *http.Request, cancel bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request TripActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	trip, err := updateShoppingTrip(group.ID, request.TripID, func(trip *models.ShoppingTrip) error {
+		if trip.ShopperID != user.ID {
+			return pantryRequestError{"Only the shopper can end this trip"}
+		}
+
+		if cancel {
+			if err := trip.Cancel(time.Now()); err != nil {
+				return pantryRequestError{err.Error()}
+			}
+			return nil
+		}
+		if _, err := trip.Complete(time.Now()); err != nil {
+			return pantryRequestError{err.Error()}
+		}
+		return nil
+	})
+	if err != nil {
+		writeTripError(w, err)
+		return
+	}
+
+	releaseTripItems(trip)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trip)
+}
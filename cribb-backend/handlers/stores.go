@@ -0,0 +1,192 @@
+// handlers/stores.go
+package handlers
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveStoreRequest defines the request structure for creating or updating a store.
+// Aisles are listed in the order they're walked.
+type SaveStoreRequest struct {
+	StoreID string         `json:"store_id,omitempty"` // Set to update an existing store
+	Name    string         `json:"name"`
+	Aisles  []models.Aisle `json:"aisles"`
+}
+
+// findGroupStore looks up a store belonging to the group, writing the error response if it can't
+func findGroupStore(w http.ResponseWriter, groupID primitive.ObjectID, idStr string) (models.Store, bool) {
+	var store models.Store
+
+	storeID, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Invalid store ID format", http.StatusBadRequest)
+		return store, false
+	}
+
+	err = config.DB.Collection("stores").FindOne(
+		context.Background(),
+		bson.M{"_id": storeID, "group_id": groupID},
+	).Decode(&store)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Store not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to fetch store", http.StatusInternalServerError)
+		}
+		return store, false
+	}
+	return store, true
+}
+
+// SaveStoreHandler creates a store, or updates one when store_id is given
+func SaveStoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SaveStoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	store := models.CreateStore(group.ID, request.Name, request.Aisles, user.ID)
+	if err := store.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.StoreID == "" {
+		result, err := config.DB.Collection("stores").InsertOne(context.Background(), store)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				http.Error(w, "A store with this name already exists", http.StatusConflict)
+				return
+			}
+			log.Printf("Store creation error: %v", err)
+			http.Error(w, "Failed to create store", http.StatusInternalServerError)
+			return
+		}
+		store.ID = result.InsertedID.(primitive.ObjectID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(store)
+		return
+	}
+
+	existing, ok := findGroupStore(w, group.ID, request.StoreID)
+	if !ok {
+		return
+	}
+	store.ID = existing.ID
+	store.CreatedBy = existing.CreatedBy
+	store.CreatedAt = existing.CreatedAt
+	store.UpdatedAt = time.Now()
+
+	_, err := config.DB.Collection("stores").ReplaceOne(
+		context.Background(),
+		bson.M{"_id": store.ID},
+		store,
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			http.Error(w, "A store with this name already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Store update error: %v", err)
+		http.Error(w, "Failed to update store", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store)
+}
+
+// GetStoresHandler lists the group's stores by name
+func GetStoresHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := config.DB.Collection("stores").Find(
+		context.Background(),
+		bson.M{"group_id": group.ID},
+		opts,
+	)
+	if err != nil {
+		http.Error(w, "Failed to fetch stores", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	stores := make([]models.Store, 0)
+	if err = cursor.All(context.Background(), &stores); err != nil {
+		http.Error(w, "Failed to decode stores", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stores)
+}
+
+// DeleteStoreHandler removes a store; past trips keep the store's name
+func DeleteStoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get store ID from URL path
+	storeIDStr := strings.TrimPrefix(r.URL.Path, "/api/stores/remove/")
+
+	_, group, ok := currentUserGroup(w, r)
+	if !ok {
+		return
+	}
+
+	store, ok := findGroupStore(w, group.ID, storeIDStr)
+	if !ok {
+		return
+	}
+
+	_, err := config.DB.Collection("stores").DeleteOne(
+		context.Background(),
+		bson.M{"_id": store.ID},
+	)
+	if err != nil {
+		http.Error(w, "Failed to delete store", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Store deleted successfully",
+	})
+}
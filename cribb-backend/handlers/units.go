@@ -0,0 +1,24 @@
+// handlers/units.go
+package handlers
+
+import (
+	"cribb-backend/units"
+	"encoding/json"
+	"net/http"
+)
+
+// GetUnitsHandler lists the supported units of measure grouped by dimension
+func GetUnitsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	grouped := make(map[units.Dimension][]units.Unit)
+	for _, unit := range units.All() {
+		grouped[unit.Dimension] = append(grouped[unit.Dimension], unit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grouped)
+}
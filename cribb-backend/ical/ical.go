@@ -0,0 +1,134 @@
+// Package ical writes iCalendar (RFC 5545) feeds.
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+// Kind is the calendar component an item is written as
+type Kind string
+
+const (
+	// Event items show up on calendars at their due time
+	Event Kind = "VEVENT"
+
+	// Todo items show up in task lists with a due date
+	Todo Kind = "VTODO"
+)
+
+// eventDuration is how long an event lasts on the calendar
+const eventDuration = 30 * time.Minute
+
+// Item is one entry in a feed. Calendar apps replace an entry when a later
+// fetch has an item with the same UID.
+type Item struct {
+	UID          string
+	Summary      string
+	Description  string
+	Due          time.Time
+	Completed    *time.Time
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Calendar is a feed of items written as one kind of component
+type Calendar struct {
+	Name  string
+	Kind  Kind
+	Items []Item
+}
+
+// formatTime writes a UTC date-time
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line, folding it so no line is longer than
+// 75 octets without splitting a UTF-8 character
+func writeLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// isRuneStart checks if a byte begins a UTF-8 character
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+// Render writes the calendar, stamping each item with now
+func (c *Calendar) Render(now time.Time) string {
+	kind := c.Kind
+	if kind == "" {
+		kind = Event
+	}
+
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//Cribb//Chores//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+	writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeLine(&b, "X-PUBLISHED-TTL:PT1H")
+
+	for _, item := range c.Items {
+		writeLine(&b, "BEGIN:"+string(kind))
+		writeLine(&b, "UID:"+item.UID)
+		writeLine(&b, "DTSTAMP:"+formatTime(now))
+		if !item.Created.IsZero() {
+			writeLine(&b, "CREATED:"+formatTime(item.Created))
+		}
+		if !item.LastModified.IsZero() {
+			writeLine(&b, "LAST-MODIFIED:"+formatTime(item.LastModified))
+		}
+		writeLine(&b, "SUMMARY:"+escapeText(item.Summary))
+		if item.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(item.Description))
+		}
+
+		if kind == Todo {
+			writeLine(&b, "DUE:"+formatTime(item.Due))
+			if item.Completed != nil {
+				writeLine(&b, "STATUS:COMPLETED")
+				writeLine(&b, "COMPLETED:"+formatTime(*item.Completed))
+				writeLine(&b, "PERCENT-COMPLETE:100")
+			} else {
+				writeLine(&b, "STATUS:NEEDS-ACTION")
+			}
+		} else {
+			writeLine(&b, "DTSTART:"+formatTime(item.Due))
+			writeLine(&b, "DTEND:"+formatTime(item.Due.Add(eventDuration)))
+			writeLine(&b, "TRANSP:TRANSPARENT")
+		}
+		writeLine(&b, "END:"+string(kind))
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package ical_test
+
+import (
+	"cribb-backend/ical"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderEvents(t *testing.T) {
+	due := time.Date(2025, 3, 14, 17, 0, 0, 0, time.UTC)
+	cal := ical.Calendar{
+		Name: "Maple House chores",
+		Items: []ical.Item{{
+			UID:         "chore-1@cribb",
+			Summary:     "Dishes, pans; and cups",
+			Description: "Line one\nLine two",
+			Due:         due,
+		}},
+	}
+
+	out := cal.Render(due.Add(-time.Hour))
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Maple House chores\r\n",
+		"BEGIN:VEVENT\r\nUID:chore-1@cribb\r\nDTSTAMP:20250314T160000Z\r\n",
+		`SUMMARY:Dishes\, pans\; and cups` + "\r\n",
+		`DESCRIPTION:Line one\nLine two` + "\r\n",
+		"DTSTART:20250314T170000Z\r\nDTEND:20250314T173000Z\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected the calendar to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderTodos(t *testing.T) {
+	due := time.Date(2025, 3, 14, 17, 0, 0, 0, time.UTC)
+	done := due.Add(-2 * time.Hour)
+	cal := ical.Calendar{
+		Kind: ical.Todo,
+		Items: []ical.Item{
+			{UID: "a@cribb", Summary: "Trash", Due: due},
+			{UID: "b@cribb", Summary: "Dishes", Due: due, Completed: &done},
+		},
+	}
+
+	out := cal.Render(due)
+	if strings.Count(out, "BEGIN:VTODO") != 2 || strings.Contains(out, "VEVENT") {
+		t.Fatalf("Expected two todos:\n%s", out)
+	}
+	if !strings.Contains(out, "DUE:20250314T170000Z\r\nSTATUS:NEEDS-ACTION") {
+		t.Errorf("Expected an open todo with its due date:\n%s", out)
+	}
+	if !strings.Contains(out, "STATUS:COMPLETED\r\nCOMPLETED:20250314T150000Z") {
+		t.Errorf("Expected a completed todo:\n%s", out)
+	}
+}
+
+func TestRenderFoldsLongLines(t *testing.T) {
+	cal := ical.Calendar{Items: []ical.Item{{
+		UID:     "long@cribb",
+		Summary: strings.Repeat("é", 100), // Two octets each
+		Due:     time.Now(),
+	}}}
+
+	out := cal.Render(time.Now())
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+strings.Repeat("é", 100)) {
+		t.Errorf("Expected folding to keep characters whole:\n%s", out)
+	}
+	for _, line := range strings.Split(out, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines of at most 75 octets, got %d: %q", len(line), line)
+		}
+	}
+}
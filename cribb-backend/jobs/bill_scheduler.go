@@ -0,0 +1,246 @@
+// jobs/bill_scheduler.go
+package jobs
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StartBillScheduler initializes and starts the recurring bill scheduler
+func StartBillScheduler() {
+	log.Println("Starting bill scheduler...")
+
+	// Run the scheduler every hour
+	ticker := time.NewTicker(1 * time.Hour)
+
+	// Run immediately once at startup
+	go runBillJobs()
+
+	// Then run on the schedule
+	go func() {
+		for range ticker.C {
+			runBillJobs()
+		}
+	}()
+}
+
+// runBillJobs generates upcoming bills before reminding members about them
+func runBillJobs() {
+	processRecurringBills()
+	sendBillReminders()
+	detectOverdueBills()
+}
+
+// processRecurringBills creates bill instances for recurring bills coming due
+func processRecurringBills() {
+	log.Println("Processing recurring bills...")
+
+	now := time.Now()
+	horizon := models.PlanDay(now).AddDate(0, 0, models.BillGenerateDaysAhead)
+	cursor, err := config.DB.Collection("recurring_bills").Find(
+		context.Background(),
+		bson.M{
+			"is_active":     true,
+			"next_due_date": bson.M{"$lte": horizon},
+		},
+	)
+
+	if err != nil {
+		log.Printf("Error finding recurring bills: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var recurringBills []models.RecurringBill
+	if err = cursor.All(context.Background(), &recurringBills); err != nil {
+		log.Printf("Error decoding recurring bills: %v", err)
+		return
+	}
+
+	for _, recurringBill := range recurringBills {
+		// Start a session for each recurring bill
+		session, err := config.DB.Client().StartSession()
+		if err != nil {
+			log.Printf("Error starting session for recurring bill %s: %v", recurringBill.ID.Hex(), err)
+			continue
+		}
+
+		// Use a closure to handle the session
+		func(s mongo.Session, rb models.RecurringBill) {
+			defer s.EndSession(context.Background())
+
+			// Execute in a transaction
+			_, err := s.WithTransaction(context.Background(), func(ctx mongo.SessionContext) (interface{}, error) {
+				// Get fresh copy of recurring bill to avoid race conditions
+				var freshBill models.RecurringBill
+				err := config.DB.Collection("recurring_bills").FindOne(
+					ctx,
+					bson.M{"_id": rb.ID},
+				).Decode(&freshBill)
+
+				if err != nil {
+					return nil, err
+				}
+
+				// If someone else already processed this or it's not active anymore, skip
+				if freshBill.NextDueDate.After(horizon) || !freshBill.IsActive {
+					return nil, nil
+				}
+
+				// Create the instance for the next due date
+				instance := models.CreateBillInstance(&freshBill, freshBill.NextDueDate)
+				_, err = config.DB.Collection("bill_instances").InsertOne(ctx, instance)
+				if err != nil {
+					return nil, err
+				}
+
+				_, err = config.DB.Collection("recurring_bills").UpdateOne(
+					ctx,
+					bson.M{"_id": freshBill.ID},
+					bson.M{
+						"$set": bson.M{
+							"next_due_date": freshBill.DueDateAfter(freshBill.NextDueDate),
+							"updated_at":    time.Now(),
+						},
+					},
+				)
+
+				if err != nil {
+					return nil, err
+				}
+
+				log.Printf("Created bill instance from recurring bill %s due %s", freshBill.ID.Hex(), instance.DueDate.Format("2006-01-02"))
+				return nil, nil
+			})
+
+			if err != nil {
+				log.Printf("Error processing recurring bill %s: %v", rb.ID.Hex(), err)
+			}
+		}(session, recurringBill)
+	}
+
+	log.Printf("Processed %d recurring bills", len(recurringBills))
+}
+
+// sendBillReminders reminds members with unpaid shares of bills coming due
+func sendBillReminders() {
+	now := time.Now()
+	cursor, err := config.DB.Collection("bill_instances").Find(
+		context.Background(),
+		bson.M{
+			"status":           models.BillStatusPending,
+			"reminder_sent_at": bson.M{"$exists": false},
+			// No reminder window is longer than a month
+			"due_date": bson.M{"$lte": models.PlanDay(now).AddDate(0, 1, 0)},
+		},
+	)
+	if err != nil {
+		log.Printf("Error finding bills to remind about: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var instances []models.BillInstance
+	if err = cursor.All(context.Background(), &instances); err != nil {
+		log.Printf("Error decoding bill instances: %v", err)
+		return
+	}
+
+	reminded := 0
+	for i := range instances {
+		if !instances[i].ReminderDue(now) {
+			continue
+		}
+		if notifyUnpaidShares(&instances[i], models.BillReminderTypeDueSoon, "reminder_sent_at", now) {
+			reminded++
+		}
+	}
+
+	if reminded > 0 {
+		log.Printf("Sent reminders for %d bills", reminded)
+	}
+}
+
+// detectOverdueBills marks bills overdue once their due date has passed and tells members who haven't paid
+func detectOverdueBills() {
+	now := time.Now()
+	cursor, err := config.DB.Collection("bill_instances").Find(
+		context.Background(),
+		bson.M{
+			"status":   models.BillStatusPending,
+			"due_date": bson.M{"$lt": models.PlanDay(now)},
+		},
+	)
+	if err != nil {
+		log.Printf("Error finding overdue bills: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var instances []models.BillInstance
+	if err = cursor.All(context.Background(), &instances); err != nil {
+		log.Printf("Error decoding bill instances: %v", err)
+		return
+	}
+
+	overdue := 0
+	for i := range instances {
+		if !instances[i].IsOverdue(now) {
+			continue
+		}
+
+		// Only the run that flips the status sends the overdue reminders
+		result, err := config.DB.Collection("bill_instances").UpdateOne(
+			context.Background(),
+			bson.M{"_id": instances[i].ID, "status": models.BillStatusPending},
+			bson.M{"$set": bson.M{"status": models.BillStatusOverdue, "updated_at": now}},
+		)
+		if err != nil {
+			log.Printf("Error marking bill %s overdue: %v", instances[i].ID.Hex(), err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		notifyUnpaidShares(&instances[i], models.BillReminderTypeOverdue, "overdue_notified_at", now)
+		overdue++
+	}
+
+	if overdue > 0 {
+		log.Printf("Marked %d bills as overdue", overdue)
+	}
+}
+
+// notifyUnpaidShares creates a reminder for each member who hasn't paid and
+// records when it was sent in the given field. It returns false if another
+// run already sent it.
+func notifyUnpaidShares(instance *models.BillInstance, reminderType models.BillReminderType, sentField string, now time.Time) bool {
+	result, err := config.DB.Collection("bill_instances").UpdateOne(
+		context.Background(),
+		bson.M{"_id": instance.ID, sentField: bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{sentField: now}},
+	)
+	if err != nil {
+		log.Printf("Error recording bill reminder for %s: %v", instance.ID.Hex(), err)
+		return false
+	}
+	if result.ModifiedCount == 0 {
+		return false
+	}
+
+	for _, share := range instance.UnpaidShares() {
+		reminder := models.CreateBillReminder(instance, share, reminderType)
+		_, err := config.DB.Collection("bill_reminders").InsertOne(context.Background(), reminder)
+		if err != nil {
+			log.Printf("Failed to create bill reminder: %v", err)
+		}
+	}
+	return true
+}
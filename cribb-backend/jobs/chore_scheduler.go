@@ -4,11 +4,14 @@ package jobs
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
 	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -70,6 +73,9 @@ func processRecurringChores() {
 		func(s mongo.Session, rc models.RecurringChore) {
 			defer s.EndSession(context.Background())
 
+			// Set by the transaction, published once it commits
+			var created *models.Chore
+
 			// Execute in a transaction
 			_, err := s.WithTransaction(context.Background(), func(ctx mongo.SessionContext) (interface{}, error) {
 				// Get fresh copy of recurring chore to avoid race conditions
@@ -90,10 +96,12 @@ func processRecurringChores() {
 
 				// Create a new chore instance
 				newChore := models.CreateChoreFromRecurring(&freshRC)
-				_, err = config.DB.Collection("chores").InsertOne(ctx, newChore)
+				result, err := config.DB.Collection("chores").InsertOne(ctx, newChore)
 				if err != nil {
 					return nil, err
 				}
+				newChore.ID = result.InsertedID.(primitive.ObjectID)
+				created = newChore
 
 				// Calculate next assignment date
 				var nextAssignment time.Time
@@ -132,6 +140,9 @@ func processRecurringChores() {
 
 			if err != nil {
 				log.Printf("Error processing recurring chore %s: %v", rc.ID.Hex(), err)
+			} else if created != nil {
+				events.Publish(created.GroupID, events.ChoreAssigned, primitive.NilObjectID, created)
+				notifications.Notify(models.CreateChoreAssignedNotification(created))
 			}
 		}(session, recurringChore)
 	}
@@ -154,28 +165,55 @@ func detectOverdueChores() {
 	// for due dates less than the start of *yesterday*.
 	startOfYesterdayUTC := startOfTodayUTC.AddDate(0, 0, -1)
 
-	result, err := config.DB.Collection("chores").UpdateMany(
+	cursor, err := config.DB.Collection("chores").Find(
 		context.Background(),
 		bson.M{
 			"status": models.ChoreStatusPending,
 			// Due date is strictly less than the start of yesterday UTC
 			"due_date": bson.M{"$lt": startOfYesterdayUTC},
 		},
-		bson.M{
-			"$set": bson.M{
-				"status":     models.ChoreStatusOverdue,
-				"updated_at": time.Now(),
-			},
-		},
 	)
-
 	if err != nil {
-		log.Printf("Error updating overdue chores: %v", err)
+		log.Printf("Error fetching overdue chores: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var chores []models.Chore
+	if err = cursor.All(context.Background(), &chores); err != nil {
+		log.Printf("Error decoding overdue chores: %v", err)
 		return
 	}
 
-	if result.ModifiedCount > 0 {
-		log.Printf("Marked %d chores as overdue", result.ModifiedCount)
+	marked := 0
+	for _, chore := range chores {
+		// Only still-pending chores are marked, so each assignee is told once
+		result, err := config.DB.Collection("chores").UpdateOne(
+			context.Background(),
+			bson.M{"_id": chore.ID, "status": models.ChoreStatusPending},
+			bson.M{
+				"$set": bson.M{
+					"status":     models.ChoreStatusOverdue,
+					"updated_at": time.Now(),
+				},
+			},
+		)
+		if err != nil {
+			log.Printf("Error updating overdue chore %s: %v", chore.ID.Hex(), err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		marked++
+		chore.Status = models.ChoreStatusOverdue
+		notifications.Notify(models.CreateChoreOverdueNotification(&chore))
+		events.Publish(chore.GroupID, events.ChoreOverdue, primitive.NilObjectID, chore)
+	}
+
+	if marked > 0 {
+		log.Printf("Marked %d chores as overdue", marked)
 	} else {
 		log.Printf("No overdue chores found")
 	}
@@ -0,0 +1,294 @@
+// jobs/digest.go
+package jobs
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/digest"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// digestExpiringDays is how far ahead a digest looks for expiring pantry items
+const digestExpiringDays = 3
+
+// StartDigestJob initializes and starts sending household digests
+func StartDigestJob() {
+	log.Println("Starting digest job...")
+
+	// Users pick their own digest time, so check often
+	ticker := time.NewTicker(models.DigestSendWindow)
+
+	// Run immediately once at startup
+	go sendDigests()
+
+	// Then run on the schedule
+	go func() {
+		for range ticker.C {
+			sendDigests()
+		}
+	}()
+}
+
+// sendDigests queues a digest for every group member whose digest time has come
+func sendDigests() {
+	ctx := context.Background()
+	now := time.Now()
+
+	cursor, err := config.DB.Collection("users").Find(
+		ctx,
+		bson.M{"group_id": bson.M{"$exists": true, "$ne": primitive.NilObjectID}},
+		options.Find().SetProjection(bson.M{"_id": 1, "name": 1, "phone_number": 1, "group_id": 1}),
+	)
+	if err != nil {
+		log.Printf("Error finding digest recipients: %v", err)
+		return
+	}
+	var users []models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		log.Printf("Error decoding digest recipients: %v", err)
+		return
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	preferences, err := notifications.LoadPreferences(ctx, userIDs)
+	if err != nil {
+		log.Printf("Error loading notification preferences: %v", err)
+		return
+	}
+
+	groupNames := make(map[primitive.ObjectID]string)
+	sent := 0
+	for _, user := range users {
+		prefs := preferences[user.ID]
+		if !prefs.Digest.IsDue(now, prefs.Location()) {
+			// Saved preferences that never had a digest start counting from
+			// the last scheduled time, so the next one goes out on schedule
+			if prefs.Digest.LastSentAt == nil && prefs.Digest.Frequency != models.DigestOff && !prefs.ID.IsZero() {
+				if scheduled := prefs.Digest.LastScheduled(now, prefs.Location()); !scheduled.IsZero() {
+					if err := markDigestSent(ctx, prefs, scheduled); err != nil {
+						log.Printf("Error recording digest schedule for user %s: %v", user.ID.Hex(), err)
+					}
+				}
+			}
+			continue
+		}
+
+		groupName, ok := groupNames[user.GroupID]
+		if !ok {
+			var group models.Group
+			err := config.DB.Collection("groups").FindOne(
+				ctx,
+				bson.M{"_id": user.GroupID},
+				options.FindOne().SetProjection(bson.M{"name": 1}),
+			).Decode(&group)
+			if err != nil {
+				log.Printf("Error finding group %s for digest: %v", user.GroupID.Hex(), err)
+				continue
+			}
+			groupName = group.Name
+			groupNames[user.GroupID] = groupName
+		}
+
+		queued, err := sendDigest(ctx, user, groupName, prefs, now)
+		if err != nil {
+			log.Printf("Error sending digest to user %s: %v", user.ID.Hex(), err)
+			continue
+		}
+		if queued {
+			sent++
+		}
+
+		// Empty digests count as sent too, so they aren't rebuilt every run
+		if err := markDigestSent(ctx, prefs, now); err != nil {
+			log.Printf("Error recording digest for user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	if sent > 0 {
+		log.Printf("Queued %d digest(s)", sent)
+	}
+}
+
+// sendDigest builds the user's digest and queues it on their digest channel.
+// It reports whether anything was queued.
+func sendDigest(ctx context.Context, user models.User, groupName string, prefs *models.NotificationPreferences, now time.Time) (bool, error) {
+	d, err := buildDigest(ctx, user, groupName, prefs, now)
+	if err != nil {
+		return false, err
+	}
+	if d.IsEmpty() {
+		return false, nil
+	}
+
+	targets, err := notifications.Targets(ctx, user, prefs, prefs.Digest.Channel)
+	if err != nil {
+		return false, err
+	}
+	if len(targets) == 0 {
+		return false, nil
+	}
+
+	rendered, err := digest.Render(d)
+	if err != nil {
+		return false, err
+	}
+
+	messages := make([]interface{}, 0, len(targets))
+	for _, target := range targets {
+		message := models.CreateOutboxMessage(user.ID, prefs.Digest.Channel, target, rendered.Subject, rendered.Text, now)
+		message.HTMLBody = rendered.HTML
+		messages = append(messages, message)
+	}
+	if _, err := config.DB.Collection(notifications.OutboxCollection).InsertMany(ctx, messages); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// buildDigest gathers the user's chores, the group's pantry and recent cart
+// activity. Sections for notification types the user turned off are left out.
+func buildDigest(ctx context.Context, user models.User, groupName string, prefs *models.NotificationPreferences, now time.Time) (*models.Digest, error) {
+	since := now.Add(-prefs.Digest.Period())
+	if last := prefs.Digest.LastSentAt; last != nil && last.After(since) {
+		since = *last
+	}
+
+	d := &models.Digest{
+		UserName:    user.Name,
+		GroupName:   groupName,
+		Frequency:   prefs.Digest.Frequency,
+		Since:       since,
+		GeneratedAt: now,
+		Location:    prefs.Location(),
+	}
+
+	if prefs.Wants(models.NotificationTypeChoreAssigned) || prefs.Wants(models.NotificationTypeChoreOverdue) {
+		cursor, err := config.DB.Collection("chores").Find(
+			ctx,
+			bson.M{
+				"assigned_to": user.ID,
+				"status":      bson.M{"$ne": models.ChoreStatusCompleted},
+				"due_date":    bson.M{"$lte": now.AddDate(0, 0, 7)},
+			},
+			options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var chores []models.Chore
+		if err = cursor.All(ctx, &chores); err != nil {
+			return nil, err
+		}
+
+		for _, chore := range chores {
+			entry := models.DigestChore{Title: chore.Title, DueDate: chore.DueDate, Points: chore.Points}
+			if chore.Status == models.ChoreStatusOverdue || chore.DueDate.Before(now) {
+				if prefs.Wants(models.NotificationTypeChoreOverdue) {
+					d.OverdueChores = append(d.OverdueChores, entry)
+				}
+			} else if prefs.Wants(models.NotificationTypeChoreAssigned) {
+				d.ChoresDue = append(d.ChoresDue, entry)
+			}
+		}
+	}
+
+	if prefs.Wants(models.NotificationTypeExpiringSoon) || prefs.Wants(models.NotificationTypeLowStock) {
+		cursor, err := config.DB.Collection("pantry_items").Find(
+			ctx,
+			bson.M{"group_id": user.GroupID},
+			options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.PantryItem
+		if err = cursor.All(ctx, &items); err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			item.EnsureBatches()
+
+			if prefs.Wants(models.NotificationTypeExpiringSoon) {
+				if batches := item.ExpiringBatches(digestExpiringDays); len(batches) > 0 {
+					entry := models.DigestPantryItem{Name: item.Name, Unit: item.Unit, ExpirationDate: batches[0].ExpirationDate}
+					for _, batch := range batches {
+						entry.Quantity += batch.Quantity
+						if batch.ExpirationDate.Before(entry.ExpirationDate) {
+							entry.ExpirationDate = batch.ExpirationDate
+						}
+					}
+					d.ExpiringItems = append(d.ExpiringItems, entry)
+				}
+			}
+
+			if prefs.Wants(models.NotificationTypeLowStock) && (item.IsLowStock() || item.IsOutOfStock()) {
+				d.LowStockItems = append(d.LowStockItems, models.DigestPantryItem{
+					Name:     item.Name,
+					Quantity: item.Quantity,
+					Unit:     item.Unit,
+				})
+			}
+		}
+		sort.Slice(d.ExpiringItems, func(i, j int) bool {
+			return d.ExpiringItems[i].ExpirationDate.Before(d.ExpiringItems[j].ExpirationDate)
+		})
+	}
+
+	if prefs.Wants(models.NotificationTypeCartChanged) {
+		cursor, err := config.DB.Collection("shopping_cart_activity").Find(
+			ctx,
+			bson.M{
+				"group_id":   user.GroupID,
+				"user_id":    bson.M{"$ne": user.ID},
+				"created_at": bson.M{"$gt": since},
+			},
+			options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var activities []models.ShoppingCartActivity
+		if err = cursor.All(ctx, &activities); err != nil {
+			return nil, err
+		}
+
+		for _, activity := range activities {
+			details := activity.Details
+			if details == "" {
+				details = "changed " + activity.ItemName
+			}
+			d.CartActivity = append(d.CartActivity, models.DigestActivity{
+				UserName:  activity.UserName,
+				ItemName:  activity.ItemName,
+				Details:   details,
+				CreatedAt: activity.CreatedAt,
+			})
+		}
+	}
+
+	return d, nil
+}
+
+// markDigestSent records when the user's digest went out. Users who never
+// saved preferences have nowhere to record it; their digests are only sent
+// in the window after the scheduled time.
+func markDigestSent(ctx context.Context, prefs *models.NotificationPreferences, sentAt time.Time) error {
+	_, err := config.DB.Collection(notifications.PreferencesCollection).UpdateOne(
+		ctx,
+		bson.M{"user_id": prefs.UserID},
+		bson.M{"$set": bson.M{"digest.last_sent_at": sentAt}},
+	)
+	return err
+}
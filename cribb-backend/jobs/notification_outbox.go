@@ -0,0 +1,122 @@
+// jobs/notification_outbox.go
+package jobs
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/delivery"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxBatchSize caps how many messages one run sends
+const outboxBatchSize = 100
+
+// StartOutboxWorker initializes and starts sending queued notifications
+func StartOutboxWorker(dispatcher *delivery.Dispatcher) {
+	log.Println("Starting notification outbox worker...")
+
+	// Check the outbox every 30 seconds
+	ticker := time.NewTicker(30 * time.Second)
+
+	// Run immediately once at startup
+	go processOutbox(dispatcher)
+
+	// Then run on the schedule
+	go func() {
+		for range ticker.C {
+			processOutbox(dispatcher)
+		}
+	}()
+}
+
+// claimOutboxMessage takes the next due message. A claimed message is pushed
+// into the future by the claim timeout, so it comes due again if this worker
+// dies before recording the result.
+func claimOutboxMessage(now time.Time) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	err := config.DB.Collection(notifications.OutboxCollection).FindOneAndUpdate(
+		context.Background(),
+		bson.M{
+			"status":          bson.M{"$in": []models.OutboxStatus{models.OutboxStatusPending, models.OutboxStatusSending}},
+			"next_attempt_at": bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{
+			"status":          models.OutboxStatusSending,
+			"next_attempt_at": now.Add(models.OutboxClaimTimeout),
+		}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&message)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// processOutbox sends due messages and records each result
+func processOutbox(dispatcher *delivery.Dispatcher) {
+	sent, failed := 0, 0
+	for i := 0; i < outboxBatchSize; i++ {
+		now := time.Now()
+		message, err := claimOutboxMessage(now)
+		if err != nil {
+			log.Printf("Error claiming outbox message: %v", err)
+			return
+		}
+		if message == nil {
+			break
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = dispatcher.Send(ctx, delivery.MessageFromOutbox(message))
+		cancel()
+
+		if err == nil {
+			message.MarkSent(time.Now())
+			sent++
+		} else {
+			message.RecordFailure(err, delivery.IsPermanent(err), time.Now())
+			if message.Status == models.OutboxStatusFailed {
+				failed++
+				log.Printf("Giving up on %s message %s after %d attempt(s): %v",
+					message.Channel, message.ID.Hex(), message.Attempts, err)
+			}
+
+			// The browser unsubscribed; stop pushing to it
+			if errors.Is(err, delivery.ErrSubscriptionGone) {
+				_, delErr := config.DB.Collection(notifications.PushSubscriptionsCollection).DeleteOne(
+					context.Background(),
+					bson.M{"endpoint": message.Target.Address},
+				)
+				if delErr != nil {
+					log.Printf("Error removing expired push subscription: %v", delErr)
+				}
+			}
+		}
+
+		_, err = config.DB.Collection(notifications.OutboxCollection).ReplaceOne(
+			context.Background(),
+			bson.M{"_id": message.ID},
+			message,
+		)
+		if err != nil {
+			log.Printf("Error recording outbox result for %s: %v", message.ID.Hex(), err)
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		log.Printf("Outbox: sent %d message(s), %d failed", sent, failed)
+	}
+}
@@ -0,0 +1,150 @@
+// jobs/pantry_forecast.go
+package jobs
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// RunOutWarningDays is how far ahead a predicted run-out triggers a notification
+	RunOutWarningDays = 3
+
+	// ShoppingHorizonDays is how many days of predicted usage the shopping list covers
+	ShoppingHorizonDays = 7
+)
+
+// ForecastGroupPantry predicts consumption for every item in a group's pantry
+func ForecastGroupPantry(groupID primitive.ObjectID) ([]models.PantryForecast, error) {
+	now := time.Now()
+
+	cursor, err := config.DB.Collection("pantry_items").Find(
+		context.Background(),
+		bson.M{"group_id": groupID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var items []models.PantryItem
+	if err = cursor.All(context.Background(), &items); err != nil {
+		return nil, err
+	}
+
+	// Load the group's recent usage in one query and split it up by item
+	historyCursor, err := config.DB.Collection("pantry_history").Find(
+		context.Background(),
+		bson.M{
+			"group_id":   groupID,
+			"action":     models.ActionTypeUse,
+			"created_at": bson.M{"$gte": now.AddDate(0, 0, -models.ForecastWindowDays)},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer historyCursor.Close(context.Background())
+
+	var history []models.PantryHistory
+	if err = historyCursor.All(context.Background(), &history); err != nil {
+		return nil, err
+	}
+
+	historyByItem := make(map[primitive.ObjectID][]models.PantryHistory)
+	for _, record := range history {
+		historyByItem[record.ItemID] = append(historyByItem[record.ItemID], record)
+	}
+
+	forecasts := make([]models.PantryForecast, 0, len(items))
+	for _, item := range items {
+		forecasts = append(forecasts, models.ForecastConsumption(item, historyByItem[item.ID], now))
+	}
+
+	return forecasts, nil
+}
+
+// checkPredictedRunOuts warns groups about items predicted to run out soon
+func checkPredictedRunOuts() {
+	log.Println("Checking pantry consumption forecasts...")
+	now := time.Now()
+
+	groupIDs, err := config.DB.Collection("pantry_items").Distinct(
+		context.Background(),
+		"group_id",
+		bson.M{"quantity": bson.M{"$gt": 0}},
+	)
+	if err != nil {
+		log.Printf("Error finding groups with pantry items: %v", err)
+		return
+	}
+
+	warned := 0
+	for _, rawID := range groupIDs {
+		groupID, ok := rawID.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+
+		forecasts, err := ForecastGroupPantry(groupID)
+		if err != nil {
+			log.Printf("Error forecasting pantry for group %s: %v", groupID.Hex(), err)
+			continue
+		}
+
+		for _, forecast := range forecasts {
+			if forecast.CurrentQuantity <= 0 || !forecast.RunsOutWithin(RunOutWarningDays) {
+				continue
+			}
+
+			// Check if a notification already exists for this item
+			count, err := config.DB.Collection("pantry_notifications").CountDocuments(
+				context.Background(),
+				bson.M{
+					"item_id": forecast.ItemID,
+					"type":    models.NotificationTypeRunningOutSoon,
+					"created_at": bson.M{
+						"$gte": now.AddDate(0, 0, -RunOutWarningDays),
+					},
+				},
+			)
+			if err != nil {
+				log.Printf("Error checking existing notifications: %v", err)
+				continue
+			}
+			if count > 0 {
+				continue
+			}
+
+			notification := models.CreatePantryNotification(
+				groupID,
+				forecast.ItemID,
+				forecast.ItemName,
+				models.NotificationTypeRunningOutSoon,
+				fmt.Sprintf("Item is predicted to run out by %s", forecast.PredictedRunOut.Format("Mon Jan 2")),
+			)
+
+			_, err = config.DB.Collection("pantry_notifications").InsertOne(
+				context.Background(),
+				notification,
+			)
+			if err != nil {
+				log.Printf("Error creating running out notification: %v", err)
+			} else {
+				notifications.Notify(models.NotificationFromPantry(notification))
+				warned++
+				log.Printf("Created running out notification for item: %s", forecast.ItemName)
+			}
+		}
+	}
+
+	log.Printf("Completed forecast check, created %d running out notifications", warned)
+}
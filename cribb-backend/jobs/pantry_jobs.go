@@ -4,8 +4,12 @@ package jobs
 import (
 	"context"
 	"cribb-backend/config"
+	"cribb-backend/events"
 	"cribb-backend/models"
+	"cribb-backend/notifications"
+	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -23,32 +27,33 @@ func StartPantryJobs() {
 	// Run immediately once at startup
 	go checkExpiringItems()
 	go checkLowStockItems()
+	go checkPredictedRunOuts()
 
 	// Then run on the schedule
 	go func() {
 		for range ticker.C {
 			checkExpiringItems()
 			checkLowStockItems()
+			checkPredictedRunOuts()
 		}
 	}()
 }
 
-// checkExpiringItems looks for items that will expire soon and creates notifications
+// checkExpiringItems looks for batches that will expire soon or have expired and creates notifications
 func checkExpiringItems() {
 	log.Println("Checking for expiring pantry items...")
 
-	// Find items that will expire in the next 3 days
+	// Find items with a batch expiring in the next 3 days or already expired.
+	// Items stored before batches existed only carry the item-level expiration date.
 	now := time.Now()
 	expirationThreshold := now.AddDate(0, 0, 3)
 
-	// Find items that will expire soon but haven't been marked yet
-	// (no existing notification of type expiring_soon)
 	cursor, err := config.DB.Collection("pantry_items").Find(
 		context.Background(),
 		bson.M{
-			"expiration_date": bson.M{
-				"$gte": now,
-				"$lte": expirationThreshold,
+			"$or": []bson.M{
+				{"batches.expiration_date": bson.M{"$lte": expirationThreshold}},
+				{"expiration_date": bson.M{"$lte": expirationThreshold}},
 			},
 		},
 	)
@@ -59,120 +64,89 @@ func checkExpiringItems() {
 	}
 	defer cursor.Close(context.Background())
 
-	var expiringItems []models.PantryItem
-	if err = cursor.All(context.Background(), &expiringItems); err != nil {
+	var items []models.PantryItem
+	if err = cursor.All(context.Background(), &items); err != nil {
 		log.Printf("Error decoding expiring items: %v", err)
 		return
 	}
 
-	// Process each item and create notifications if needed
-	for _, item := range expiringItems {
-		// Check if a notification already exists for this item
-		count, err := config.DB.Collection("pantry_notifications").CountDocuments(
-			context.Background(),
-			bson.M{
-				"item_id": item.ID,
-				"type":    models.NotificationTypeExpiringSoon,
-				"created_at": bson.M{
-					"$gte": now.AddDate(0, 0, -3), // Only check for notifications in the last 3 days
-				},
-			},
-		)
+	expiringCount, expiredCount := 0, 0
+	for _, item := range items {
+		item.EnsureBatches()
 
-		if err != nil {
-			log.Printf("Error checking existing notifications: %v", err)
-			continue
+		for _, batch := range item.ExpiringBatches(3) {
+			expiringCount++
+			notifyBatchExpiration(item, batch, models.NotificationTypeExpiringSoon, now)
 		}
 
-		// If no notification exists, create one
-		if count == 0 {
-			notification := models.CreatePantryNotification(
-				item.GroupID,
-				item.ID,
-				item.Name,
-				models.NotificationTypeExpiringSoon,
-				"Item will expire in 3 days or less",
-			)
-
-			_, err = config.DB.Collection("pantry_notifications").InsertOne(
-				context.Background(),
-				notification,
-			)
-
-			if err != nil {
-				log.Printf("Error creating expiration notification: %v", err)
-			} else {
-				log.Printf("Created expiration notification for item: %s", item.Name)
-			}
+		for _, batch := range item.ExpiredBatches() {
+			expiredCount++
+			notifyBatchExpiration(item, batch, models.NotificationTypeExpired, now)
 		}
 	}
 
-	// Also check for already expired items
-	cursor, err = config.DB.Collection("pantry_items").Find(
-		context.Background(),
-		bson.M{
-			"expiration_date": bson.M{
-				"$lt": now,
-			},
+	log.Printf("Completed expiring items check, found %d expiring and %d expired batches",
+		expiringCount, expiredCount)
+}
+
+// notifyBatchExpiration creates an expiration notification for a batch unless one was created recently
+func notifyBatchExpiration(item models.PantryItem, batch models.PantryBatch, notificationType models.NotificationType, now time.Time) {
+	// Check if a notification already exists for this batch
+	filter := bson.M{
+		"item_id": item.ID,
+		"type":    notificationType,
+		"created_at": bson.M{
+			"$gte": now.AddDate(0, 0, -3), // Only check for notifications in the last 3 days
 		},
-	)
+	}
+	if batch.ID == item.ID {
+		// Legacy single batch, notifications may predate batch IDs
+		filter["batch_id"] = bson.M{"$in": []interface{}{batch.ID, nil}}
+	} else {
+		filter["batch_id"] = batch.ID
+	}
 
+	count, err := config.DB.Collection("pantry_notifications").CountDocuments(context.Background(), filter)
 	if err != nil {
-		log.Printf("Error finding expired items: %v", err)
+		log.Printf("Error checking existing notifications: %v", err)
 		return
 	}
-	defer cursor.Close(context.Background())
-
-	var expiredItems []models.PantryItem
-	if err = cursor.All(context.Background(), &expiredItems); err != nil {
-		log.Printf("Error decoding expired items: %v", err)
+	if count > 0 {
 		return
 	}
 
-	// Process each expired item
-	for _, item := range expiredItems {
-		// Check if a notification already exists for this item
-		count, err := config.DB.Collection("pantry_notifications").CountDocuments(
-			context.Background(),
-			bson.M{
-				"item_id": item.ID,
-				"type":    models.NotificationTypeExpired,
-				"created_at": bson.M{
-					"$gte": now.AddDate(0, 0, -3), // Only check for notifications in the last 3 days
-				},
-			},
-		)
-
-		if err != nil {
-			log.Printf("Error checking existing notifications: %v", err)
-			continue
-		}
+	var message string
+	switch {
+	case notificationType == models.NotificationTypeExpired && len(item.Batches) > 1:
+		message = fmt.Sprintf("A batch of %g %s has expired", batch.Quantity, item.Unit)
+	case notificationType == models.NotificationTypeExpired:
+		message = "Item has expired"
+	case len(item.Batches) > 1:
+		message = fmt.Sprintf("A batch of %g %s will expire in 3 days or less", batch.Quantity, item.Unit)
+	default:
+		message = "Item will expire in 3 days or less"
+	}
 
-		// If no notification exists, create one
-		if count == 0 {
-			notification := models.CreatePantryNotification(
-				item.GroupID,
-				item.ID,
-				item.Name,
-				models.NotificationTypeExpired,
-				"Item has expired",
-			)
+	notification := models.CreatePantryNotification(
+		item.GroupID,
+		item.ID,
+		item.Name,
+		notificationType,
+		message,
+	)
+	notification.BatchID = batch.ID
 
-			_, err = config.DB.Collection("pantry_notifications").InsertOne(
-				context.Background(),
-				notification,
-			)
+	_, err = config.DB.Collection("pantry_notifications").InsertOne(
+		context.Background(),
+		notification,
+	)
 
-			if err != nil {
-				log.Printf("Error creating expired notification: %v", err)
-			} else {
-				log.Printf("Created expired notification for item: %s", item.Name)
-			}
-		}
+	if err != nil {
+		log.Printf("Error creating %s notification: %v", notificationType, err)
+	} else {
+		notifications.Notify(models.NotificationFromPantry(notification))
+		log.Printf("Created %s notification for item: %s", notificationType, item.Name)
 	}
-
-	log.Printf("Completed expiring items check, found %d expiring and %d expired items",
-		len(expiringItems), len(expiredItems))
 }
 
 // checkLowStockItems looks for items that are running low and creates notifications
@@ -248,6 +222,8 @@ func checkLowStockItems() {
 					if err != nil {
 						log.Printf("Error creating out of stock notification: %v", err)
 					} else {
+						notifications.Notify(models.NotificationFromPantry(notification))
+						events.Publish(item.GroupID, events.PantryOutOfStock, primitive.NilObjectID, item)
 						log.Printf("Created out of stock notification for item: %s", item.Name)
 					}
 				}
@@ -257,15 +233,18 @@ func checkLowStockItems() {
 		}
 	}
 
-	// Then handle low stock items (but exclude items with quantity 0)
-	lowStockThreshold := 1.0 // Setting a fixed threshold for simplicity
-
+	// Then handle low stock items (but exclude items with quantity 0).
+	// Items without their own min threshold fall back to the default,
+	// mirroring PantryItem.LowStockThreshold.
 	cursor, err = config.DB.Collection("pantry_items").Find(
 		context.Background(),
 		bson.M{
-			"quantity": bson.M{
-				"$gt":  0,
-				"$lte": lowStockThreshold,
+			"quantity": bson.M{"$gt": 0},
+			"$expr": bson.M{
+				"$lte": bson.A{
+					"$quantity",
+					bson.M{"$ifNull": bson.A{"$min_threshold", models.DefaultLowStockThreshold}},
+				},
 			},
 		},
 	)
@@ -319,6 +298,8 @@ func checkLowStockItems() {
 			if err != nil {
 				log.Printf("Error creating low stock notification: %v", err)
 			} else {
+				notifications.Notify(models.NotificationFromPantry(notification))
+				events.Publish(item.GroupID, events.PantryLowStock, primitive.NilObjectID, item)
 				log.Printf("Created low stock notification for item: %s", item.Name)
 			}
 		}
@@ -328,16 +309,13 @@ func checkLowStockItems() {
 }
 
 // GenerateShoppingList automatically creates a shopping list based on low stock items
+// and consumption forecasts. Suggested quantities restock each item up to its par level,
+// or enough to cover the predicted need over the shopping horizon if that is more.
 func GenerateShoppingList(groupID primitive.ObjectID) ([]map[string]interface{}, error) {
-	// Find all low stock items
+	// Find all of the group's items and keep the ones at or below their min threshold
 	cursor, err := config.DB.Collection("pantry_items").Find(
 		context.Background(),
-		bson.M{
-			"group_id": groupID,
-			"quantity": bson.M{
-				"$lte": 1.0, // Low stock threshold
-			},
-		},
+		bson.M{"group_id": groupID},
 	)
 
 	if err != nil {
@@ -372,31 +350,79 @@ func GenerateShoppingList(groupID primitive.ObjectID) ([]map[string]interface{},
 		return nil, err
 	}
 
+	// Forecast consumption so predicted needs can be folded in
+	forecasts, err := ForecastGroupPantry(groupID)
+	if err != nil {
+		return nil, err
+	}
+	forecastMap := make(map[string]models.PantryForecast, len(forecasts))
+	for _, forecast := range forecasts {
+		forecastMap[forecast.ItemID.Hex()] = forecast
+	}
+
 	// Create a map to track items already in the shopping list
 	itemMap := make(map[string]bool)
 	shoppingList := make([]map[string]interface{}, 0)
 
-	// Add low stock items to the shopping list
+	// Add low stock and out of stock items to the shopping list
 	for _, item := range items {
-		if !itemMap[item.ID.Hex()] {
-			itemMap[item.ID.Hex()] = true
+		if itemMap[item.ID.Hex()] || !(item.IsLowStock() || item.IsOutOfStock()) {
+			continue
+		}
 
-			// Suggest a quantity to buy based on typical usage
-			suggestedQuantity := 1.0
-			if item.Quantity <= 0 {
-				suggestedQuantity = 2.0 // If completely out, suggest buying 2
-			}
+		predictedNeed := forecastMap[item.ID.Hex()].PredictedNeed(ShoppingHorizonDays)
+		suggestedQuantity := math.Max(item.SuggestedPurchaseQuantity(), predictedNeed)
+		if suggestedQuantity <= 0 {
+			continue
+		}
+		itemMap[item.ID.Hex()] = true
 
-			shoppingList = append(shoppingList, map[string]interface{}{
-				"item_id":            item.ID.Hex(),
-				"name":               item.Name,
-				"category":           item.Category,
-				"current_quantity":   item.Quantity,
-				"unit":               item.Unit,
-				"suggested_quantity": suggestedQuantity,
-				"reason":             "Low stock",
-			})
+		reason := "Low stock"
+		if item.IsOutOfStock() {
+			reason = "Out of stock"
+		}
+
+		shoppingList = append(shoppingList, map[string]interface{}{
+			"item_id":            item.ID.Hex(),
+			"name":               item.Name,
+			"category":           item.Category,
+			"current_quantity":   item.Quantity,
+			"unit":               item.Unit,
+			"min_threshold":      item.LowStockThreshold(),
+			"par_level":          item.TargetLevel(),
+			"suggested_quantity": suggestedQuantity,
+			"predicted_need":     predictedNeed,
+			"reason":             reason,
+		})
+	}
+
+	// Add items that are predicted to run out within the shopping horizon
+	for _, item := range items {
+		forecast := forecastMap[item.ID.Hex()]
+		if itemMap[item.ID.Hex()] || !forecast.RunsOutWithin(ShoppingHorizonDays) {
+			continue
 		}
+
+		predictedNeed := forecast.PredictedNeed(ShoppingHorizonDays)
+		suggestedQuantity := math.Max(item.SuggestedPurchaseQuantity(), predictedNeed)
+		if suggestedQuantity <= 0 {
+			continue
+		}
+		itemMap[item.ID.Hex()] = true
+
+		shoppingList = append(shoppingList, map[string]interface{}{
+			"item_id":            item.ID.Hex(),
+			"name":               item.Name,
+			"category":           item.Category,
+			"current_quantity":   item.Quantity,
+			"unit":               item.Unit,
+			"min_threshold":      item.LowStockThreshold(),
+			"par_level":          item.TargetLevel(),
+			"suggested_quantity": suggestedQuantity,
+			"predicted_need":     predictedNeed,
+			"predicted_run_out":  forecast.PredictedRunOut,
+			"reason":             "Predicted to run out soon",
+		})
 	}
 
 	// Add items from low stock notifications if not already in the list
@@ -416,10 +442,14 @@ func GenerateShoppingList(groupID primitive.ObjectID) ([]map[string]interface{},
 				continue
 			}
 
-			itemMap[notification.ItemID.Hex()] = true
+			// Skip items that have since been restocked to their par level
+			predictedNeed := forecastMap[item.ID.Hex()].PredictedNeed(ShoppingHorizonDays)
+			suggestedQuantity := math.Max(item.SuggestedPurchaseQuantity(), predictedNeed)
+			if suggestedQuantity <= 0 {
+				continue
+			}
 
-			// Suggest a quantity based on typical usage
-			suggestedQuantity := 2.0 // Default suggestion
+			itemMap[notification.ItemID.Hex()] = true
 
 			shoppingList = append(shoppingList, map[string]interface{}{
 				"item_id":            item.ID.Hex(),
@@ -427,7 +457,10 @@ func GenerateShoppingList(groupID primitive.ObjectID) ([]map[string]interface{},
 				"category":           item.Category,
 				"current_quantity":   item.Quantity,
 				"unit":               item.Unit,
+				"min_threshold":      item.LowStockThreshold(),
+				"par_level":          item.TargetLevel(),
 				"suggested_quantity": suggestedQuantity,
+				"predicted_need":     predictedNeed,
 				"reason":             notification.Message,
 			})
 		}
@@ -0,0 +1,134 @@
+// jobs/webhook_deliveries.go
+package jobs
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/delivery"
+	"cribb-backend/events"
+	"cribb-backend/models"
+	"cribb-backend/webhooks"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StartWebhookWorker queues a delivery for every published group event and
+// starts posting them to the groups' webhooks
+func StartWebhookWorker() {
+	log.Println("Starting webhook delivery worker...")
+
+	events.AddHook(webhooks.Enqueue)
+	sender := delivery.NewSignedWebhook()
+
+	// Check for due deliveries every 30 seconds
+	ticker := time.NewTicker(30 * time.Second)
+
+	// Run immediately once at startup
+	go processWebhookDeliveries(sender)
+
+	// Then run on the schedule
+	go func() {
+		for range ticker.C {
+			processWebhookDeliveries(sender)
+		}
+	}()
+}
+
+// claimWebhookDelivery takes the next due delivery, the same way
+// claimOutboxMessage does
+func claimWebhookDelivery(now time.Time) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := config.DB.Collection(webhooks.DeliveriesCollection).FindOneAndUpdate(
+		context.Background(),
+		bson.M{
+			"status":          bson.M{"$in": []models.OutboxStatus{models.OutboxStatusPending, models.OutboxStatusSending}},
+			"next_attempt_at": bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{
+			"status":          models.OutboxStatusSending,
+			"next_attempt_at": now.Add(models.OutboxClaimTimeout),
+		}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// processWebhookDeliveries posts due deliveries and records each attempt
+func processWebhookDeliveries(sender *delivery.SignedWebhook) {
+	hooks := make(map[primitive.ObjectID]*models.GroupWebhook)
+	sent, failed := 0, 0
+	for i := 0; i < outboxBatchSize; i++ {
+		d, err := claimWebhookDelivery(time.Now())
+		if err != nil {
+			log.Printf("Error claiming webhook delivery: %v", err)
+			return
+		}
+		if d == nil {
+			break
+		}
+
+		webhook, cached := hooks[d.WebhookID]
+		if !cached {
+			var found models.GroupWebhook
+			err := config.DB.Collection(webhooks.Collection).FindOne(
+				context.Background(),
+				bson.M{"_id": d.WebhookID},
+			).Decode(&found)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				log.Printf("Error finding webhook %s: %v", d.WebhookID.Hex(), err)
+				continue
+			}
+			if err == nil {
+				webhook = &found
+			}
+			hooks[d.WebhookID] = webhook
+		}
+
+		switch {
+		case webhook == nil || !webhook.Active:
+			// Removed or disabled since the event; nothing to deliver to
+			now := time.Now()
+			d.RecordAttempt(models.WebhookAttempt{At: now}, errors.New("webhook was removed or disabled"), true)
+			failed++
+		default:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			err = webhooks.Attempt(ctx, sender, webhook, d)
+			cancel()
+
+			if err == nil {
+				sent++
+			} else if d.Status == models.OutboxStatusFailed {
+				failed++
+				log.Printf("Giving up on webhook delivery %s after %d attempt(s): %v",
+					d.ID.Hex(), d.Attempts, err)
+			}
+		}
+
+		_, err = config.DB.Collection(webhooks.DeliveriesCollection).ReplaceOne(
+			context.Background(),
+			bson.M{"_id": d.ID},
+			d,
+		)
+		if err != nil {
+			log.Printf("Error recording webhook delivery %s: %v", d.ID.Hex(), err)
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		log.Printf("Webhooks: delivered %d, %d failed", sent, failed)
+	}
+}
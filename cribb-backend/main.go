@@ -2,6 +2,7 @@ package main
 
 import (
 	"cribb-backend/config"
+	"cribb-backend/delivery"
 	"cribb-backend/handlers"
 	"cribb-backend/jobs"
 	"cribb-backend/middleware"
@@ -14,9 +15,16 @@ func main() {
 	// Connect to MongoDB and initialize collections
 	config.ConnectDB()
 
+	// Reject tokens of deleted accounts
+	middleware.SessionRevoked = handlers.IsSessionRevoked
+
 	// Start the background jobs
 	jobs.StartChoreScheduler()
 	jobs.StartPantryJobs() // Start the pantry background jobs
+	jobs.StartBillScheduler()
+	jobs.StartOutboxWorker(delivery.FromEnv())
+	jobs.StartDigestJob()
+	jobs.StartWebhookWorker()
 
 	// Register routes
 	http.HandleFunc("/health", middleware.CORSMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +40,8 @@ func main() {
 	http.HandleFunc("/api/users", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetUsersHandler)))
 	http.HandleFunc("/api/users/by-username", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetUserByUsernameHandler)))
 	http.HandleFunc("/api/users/by-score", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetUsersByScoreHandler)))
+	http.HandleFunc("/api/users/account", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteAccountHandler)))
+	http.HandleFunc("/api/users/account/export", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.ExportPersonalDataHandler)))
 
 	// Group routes - wrap existing middleware with CORS middleware
 	http.HandleFunc("/api/groups", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CreateGroupHandler)))
@@ -52,11 +62,37 @@ func main() {
 	http.HandleFunc("/api/chores/recurring/update", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.UpdateRecurringChoreHandler)))
 	http.HandleFunc("/api/chores/recurring/delete", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteRecurringChoreHandler)))
 
+	// Product catalog routes
+	http.HandleFunc("/api/products/lookup", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.LookupProductHandler)))
+	http.HandleFunc("/api/products/custom/list", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetCustomProductsHandler)))
+	http.HandleFunc("/api/products/custom/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveCustomProductHandler)))
+	http.HandleFunc("/api/products/custom/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteCustomProductHandler)))
+
+	// Units of measure supported by pantry and cart quantities
+	http.HandleFunc("/api/units", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetUnitsHandler)))
+
 	// Pantry routes - existing - wrap with CORS middleware
 	http.HandleFunc("/api/pantry/add", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.AddPantryItemHandler)))
 	http.HandleFunc("/api/pantry/use", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.UsePantryItemHandler)))
 	http.HandleFunc("/api/pantry/list", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetPantryItemsHandler)))
 	http.HandleFunc("/api/pantry/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeletePantryItemHandler)))
+	http.HandleFunc("/api/pantry/batches/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeletePantryBatchHandler)))
+	http.HandleFunc("/api/pantry/add-by-barcode", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.AddPantryItemByBarcodeHandler)))
+	http.HandleFunc("/api/pantry/move", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MovePantryItemHandler)))
+	http.HandleFunc("/api/pantry/shelf-life/rules", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetShelfLifeRulesHandler)))
+	http.HandleFunc("/api/pantry/shelf-life/rules/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveShelfLifeRuleHandler)))
+	http.HandleFunc("/api/pantry/shelf-life/rules/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteShelfLifeRuleHandler)))
+
+	// Recipe and meal plan routes
+	http.HandleFunc("/api/recipes", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetRecipesHandler)))
+	http.HandleFunc("/api/recipes/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveRecipeHandler)))
+	http.HandleFunc("/api/recipes/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteRecipeHandler)))
+	http.HandleFunc("/api/recipes/can-cook", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetCookableRecipesHandler)))
+	http.HandleFunc("/api/recipes/cook", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CookRecipeHandler)))
+	http.HandleFunc("/api/meal-plan/add", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.AddMealPlanEntryHandler)))
+	http.HandleFunc("/api/meal-plan/week", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetMealPlanWeekHandler)))
+	http.HandleFunc("/api/meal-plan/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteMealPlanEntryHandler)))
+	http.HandleFunc("/api/meal-plan/shopping-list", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MealPlanShoppingListHandler)))
 
 	// Pantry routes - new - wrap with CORS middleware
 	http.HandleFunc("/api/pantry/warnings", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetPantryWarningsHandler)))
@@ -65,6 +101,8 @@ func main() {
 	http.HandleFunc("/api/pantry/history", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetPantryHistoryHandler)))
 	http.HandleFunc("/api/pantry/notify/read", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MarkNotificationReadHandler)))
 	http.HandleFunc("/api/pantry/notify/delete", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteNotificationHandler)))
+	http.HandleFunc("/api/pantry/forecast", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetPantryForecastHandler)))
+	http.HandleFunc("/api/pantry/stock-levels", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.UpdatePantryStockLevelsHandler)))
 
 	// Shopping cart routes - apply CORS and Auth middleware with validation
 	addCartItemValidation := middleware.ValidateRequest(handlers.AddShoppingCartItemHandler, handlers.AddShoppingCartItemRequest{})
@@ -106,6 +144,103 @@ func main() {
 			middleware.AuthMiddleware(
 				handlers.MarkActivityReadHandler)))
 
+	// Checking cart items off into the pantry
+	http.HandleFunc("/api/shopping-cart/purchase",
+		middleware.CORSMiddleware(
+			middleware.AuthMiddleware(
+				handlers.PurchaseCartItemHandler)))
+
+	http.HandleFunc("/api/shopping-cart/checkout",
+		middleware.CORSMiddleware(
+			middleware.AuthMiddleware(
+				handlers.CheckoutCartHandler)))
+
+	// Shared expense routes
+	http.HandleFunc("/api/expenses/create", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CreateExpenseHandler)))
+	http.HandleFunc("/api/expenses/list", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetExpensesHandler)))
+	http.HandleFunc("/api/expenses/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteExpenseHandler)))
+	http.HandleFunc("/api/expenses/balances", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetExpenseBalancesHandler)))
+	http.HandleFunc("/api/expenses/settle", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SettleUpHandler)))
+	http.HandleFunc("/api/expenses/settlements", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetSettlementsHandler)))
+
+	// Recurring bill routes
+	http.HandleFunc("/api/bills", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetRecurringBillsHandler)))
+	http.HandleFunc("/api/bills/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveRecurringBillHandler)))
+	http.HandleFunc("/api/bills/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeactivateRecurringBillHandler)))
+	http.HandleFunc("/api/bills/instances", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetBillInstancesHandler)))
+	http.HandleFunc("/api/bills/pay", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.PayBillHandler)))
+	http.HandleFunc("/api/bills/reminders", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetBillRemindersHandler)))
+	http.HandleFunc("/api/bills/reminders/read", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MarkBillReminderReadHandler)))
+
+	// Budget and price history routes
+	http.HandleFunc("/api/budget", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetBudgetHandler)))
+	http.HandleFunc("/api/budget/set", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SetBudgetHandler)))
+	http.HandleFunc("/api/budget/alerts", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetBudgetAlertsHandler)))
+	http.HandleFunc("/api/prices/history", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetPriceHistoryHandler)))
+
+	// Shared group shopping list routes
+	http.HandleFunc("/api/group-list", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.ListGroupListItemsHandler)))
+	http.HandleFunc("/api/group-list/add", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.AddGroupListItemHandler)))
+	http.HandleFunc("/api/group-list/claim", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.ClaimGroupListItemHandler)))
+	http.HandleFunc("/api/group-list/unclaim", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.UnclaimGroupListItemHandler)))
+	http.HandleFunc("/api/group-list/remove", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.RemoveGroupListRequestHandler)))
+	http.HandleFunc("/api/group-list/complete", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CompleteGroupListItemHandler)))
+
+	// Store and shopping trip routes
+	http.HandleFunc("/api/stores", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetStoresHandler)))
+	http.HandleFunc("/api/stores/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveStoreHandler)))
+	http.HandleFunc("/api/stores/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteStoreHandler)))
+	http.HandleFunc("/api/trips", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetTripsHandler)))
+	http.HandleFunc("/api/trips/start", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.StartTripHandler)))
+	http.HandleFunc("/api/trips/active", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetActiveTripHandler)))
+	http.HandleFunc("/api/trips/check", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CheckTripItemHandler)))
+	http.HandleFunc("/api/trips/complete", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CompleteTripHandler)))
+	http.HandleFunc("/api/trips/cancel", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CancelTripHandler)))
+
+	// Named shopping list routes
+	http.HandleFunc("/api/shopping-lists", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetShoppingListsHandler)))
+	http.HandleFunc("/api/shopping-lists/create", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CreateShoppingListHandler)))
+	http.HandleFunc("/api/shopping-lists/rename", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.RenameShoppingListHandler)))
+	http.HandleFunc("/api/shopping-lists/archive", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.ArchiveShoppingListHandler)))
+	http.HandleFunc("/api/shopping-lists/restore", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.RestoreShoppingListHandler)))
+	http.HandleFunc("/api/shopping-cart/move", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MoveCartItemsHandler)))
+
+	// Notification inbox routes
+	http.HandleFunc("/api/notifications", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetNotificationsHandler)))
+	http.HandleFunc("/api/notifications/read", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MarkNotificationsReadHandler)))
+	http.HandleFunc("/api/notifications/read-all", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.MarkAllNotificationsReadHandler)))
+	http.HandleFunc("/api/notifications/preferences", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetNotificationPreferencesHandler)))
+	http.HandleFunc("/api/notifications/preferences/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveNotificationPreferencesHandler)))
+	http.HandleFunc("/api/notifications/push/key", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetPushPublicKeyHandler)))
+	http.HandleFunc("/api/notifications/push/subscribe", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SubscribePushHandler)))
+	http.HandleFunc("/api/notifications/push/unsubscribe", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.UnsubscribePushHandler)))
+
+	// Group export (admins only) and import into a new group
+	http.HandleFunc("/api/groups/export", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.ExportGroupHandler)))
+	http.HandleFunc("/api/groups/import", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.ImportGroupHandler)))
+
+	// Group webhook routes (admins only)
+	http.HandleFunc("/api/groups/webhooks", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetGroupWebhooksHandler)))
+	http.HandleFunc("/api/groups/webhooks/save", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.SaveGroupWebhookHandler)))
+	http.HandleFunc("/api/groups/webhooks/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteGroupWebhookHandler)))
+	http.HandleFunc("/api/groups/webhooks/deliveries", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetWebhookDeliveriesHandler)))
+	http.HandleFunc("/api/groups/webhooks/test", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.TestGroupWebhookHandler)))
+
+	// Group chat integrations (admins manage them; chats post signed commands)
+	http.HandleFunc("/api/groups/chat-integrations", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetChatIntegrationsHandler)))
+	http.HandleFunc("/api/groups/chat-integrations/create", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CreateChatIntegrationHandler)))
+	http.HandleFunc("/api/groups/chat-integrations/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteChatIntegrationHandler)))
+	http.HandleFunc("/api/chat/command", middleware.CORSMiddleware(handlers.ChatCommandHandler))
+
+	// Chore calendar feeds (the .ics URL carries its own token)
+	http.HandleFunc("/api/calendar/feeds", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.GetCalendarFeedsHandler)))
+	http.HandleFunc("/api/calendar/feeds/create", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.CreateCalendarFeedHandler)))
+	http.HandleFunc("/api/calendar/feeds/remove/", middleware.CORSMiddleware(middleware.AuthMiddleware(handlers.DeleteCalendarFeedHandler)))
+	http.HandleFunc("/api/calendar/feed/", middleware.CORSMiddleware(handlers.CalendarFeedHandler))
+
+	// Real-time group event stream (Server-Sent Events)
+	http.HandleFunc("/api/events/stream", middleware.CORSMiddleware(middleware.StreamAuthMiddleware(handlers.GroupEventStreamHandler)))
+
 	port := 8080
 	log.Printf("Server starting on port %d...", port)
 	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
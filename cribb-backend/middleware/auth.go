@@ -3,8 +3,10 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"cribb-backend/config"
 
@@ -22,6 +24,10 @@ type UserClaims struct {
 	Username string `json:"username"`
 }
 
+// SessionRevoked reports whether a token issued to the user at issuedAt has
+// been revoked. It's set at startup; until then tokens aren't checked.
+var SessionRevoked func(userID string, issuedAt time.Time) bool
+
 // AuthMiddleware is a middleware for authenticating requests with JWT
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -43,43 +49,85 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		tokenString := parts[1]
 
 		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return config.JWTSecret, nil
-		})
-
+		userClaims, err := parseUserToken(tokenString)
 		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
-		if !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+		// Store user info in context
+		ctx := context.WithValue(r.Context(), UserContextKey, userClaims)
+
+		// Call next handler with updated context
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// StreamAuthMiddleware authenticates long-lived streaming requests. Browsers'
+// EventSource can't set headers, so the token may also be passed as ?access_token=.
+func StreamAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString := r.URL.Query().Get("access_token")
+		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Authorization header format must be Bearer {token}", http.StatusUnauthorized)
+				return
+			}
+			tokenString = parts[1]
+		}
+		if tokenString == "" {
+			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
 			return
 		}
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+		userClaims, err := parseUserToken(tokenString)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
-		// Store user info in context
-		userClaims := UserClaims{
-			ID:       claims["id"].(string),
-			Username: claims["username"].(string),
-		}
 		ctx := context.WithValue(r.Context(), UserContextKey, userClaims)
-
-		// Call next handler with updated context
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// parseUserToken validates a JWT and returns the user it was issued to
+func parseUserToken(tokenString string) (UserClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Validate the signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return config.JWTSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return UserClaims{}, errors.New("Invalid token")
+	}
+
+	// Extract claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return UserClaims{}, errors.New("Invalid token claims")
+	}
+	id, idOK := claims["id"].(string)
+	username, usernameOK := claims["username"].(string)
+	if !idOK || !usernameOK {
+		return UserClaims{}, errors.New("Invalid token claims")
+	}
+
+	// Tokens from before issued-at was recorded count as issued at the zero time
+	var issuedAt time.Time
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = time.Unix(int64(iat), 0)
+	}
+	if SessionRevoked != nil && SessionRevoked(id, issuedAt) {
+		return UserClaims{}, errors.New("Session has been revoked")
+	}
+
+	return UserClaims{ID: id, Username: username}, nil
+}
+
 // GetUserFromContext extracts user claims from the request context
 func GetUserFromContext(ctx context.Context) (UserClaims, bool) {
 	user, ok := ctx.Value(UserContextKey).(UserClaims)
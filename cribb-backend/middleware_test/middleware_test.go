@@ -67,6 +67,50 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareRevokedSession(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+	middleware.SessionRevoked = func(userID string, issuedAt time.Time) bool {
+		return userID == "deleted-id" && issuedAt.Before(revokedAt)
+	}
+	defer func() { middleware.SessionRevoked = nil }()
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		userID   string
+		issuedAt time.Time
+		want     int
+	}{
+		{"issued before revocation", "deleted-id", revokedAt.Add(-time.Hour), http.StatusUnauthorized},
+		{"issued after revocation", "deleted-id", time.Now(), http.StatusOK},
+		{"other user", "test-id", revokedAt.Add(-time.Hour), http.StatusOK},
+	}
+	for _, tt := range tests {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"id":       tt.userID,
+			"username": "testuser",
+			"iat":      tt.issuedAt.Unix(),
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, err := token.SignedString(config.JWTSecret)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		rr := httptest.NewRecorder()
+		middleware.AuthMiddleware(testHandler).ServeHTTP(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("%s: got status %v, want %v", tt.name, rr.Code, tt.want)
+		}
+	}
+}
+
 func TestAuthMiddlewareMissingToken(t *testing.T) {
 	// Create a test handler that should not be called
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,37 @@
+// models/account.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SessionLifetime is how long a login token is valid
+const SessionLifetime = 7 * 24 * time.Hour
+
+// DeletedUserName replaces a deleted user's name in the group's history
+const DeletedUserName = "Deleted user"
+
+// SessionRevocation rejects every token issued to a user before RevokedAt.
+// It's kept until those tokens would have expired anyway.
+type SessionRevocation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id" validate:"required"`
+	RevokedAt time.Time          `bson:"revoked_at" json:"revoked_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+}
+
+// CreateSessionRevocation revokes the user's tokens issued up to now
+func CreateSessionRevocation(userID primitive.ObjectID, now time.Time) *SessionRevocation {
+	return &SessionRevocation{
+		UserID:    userID,
+		RevokedAt: now,
+		ExpiresAt: now.Add(SessionLifetime),
+	}
+}
+
+// Revokes checks if a token issued at issuedAt was revoked
+func (s *SessionRevocation) Revokes(issuedAt time.Time) bool {
+	return issuedAt.Before(s.RevokedAt)
+}
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BillStatus represents the state of a bill instance
+type BillStatus string
+
+const (
+	// BillStatusPending indicates the bill is not yet fully paid and not past due
+	BillStatusPending BillStatus = "pending"
+
+	// BillStatusOverdue indicates the due date passed before every member paid
+	BillStatusOverdue BillStatus = "overdue"
+
+	// BillStatusPaid indicates every member has paid their share
+	BillStatusPaid BillStatus = "paid"
+)
+
+// BillReminderType defines why a member is reminded about a bill
+type BillReminderType string
+
+const (
+	BillReminderTypeDueSoon BillReminderType = "due_soon"
+	BillReminderTypeOverdue BillReminderType = "overdue"
+)
+
+// BillGenerateDaysAhead is how long before its due date a bill instance is created
+const BillGenerateDaysAhead = 7
+
+// DefaultBillReminderDays is how many days before the due date members are reminded
+const DefaultBillReminderDays = 3
+
+// RecurringBill is a bill the group pays on a schedule, like rent or internet
+type RecurringBill struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID        primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Name           string             `bson:"name" json:"name" validate:"required"`
+	Category       string             `bson:"category,omitempty" json:"category,omitempty"`   // rent, utilities, internet...
+	Amount         int64              `bson:"amount" json:"amount" validate:"required,min=1"` // Cents
+	Currency       string             `bson:"currency" json:"currency"`
+	DueDay         int                `bson:"due_day" json:"due_day" validate:"required,min=1,max=31"` // Clamped to the month's last day
+	IntervalMonths int                `bson:"interval_months" json:"interval_months"`
+	SplitType      SplitType          `bson:"split_type" json:"split_type"`
+	Splits         []ExpenseShare     `bson:"splits" json:"splits"`
+	ReminderDays   int                `bson:"reminder_days" json:"reminder_days"`
+	IsActive       bool               `bson:"is_active" json:"is_active"`
+	NextDueDate    time.Time          `bson:"next_due_date" json:"next_due_date"`
+	CreatedBy      primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// BillShare is one member's part of a bill instance
+type BillShare struct {
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Amount int64              `bson:"amount" json:"amount"` // Cents
+	PaidAt *time.Time         `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+}
+
+// BillInstance is a single occurrence of a recurring bill
+type BillInstance struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID           primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	BillID            primitive.ObjectID `bson:"bill_id" json:"bill_id" validate:"required"`
+	Name              string             `bson:"name" json:"name"`
+	Category          string             `bson:"category,omitempty" json:"category,omitempty"`
+	Amount            int64              `bson:"amount" json:"amount"` // Cents
+	Currency          string             `bson:"currency" json:"currency"`
+	DueDate           time.Time          `bson:"due_date" json:"due_date"`
+	Shares            []BillShare        `bson:"shares" json:"shares"`
+	Status            BillStatus         `bson:"status" json:"status"`
+	ReminderDays      int                `bson:"reminder_days" json:"reminder_days"`
+	ReminderSentAt    *time.Time         `bson:"reminder_sent_at,omitempty" json:"reminder_sent_at,omitempty"`
+	OverdueNotifiedAt *time.Time         `bson:"overdue_notified_at,omitempty" json:"overdue_notified_at,omitempty"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// BillReminder tells a member that their share of a bill is due soon or overdue
+type BillReminder struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID        primitive.ObjectID `bson:"group_id" json:"group_id"`
+	BillInstanceID primitive.ObjectID `bson:"bill_instance_id" json:"bill_instance_id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type           BillReminderType   `bson:"type" json:"type"`
+	Message        string             `bson:"message" json:"message"`
+	Amount         int64              `bson:"amount" json:"amount"` // The member's share, in cents
+	DueDate        time.Time          `bson:"due_date" json:"due_date"`
+	IsRead         bool               `bson:"is_read" json:"is_read"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// CreateRecurringBill creates a new recurring bill; the splits are filled in by Split
+func CreateRecurringBill(
+	groupID primitive.ObjectID,
+	name string,
+	category string,
+	amount int64,
+	currency string,
+	dueDay int,
+	intervalMonths int,
+	reminderDays int,
+	createdBy primitive.ObjectID,
+) *RecurringBill {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if intervalMonths <= 0 {
+		intervalMonths = 1
+	}
+	if reminderDays <= 0 {
+		reminderDays = DefaultBillReminderDays
+	}
+
+	return &RecurringBill{
+		GroupID:        groupID,
+		Name:           strings.TrimSpace(name),
+		Category:       strings.ToLower(strings.TrimSpace(category)),
+		Amount:         amount,
+		Currency:       strings.ToUpper(strings.TrimSpace(currency)),
+		DueDay:         dueDay,
+		IntervalMonths: intervalMonths,
+		SplitType:      SplitTypeEqual,
+		Splits:         make([]ExpenseShare, 0),
+		ReminderDays:   reminderDays,
+		IsActive:       true,
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+}
+
+// Validate checks the bill definition
+func (b *RecurringBill) Validate() error {
+	if b.Name == "" {
+		return errors.New("bill name is required")
+	}
+	if b.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if b.DueDay < 1 || b.DueDay > 31 {
+		return errors.New("due day must be between 1 and 31")
+	}
+	if b.IntervalMonths > 12 {
+		return errors.New("bills can repeat at most every 12 months")
+	}
+	return ValidateCurrency(b.Currency)
+}
+
+// Split divides the bill's amount between members; by-item splits don't apply to bills
+func (b *RecurringBill) Split(splitType SplitType, input SplitInput, members map[primitive.ObjectID]bool) error {
+	if splitType == "" {
+		splitType = SplitTypeEqual
+	}
+	if splitType == SplitTypeByItem {
+		return errors.New("split type must be equal, shares or exact")
+	}
+
+	splits, err := SplitAmount(b.Amount, splitType, input, members)
+	if err != nil {
+		return err
+	}
+	b.SplitType = splitType
+	b.Splits = splits
+	return nil
+}
+
+// DueDateIn returns the due date in a month, clamping the day to the month's length
+func DueDateIn(year int, month time.Month, dueDay int) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if dueDay > lastDay {
+		dueDay = lastDay
+	}
+	return time.Date(year, month, dueDay, 0, 0, 0, 0, time.UTC)
+}
+
+// FirstDueDate returns the first due date on or after the given day
+func (b *RecurringBill) FirstDueDate(from time.Time) time.Time {
+	day := PlanDay(from)
+	due := DueDateIn(day.Year(), day.Month(), b.DueDay)
+	if due.Before(day) {
+		due = DueDateIn(day.Year(), day.Month()+1, b.DueDay)
+	}
+	return due
+}
+
+// DueDateAfter returns the due date that follows the given one
+func (b *RecurringBill) DueDateAfter(due time.Time) time.Time {
+	interval := b.IntervalMonths
+	if interval <= 0 {
+		interval = 1
+	}
+	return DueDateIn(due.Year(), due.Month()+time.Month(interval), b.DueDay)
+}
+
+// CreateBillInstance creates the instance of a bill due on the given date
+func CreateBillInstance(bill *RecurringBill, dueDate time.Time) *BillInstance {
+	shares := make([]BillShare, 0, len(bill.Splits))
+	for _, split := range bill.Splits {
+		shares = append(shares, BillShare{UserID: split.UserID, Amount: split.Amount})
+	}
+
+	return &BillInstance{
+		GroupID:      bill.GroupID,
+		BillID:       bill.ID,
+		Name:         bill.Name,
+		Category:     bill.Category,
+		Amount:       bill.Amount,
+		Currency:     bill.Currency,
+		DueDate:      dueDate,
+		Shares:       shares,
+		Status:       BillStatusPending,
+		ReminderDays: bill.ReminderDays,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// FindShare returns the member's share of the bill
+func (i *BillInstance) FindShare(userID primitive.ObjectID) (*BillShare, bool) {
+	for idx := range i.Shares {
+		if i.Shares[idx].UserID == userID {
+			return &i.Shares[idx], true
+		}
+	}
+	return nil, false
+}
+
+// MarkPaid records whether a member has paid their share and updates the status
+func (i *BillInstance) MarkPaid(userID primitive.ObjectID, paid bool, now time.Time) error {
+	share, found := i.FindShare(userID)
+	if !found {
+		return errors.New("member has no share of this bill")
+	}
+
+	if paid {
+		if share.PaidAt == nil {
+			share.PaidAt = &now
+		}
+	} else {
+		share.PaidAt = nil
+	}
+
+	i.Status = i.statusAt(now)
+	i.UpdatedAt = now
+	return nil
+}
+
+// UnpaidShares returns the shares not yet paid
+func (i *BillInstance) UnpaidShares() []BillShare {
+	unpaid := make([]BillShare, 0)
+	for _, share := range i.Shares {
+		if share.PaidAt == nil {
+			unpaid = append(unpaid, share)
+		}
+	}
+	return unpaid
+}
+
+// IsOverdue checks if the whole due date has passed with shares still unpaid
+func (i *BillInstance) IsOverdue(now time.Time) bool {
+	return len(i.UnpaidShares()) > 0 && PlanDay(now).After(i.DueDate)
+}
+
+// ReminderDue checks if the due-soon reminder should be sent
+func (i *BillInstance) ReminderDue(now time.Time) bool {
+	if i.ReminderSentAt != nil || i.Status != BillStatusPending {
+		return false
+	}
+	return !PlanDay(now).AddDate(0, 0, i.ReminderDays).Before(i.DueDate)
+}
+
+// statusAt works out the instance's status from its shares and due date
+func (i *BillInstance) statusAt(now time.Time) BillStatus {
+	switch {
+	case len(i.UnpaidShares()) == 0:
+		return BillStatusPaid
+	case i.IsOverdue(now):
+		return BillStatusOverdue
+	default:
+		return BillStatusPending
+	}
+}
+
+// CreateBillReminder creates a reminder for a member's unpaid share
+func CreateBillReminder(instance *BillInstance, share BillShare, reminderType BillReminderType) *BillReminder {
+	message := fmt.Sprintf("%s: your share of %s %s is due on %s",
+		instance.Name, FormatCents(share.Amount), instance.Currency, instance.DueDate.Format("Jan 2"))
+	if reminderType == BillReminderTypeOverdue {
+		message = fmt.Sprintf("%s is overdue: your share of %s %s was due on %s",
+			instance.Name, FormatCents(share.Amount), instance.Currency, instance.DueDate.Format("Jan 2"))
+	}
+
+	return &BillReminder{
+		GroupID:        instance.GroupID,
+		BillInstanceID: instance.ID,
+		UserID:         share.UserID,
+		Type:           reminderType,
+		Message:        message,
+		Amount:         share.Amount,
+		DueDate:        instance.DueDate,
+		IsRead:         false,
+		CreatedAt:      time.Now(),
+	}
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BudgetAlertLevel defines how close spending is to a budget limit
+type BudgetAlertLevel string
+
+const (
+	// BudgetAlertLevelWarning means projected spending has reached the alert threshold
+	BudgetAlertLevelWarning BudgetAlertLevel = "warning"
+
+	// BudgetAlertLevelExceeded means actual spending has gone over the limit
+	BudgetAlertLevelExceeded BudgetAlertLevel = "exceeded"
+)
+
+// DefaultBudgetAlertThreshold is the percentage of a limit that triggers a warning
+const DefaultBudgetAlertThreshold = 90
+
+// BudgetMonthLayout is the format of the month a budget status or alert is for
+const BudgetMonthLayout = "2006-01"
+
+// GroceryBudget is a group's monthly grocery budget, with optional per-category limits
+type GroceryBudget struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID        primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Amount         int64              `bson:"amount" json:"amount" validate:"required,min=1"` // Cents per month
+	Currency       string             `bson:"currency" json:"currency"`
+	CategoryLimits map[string]int64   `bson:"category_limits,omitempty" json:"category_limits,omitempty"`
+	AlertThreshold int                `bson:"alert_threshold" json:"alert_threshold"` // Percent of a limit
+	UpdatedBy      primitive.ObjectID `bson:"updated_by" json:"updated_by"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CategorySpend is spending against one category, or the whole budget when Category is empty
+type CategorySpend struct {
+	Category  string `json:"category,omitempty"`
+	Limit     int64  `json:"limit,omitempty"` // Zero when the category has no limit of its own
+	Spent     int64  `json:"spent"`
+	InCart    int64  `json:"in_cart"`
+	Projected int64  `json:"projected"` // Spent plus what's in the cart
+	Remaining int64  `json:"remaining,omitempty"`
+}
+
+// BudgetStatus is a group's spending for a month against its budget
+type BudgetStatus struct {
+	Month      string          `json:"month"`
+	Currency   string          `json:"currency"`
+	Total      CategorySpend   `json:"total"`
+	Categories []CategorySpend `json:"categories"`
+	Unpriced   []string        `json:"unpriced,omitempty"` // Cart items with no price history
+}
+
+// BudgetAlert records that spending crossed a threshold; at most one per month, category and level
+type BudgetAlert struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"group_id" json:"group_id"`
+	Month     string             `bson:"month" json:"month"`
+	Category  string             `bson:"category" json:"category"` // Empty for the overall budget
+	Level     BudgetAlertLevel   `bson:"level" json:"level"`
+	Limit     int64              `bson:"limit" json:"limit"`
+	Spent     int64              `bson:"spent" json:"spent"`
+	Projected int64              `bson:"projected" json:"projected"`
+	Currency  string             `bson:"currency" json:"currency"`
+	Message   string             `bson:"message" json:"message"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// CreateGroceryBudget creates a new monthly grocery budget
+func CreateGroceryBudget(
+	groupID primitive.ObjectID,
+	amount int64,
+	currency string,
+	categoryLimits map[string]int64,
+	alertThreshold int,
+	updatedBy primitive.ObjectID,
+) *GroceryBudget {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if alertThreshold <= 0 {
+		alertThreshold = DefaultBudgetAlertThreshold
+	}
+
+	limits := make(map[string]int64, len(categoryLimits))
+	for category, limit := range categoryLimits {
+		limits[strings.ToLower(strings.TrimSpace(category))] = limit
+	}
+
+	return &GroceryBudget{
+		GroupID:        groupID,
+		Amount:         amount,
+		Currency:       strings.ToUpper(strings.TrimSpace(currency)),
+		CategoryLimits: limits,
+		AlertThreshold: alertThreshold,
+		UpdatedBy:      updatedBy,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+}
+
+// Validate checks the budget's amounts
+func (b *GroceryBudget) Validate() error {
+	if b.Amount <= 0 {
+		return errors.New("budget amount must be positive")
+	}
+	if b.AlertThreshold > 100 {
+		return errors.New("alert threshold must be at most 100 percent")
+	}
+	for category, limit := range b.CategoryLimits {
+		if category == "" {
+			return errors.New("category limits need a category name")
+		}
+		if limit <= 0 {
+			return fmt.Errorf("limit for %s must be positive", category)
+		}
+	}
+	return ValidateCurrency(b.Currency)
+}
+
+// BudgetMonth returns the start of the month containing t and the start of the next one
+func BudgetMonth(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// ComputeBudgetStatus totals spending and cart contents by category against the budget
+func ComputeBudgetStatus(budget *GroceryBudget, month time.Time, spent, inCart map[string]int64) BudgetStatus {
+	status := BudgetStatus{
+		Month:      month.Format(BudgetMonthLayout),
+		Currency:   budget.Currency,
+		Total:      CategorySpend{Limit: budget.Amount},
+		Categories: make([]CategorySpend, 0),
+	}
+
+	categories := make(map[string]*CategorySpend)
+	categoryFor := func(name string) *CategorySpend {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if categories[name] == nil {
+			categories[name] = &CategorySpend{Category: name, Limit: budget.CategoryLimits[name]}
+		}
+		return categories[name]
+	}
+	for category := range budget.CategoryLimits {
+		categoryFor(category)
+	}
+	for category, amount := range spent {
+		categoryFor(category).Spent += amount
+		status.Total.Spent += amount
+	}
+	for category, amount := range inCart {
+		categoryFor(category).InCart += amount
+		status.Total.InCart += amount
+	}
+
+	for _, category := range categories {
+		category.finish()
+		status.Categories = append(status.Categories, *category)
+	}
+	status.Total.finish()
+
+	sort.Slice(status.Categories, func(i, j int) bool {
+		return status.Categories[i].Category < status.Categories[j].Category
+	})
+	return status
+}
+
+// finish fills in the projected and remaining amounts
+func (c *CategorySpend) finish() {
+	c.Projected = c.Spent + c.InCart
+	if c.Limit > 0 {
+		c.Remaining = c.Limit - c.Spent
+	}
+}
+
+// alertLevel returns the highest alert level the spending has reached, if any
+func (c CategorySpend) alertLevel(threshold int) (BudgetAlertLevel, bool) {
+	switch {
+	case c.Limit <= 0:
+		return "", false
+	case c.Spent > c.Limit:
+		return BudgetAlertLevelExceeded, true
+	case c.Projected*100 >= c.Limit*int64(threshold):
+		return BudgetAlertLevelWarning, true
+	default:
+		return "", false
+	}
+}
+
+// Alerts returns an alert for the overall budget and each category that has reached its threshold
+func (s BudgetStatus) Alerts(groupID primitive.ObjectID, threshold int) []BudgetAlert {
+	alerts := make([]BudgetAlert, 0)
+	for _, spend := range append([]CategorySpend{s.Total}, s.Categories...) {
+		level, reached := spend.alertLevel(threshold)
+		if !reached {
+			continue
+		}
+
+		name := "Grocery budget"
+		if spend.Category != "" {
+			name = "Budget for " + spend.Category
+		}
+		message := fmt.Sprintf("%s is nearly used up: %s of %s %s spent, %s with the cart",
+			name, FormatCents(spend.Spent), FormatCents(spend.Limit), s.Currency, FormatCents(spend.Projected))
+		if level == BudgetAlertLevelExceeded {
+			message = fmt.Sprintf("%s exceeded: %s of %s %s spent",
+				name, FormatCents(spend.Spent), FormatCents(spend.Limit), s.Currency)
+		}
+
+		alerts = append(alerts, BudgetAlert{
+			GroupID:   groupID,
+			Month:     s.Month,
+			Category:  spend.Category,
+			Level:     level,
+			Limit:     spend.Limit,
+			Spent:     spend.Spent,
+			Projected: spend.Projected,
+			Currency:  s.Currency,
+			Message:   message,
+			CreatedAt: time.Now(),
+		})
+	}
+	return alerts
+}
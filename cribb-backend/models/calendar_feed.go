@@ -0,0 +1,68 @@
+// models/calendar_feed.go
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CalendarFeedScope is which chores a calendar feed includes
+type CalendarFeedScope string
+
+const (
+	CalendarFeedMine  CalendarFeedScope = "mine"  // Chores assigned to the user
+	CalendarFeedGroup CalendarFeedScope = "group" // Every chore in the user's group
+)
+
+const (
+	// CalendarFeedHorizon is how far ahead recurring chores are projected
+	CalendarFeedHorizon = 90 * 24 * time.Hour
+
+	// CalendarFeedMaxOccurrences caps the projected instances of one recurring chore
+	CalendarFeedMaxOccurrences = 12
+
+	// CalendarFeedCompletedWindow is how long completed chores stay in a feed
+	CalendarFeedCompletedWindow = 30 * 24 * time.Hour
+)
+
+// CalendarFeed is a secret URL calendar apps subscribe to. Only a hash of the
+// token is stored; the token itself is shown when the feed is created.
+type CalendarFeed struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id" validate:"required"`
+	Scope         CalendarFeedScope  `bson:"scope" json:"scope" validate:"required"`
+	TokenHash     string             `bson:"token_hash" json:"-"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	LastFetchedAt *time.Time         `bson:"last_fetched_at,omitempty" json:"last_fetched_at,omitempty"`
+}
+
+// IsValid checks if the scope is one of the known values
+func (s CalendarFeedScope) IsValid() bool {
+	return s == CalendarFeedMine || s == CalendarFeedGroup
+}
+
+// HashCalendarToken returns the stored form of a feed token
+func HashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateCalendarFeed creates a feed with a new token, returning the token
+func CreateCalendarFeed(userID primitive.ObjectID, scope CalendarFeedScope) (*CalendarFeed, string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(secret)
+
+	return &CalendarFeed{
+		UserID:    userID,
+		Scope:     scope,
+		TokenHash: HashCalendarToken(token),
+		CreatedAt: time.Now(),
+	}, token, nil
+}
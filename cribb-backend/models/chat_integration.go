@@ -0,0 +1,68 @@
+// models/chat_integration.go
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChatCommandMaxAge is how old a signed chat command may be before it's
+// treated as a replay
+const ChatCommandMaxAge = 5 * time.Minute
+
+// ChatCommandReceipt records the signature of an accepted chat command, so
+// the same signed request can't be replayed while it's still fresh. It
+// expires once the signature would be too old to accept anyway.
+type ChatCommandReceipt struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	IntegrationID primitive.ObjectID `bson:"integration_id" json:"integration_id"`
+	Signature     string             `bson:"signature" json:"signature"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"expires_at"`
+}
+
+// CreateChatCommandReceipt records a command signed at signedAt
+func CreateChatCommandReceipt(integrationID primitive.ObjectID, signature string, signedAt time.Time) *ChatCommandReceipt {
+	return &ChatCommandReceipt{
+		IntegrationID: integrationID,
+		Signature:     signature,
+		ExpiresAt:     signedAt.Add(ChatCommandMaxAge),
+	}
+}
+
+// ChatIntegration lets a group chat send commands to Cribb. Requests are
+// signed with the secret, which is only shown when the integration is created.
+type ChatIntegration struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID    primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Name       string             `bson:"name" json:"name" validate:"required"` // e.g. "Slack" or "House WhatsApp"
+	Secret     string             `bson:"secret" json:"-"`
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+}
+
+// CreateChatIntegration creates an integration with a new secret
+func CreateChatIntegration(groupID primitive.ObjectID, name string, createdBy primitive.ObjectID) (*ChatIntegration, error) {
+	secret, err := GenerateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &ChatIntegration{
+		GroupID:   groupID,
+		Name:      strings.TrimSpace(name),
+		Secret:    secret,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Validate checks the integration has a name
+func (c *ChatIntegration) Validate() error {
+	if c.Name == "" {
+		return errors.New("integration name is required")
+	}
+	return nil
+}
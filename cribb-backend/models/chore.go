@@ -143,3 +143,89 @@ func CreateChoreFromRecurring(recurringChore *RecurringChore) *Chore {
 		UpdatedAt:   time.Now(),
 	}
 }
+
+// ChoreOccurrence is a future instance of a recurring chore that hasn't been created yet
+type ChoreOccurrence struct {
+	RecurringID primitive.ObjectID `json:"recurring_id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	AssignedTo  primitive.ObjectID `json:"assigned_to"`
+	Points      int                `json:"points"`
+	AssignedAt  time.Time          `json:"assigned_at"`
+	DueDate     time.Time          `json:"due_date"`
+}
+
+// advance moves a time forward by one period of the chore's frequency
+func (rc *RecurringChore) advance(t time.Time) time.Time {
+	switch rc.Frequency {
+	case "daily":
+		return t.Add(24 * time.Hour)
+	case "biweekly":
+		return t.Add(14 * 24 * time.Hour)
+	case "monthly":
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.Add(7 * 24 * time.Hour) // Default to weekly
+	}
+}
+
+// ProjectOccurrences returns the instances the scheduler will create up to
+// until, at most limit of them, without changing the rotation. Instances are
+// created at the next assignment and then once per period, each due one
+// period after it's created and assigned to the next member in the rotation.
+func (rc *RecurringChore) ProjectOccurrences(now, until time.Time, limit int) []ChoreOccurrence {
+	if !rc.IsActive || len(rc.MemberRotation) == 0 {
+		return nil
+	}
+
+	// An assignment that's already due is picked up on the scheduler's next run
+	assignedAt := rc.NextAssignment
+	if assignedAt.Before(now) {
+		assignedAt = now
+	}
+
+	var occurrences []ChoreOccurrence
+	index := rc.CurrentIndex % len(rc.MemberRotation)
+	for len(occurrences) < limit && !assignedAt.After(until) {
+		occurrences = append(occurrences, ChoreOccurrence{
+			RecurringID: rc.ID,
+			Title:       rc.Title,
+			Description: rc.Description,
+			AssignedTo:  rc.MemberRotation[index],
+			Points:      rc.Points,
+			AssignedAt:  assignedAt,
+			DueDate:     rc.advance(assignedAt),
+		})
+		index = (index + 1) % len(rc.MemberRotation)
+		assignedAt = rc.advance(assignedAt)
+	}
+	return occurrences
+}
+
+// RemoveMember takes a user out of the rotation, keeping the same member up
+// next. A chore with nobody left in its rotation is deactivated. It returns
+// false if the user wasn't in the rotation.
+func (rc *RecurringChore) RemoveMember(userID primitive.ObjectID) bool {
+	rotation := make([]primitive.ObjectID, 0, len(rc.MemberRotation))
+	index := rc.CurrentIndex
+	for i, member := range rc.MemberRotation {
+		if member != userID {
+			rotation = append(rotation, member)
+		} else if i < rc.CurrentIndex {
+			index--
+		}
+	}
+	if len(rotation) == len(rc.MemberRotation) {
+		return false
+	}
+
+	rc.MemberRotation = rotation
+	if index >= len(rotation) || index < 0 {
+		index = 0
+	}
+	rc.CurrentIndex = index
+	if len(rotation) == 0 {
+		rc.IsActive = false
+	}
+	return true
+}
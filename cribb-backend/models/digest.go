@@ -0,0 +1,172 @@
+// models/digest.go
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DigestFrequency is how often a user gets a household digest
+type DigestFrequency string
+
+const (
+	// DigestOff means the user gets no digest
+	DigestOff DigestFrequency = "off"
+
+	// DigestDaily sends a digest every day at the chosen time
+	DigestDaily DigestFrequency = "daily"
+
+	// DigestWeekly sends a digest once a week on the chosen day and time
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// DigestSendWindow is how often digests are checked, and so how long after
+// its scheduled time a digest that was never sent can still go out
+const DigestSendWindow = 15 * time.Minute
+
+// DigestSettings holds when and where a user's digest is delivered.
+// Time is a clock time in the user's timezone.
+type DigestSettings struct {
+	Frequency  DigestFrequency     `bson:"frequency" json:"frequency"`
+	Time       string              `bson:"time" json:"time"`
+	Weekday    time.Weekday        `bson:"weekday" json:"weekday"` // Day of weekly digests, 0 is Sunday
+	Channel    NotificationChannel `bson:"channel" json:"channel"`
+	LastSentAt *time.Time          `bson:"last_sent_at,omitempty" json:"last_sent_at,omitempty"`
+}
+
+// DefaultDigestSettings returns a daily morning email digest
+func DefaultDigestSettings() DigestSettings {
+	return DigestSettings{
+		Frequency: DigestDaily,
+		Time:      "08:00",
+		Weekday:   time.Monday,
+		Channel:   NotificationChannelEmail,
+	}
+}
+
+// Validate checks the frequency, time, day and channel
+func (s DigestSettings) Validate() error {
+	switch s.Frequency {
+	case DigestOff, DigestDaily, DigestWeekly:
+	default:
+		return fmt.Errorf("unknown digest frequency %q", s.Frequency)
+	}
+	if s.Frequency == DigestOff {
+		return nil
+	}
+	if _, err := minutes(s.Time); err != nil {
+		return err
+	}
+	if s.Weekday < time.Sunday || s.Weekday > time.Saturday {
+		return errors.New("digest weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	switch s.Channel {
+	case NotificationChannelEmail, NotificationChannelPush, NotificationChannelSMS, NotificationChannelWebhook:
+	default:
+		return fmt.Errorf("digests can't be delivered over %q", s.Channel)
+	}
+	return nil
+}
+
+// Period returns how far back a digest looks for new activity
+func (s DigestSettings) Period() time.Duration {
+	if s.Frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// LastScheduled returns the most recent time at or before now that a digest
+// was scheduled for, in loc
+func (s DigestSettings) LastScheduled(now time.Time, loc *time.Location) time.Time {
+	clock, err := minutes(s.Time)
+	if err != nil {
+		return time.Time{}
+	}
+	local := now.In(loc)
+	at := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, clock/60, clock%60, 0, 0, loc)
+	}
+
+	scheduled := at(0)
+	if scheduled.After(now) {
+		scheduled = at(-1)
+	}
+	if s.Frequency == DigestWeekly {
+		// Step back to the chosen day of the week
+		back := (int(scheduled.Weekday()) - int(s.Weekday) + 7) % 7
+		scheduled = time.Date(scheduled.Year(), scheduled.Month(), scheduled.Day()-back, clock/60, clock%60, 0, 0, loc)
+	}
+	return scheduled
+}
+
+// IsDue checks if a digest should go out now: its scheduled time has passed
+// since the last one was sent. A digest that was never sent is only due in
+// the DigestSendWindow after its scheduled time, so it waits for the user's
+// chosen time instead of going out whenever the job first sees it.
+func (s DigestSettings) IsDue(now time.Time, loc *time.Location) bool {
+	if s.Frequency != DigestDaily && s.Frequency != DigestWeekly {
+		return false
+	}
+	scheduled := s.LastScheduled(now, loc)
+	if scheduled.IsZero() {
+		return false
+	}
+	if s.LastSentAt == nil {
+		return now.Sub(scheduled) < DigestSendWindow
+	}
+	return s.LastSentAt.Before(scheduled)
+}
+
+// DigestChore is a chore listed in a digest
+type DigestChore struct {
+	Title   string    `json:"title"`
+	DueDate time.Time `json:"due_date"`
+	Points  int       `json:"points"`
+}
+
+// DigestPantryItem is a pantry item listed in a digest
+type DigestPantryItem struct {
+	Name           string    `json:"name"`
+	Quantity       float64   `json:"quantity"`
+	Unit           string    `json:"unit"`
+	ExpirationDate time.Time `json:"expiration_date,omitempty"`
+}
+
+// DigestActivity is a shopping cart change listed in a digest
+type DigestActivity struct {
+	UserName  string    `json:"user_name"`
+	ItemName  string    `json:"item_name"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Digest summarizes what a user should know about their household
+type Digest struct {
+	UserName      string             `json:"user_name"`
+	GroupName     string             `json:"group_name"`
+	Frequency     DigestFrequency    `json:"frequency"`
+	Since         time.Time          `json:"since"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	Location      *time.Location     `json:"-"` // Dates are shown in the user's timezone
+	ChoresDue     []DigestChore      `json:"chores_due"`
+	OverdueChores []DigestChore      `json:"overdue_chores"`
+	ExpiringItems []DigestPantryItem `json:"expiring_items"`
+	LowStockItems []DigestPantryItem `json:"low_stock_items"`
+	CartActivity  []DigestActivity   `json:"cart_activity"`
+}
+
+// IsEmpty checks if the digest has nothing worth sending
+func (d *Digest) IsEmpty() bool {
+	return len(d.ChoresDue) == 0 && len(d.OverdueChores) == 0 &&
+		len(d.ExpiringItems) == 0 && len(d.LowStockItems) == 0 && len(d.CartActivity) == 0
+}
+
+// Subject returns the heading of the digest
+func (d *Digest) Subject() string {
+	if d.Frequency == DigestWeekly {
+		return "Your weekly " + d.GroupName + " digest"
+	}
+	return "Your daily " + d.GroupName + " digest"
+}
@@ -0,0 +1,490 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SplitType defines how an expense is divided between members
+type SplitType string
+
+const (
+	// SplitTypeEqual divides the amount evenly between the participants
+	SplitTypeEqual SplitType = "equal"
+
+	// SplitTypeShares divides the amount in proportion to each participant's shares
+	SplitTypeShares SplitType = "shares"
+
+	// SplitTypeExact uses the amount given for each participant
+	SplitTypeExact SplitType = "exact"
+
+	// SplitTypeByItem divides each line item evenly between the members who share it
+	SplitTypeByItem SplitType = "by_item"
+)
+
+// ExpenseSource records where an expense came from
+type ExpenseSource string
+
+const (
+	ExpenseSourceManual       ExpenseSource = "manual"
+	ExpenseSourceCartCheckout ExpenseSource = "cart_checkout"
+)
+
+// DefaultCurrency is used when an expense doesn't name one
+const DefaultCurrency = "USD"
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ExpenseShare is what one member owes of an expense, in cents
+type ExpenseShare struct {
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Amount int64              `bson:"amount" json:"amount"`
+	Shares float64            `bson:"shares,omitempty" json:"shares,omitempty"` // Weight for shares splits
+}
+
+// ExpenseItem is a line of a by-item expense, shared by some members
+type ExpenseItem struct {
+	Name    string               `bson:"name" json:"name"`
+	Amount  int64                `bson:"amount" json:"amount"` // Cents
+	UserIDs []primitive.ObjectID `bson:"user_ids" json:"user_ids"`
+}
+
+// Expense is a purchase paid by one member and split between several
+type Expense struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID     primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Description string             `bson:"description" json:"description" validate:"required"`
+	PaidBy      primitive.ObjectID `bson:"paid_by" json:"paid_by" validate:"required"`
+	Amount      int64              `bson:"amount" json:"amount" validate:"required,min=1"` // Cents
+	Currency    string             `bson:"currency" json:"currency"`
+	SplitType   SplitType          `bson:"split_type" json:"split_type"`
+	Splits      []ExpenseShare     `bson:"splits" json:"splits"`
+	Items       []ExpenseItem      `bson:"items,omitempty" json:"items,omitempty"`
+	Source      ExpenseSource      `bson:"source" json:"source"`
+	Date        time.Time          `bson:"date" json:"date"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Settlement is a payment between two members that pays down their balances
+type Settlement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	FromUser  primitive.ObjectID `bson:"from_user" json:"from_user" validate:"required"`
+	ToUser    primitive.ObjectID `bson:"to_user" json:"to_user" validate:"required"`
+	Amount    int64              `bson:"amount" json:"amount" validate:"required,min=1"` // Cents
+	Currency  string             `bson:"currency" json:"currency"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// MemberBalance is a member's net position in one currency; positive means they are owed money
+type MemberBalance struct {
+	UserID  primitive.ObjectID `json:"user_id"`
+	Balance int64              `json:"balance"` // Cents
+}
+
+// Transfer is a payment that settles balances
+type Transfer struct {
+	FromUser primitive.ObjectID `json:"from_user"`
+	ToUser   primitive.ObjectID `json:"to_user"`
+	Amount   int64              `json:"amount"` // Cents
+	Currency string             `json:"currency"`
+}
+
+// SplitInput describes how to split an expense
+type SplitInput struct {
+	Participants []primitive.ObjectID           // Members sharing an equal split
+	Shares       map[primitive.ObjectID]float64 // Weights for a shares split
+	Exact        map[primitive.ObjectID]int64   // Cents for an exact split
+	Items        []ExpenseItem                  // Lines for a by-item split
+}
+
+// CreateExpense creates a new expense; the splits are filled in by Split
+func CreateExpense(
+	groupID primitive.ObjectID,
+	description string,
+	paidBy primitive.ObjectID,
+	amount int64,
+	currency string,
+	splitType SplitType,
+	source ExpenseSource,
+	createdBy primitive.ObjectID,
+) *Expense {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return &Expense{
+		GroupID:     groupID,
+		Description: strings.TrimSpace(description),
+		PaidBy:      paidBy,
+		Amount:      amount,
+		Currency:    strings.ToUpper(strings.TrimSpace(currency)),
+		SplitType:   splitType,
+		Splits:      make([]ExpenseShare, 0),
+		Source:      source,
+		Date:        time.Now(),
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// CreateSettlement creates a new settlement record
+func CreateSettlement(
+	groupID primitive.ObjectID,
+	fromUser primitive.ObjectID,
+	toUser primitive.ObjectID,
+	amount int64,
+	currency string,
+	note string,
+	createdBy primitive.ObjectID,
+) *Settlement {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return &Settlement{
+		GroupID:   groupID,
+		FromUser:  fromUser,
+		ToUser:    toUser,
+		Amount:    amount,
+		Currency:  strings.ToUpper(strings.TrimSpace(currency)),
+		Note:      note,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ToCents converts a decimal amount to cents
+func ToCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// ValidateCurrency checks that a currency is a three letter ISO code
+func ValidateCurrency(currency string) error {
+	if !currencyPattern.MatchString(currency) {
+		return fmt.Errorf("currency must be a three letter code like %s", DefaultCurrency)
+	}
+	return nil
+}
+
+// Validate checks the expense before its splits are computed
+func (e *Expense) Validate() error {
+	if e.Description == "" {
+		return errors.New("description is required")
+	}
+	if e.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	return ValidateCurrency(e.Currency)
+}
+
+// Split computes what each member owes of the expense. Members must all be in
+// the given set; cents that don't divide evenly go to the largest remainders.
+func (e *Expense) Split(input SplitInput, members map[primitive.ObjectID]bool) error {
+	if e.SplitType == "" {
+		e.SplitType = SplitTypeEqual
+	}
+
+	splits, err := SplitAmount(e.Amount, e.SplitType, input, members)
+	if err != nil {
+		return err
+	}
+	if e.SplitType == SplitTypeByItem {
+		e.Items = input.Items
+	}
+	e.Splits = splits
+	return nil
+}
+
+// SplitAmount divides an amount in cents between members using a split rule.
+// Every member in the result must be in the given set.
+func SplitAmount(amount int64, splitType SplitType, input SplitInput, members map[primitive.ObjectID]bool) ([]ExpenseShare, error) {
+	var splits []ExpenseShare
+	var err error
+
+	switch splitType {
+	case SplitTypeEqual:
+		if len(input.Participants) == 0 {
+			return nil, errors.New("at least one participant is required")
+		}
+		weights := make([]float64, len(input.Participants))
+		for i := range weights {
+			weights[i] = 1
+		}
+		splits, err = splitWeighted(amount, input.Participants, weights)
+
+	case SplitTypeShares:
+		users := sortedUserIDs(input.Shares)
+		weights := make([]float64, len(users))
+		for i, userID := range users {
+			weights[i] = input.Shares[userID]
+		}
+		splits, err = splitWeighted(amount, users, weights)
+		for i := range splits {
+			splits[i].Shares = weights[i]
+		}
+
+	case SplitTypeExact:
+		var total int64
+		for _, userID := range sortedUserIDs(input.Exact) {
+			share := input.Exact[userID]
+			if share < 0 {
+				return nil, errors.New("exact amounts cannot be negative")
+			}
+			total += share
+			splits = append(splits, ExpenseShare{UserID: userID, Amount: share})
+		}
+		if len(splits) == 0 {
+			return nil, errors.New("exact amounts are required")
+		}
+		if total != amount {
+			return nil, fmt.Errorf("exact amounts add up to %s, not %s", FormatCents(total), FormatCents(amount))
+		}
+
+	case SplitTypeByItem:
+		splits, err = splitByItem(amount, input.Items)
+
+	default:
+		return nil, errors.New("split type must be equal, shares, exact or by_item")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, split := range splits {
+		if !members[split.UserID] {
+			return nil, fmt.Errorf("user %s is not a member of this group", split.UserID.Hex())
+		}
+	}
+	return splits, nil
+}
+
+// splitWeighted divides cents in proportion to the weights, handing leftover
+// cents to the largest remainders so the parts always add up to the total
+func splitWeighted(amount int64, users []primitive.ObjectID, weights []float64) ([]ExpenseShare, error) {
+	var totalWeight float64
+	seen := make(map[primitive.ObjectID]bool, len(users))
+	for i, weight := range weights {
+		if weight < 0 {
+			return nil, errors.New("shares cannot be negative")
+		}
+		if seen[users[i]] {
+			return nil, errors.New("each participant can only be listed once")
+		}
+		seen[users[i]] = true
+		totalWeight += weight
+	}
+	if totalWeight <= 0 {
+		return nil, errors.New("at least one participant needs a positive share")
+	}
+
+	splits := make([]ExpenseShare, len(users))
+	remainders := make([]float64, len(users))
+	var allocated int64
+	for i, userID := range users {
+		exact := float64(amount) * weights[i] / totalWeight
+		cents := int64(math.Floor(exact))
+		splits[i] = ExpenseShare{UserID: userID, Amount: cents}
+		remainders[i] = exact - float64(cents)
+		allocated += cents
+	}
+
+	order := make([]int, len(users))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; allocated < amount; i++ {
+		splits[order[i%len(order)]].Amount++
+		allocated++
+	}
+
+	return splits, nil
+}
+
+// splitByItem splits each line item evenly between the members sharing it
+func splitByItem(amount int64, items []ExpenseItem) ([]ExpenseShare, error) {
+	if len(items) == 0 {
+		return nil, errors.New("items are required for a by-item split")
+	}
+
+	owed := make(map[primitive.ObjectID]int64)
+	order := make([]primitive.ObjectID, 0)
+	var total int64
+	for _, item := range items {
+		if item.Amount <= 0 || len(item.UserIDs) == 0 {
+			return nil, fmt.Errorf("item %q needs a positive amount and at least one member", item.Name)
+		}
+		total += item.Amount
+
+		weights := make([]float64, len(item.UserIDs))
+		for i := range weights {
+			weights[i] = 1
+		}
+		parts, err := splitWeighted(item.Amount, item.UserIDs, weights)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			if _, ok := owed[part.UserID]; !ok {
+				order = append(order, part.UserID)
+			}
+			owed[part.UserID] += part.Amount
+		}
+	}
+	if total != amount {
+		return nil, fmt.Errorf("items add up to %s, not %s", FormatCents(total), FormatCents(amount))
+	}
+
+	splits := make([]ExpenseShare, 0, len(order))
+	for _, userID := range order {
+		splits = append(splits, ExpenseShare{UserID: userID, Amount: owed[userID]})
+	}
+	return splits, nil
+}
+
+// ComputeBalances works out each member's net balance per currency from the
+// expenses and settlements. Paying raises a balance and owing lowers it.
+func ComputeBalances(expenses []Expense, settlements []Settlement) map[string]map[primitive.ObjectID]int64 {
+	balances := make(map[string]map[primitive.ObjectID]int64)
+	account := func(currency string) map[primitive.ObjectID]int64 {
+		if balances[currency] == nil {
+			balances[currency] = make(map[primitive.ObjectID]int64)
+		}
+		return balances[currency]
+	}
+
+	for _, expense := range expenses {
+		ledger := account(expense.Currency)
+		ledger[expense.PaidBy] += expense.Amount
+		for _, split := range expense.Splits {
+			ledger[split.UserID] -= split.Amount
+		}
+	}
+
+	for _, settlement := range settlements {
+		ledger := account(settlement.Currency)
+		ledger[settlement.FromUser] += settlement.Amount
+		ledger[settlement.ToUser] -= settlement.Amount
+	}
+
+	return balances
+}
+
+// SortedBalances lists the non-zero balances of one currency, largest credit first
+func SortedBalances(ledger map[primitive.ObjectID]int64) []MemberBalance {
+	balances := make([]MemberBalance, 0, len(ledger))
+	for userID, balance := range ledger {
+		if balance != 0 {
+			balances = append(balances, MemberBalance{UserID: userID, Balance: balance})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Balance != balances[j].Balance {
+			return balances[i].Balance > balances[j].Balance
+		}
+		return balances[i].UserID.Hex() < balances[j].UserID.Hex()
+	})
+	return balances
+}
+
+// SettleUp suggests transfers that bring every balance in a currency to zero.
+// Debtors and creditors with matching amounts are paired first, then the
+// largest debtor pays the largest creditor until everyone is even. This needs
+// at most one transfer fewer than the number of members with a balance.
+func SettleUp(ledger map[primitive.ObjectID]int64, currency string) []Transfer {
+	var creditors, debtors []MemberBalance
+	for _, balance := range SortedBalances(ledger) {
+		if balance.Balance > 0 {
+			creditors = append(creditors, balance)
+		} else {
+			debtors = append(debtors, MemberBalance{UserID: balance.UserID, Balance: -balance.Balance})
+		}
+	}
+
+	transfers := make([]Transfer, 0)
+
+	// Exact matches settle two members with one transfer
+	for i := range debtors {
+		for j := range creditors {
+			if debtors[i].Balance > 0 && debtors[i].Balance == creditors[j].Balance {
+				transfers = append(transfers, Transfer{
+					FromUser: debtors[i].UserID,
+					ToUser:   creditors[j].UserID,
+					Amount:   debtors[i].Balance,
+					Currency: currency,
+				})
+				debtors[i].Balance, creditors[j].Balance = 0, 0
+				break
+			}
+		}
+	}
+
+	for {
+		debtor := largestBalance(debtors)
+		creditor := largestBalance(creditors)
+		if debtor < 0 || creditor < 0 {
+			break
+		}
+
+		amount := debtors[debtor].Balance
+		if creditors[creditor].Balance < amount {
+			amount = creditors[creditor].Balance
+		}
+		transfers = append(transfers, Transfer{
+			FromUser: debtors[debtor].UserID,
+			ToUser:   creditors[creditor].UserID,
+			Amount:   amount,
+			Currency: currency,
+		})
+		debtors[debtor].Balance -= amount
+		creditors[creditor].Balance -= amount
+	}
+
+	return transfers
+}
+
+// largestBalance returns the index of the largest positive balance, or -1
+func largestBalance(balances []MemberBalance) int {
+	largest := -1
+	for i, balance := range balances {
+		if balance.Balance > 0 && (largest < 0 || balance.Balance > balances[largest].Balance) {
+			largest = i
+		}
+	}
+	return largest
+}
+
+// FormatCents formats cents as a decimal amount, e.g. 1250 -> "12.50"
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// sortedUserIDs returns a map's user IDs in a stable order
+func sortedUserIDs[V any](values map[primitive.ObjectID]V) []primitive.ObjectID {
+	users := make([]primitive.ObjectID, 0, len(values))
+	for userID := range values {
+		users = append(users, userID)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Hex() < users[j].Hex()
+	})
+	return users
+}
@@ -15,6 +15,7 @@ type Group struct {
 	Name      string               `bson:"name" json:"name" validate:"required,min=3"`
 	GroupCode string               `bson:"group_code" json:"group_code"`
 	Members   []primitive.ObjectID `bson:"members" json:"members"`
+	Admins    []primitive.ObjectID `bson:"admins,omitempty" json:"admins,omitempty"`
 	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
 }
@@ -38,6 +39,20 @@ func NewGroup(name string) *Group {
 	}
 }
 
+// IsAdmin checks if a member can manage the group's settings. Groups created
+// before admins existed have none, so all of their members can.
+func (g *Group) IsAdmin(userID primitive.ObjectID) bool {
+	if len(g.Admins) == 0 {
+		return true
+	}
+	for _, admin := range g.Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
 // MigrateExistingGroups adds group codes to existing groups
 func MigrateExistingGroups(db *mongo.Database) error {
 	ctx := context.Background()
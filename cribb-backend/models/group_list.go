@@ -0,0 +1,206 @@
+package models
+
+import (
+	"cribb-backend/units"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrListItemClaimed is returned when claiming an item another member already claimed
+	ErrListItemClaimed = errors.New("item is already claimed by another member")
+
+	// ErrListItemNotClaimed is returned when releasing an item the member hasn't claimed
+	ErrListItemNotClaimed = errors.New("item is not claimed by you")
+)
+
+// ListRequest is one member's request for an item on the group list
+type ListRequest struct {
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	UserName    string             `bson:"user_name" json:"user_name"`
+	Quantity    float64            `bson:"quantity" json:"quantity"` // In the list item's unit
+	RequestedAt time.Time          `bson:"requested_at" json:"requested_at"`
+}
+
+// GroupListItem is an item on the group's shared shopping list. Requests for
+// the same item in compatible units are merged into one entry.
+type GroupListItem struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	GroupID       primitive.ObjectID  `bson:"group_id" json:"group_id" validate:"required"`
+	ItemName      string              `bson:"item_name" json:"item_name" validate:"required"`
+	ItemKey       string              `bson:"item_key" json:"item_key"`
+	Quantity      float64             `bson:"quantity" json:"quantity"` // Sum of the requests
+	Unit          string              `bson:"unit" json:"unit"`
+	UnitKey       string              `bson:"unit_key" json:"-"` // Items merge when their unit keys match
+	Category      string              `bson:"category" json:"category"`
+	Requests      []ListRequest       `bson:"requests" json:"requests"`
+	ClaimedBy     *primitive.ObjectID `bson:"claimed_by,omitempty" json:"claimed_by,omitempty"`
+	ClaimedByName string              `bson:"claimed_by_name,omitempty" json:"claimed_by_name,omitempty"`
+	ClaimedAt     *time.Time          `bson:"claimed_at,omitempty" json:"claimed_at,omitempty"`
+	Version       int64               `bson:"version" json:"version"` // Bumped on every change
+	AddedAt       time.Time           `bson:"added_at" json:"added_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// ListUnitKey returns the key that decides which units can share a list entry:
+// the dimension for known units, otherwise the unit itself. Containers only
+// share an entry with the same container.
+func ListUnitKey(unit string) string {
+	if unit == "" {
+		return string(units.Count)
+	}
+	if u, ok := units.Lookup(unit); ok {
+		if u.Dimension == units.Container {
+			return u.Symbol
+		}
+		return string(u.Dimension)
+	}
+	return strings.ToLower(strings.TrimSpace(unit))
+}
+
+// CreateGroupListItem creates a new, unclaimed list item with no requests yet
+func CreateGroupListItem(
+	groupID primitive.ObjectID,
+	itemName string,
+	unit string,
+	category string,
+) *GroupListItem {
+	if unit == "" {
+		unit = "pc"
+	}
+
+	return &GroupListItem{
+		GroupID:   groupID,
+		ItemName:  strings.TrimSpace(itemName),
+		ItemKey:   IngredientKey(itemName),
+		Unit:      unit,
+		UnitKey:   ListUnitKey(unit),
+		Category:  category,
+		Requests:  make([]ListRequest, 0),
+		AddedAt:   time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// AddRequest adds a member's quantity to the item, converting it into the item's
+// unit. A member who already asked for the item has their request increased.
+func (i *GroupListItem) AddRequest(userID primitive.ObjectID, userName string, quantity float64, unit string, now time.Time) error {
+	if quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	if unit == "" {
+		unit = "pc"
+	}
+
+	converted := quantity
+	if !strings.EqualFold(unit, i.Unit) {
+		var err error
+		if converted, err = units.Convert(quantity, unit, i.Unit); err != nil {
+			return err
+		}
+	}
+
+	found := false
+	for idx := range i.Requests {
+		if i.Requests[idx].UserID == userID {
+			i.Requests[idx].Quantity = units.Round(i.Requests[idx].Quantity + converted)
+			i.Requests[idx].RequestedAt = now
+			found = true
+			break
+		}
+	}
+	if !found {
+		i.Requests = append(i.Requests, ListRequest{
+			UserID:      userID,
+			UserName:    userName,
+			Quantity:    converted,
+			RequestedAt: now,
+		})
+	}
+
+	i.Quantity = units.Round(i.Quantity + converted)
+	i.touch(now)
+	return nil
+}
+
+// RemoveRequest takes back a member's request and reports whether they had one
+func (i *GroupListItem) RemoveRequest(userID primitive.ObjectID, now time.Time) bool {
+	for idx, request := range i.Requests {
+		if request.UserID == userID {
+			i.Requests = append(i.Requests[:idx], i.Requests[idx+1:]...)
+			i.Quantity = units.Round(i.Quantity - request.Quantity)
+			i.touch(now)
+			return true
+		}
+	}
+	return false
+}
+
+// Fulfil takes a bought quantity off the oldest requests first and releases the
+// claim so anything still wanted can be picked up by someone else
+func (i *GroupListItem) Fulfil(quantity float64, now time.Time) {
+	remaining := make([]ListRequest, 0, len(i.Requests))
+	for _, request := range i.Requests {
+		if quantity >= request.Quantity {
+			quantity = units.Round(quantity - request.Quantity)
+			continue
+		}
+		request.Quantity = units.Round(request.Quantity - quantity)
+		quantity = 0
+		remaining = append(remaining, request)
+	}
+
+	i.Requests = remaining
+	i.Quantity = 0
+	for _, request := range remaining {
+		i.Quantity = units.Round(i.Quantity + request.Quantity)
+	}
+	i.ClaimedBy = nil
+	i.ClaimedByName = ""
+	i.ClaimedAt = nil
+	i.touch(now)
+}
+
+// IsClaimed checks if a shopper has claimed the item
+func (i *GroupListItem) IsClaimed() bool {
+	return i.ClaimedBy != nil
+}
+
+// Claim marks the member as the one buying the item. Claiming an item the
+// member already holds is allowed.
+func (i *GroupListItem) Claim(userID primitive.ObjectID, userName string, now time.Time) error {
+	if i.IsClaimed() {
+		if *i.ClaimedBy == userID {
+			return nil
+		}
+		return ErrListItemClaimed
+	}
+
+	i.ClaimedBy = &userID
+	i.ClaimedByName = userName
+	i.ClaimedAt = &now
+	i.touch(now)
+	return nil
+}
+
+// Unclaim releases the member's claim on the item
+func (i *GroupListItem) Unclaim(userID primitive.ObjectID, now time.Time) error {
+	if !i.IsClaimed() || *i.ClaimedBy != userID {
+		return ErrListItemNotClaimed
+	}
+
+	i.ClaimedBy = nil
+	i.ClaimedByName = ""
+	i.ClaimedAt = nil
+	i.touch(now)
+	return nil
+}
+
+// touch records a change to the item
+func (i *GroupListItem) touch(now time.Time) {
+	i.Version++
+	i.UpdatedAt = now
+}
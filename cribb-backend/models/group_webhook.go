@@ -0,0 +1,151 @@
+// models/group_webhook.go
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxGroupWebhooks caps how many webhooks a group can register
+	MaxGroupWebhooks = 10
+
+	// WebhookDeliveryRetention is how long delivery logs are kept
+	WebhookDeliveryRetention = 14 * 24 * time.Hour
+)
+
+// GroupWebhook is a URL a group's events are posted to. Payloads are signed
+// with the secret, which is only shown when the webhook is created.
+type GroupWebhook struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID     primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	URL         string             `bson:"url" json:"url" validate:"required"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Events      []string           `bson:"events" json:"events" validate:"required"`
+	Secret      string             `bson:"secret" json:"-"`
+	Active      bool               `bson:"active" json:"active"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// GenerateWebhookSecret returns a new random signing secret
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// CreateGroupWebhook creates an active webhook with a new secret
+func CreateGroupWebhook(groupID primitive.ObjectID, webhookURL, description string, events []string, createdBy primitive.ObjectID) (*GroupWebhook, error) {
+	secret, err := GenerateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &GroupWebhook{
+		GroupID:     groupID,
+		URL:         webhookURL,
+		Description: description,
+		Events:      events,
+		Secret:      secret,
+		Active:      true,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+// Validate checks the webhook has a public http or https URL and at least one event
+func (w *GroupWebhook) Validate() error {
+	if err := ValidatePublicURL(w.URL, "https", "http"); err != nil {
+		return fmt.Errorf("webhook URL must be a public http or https URL: %w", err)
+	}
+	if len(w.Events) == 0 {
+		return errors.New("at least one event type is required")
+	}
+	return nil
+}
+
+// Wants checks if the webhook is subscribed to an event type
+func (w *GroupWebhook) Wants(eventType string) bool {
+	if !w.Active {
+		return false
+	}
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookAttempt is one try at delivering a webhook, kept as its delivery log
+type WebhookAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// WebhookDelivery is one event on its way to one webhook. The payload is kept
+// as sent so retries post identical bytes.
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID     primitive.ObjectID `bson:"webhook_id" json:"webhook_id" validate:"required"`
+	GroupID       primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	EventType     string             `bson:"event_type" json:"event_type"`
+	Payload       string             `bson:"payload" json:"payload"`
+	Status        OutboxStatus       `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	MaxAttempts   int                `bson:"max_attempts" json:"max_attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	Log           []WebhookAttempt   `bson:"log" json:"log"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	DeliveredAt   *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"-"`
+}
+
+// CreateWebhookDelivery queues a payload for its first attempt
+func CreateWebhookDelivery(webhook *GroupWebhook, eventType string, payload []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		WebhookID:     webhook.ID,
+		GroupID:       webhook.GroupID,
+		EventType:     eventType,
+		Payload:       string(payload),
+		Status:        OutboxStatusPending,
+		MaxAttempts:   DefaultOutboxAttempts,
+		NextAttemptAt: now,
+		Log:           []WebhookAttempt{},
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(WebhookDeliveryRetention),
+	}
+}
+
+// RecordAttempt logs an attempt. Failures are retried with the same backoff
+// as the notification outbox until they're permanent or out of attempts.
+func (d *WebhookDelivery) RecordAttempt(attempt WebhookAttempt, err error, permanent bool) {
+	d.Attempts++
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	d.Log = append(d.Log, attempt)
+
+	switch {
+	case err == nil:
+		d.Status = OutboxStatusSent
+		d.DeliveredAt = &attempt.At
+	case permanent || d.Attempts >= d.MaxAttempts:
+		d.Status = OutboxStatusFailed
+	default:
+		d.Status = OutboxStatusPending
+		d.NextAttemptAt = attempt.At.Add(OutboxBackoff(d.Attempts))
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MealType defines which meal of the day a plan entry is for
+type MealType string
+
+const (
+	MealTypeBreakfast MealType = "breakfast"
+	MealTypeLunch     MealType = "lunch"
+	MealTypeDinner    MealType = "dinner"
+	MealTypeSnack     MealType = "snack"
+)
+
+// MealPlanEntry represents a recipe planned for a day in a group's meal plan
+type MealPlanEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID    primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	RecipeID   primitive.ObjectID `bson:"recipe_id" json:"recipe_id" validate:"required"`
+	RecipeName string             `bson:"recipe_name" json:"recipe_name"`
+	Date       time.Time          `bson:"date" json:"date" validate:"required"` // Midnight UTC of the planned day
+	MealType   MealType           `bson:"meal_type" json:"meal_type"`
+	Servings   int                `bson:"servings" json:"servings"`
+	CookedAt   *time.Time         `bson:"cooked_at,omitempty" json:"cooked_at,omitempty"`
+	CookedBy   primitive.ObjectID `bson:"cooked_by,omitempty" json:"cooked_by,omitempty"`
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// CreateMealPlanEntry creates a new meal plan entry
+func CreateMealPlanEntry(
+	groupID primitive.ObjectID,
+	recipe *Recipe,
+	date time.Time,
+	mealType MealType,
+	servings int,
+	createdBy primitive.ObjectID,
+) *MealPlanEntry {
+	if servings <= 0 {
+		servings = recipe.Servings
+	}
+
+	return &MealPlanEntry{
+		GroupID:    groupID,
+		RecipeID:   recipe.ID,
+		RecipeName: recipe.Name,
+		Date:       PlanDay(date),
+		MealType:   mealType,
+		Servings:   servings,
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// IsCooked checks if the planned meal has been cooked
+func (e *MealPlanEntry) IsCooked() bool {
+	return e.CookedAt != nil
+}
+
+// ParseMealType validates a meal type, defaulting to dinner
+func ParseMealType(mealType string) (MealType, error) {
+	switch parsed := MealType(strings.ToLower(strings.TrimSpace(mealType))); parsed {
+	case "":
+		return MealTypeDinner, nil
+	case MealTypeBreakfast, MealTypeLunch, MealTypeDinner, MealTypeSnack:
+		return parsed, nil
+	default:
+		return "", errors.New("meal type must be breakfast, lunch, dinner or snack")
+	}
+}
+
+// PlanDay truncates a time to midnight UTC of its calendar day
+func PlanDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// WeekStart returns the Monday of the week containing the given day
+func WeekStart(t time.Time) time.Time {
+	day := PlanDay(t)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
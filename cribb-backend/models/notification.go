@@ -0,0 +1,192 @@
+// models/notification.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationDomain is the part of the app a notification comes from
+type NotificationDomain string
+
+const (
+	// NotificationDomainChores covers chore assignment and overdue chores
+	NotificationDomainChores NotificationDomain = "chores"
+
+	// NotificationDomainPantry covers stock and expiry warnings
+	NotificationDomainPantry NotificationDomain = "pantry"
+
+	// NotificationDomainCart covers changes to the shared shopping cart
+	NotificationDomainCart NotificationDomain = "cart"
+
+	// NotificationDomainGroup covers group membership changes
+	NotificationDomainGroup NotificationDomain = "group"
+)
+
+const (
+	// NotificationTypeChoreAssigned indicates a chore was assigned to someone
+	NotificationTypeChoreAssigned NotificationType = "chore_assigned"
+
+	// NotificationTypeChoreOverdue indicates a chore is past its due date
+	NotificationTypeChoreOverdue NotificationType = "chore_overdue"
+
+	// NotificationTypeCartChanged indicates the shopping cart was changed
+	NotificationTypeCartChanged NotificationType = "cart_changed"
+
+	// NotificationTypeMemberJoined indicates someone joined the group
+	NotificationTypeMemberJoined NotificationType = "member_joined"
+)
+
+// UserSubjectTypes are the notification types whose subject is a member
+// rather than a chore or item. They go when the member deletes their account.
+var UserSubjectTypes = []NotificationType{NotificationTypeMemberJoined}
+
+// NotificationRetention is how long notifications are kept before the TTL index removes them
+const NotificationRetention = 30 * 24 * time.Hour
+
+// Notification is an entry in a user's inbox. Notifications without a UserID
+// go to the whole group.
+type Notification struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	GroupID     primitive.ObjectID   `bson:"group_id" json:"group_id" validate:"required"`
+	UserID      primitive.ObjectID   `bson:"user_id,omitempty" json:"user_id,omitempty"`   // Recipient, unset for group-wide notifications
+	ActorID     primitive.ObjectID   `bson:"actor_id,omitempty" json:"actor_id,omitempty"` // Who caused it; they don't get their own notifications
+	Domain      NotificationDomain   `bson:"domain" json:"domain" validate:"required"`
+	Type        NotificationType     `bson:"type" json:"type" validate:"required"`
+	SubjectID   primitive.ObjectID   `bson:"subject_id,omitempty" json:"subject_id,omitempty"` // The chore, pantry item, cart item or member concerned
+	SubjectName string               `bson:"subject_name,omitempty" json:"subject_name,omitempty"`
+	Message     string               `bson:"message" json:"message"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	ReadBy      []primitive.ObjectID `bson:"read_by" json:"read_by"`
+	HiddenFrom  []primitive.ObjectID `bson:"hidden_from,omitempty" json:"-"` // Members who turned this type off in their inbox
+	ExpiresAt   time.Time            `bson:"expires_at" json:"expires_at"`
+}
+
+// CreateNotification creates a new group-wide notification
+func CreateNotification(
+	groupID primitive.ObjectID,
+	domain NotificationDomain,
+	notificationType NotificationType,
+	subjectID primitive.ObjectID,
+	subjectName string,
+	message string,
+) *Notification {
+	now := time.Now()
+	return &Notification{
+		GroupID:     groupID,
+		Domain:      domain,
+		Type:        notificationType,
+		SubjectID:   subjectID,
+		SubjectName: subjectName,
+		Message:     message,
+		CreatedAt:   now,
+		ReadBy:      make([]primitive.ObjectID, 0),
+		ExpiresAt:   now.Add(NotificationRetention),
+	}
+}
+
+// NotificationFromPantry creates the inbox entry for a pantry notification
+func NotificationFromPantry(p *PantryNotification) *Notification {
+	subjectID := p.ItemID
+	if !p.BatchID.IsZero() {
+		subjectID = p.BatchID
+	}
+	return CreateNotification(p.GroupID, NotificationDomainPantry, p.Type, subjectID, p.ItemName, p.Message)
+}
+
+// NotificationFromCartActivity creates the inbox entry for a cart activity.
+// The member who made the change doesn't see it in their own inbox.
+func NotificationFromCartActivity(a *ShoppingCartActivity) *Notification {
+	message := a.Details
+	if message == "" {
+		message = a.UserName + " changed " + a.ItemName
+	}
+	n := CreateNotification(a.GroupID, NotificationDomainCart, NotificationTypeCartChanged, a.ItemID, a.ItemName, message)
+	n.ActorID = a.UserID
+	return n
+}
+
+// createChoreNotification creates a notification for a chore's assignee
+func createChoreNotification(chore *Chore, notificationType NotificationType, message string) *Notification {
+	n := CreateNotification(chore.GroupID, NotificationDomainChores, notificationType, chore.ID, chore.Title, message)
+	n.UserID = chore.AssignedTo
+	return n
+}
+
+// CreateChoreAssignedNotification tells the assignee about a new chore
+func CreateChoreAssignedNotification(chore *Chore) *Notification {
+	message := chore.Title + " was assigned to you"
+	if !chore.DueDate.IsZero() {
+		message += ", due " + chore.DueDate.Format("Mon Jan 2")
+	}
+	return createChoreNotification(chore, NotificationTypeChoreAssigned, message)
+}
+
+// CreateChoreOverdueNotification tells the assignee a chore is past its due date
+func CreateChoreOverdueNotification(chore *Chore) *Notification {
+	return createChoreNotification(chore, NotificationTypeChoreOverdue, chore.Title+" is overdue")
+}
+
+// CreateMemberJoinedNotification tells the rest of the group someone joined
+func CreateMemberJoinedNotification(groupID primitive.ObjectID, user *User) *Notification {
+	n := CreateNotification(groupID, NotificationDomainGroup, NotificationTypeMemberJoined, user.ID, user.Name, user.Name+" joined the group")
+	n.ActorID = user.ID
+	return n
+}
+
+// notificationTitles are the headings used for email subjects and push messages
+var notificationTitles = map[NotificationType]string{
+	NotificationTypeLowStock:       "Running low",
+	NotificationTypeOutOfStock:     "Out of stock",
+	NotificationTypeRunningOutSoon: "Running out soon",
+	NotificationTypeExpiringSoon:   "Expiring soon",
+	NotificationTypeExpired:        "Expired",
+	NotificationTypeChoreAssigned:  "New chore",
+	NotificationTypeChoreOverdue:   "Chore overdue",
+	NotificationTypeCartChanged:    "Shopping cart updated",
+	NotificationTypeMemberJoined:   "New roommate",
+}
+
+// Title returns a short heading for the notification
+func (n *Notification) Title() string {
+	title, ok := notificationTitles[n.Type]
+	if !ok {
+		title = "Notification"
+	}
+	if n.SubjectName != "" {
+		title += ": " + n.SubjectName
+	}
+	return title
+}
+
+// IsVisibleTo checks if the notification belongs in a user's inbox
+func (n *Notification) IsVisibleTo(userID primitive.ObjectID) bool {
+	if n.ActorID == userID {
+		return false
+	}
+	for _, id := range n.HiddenFrom {
+		if id == userID {
+			return false
+		}
+	}
+	return n.UserID.IsZero() || n.UserID == userID
+}
+
+// HasBeenReadBy checks if the notification has been read by a specific user
+func (n *Notification) HasBeenReadBy(userID primitive.ObjectID) bool {
+	for _, id := range n.ReadBy {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkAsReadByUser marks the notification as read by a specific user
+func (n *Notification) MarkAsReadByUser(userID primitive.ObjectID) {
+	if n.HasBeenReadBy(userID) {
+		return
+	}
+	n.ReadBy = append(n.ReadBy, userID)
+}
@@ -0,0 +1,257 @@
+// models/notification_preferences.go
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationChannel is a way a notification reaches a user
+type NotificationChannel string
+
+const (
+	// NotificationChannelInApp is the notification inbox
+	NotificationChannelInApp NotificationChannel = "in_app"
+
+	// NotificationChannelPush is a mobile push notification
+	NotificationChannelPush NotificationChannel = "push"
+
+	// NotificationChannelEmail is an email
+	NotificationChannelEmail NotificationChannel = "email"
+
+	// NotificationChannelSMS is a text message to the user's phone number
+	NotificationChannelSMS NotificationChannel = "sms"
+
+	// NotificationChannelWebhook is a JSON POST to a URL the user chose
+	NotificationChannelWebhook NotificationChannel = "webhook"
+)
+
+// NotificationChannels lists every channel in delivery order
+var NotificationChannels = []NotificationChannel{
+	NotificationChannelInApp,
+	NotificationChannelPush,
+	NotificationChannelEmail,
+	NotificationChannelSMS,
+	NotificationChannelWebhook,
+}
+
+// DeliveryMode says when a notification goes out on a channel
+type DeliveryMode string
+
+const (
+	// DeliveryImmediate sends the notification as soon as it happens, outside quiet hours
+	DeliveryImmediate DeliveryMode = "immediate"
+
+	// DeliveryDigest collects the notification into a periodic summary
+	DeliveryDigest DeliveryMode = "digest"
+
+	// DeliveryOff doesn't send the notification on the channel at all
+	DeliveryOff DeliveryMode = "off"
+)
+
+// NotificationTypes lists every notification type users can set preferences for
+var NotificationTypes = []NotificationType{
+	NotificationTypeLowStock,
+	NotificationTypeOutOfStock,
+	NotificationTypeRunningOutSoon,
+	NotificationTypeExpiringSoon,
+	NotificationTypeExpired,
+	NotificationTypeChoreAssigned,
+	NotificationTypeChoreOverdue,
+	NotificationTypeCartChanged,
+	NotificationTypeMemberJoined,
+}
+
+// defaultDeliveryModes are used for channels a user hasn't set a preference for
+var defaultDeliveryModes = map[NotificationChannel]DeliveryMode{
+	NotificationChannelInApp:   DeliveryImmediate,
+	NotificationChannelPush:    DeliveryImmediate,
+	NotificationChannelEmail:   DeliveryDigest,
+	NotificationChannelSMS:     DeliveryOff, // Texts cost money, so they're opt-in
+	NotificationChannelWebhook: DeliveryOff, // Needs a URL, so it's opt-in
+}
+
+// quietHoursLayout is the clock format quiet hours are given in
+const quietHoursLayout = "15:04"
+
+// QuietHours is a daily window, in the user's timezone, when nothing is sent
+// immediately. The window may wrap past midnight, e.g. 22:00 to 07:00.
+type QuietHours struct {
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+}
+
+// NotificationPreferences holds how a user wants to be notified
+type NotificationPreferences struct {
+	ID         primitive.ObjectID                                        `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID                                        `bson:"user_id" json:"user_id" validate:"required"`
+	Timezone   string                                                    `bson:"timezone" json:"timezone"`
+	QuietHours *QuietHours                                               `bson:"quiet_hours,omitempty" json:"quiet_hours,omitempty"`
+	Email      string                                                    `bson:"email,omitempty" json:"email,omitempty"`             // Where email notifications go
+	WebhookURL string                                                    `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"` // Where webhook notifications are posted
+	Types      map[NotificationType]map[NotificationChannel]DeliveryMode `bson:"types" json:"types"`
+	Digest     DigestSettings                                            `bson:"digest" json:"digest"`
+	UpdatedAt  time.Time                                                 `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultNotificationPreferences returns the preferences of a user who hasn't set any
+func DefaultNotificationPreferences(userID primitive.ObjectID) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:   userID,
+		Timezone: "UTC",
+		Types:    make(map[NotificationType]map[NotificationChannel]DeliveryMode),
+		Digest:   DefaultDigestSettings(),
+	}
+}
+
+// minutes parses an HH:MM clock time into minutes after midnight
+func minutes(clock string) (int, error) {
+	t, err := time.Parse(quietHoursLayout, clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks the clock times and that the window isn't empty
+func (q QuietHours) Validate() error {
+	start, err := minutes(q.Start)
+	if err != nil {
+		return err
+	}
+	end, err := minutes(q.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("quiet hours must start and end at different times")
+	}
+	return nil
+}
+
+// Until returns when the quiet hours containing t end, or t itself if t is
+// outside quiet hours. t is interpreted in loc.
+func (q QuietHours) Until(t time.Time, loc *time.Location) time.Time {
+	start, err := minutes(q.Start)
+	if err != nil {
+		return t
+	}
+	end, err := minutes(q.End)
+	if err != nil {
+		return t
+	}
+
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	endOn := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, end/60, end%60, 0, 0, loc)
+	}
+
+	switch {
+	case start < end && now >= start && now < end:
+		// Same-day window
+		return endOn(0)
+	case start > end && now >= start:
+		// Overnight window, before midnight
+		return endOn(1)
+	case start > end && now < end:
+		// Overnight window, after midnight
+		return endOn(0)
+	}
+	return t
+}
+
+// Location returns the user's timezone, falling back to UTC
+func (p *NotificationPreferences) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Validate checks the timezone, quiet hours, digest and every type, channel and mode
+func (p *NotificationPreferences) Validate() error {
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", p.Timezone)
+	}
+	if p.QuietHours != nil {
+		if err := p.QuietHours.Validate(); err != nil {
+			return fmt.Errorf("quiet hours: %v", err)
+		}
+	}
+	if p.Email != "" {
+		if _, err := mail.ParseAddress(p.Email); err != nil {
+			return fmt.Errorf("invalid email address %q", p.Email)
+		}
+	}
+	if p.WebhookURL != "" {
+		if err := ValidatePublicURL(p.WebhookURL, "https"); err != nil {
+			return fmt.Errorf("webhook URL must be a public https URL: %w", err)
+		}
+	}
+	if err := p.Digest.Validate(); err != nil {
+		return fmt.Errorf("digest: %v", err)
+	}
+
+	knownTypes := make(map[NotificationType]bool, len(NotificationTypes))
+	for _, t := range NotificationTypes {
+		knownTypes[t] = true
+	}
+	for notificationType, channels := range p.Types {
+		if !knownTypes[notificationType] {
+			return fmt.Errorf("unknown notification type %q", notificationType)
+		}
+		for channel, mode := range channels {
+			if _, ok := defaultDeliveryModes[channel]; !ok {
+				return fmt.Errorf("unknown notification channel %q", channel)
+			}
+			switch mode {
+			case DeliveryImmediate, DeliveryDigest, DeliveryOff:
+			default:
+				return fmt.Errorf("unknown delivery mode %q", mode)
+			}
+			if channel == NotificationChannelInApp && mode == DeliveryDigest {
+				return errors.New("the in-app inbox can't be delivered as a digest")
+			}
+		}
+	}
+	return nil
+}
+
+// ModeFor returns how a notification type should be delivered on a channel
+func (p *NotificationPreferences) ModeFor(notificationType NotificationType, channel NotificationChannel) DeliveryMode {
+	if mode, ok := p.Types[notificationType][channel]; ok {
+		return mode
+	}
+	if mode, ok := defaultDeliveryModes[channel]; ok {
+		return mode
+	}
+	return DeliveryOff
+}
+
+// Wants checks if the user wants a notification type on any channel
+func (p *NotificationPreferences) Wants(notificationType NotificationType) bool {
+	for _, channel := range NotificationChannels {
+		if p.ModeFor(notificationType, channel) != DeliveryOff {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliverAt returns when an immediate notification created at t may be sent,
+// holding it until the user's quiet hours end
+func (p *NotificationPreferences) DeliverAt(t time.Time) time.Time {
+	if p.QuietHours == nil {
+		return t
+	}
+	return p.QuietHours.Until(t, p.Location())
+}
@@ -0,0 +1,121 @@
+// models/outbox.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxStatus tracks an outbound message through delivery
+type OutboxStatus string
+
+const (
+	// OutboxStatusPending messages are waiting for their next attempt
+	OutboxStatusPending OutboxStatus = "pending"
+
+	// OutboxStatusSending messages are claimed by a worker
+	OutboxStatusSending OutboxStatus = "sending"
+
+	// OutboxStatusSent messages were accepted by the channel
+	OutboxStatusSent OutboxStatus = "sent"
+
+	// OutboxStatusFailed messages ran out of attempts or can never be delivered
+	OutboxStatusFailed OutboxStatus = "failed"
+)
+
+const (
+	// DefaultOutboxAttempts is how many times a message is tried before giving up
+	DefaultOutboxAttempts = 6
+
+	// outboxBaseBackoff is the wait after the first failed attempt; it doubles each time
+	outboxBaseBackoff = time.Minute
+
+	// outboxMaxBackoff caps the wait between attempts
+	outboxMaxBackoff = 2 * time.Hour
+
+	// OutboxClaimTimeout is how long a claimed message may stay sending before
+	// another worker assumes the first one died and retries it
+	OutboxClaimTimeout = 5 * time.Minute
+)
+
+// DeliveryTarget is where a message goes on its channel
+type DeliveryTarget struct {
+	Address string `bson:"address" json:"address"`                   // Email address, phone number, webhook URL or push endpoint
+	P256dh  string `bson:"p256dh,omitempty" json:"p256dh,omitempty"` // Web Push subscription public key
+	Auth    string `bson:"auth,omitempty" json:"auth,omitempty"`     // Web Push subscription auth secret
+}
+
+// OutboxMessage is a notification waiting to go out on one channel to one user
+type OutboxMessage struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	NotificationID primitive.ObjectID  `bson:"notification_id,omitempty" json:"notification_id,omitempty"`
+	UserID         primitive.ObjectID  `bson:"user_id" json:"user_id" validate:"required"`
+	Channel        NotificationChannel `bson:"channel" json:"channel" validate:"required"`
+	Target         DeliveryTarget      `bson:"target" json:"target"`
+	Subject        string              `bson:"subject" json:"subject"`
+	Body           string              `bson:"body" json:"body"`
+	HTMLBody       string              `bson:"html_body,omitempty" json:"html_body,omitempty"` // Rich version of Body for channels that support it
+	Status         OutboxStatus        `bson:"status" json:"status"`
+	Attempts       int                 `bson:"attempts" json:"attempts"`
+	MaxAttempts    int                 `bson:"max_attempts" json:"max_attempts"`
+	NextAttemptAt  time.Time           `bson:"next_attempt_at" json:"next_attempt_at"`
+	LastError      string              `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	SentAt         *time.Time          `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
+
+// CreateOutboxMessage queues a message for its first attempt at sendAt
+func CreateOutboxMessage(
+	userID primitive.ObjectID,
+	channel NotificationChannel,
+	target DeliveryTarget,
+	subject string,
+	body string,
+	sendAt time.Time,
+) *OutboxMessage {
+	return &OutboxMessage{
+		UserID:        userID,
+		Channel:       channel,
+		Target:        target,
+		Subject:       subject,
+		Body:          body,
+		Status:        OutboxStatusPending,
+		MaxAttempts:   DefaultOutboxAttempts,
+		NextAttemptAt: sendAt,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// OutboxBackoff returns how long to wait after the given number of failed attempts
+func OutboxBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// MarkSent records a successful delivery
+func (m *OutboxMessage) MarkSent(now time.Time) {
+	m.Attempts++
+	m.Status = OutboxStatusSent
+	m.LastError = ""
+	m.SentAt = &now
+}
+
+// RecordFailure records a failed attempt and schedules the next one with
+// exponential backoff. Permanent failures and the last attempt fail the message.
+func (m *OutboxMessage) RecordFailure(err error, permanent bool, now time.Time) {
+	m.Attempts++
+	m.LastError = err.Error()
+	if permanent || m.Attempts >= m.MaxAttempts {
+		m.Status = OutboxStatusFailed
+		return
+	}
+	m.Status = OutboxStatusPending
+	m.NextAttemptAt = now.Add(OutboxBackoff(m.Attempts))
+}
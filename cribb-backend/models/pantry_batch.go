@@ -0,0 +1,200 @@
+package models
+
+import (
+	"cribb-backend/units"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInsufficientQuantity is returned when more is consumed than the pantry holds
+var ErrInsufficientQuantity = errors.New("not enough quantity available")
+
+// PantryBatch is a single purchase of a pantry item with its own expiration date
+type PantryBatch struct {
+	ID                  primitive.ObjectID `bson:"_id" json:"id"`
+	Quantity            float64            `bson:"quantity" json:"quantity"`
+	ExpirationDate      time.Time          `bson:"expiration_date,omitempty" json:"expiration_date,omitempty"`
+	ExpirationEstimated bool               `bson:"expiration_estimated,omitempty" json:"expiration_estimated,omitempty"` // Filled in from shelf-life rules
+	Price               float64            `bson:"price,omitempty" json:"price,omitempty"`                               // Total paid for the batch, if recorded
+	PurchasedBy         primitive.ObjectID `bson:"purchased_by" json:"purchased_by"`
+	AddedAt             time.Time          `bson:"added_at" json:"added_at"`
+}
+
+// BatchConsumption records how much was taken from one batch
+type BatchConsumption struct {
+	BatchID        primitive.ObjectID `json:"batch_id"`
+	Quantity       float64            `json:"quantity"`
+	ExpirationDate time.Time          `json:"expiration_date,omitempty"`
+	Emptied        bool               `json:"emptied"`
+}
+
+// CreatePantryBatch creates a new batch
+func CreatePantryBatch(quantity float64, expirationDate time.Time, purchasedBy primitive.ObjectID) PantryBatch {
+	return PantryBatch{
+		ID:             primitive.NewObjectID(),
+		Quantity:       quantity,
+		ExpirationDate: expirationDate,
+		PurchasedBy:    purchasedBy,
+		AddedAt:        time.Now(),
+	}
+}
+
+// IsExpiringSoon checks if the batch is expiring within the given number of days
+func (b *PantryBatch) IsExpiringSoon(days int) bool {
+	if b.ExpirationDate.IsZero() {
+		return false
+	}
+
+	expirationThreshold := time.Now().AddDate(0, 0, days)
+	return b.ExpirationDate.Before(expirationThreshold) && b.ExpirationDate.After(time.Now())
+}
+
+// IsExpired checks if the batch is already expired
+func (b *PantryBatch) IsExpired() bool {
+	if b.ExpirationDate.IsZero() {
+		return false
+	}
+
+	return b.ExpirationDate.Before(time.Now())
+}
+
+// EnsureBatches converts an item stored before batches existed into a single batch.
+// The batch reuses the item's ID so repeated conversions stay stable.
+func (p *PantryItem) EnsureBatches() {
+	if len(p.Batches) > 0 || p.Quantity <= 0 {
+		return
+	}
+
+	p.Batches = []PantryBatch{{
+		ID:             p.ID,
+		Quantity:       p.Quantity,
+		ExpirationDate: p.ExpirationDate,
+		PurchasedBy:    p.AddedBy,
+		AddedAt:        p.CreatedAt,
+	}}
+}
+
+// AddBatch adds a batch and refreshes the item's totals
+func (p *PantryItem) AddBatch(batch PantryBatch) {
+	p.EnsureBatches()
+	p.Batches = append(p.Batches, batch)
+	p.SyncBatches()
+}
+
+// RemoveBatch removes a batch by ID and refreshes the item's totals
+func (p *PantryItem) RemoveBatch(batchID primitive.ObjectID) (PantryBatch, bool) {
+	p.EnsureBatches()
+	for i, batch := range p.Batches {
+		if batch.ID == batchID {
+			p.Batches = append(p.Batches[:i], p.Batches[i+1:]...)
+			p.SyncBatches()
+			return batch, true
+		}
+	}
+	return PantryBatch{}, false
+}
+
+// FindBatch returns the batch with the given ID
+func (p *PantryItem) FindBatch(batchID primitive.ObjectID) (PantryBatch, bool) {
+	for _, batch := range p.Batches {
+		if batch.ID == batchID {
+			return batch, true
+		}
+	}
+	return PantryBatch{}, false
+}
+
+// SyncBatches orders batches first-expiring-first, drops empty ones and
+// recomputes the item's quantity and earliest expiration date
+func (p *PantryItem) SyncBatches() {
+	batches := make([]PantryBatch, 0, len(p.Batches))
+	for _, batch := range p.Batches {
+		if batch.Quantity > 0 {
+			batches = append(batches, batch)
+		}
+	}
+
+	sort.SliceStable(batches, func(i, j int) bool {
+		a, b := batches[i], batches[j]
+		// Batches without an expiration date are used last
+		if a.ExpirationDate.IsZero() != b.ExpirationDate.IsZero() {
+			return !a.ExpirationDate.IsZero()
+		}
+		if !a.ExpirationDate.Equal(b.ExpirationDate) {
+			return a.ExpirationDate.Before(b.ExpirationDate)
+		}
+		return a.AddedAt.Before(b.AddedAt)
+	})
+
+	var total float64
+	var earliest time.Time
+	for _, batch := range batches {
+		total += batch.Quantity
+		if !batch.ExpirationDate.IsZero() && (earliest.IsZero() || batch.ExpirationDate.Before(earliest)) {
+			earliest = batch.ExpirationDate
+		}
+	}
+
+	p.Batches = batches
+	p.Quantity = units.Round(total)
+	p.ExpirationDate = earliest
+	p.UpdatedAt = time.Now()
+}
+
+// ConsumeFIFO takes the given quantity from the first-expiring batches
+func (p *PantryItem) ConsumeFIFO(quantity float64) ([]BatchConsumption, error) {
+	p.EnsureBatches()
+	p.SyncBatches()
+
+	if quantity > p.Quantity {
+		return nil, ErrInsufficientQuantity
+	}
+
+	consumed := make([]BatchConsumption, 0)
+	remaining := quantity
+	for i := range p.Batches {
+		if remaining <= 0 {
+			break
+		}
+
+		taken := math.Min(p.Batches[i].Quantity, remaining)
+		p.Batches[i].Quantity = units.Round(p.Batches[i].Quantity - taken)
+		remaining = units.Round(remaining - taken)
+
+		consumed = append(consumed, BatchConsumption{
+			BatchID:        p.Batches[i].ID,
+			Quantity:       taken,
+			ExpirationDate: p.Batches[i].ExpirationDate,
+			Emptied:        p.Batches[i].Quantity <= 0,
+		})
+	}
+
+	p.SyncBatches()
+	return consumed, nil
+}
+
+// ExpiringBatches returns the batches expiring within the given number of days
+func (p *PantryItem) ExpiringBatches(days int) []PantryBatch {
+	batches := make([]PantryBatch, 0)
+	for _, batch := range p.Batches {
+		if batch.IsExpiringSoon(days) {
+			batches = append(batches, batch)
+		}
+	}
+	return batches
+}
+
+// ExpiredBatches returns the batches that have already expired
+func (p *PantryItem) ExpiredBatches() []PantryBatch {
+	batches := make([]PantryBatch, 0)
+	for _, batch := range p.Batches {
+		if batch.IsExpired() {
+			batches = append(batches, batch)
+		}
+	}
+	return batches
+}
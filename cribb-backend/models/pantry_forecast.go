@@ -0,0 +1,99 @@
+// models/pantry_forecast.go
+package models
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// ForecastWindowDays is how far back usage history is considered
+	ForecastWindowDays = 30
+
+	// MinForecastSamples is the number of use records needed before forecasting
+	MinForecastSamples = 2
+)
+
+// PantryForecast is the predicted consumption of a pantry item
+type PantryForecast struct {
+	ItemID          primitive.ObjectID `json:"item_id"`
+	ItemName        string             `json:"item_name"`
+	Unit            string             `json:"unit"`
+	CurrentQuantity float64            `json:"current_quantity"`
+	DailyRate       float64            `json:"daily_rate"`
+	DaysRemaining   float64            `json:"days_remaining,omitempty"`
+	PredictedRunOut time.Time          `json:"predicted_run_out,omitempty"`
+	SampleCount     int                `json:"sample_count"`
+	HasEnoughData   bool               `json:"has_enough_data"`
+}
+
+// ForecastConsumption estimates an item's daily consumption rate from its use history
+// and predicts when it will run out. History for other items or actions is ignored.
+func ForecastConsumption(item PantryItem, history []PantryHistory, now time.Time) PantryForecast {
+	forecast := PantryForecast{
+		ItemID:          item.ID,
+		ItemName:        item.Name,
+		Unit:            item.Unit,
+		CurrentQuantity: item.Quantity,
+	}
+
+	windowStart := now.AddDate(0, 0, -ForecastWindowDays)
+	var total float64
+	var earliest time.Time
+
+	for _, record := range history {
+		if record.ItemID != item.ID || record.Action != ActionTypeUse || record.Quantity <= 0 {
+			continue
+		}
+		if record.CreatedAt.Before(windowStart) || record.CreatedAt.After(now) {
+			continue
+		}
+
+		total += record.Quantity
+		forecast.SampleCount++
+		if earliest.IsZero() || record.CreatedAt.Before(earliest) {
+			earliest = record.CreatedAt
+		}
+	}
+
+	if forecast.SampleCount < MinForecastSamples {
+		return forecast
+	}
+
+	// Spread the usage over the time since the first use, but at least one day
+	// so a burst of uses on the same day doesn't look like an enormous rate
+	spanDays := math.Max(now.Sub(earliest).Hours()/24, 1)
+	forecast.DailyRate = total / spanDays
+	forecast.HasEnoughData = true
+
+	if item.Quantity > 0 {
+		forecast.DaysRemaining = item.Quantity / forecast.DailyRate
+		forecast.PredictedRunOut = now.Add(time.Duration(forecast.DaysRemaining * 24 * float64(time.Hour)))
+	} else {
+		forecast.PredictedRunOut = now
+	}
+
+	return forecast
+}
+
+// RunsOutWithin checks if the item is predicted to run out within the given number of days
+func (f PantryForecast) RunsOutWithin(days int) bool {
+	if !f.HasEnoughData || f.DailyRate <= 0 {
+		return false
+	}
+	return f.DaysRemaining <= float64(days)
+}
+
+// PredictedNeed returns how much more is needed to cover the given number of days
+func (f PantryForecast) PredictedNeed(days int) float64 {
+	if !f.HasEnoughData {
+		return 0
+	}
+	need := f.DailyRate*float64(days) - f.CurrentQuantity
+	if need < 0 {
+		return 0
+	}
+	return need
+}
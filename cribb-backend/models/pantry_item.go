@@ -1,23 +1,37 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	// DefaultLowStockThreshold is used when an item has no min threshold of its own
+	DefaultLowStockThreshold = 1.0
+
+	// DefaultParLevel is used when an item has neither a par level nor a min threshold
+	DefaultParLevel = 2.0
+)
+
 // PantryItem represents an item in a group's shared pantry
 type PantryItem struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	GroupID        primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
-	Name           string             `bson:"name" json:"name" validate:"required"`
-	Quantity       float64            `bson:"quantity" json:"quantity" validate:"required,min=0"`
-	Unit           string             `bson:"unit" json:"unit" validate:"required"`
-	Category       string             `bson:"category" json:"category"`
-	ExpirationDate time.Time          `bson:"expiration_date,omitempty" json:"expiration_date,omitempty"`
-	AddedBy        primitive.ObjectID `bson:"added_by" json:"added_by" validate:"required"`
-	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID         primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Name            string             `bson:"name" json:"name" validate:"required"`
+	Quantity        float64            `bson:"quantity" json:"quantity" validate:"required,min=0"`
+	Unit            string             `bson:"unit" json:"unit" validate:"required"`
+	Category        string             `bson:"category" json:"category"`
+	Barcode         string             `bson:"barcode,omitempty" json:"barcode,omitempty"`
+	StorageLocation StorageLocation    `bson:"storage_location,omitempty" json:"storage_location,omitempty"`
+	ExpirationDate  time.Time          `bson:"expiration_date,omitempty" json:"expiration_date,omitempty"` // Earliest expiration date across batches
+	Batches         []PantryBatch      `bson:"batches" json:"batches"`                                     // Purchases ordered first-expiring-first
+	MinThreshold    float64            `bson:"min_threshold,omitempty" json:"min_threshold,omitempty"`     // Item is low at or below this quantity
+	ParLevel        float64            `bson:"par_level,omitempty" json:"par_level,omitempty"`             // Quantity to restock up to
+	AddedBy         primitive.ObjectID `bson:"added_by" json:"added_by" validate:"required"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 // CreatePantryItem creates a new pantry item
@@ -30,17 +44,20 @@ func CreatePantryItem(
 	expirationDate time.Time,
 	addedBy primitive.ObjectID,
 ) *PantryItem {
-	return &PantryItem{
-		GroupID:        groupID,
-		Name:           name,
-		Quantity:       quantity,
-		Unit:           unit,
-		Category:       category,
-		ExpirationDate: expirationDate,
-		AddedBy:        addedBy,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+	item := &PantryItem{
+		GroupID:   groupID,
+		Name:      name,
+		Unit:      unit,
+		Category:  category,
+		Batches:   make([]PantryBatch, 0),
+		AddedBy:   addedBy,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
+	if quantity > 0 {
+		item.AddBatch(CreatePantryBatch(quantity, expirationDate, addedBy))
+	}
+	return item
 }
 
 // IsExpiringSoon checks if the item is expiring within the given number of days
@@ -67,3 +84,51 @@ func (p *PantryItem) UpdateQuantity(newQuantity float64) {
 	p.Quantity = newQuantity
 	p.UpdatedAt = time.Now()
 }
+
+// LowStockThreshold returns the quantity at or below which the item counts as low
+func (p *PantryItem) LowStockThreshold() float64 {
+	if p.MinThreshold > 0 {
+		return p.MinThreshold
+	}
+	return DefaultLowStockThreshold
+}
+
+// TargetLevel returns the quantity the item should be restocked up to
+func (p *PantryItem) TargetLevel() float64 {
+	if p.ParLevel > 0 {
+		return p.ParLevel
+	}
+	if p.MinThreshold > 0 {
+		return p.MinThreshold * 2
+	}
+	return DefaultParLevel
+}
+
+// IsOutOfStock checks if the item has nothing left
+func (p *PantryItem) IsOutOfStock() bool {
+	return p.Quantity <= 0
+}
+
+// IsLowStock checks if the item is running low but not yet out of stock
+func (p *PantryItem) IsLowStock() bool {
+	return p.Quantity > 0 && p.Quantity <= p.LowStockThreshold()
+}
+
+// SuggestedPurchaseQuantity returns how much to buy to get back to the par level
+func (p *PantryItem) SuggestedPurchaseQuantity() float64 {
+	if p.Quantity >= p.TargetLevel() {
+		return 0
+	}
+	return p.TargetLevel() - p.Quantity
+}
+
+// ValidateStockLevels checks that a min threshold and par level make sense together
+func ValidateStockLevels(minThreshold, parLevel float64) error {
+	if minThreshold < 0 || parLevel < 0 {
+		return errors.New("min threshold and par level cannot be negative")
+	}
+	if minThreshold > 0 && parLevel > 0 && parLevel < minThreshold {
+		return errors.New("par level must be greater than or equal to the min threshold")
+	}
+	return nil
+}
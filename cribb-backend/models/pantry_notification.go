@@ -6,7 +6,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// NotificationType defines the type of pantry notification
+// NotificationType defines the type of a notification
 type NotificationType string
 
 const (
@@ -20,6 +20,9 @@ const (
 	NotificationTypeExpired NotificationType = "expired"
 
 	NotificationTypeOutOfStock NotificationType = "out_of_stock"
+
+	// NotificationTypeRunningOutSoon indicates an item is predicted to run out soon
+	NotificationTypeRunningOutSoon NotificationType = "running_out_soon"
 )
 
 // PantryNotification represents a notification about a pantry item
@@ -28,6 +31,7 @@ type PantryNotification struct {
 	GroupID   primitive.ObjectID   `bson:"group_id" json:"group_id" validate:"required"`
 	ItemID    primitive.ObjectID   `bson:"item_id" json:"item_id" validate:"required"`
 	ItemName  string               `bson:"item_name" json:"item_name" validate:"required"`
+	BatchID   primitive.ObjectID   `bson:"batch_id,omitempty" json:"batch_id,omitempty"` // Set for expiration notifications about a single batch
 	Type      NotificationType     `bson:"type" json:"type" validate:"required"`
 	Message   string               `bson:"message" json:"message"`
 	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"cribb-backend/units"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceRecord is the price a group paid for a product at a store, captured at purchase
+type PriceRecord struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID      primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	ProductKey   string             `bson:"product_key" json:"product_key"` // IngredientKey of the item name
+	ItemName     string             `bson:"item_name" json:"item_name"`
+	Store        string             `bson:"store,omitempty" json:"store,omitempty"`
+	Category     string             `bson:"category,omitempty" json:"category,omitempty"`
+	Quantity     float64            `bson:"quantity" json:"quantity"`
+	Unit         string             `bson:"unit" json:"unit"`
+	BaseQuantity float64            `bson:"base_quantity,omitempty" json:"base_quantity,omitempty"` // Quantity in g, ml or pc
+	BaseUnit     string             `bson:"base_unit,omitempty" json:"base_unit,omitempty"`
+	Total        int64              `bson:"total" json:"total"` // Cents paid for the whole quantity
+	Currency     string             `bson:"currency" json:"currency"`
+	PurchasedBy  primitive.ObjectID `bson:"purchased_by" json:"purchased_by"`
+	PurchasedAt  time.Time          `bson:"purchased_at" json:"purchased_at"`
+}
+
+// StorePriceSummary summarizes what a product has cost at one store
+type StorePriceSummary struct {
+	Store           string    `json:"store"`
+	Unit            string    `json:"unit"`
+	LatestUnitPrice float64   `json:"latest_unit_price"` // Cents per unit
+	MinUnitPrice    float64   `json:"min_unit_price"`
+	MaxUnitPrice    float64   `json:"max_unit_price"`
+	AvgUnitPrice    float64   `json:"avg_unit_price"`
+	Purchases       int       `json:"purchases"`
+	LastPurchasedAt time.Time `json:"last_purchased_at"`
+}
+
+// CreatePriceRecord creates a new price record. Known units are also converted
+// to their base unit so prices for different pack sizes can be compared.
+func CreatePriceRecord(
+	groupID primitive.ObjectID,
+	itemName string,
+	store string,
+	category string,
+	quantity float64,
+	unit string,
+	total int64,
+	currency string,
+	purchasedBy primitive.ObjectID,
+	purchasedAt time.Time,
+) *PriceRecord {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	record := &PriceRecord{
+		GroupID:     groupID,
+		ProductKey:  IngredientKey(itemName),
+		ItemName:    strings.TrimSpace(itemName),
+		Store:       strings.TrimSpace(store),
+		Category:    category,
+		Quantity:    quantity,
+		Unit:        unit,
+		Total:       total,
+		Currency:    strings.ToUpper(strings.TrimSpace(currency)),
+		PurchasedBy: purchasedBy,
+		PurchasedAt: purchasedAt,
+	}
+	if baseQuantity, baseUnit, err := units.ToBase(quantity, unit); err == nil {
+		record.BaseQuantity = baseQuantity
+		record.BaseUnit = baseUnit
+	}
+	return record
+}
+
+// UnitPrice returns the price in cents per base unit, or per the record's own
+// unit when it isn't a known unit
+func (p *PriceRecord) UnitPrice() (float64, string) {
+	if p.BaseUnit != "" && p.BaseQuantity > 0 {
+		return float64(p.Total) / p.BaseQuantity, p.BaseUnit
+	}
+	if p.Quantity <= 0 {
+		return 0, p.Unit
+	}
+	return float64(p.Total) / p.Quantity, p.Unit
+}
+
+// EstimateCost prices a quantity from the most recent record it can be compared
+// with. Records must be sorted newest first.
+func EstimateCost(records []PriceRecord, quantity float64, unit string) (int64, bool) {
+	for i := range records {
+		unitPrice, priceUnit := records[i].UnitPrice()
+		if unitPrice <= 0 {
+			continue
+		}
+
+		var converted float64
+		if records[i].BaseUnit != "" {
+			baseQuantity, baseUnit, err := units.ToBase(quantity, unit)
+			if err != nil || baseUnit != priceUnit {
+				continue
+			}
+			converted = baseQuantity
+		} else if strings.EqualFold(unit, priceUnit) {
+			converted = quantity
+		} else {
+			continue
+		}
+
+		return int64(unitPrice*converted + 0.5), true
+	}
+	return 0, false
+}
+
+// SummarizePrices groups records by store and unit, cheapest latest price first
+func SummarizePrices(records []PriceRecord) []StorePriceSummary {
+	byKey := make(map[string]*StorePriceSummary)
+	totals := make(map[string]float64)
+	keys := make([]string, 0)
+
+	for i := range records {
+		unitPrice, unit := records[i].UnitPrice()
+		if unitPrice <= 0 {
+			continue
+		}
+
+		key := strings.ToLower(records[i].Store) + "|" + unit
+		summary, exists := byKey[key]
+		if !exists {
+			summary = &StorePriceSummary{
+				Store:        records[i].Store,
+				Unit:         unit,
+				MinUnitPrice: unitPrice,
+				MaxUnitPrice: unitPrice,
+			}
+			byKey[key] = summary
+			keys = append(keys, key)
+		}
+
+		if !records[i].PurchasedAt.Before(summary.LastPurchasedAt) {
+			summary.LatestUnitPrice = unitPrice
+			summary.LastPurchasedAt = records[i].PurchasedAt
+		}
+		if unitPrice < summary.MinUnitPrice {
+			summary.MinUnitPrice = unitPrice
+		}
+		if unitPrice > summary.MaxUnitPrice {
+			summary.MaxUnitPrice = unitPrice
+		}
+		summary.Purchases++
+		totals[key] += unitPrice
+	}
+
+	summaries := make([]StorePriceSummary, 0, len(keys))
+	for _, key := range keys {
+		summary := byKey[key]
+		summary.AvgUnitPrice = totals[key] / float64(summary.Purchases)
+		summaries = append(summaries, *summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Unit != summaries[j].Unit {
+			return summaries[i].Unit < summaries[j].Unit
+		}
+		return summaries[i].LatestUnitPrice < summaries[j].LatestUnitPrice
+	})
+	return summaries
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductSource defines where a catalog product came from
+type ProductSource string
+
+const (
+	// ProductSourceOpenFoodFacts marks products imported from an Open Food Facts dump
+	ProductSourceOpenFoodFacts ProductSource = "openfoodfacts"
+
+	// ProductSourceCustom marks products a group entered itself
+	ProductSourceCustom ProductSource = "custom"
+)
+
+// Product represents a catalog entry keyed by barcode.
+// Entries without a group ID are shared by everyone; group entries override them.
+type Product struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Barcode         string             `bson:"barcode" json:"barcode" validate:"required"`
+	Name            string             `bson:"name" json:"name" validate:"required"`
+	Brand           string             `bson:"brand,omitempty" json:"brand,omitempty"`
+	Category        string             `bson:"category" json:"category"`
+	DefaultUnit     string             `bson:"default_unit" json:"default_unit"`
+	DefaultQuantity float64            `bson:"default_quantity" json:"default_quantity"`
+	ShelfLifeDays   int                `bson:"shelf_life_days,omitempty" json:"shelf_life_days,omitempty"` // Typical days until expiry once bought
+	Source          ProductSource      `bson:"source" json:"source"`
+	GroupID         primitive.ObjectID `bson:"group_id,omitempty" json:"group_id,omitempty"`
+	CreatedBy       primitive.ObjectID `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CreateProduct creates a new shared catalog product
+func CreateProduct(
+	barcode string,
+	name string,
+	brand string,
+	category string,
+	defaultUnit string,
+	defaultQuantity float64,
+	shelfLifeDays int,
+	source ProductSource,
+) *Product {
+	return &Product{
+		Barcode:         barcode,
+		Name:            name,
+		Brand:           brand,
+		Category:        category,
+		DefaultUnit:     defaultUnit,
+		DefaultQuantity: defaultQuantity,
+		ShelfLifeDays:   shelfLifeDays,
+		Source:          source,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// CreateCustomProduct creates a product entry that only applies to one group
+func CreateCustomProduct(
+	groupID primitive.ObjectID,
+	createdBy primitive.ObjectID,
+	barcode string,
+	name string,
+	brand string,
+	category string,
+	defaultUnit string,
+	defaultQuantity float64,
+	shelfLifeDays int,
+) *Product {
+	product := CreateProduct(barcode, name, brand, category, defaultUnit, defaultQuantity, shelfLifeDays, ProductSourceCustom)
+	product.GroupID = groupID
+	product.CreatedBy = createdBy
+	return product
+}
+
+// EstimatedExpiration returns the typical expiration date for a purchase made at the given time
+func (p *Product) EstimatedExpiration(purchasedAt time.Time) time.Time {
+	if p.ShelfLifeDays <= 0 {
+		return time.Time{}
+	}
+	return purchasedAt.AddDate(0, 0, p.ShelfLifeDays)
+}
@@ -0,0 +1,75 @@
+// models/public_url.go
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrPrivateHost is returned for URLs and addresses that point inside the
+// server's own network
+var ErrPrivateHost = errors.New("host is not a public address")
+
+// reservedNetworks are ranges outside the private and link-local ones that
+// still never belong to a public server
+var reservedNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "This" network
+		"100.64.0.0/10", // Carrier-grade NAT
+		"192.0.0.0/24",  // Protocol assignments
+		"198.18.0.0/15", // Benchmarking
+		"240.0.0.0/4",   // Reserved, including broadcast
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// IsPublicIP reports whether ip is a public unicast address, rather than a
+// loopback, private, link-local, unspecified or otherwise reserved one
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidatePublicURL checks that raw is an absolute URL with one of the given
+// schemes and a host that isn't obviously internal. Hostnames are only
+// resolved when connecting, where delivery.PublicClient checks them again.
+func ValidatePublicURL(raw string, schemes ...string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Errorf("invalid URL %q", raw)
+	}
+
+	allowed := false
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("URL must use %s", strings.Join(schemes, " or "))
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateHost
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return ErrPrivateHost
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// models/push_subscription.go
+package models
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PushSubscription is a browser or device registered for Web Push notifications
+type PushSubscription struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id" validate:"required"`
+	Endpoint  string             `bson:"endpoint" json:"endpoint" validate:"required"`
+	P256dh    string             `bson:"p256dh" json:"p256dh" validate:"required"`
+	Auth      string             `bson:"auth" json:"auth" validate:"required"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// CreatePushSubscription creates a new push subscription
+func CreatePushSubscription(userID primitive.ObjectID, endpoint, p256dh, auth, userAgent string) *PushSubscription {
+	return &PushSubscription{
+		UserID:    userID,
+		Endpoint:  endpoint,
+		P256dh:    p256dh,
+		Auth:      auth,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Validate checks the subscription has an https endpoint and both keys
+func (s *PushSubscription) Validate() error {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return errors.New("push endpoint must be an https URL")
+	}
+	if s.P256dh == "" || s.Auth == "" {
+		return errors.New("push subscription keys are required")
+	}
+	return nil
+}
+
+// Target returns where push messages for this subscription are sent
+func (s *PushSubscription) Target() DeliveryTarget {
+	return DeliveryTarget{Address: s.Endpoint, P256dh: s.P256dh, Auth: s.Auth}
+}
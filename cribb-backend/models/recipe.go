@@ -0,0 +1,232 @@
+package models
+
+import (
+	"cribb-backend/units"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecipeIngredient is one ingredient of a recipe
+type RecipeIngredient struct {
+	Name     string  `bson:"name" json:"name" validate:"required"`
+	Quantity float64 `bson:"quantity" json:"quantity" validate:"required,gt=0"`
+	Unit     string  `bson:"unit" json:"unit" validate:"required"`
+	Optional bool    `bson:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// Recipe represents a dish a group cooks
+type Recipe struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID      primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Name         string             `bson:"name" json:"name" validate:"required"`
+	Description  string             `bson:"description,omitempty" json:"description,omitempty"`
+	Servings     int                `bson:"servings" json:"servings" validate:"required,min=1"`
+	Ingredients  []RecipeIngredient `bson:"ingredients" json:"ingredients"`
+	Instructions string             `bson:"instructions,omitempty" json:"instructions,omitempty"`
+	CreatedBy    primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// IngredientAvailability compares what a recipe needs of one ingredient with the pantry
+type IngredientAvailability struct {
+	Name      string             `json:"name"`
+	Required  float64            `json:"required"`
+	Unit      string             `json:"unit"`
+	Available float64            `json:"available"` // In the ingredient's unit
+	Missing   float64            `json:"missing"`
+	Optional  bool               `json:"optional,omitempty"`
+	ItemID    primitive.ObjectID `json:"item_id,omitempty"`
+	Note      string             `json:"note,omitempty"`
+}
+
+// RecipeMatch describes whether a recipe can be cooked from the pantry
+type RecipeMatch struct {
+	RecipeID     primitive.ObjectID       `json:"recipe_id"`
+	RecipeName   string                   `json:"recipe_name"`
+	Servings     int                      `json:"servings"`
+	CanCook      bool                     `json:"can_cook"`
+	MissingCount int                      `json:"missing_count"`
+	Ingredients  []IngredientAvailability `json:"ingredients"`
+}
+
+// CreateRecipe creates a new recipe
+func CreateRecipe(
+	groupID primitive.ObjectID,
+	name string,
+	description string,
+	servings int,
+	ingredients []RecipeIngredient,
+	instructions string,
+	createdBy primitive.ObjectID,
+) *Recipe {
+	return &Recipe{
+		GroupID:      groupID,
+		Name:         strings.TrimSpace(name),
+		Description:  description,
+		Servings:     servings,
+		Ingredients:  ingredients,
+		Instructions: instructions,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// Validate checks the recipe and normalizes ingredient units to their canonical symbols
+func (r *Recipe) Validate() error {
+	if r.Name == "" {
+		return errors.New("recipe name is required")
+	}
+	if r.Servings < 1 {
+		return errors.New("servings must be at least 1")
+	}
+	if len(r.Ingredients) == 0 {
+		return errors.New("a recipe needs at least one ingredient")
+	}
+
+	for i, ingredient := range r.Ingredients {
+		if strings.TrimSpace(ingredient.Name) == "" || ingredient.Quantity <= 0 {
+			return fmt.Errorf("ingredient %d needs a name and a positive quantity", i+1)
+		}
+		unit, err := units.Normalize(ingredient.Unit)
+		if err != nil {
+			return fmt.Errorf("ingredient %s has an unknown unit %q", ingredient.Name, ingredient.Unit)
+		}
+		r.Ingredients[i].Name = strings.TrimSpace(ingredient.Name)
+		r.Ingredients[i].Unit = unit
+	}
+	return nil
+}
+
+// Scaled returns the ingredients needed for the given number of servings
+func (r *Recipe) Scaled(servings int) []RecipeIngredient {
+	if servings <= 0 || r.Servings <= 0 {
+		servings = r.Servings
+	}
+	factor := float64(servings) / float64(r.Servings)
+
+	scaled := make([]RecipeIngredient, len(r.Ingredients))
+	for i, ingredient := range r.Ingredients {
+		scaled[i] = ingredient
+		scaled[i].Quantity = units.Round(ingredient.Quantity * factor)
+	}
+	return scaled
+}
+
+// IngredientKey normalizes an ingredient or item name for matching, so
+// "Eggs", " egg " and "EGGS" all refer to the same pantry item
+func IngredientKey(name string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if len(key) > 3 && strings.HasSuffix(key, "s") && !strings.HasSuffix(key, "ss") {
+		key = strings.TrimSuffix(key, "s")
+	}
+	return key
+}
+
+// IndexPantryItems maps pantry items by ingredient key
+func IndexPantryItems(items []PantryItem) map[string]PantryItem {
+	index := make(map[string]PantryItem, len(items))
+	for _, item := range items {
+		index[IngredientKey(item.Name)] = item
+	}
+	return index
+}
+
+// MatchRecipe checks a recipe, scaled to the given servings, against the pantry
+func MatchRecipe(recipe *Recipe, servings int, pantry map[string]PantryItem) RecipeMatch {
+	if servings <= 0 {
+		servings = recipe.Servings
+	}
+
+	match := RecipeMatch{
+		RecipeID:    recipe.ID,
+		RecipeName:  recipe.Name,
+		Servings:    servings,
+		CanCook:     true,
+		Ingredients: make([]IngredientAvailability, 0, len(recipe.Ingredients)),
+	}
+
+	// Ingredients that use the same pantry item share its stock, so each one
+	// is checked against what the earlier ones left, in the item's unit
+	taken := make(map[string]float64)
+
+	for _, ingredient := range recipe.Scaled(servings) {
+		availability := IngredientAvailability{
+			Name:     ingredient.Name,
+			Required: ingredient.Quantity,
+			Unit:     ingredient.Unit,
+			Optional: ingredient.Optional,
+		}
+
+		key := IngredientKey(ingredient.Name)
+		needed := 0.0 // Required quantity in the item's unit
+		enough := false
+		if item, found := pantry[key]; found {
+			availability.ItemID = item.ID
+			stock := units.Round(item.Quantity - taken[key])
+			if strings.EqualFold(item.Unit, ingredient.Unit) {
+				availability.Available = stock
+				needed = ingredient.Quantity
+				enough = stock >= needed
+			} else if converted, err := units.Convert(stock, item.Unit, ingredient.Unit); err == nil {
+				// Compared in the item's unit, which is what gets deducted
+				availability.Available = converted
+				needed, _ = units.Convert(ingredient.Quantity, ingredient.Unit, item.Unit)
+				enough = stock >= needed
+			} else {
+				availability.Note = fmt.Sprintf("pantry stock is measured in %s", item.Unit)
+			}
+		}
+
+		if enough {
+			taken[key] += needed
+		} else {
+			availability.Missing = units.Round(availability.Required - availability.Available)
+			if !availability.Optional {
+				match.CanCook = false
+				match.MissingCount++
+			}
+		}
+
+		match.Ingredients = append(match.Ingredients, availability)
+	}
+
+	return match
+}
+
+// MatchRecipes checks recipes at their own servings against the pantry.
+// Cookable recipes come first, then those missing the fewest ingredients.
+func MatchRecipes(recipes []Recipe, items []PantryItem) []RecipeMatch {
+	pantry := IndexPantryItems(items)
+
+	matches := make([]RecipeMatch, 0, len(recipes))
+	for i := range recipes {
+		matches = append(matches, MatchRecipe(&recipes[i], recipes[i].Servings, pantry))
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].MissingCount != matches[j].MissingCount {
+			return matches[i].MissingCount < matches[j].MissingCount
+		}
+		return matches[i].RecipeName < matches[j].RecipeName
+	})
+	return matches
+}
+
+// MissingSummary lists the missing required ingredients, e.g. "eggs (need 2 pc, have 1 pc)"
+func (m *RecipeMatch) MissingSummary() string {
+	missing := make([]string, 0, m.MissingCount)
+	for _, ingredient := range m.Ingredients {
+		if ingredient.Missing > 0 && !ingredient.Optional {
+			missing = append(missing, fmt.Sprintf("%s (need %g %s, have %g %s)",
+				ingredient.Name, ingredient.Required, ingredient.Unit, units.Round(ingredient.Available), ingredient.Unit))
+		}
+	}
+	return strings.Join(missing, ", ")
+}
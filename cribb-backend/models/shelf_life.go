@@ -0,0 +1,228 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StorageLocation defines where a pantry item is kept
+type StorageLocation string
+
+const (
+	// StorageLocationPantry is shelf storage at room temperature
+	StorageLocationPantry StorageLocation = "pantry"
+
+	// StorageLocationFridge is refrigerated storage
+	StorageLocationFridge StorageLocation = "fridge"
+
+	// StorageLocationFreezer is frozen storage
+	StorageLocationFreezer StorageLocation = "freezer"
+)
+
+// ShelfLifeSource describes which rule an estimated shelf life came from
+type ShelfLifeSource string
+
+const (
+	ShelfLifeSourceProductRule  ShelfLifeSource = "product_rule"  // Group rule for the item name
+	ShelfLifeSourceProduct      ShelfLifeSource = "product"       // Catalog product entry
+	ShelfLifeSourceCategoryRule ShelfLifeSource = "category_rule" // Group rule for the category
+	ShelfLifeSourceDefault      ShelfLifeSource = "default"       // Built-in category defaults
+)
+
+// defaultShelfLifeDays holds typical shelf lives by category and storage location
+var defaultShelfLifeDays = map[string]map[StorageLocation]int{
+	"dairy":        {StorageLocationPantry: 1, StorageLocationFridge: 7, StorageLocationFreezer: 90},
+	"meat":         {StorageLocationPantry: 1, StorageLocationFridge: 3, StorageLocationFreezer: 180},
+	"seafood":      {StorageLocationPantry: 1, StorageLocationFridge: 2, StorageLocationFreezer: 90},
+	"eggs":         {StorageLocationPantry: 14, StorageLocationFridge: 28, StorageLocationFreezer: 365},
+	"produce":      {StorageLocationPantry: 5, StorageLocationFridge: 7, StorageLocationFreezer: 240},
+	"bakery":       {StorageLocationPantry: 5, StorageLocationFridge: 7, StorageLocationFreezer: 90},
+	"leftovers":    {StorageLocationPantry: 1, StorageLocationFridge: 4, StorageLocationFreezer: 90},
+	"frozen":       {StorageLocationPantry: 1, StorageLocationFridge: 2, StorageLocationFreezer: 180},
+	"beverages":    {StorageLocationPantry: 180, StorageLocationFridge: 180, StorageLocationFreezer: 180},
+	"canned goods": {StorageLocationPantry: 730, StorageLocationFridge: 730, StorageLocationFreezer: 730},
+	"dry goods":    {StorageLocationPantry: 365, StorageLocationFridge: 365, StorageLocationFreezer: 365},
+	"snacks":       {StorageLocationPantry: 120, StorageLocationFridge: 120, StorageLocationFreezer: 180},
+	"condiments":   {StorageLocationPantry: 180, StorageLocationFridge: 180, StorageLocationFreezer: 180},
+}
+
+// defaultStorageLocations holds where each category is usually kept; anything else goes in the pantry
+var defaultStorageLocations = map[string]StorageLocation{
+	"dairy":     StorageLocationFridge,
+	"meat":      StorageLocationFridge,
+	"seafood":   StorageLocationFridge,
+	"eggs":      StorageLocationFridge,
+	"produce":   StorageLocationFridge,
+	"leftovers": StorageLocationFridge,
+	"frozen":    StorageLocationFreezer,
+}
+
+// ShelfLifeRule is a group's own shelf life for a category or a specific product
+type ShelfLifeRule struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID         primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Category        string             `bson:"category,omitempty" json:"category,omitempty"`
+	ProductName     string             `bson:"product_name,omitempty" json:"product_name,omitempty"`
+	StorageLocation StorageLocation    `bson:"storage_location,omitempty" json:"storage_location,omitempty"` // Empty applies to every location
+	Days            int                `bson:"days" json:"days" validate:"required,min=1"`
+	CreatedBy       primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ShelfLifeEstimate is the resolved shelf life of an item
+type ShelfLifeEstimate struct {
+	Days            int             `json:"days"`
+	Source          ShelfLifeSource `json:"source"`
+	StorageLocation StorageLocation `json:"storage_location"`
+}
+
+// CreateShelfLifeRule creates a new group shelf life rule
+func CreateShelfLifeRule(
+	groupID primitive.ObjectID,
+	category string,
+	productName string,
+	location StorageLocation,
+	days int,
+	createdBy primitive.ObjectID,
+) *ShelfLifeRule {
+	return &ShelfLifeRule{
+		GroupID:         groupID,
+		Category:        strings.TrimSpace(category),
+		ProductName:     strings.TrimSpace(productName),
+		StorageLocation: location,
+		Days:            days,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// Validate checks that the rule targets something and has a positive shelf life
+func (r *ShelfLifeRule) Validate() error {
+	if r.Category == "" && r.ProductName == "" {
+		return errors.New("a category or product name is required")
+	}
+	if r.Days <= 0 {
+		return errors.New("days must be positive")
+	}
+	return nil
+}
+
+// ParseStorageLocation validates a storage location; empty input is allowed and stays empty
+func ParseStorageLocation(location string) (StorageLocation, error) {
+	switch parsed := StorageLocation(strings.ToLower(strings.TrimSpace(location))); parsed {
+	case "", StorageLocationPantry, StorageLocationFridge, StorageLocationFreezer:
+		return parsed, nil
+	default:
+		return "", errors.New("storage location must be pantry, fridge or freezer")
+	}
+}
+
+// DefaultStorageLocation returns where items of a category are usually kept
+func DefaultStorageLocation(category string) StorageLocation {
+	if location, ok := defaultStorageLocations[strings.ToLower(strings.TrimSpace(category))]; ok {
+		return location
+	}
+	return StorageLocationPantry
+}
+
+// DefaultShelfLifeDays returns the built-in shelf life of a category in a location, or 0 if unknown
+func DefaultShelfLifeDays(category string, location StorageLocation) int {
+	return defaultShelfLifeDays[strings.ToLower(strings.TrimSpace(category))][location]
+}
+
+// ResolveShelfLife picks the shelf life of an item kept in the given location.
+// A group rule for the item name beats the catalog's product value, which beats
+// group category rules and the built-in defaults. Rules for the exact location
+// beat rules for any location. The catalog value is only trusted for the
+// category's usual location. Days is 0 when nothing applies.
+func ResolveShelfLife(rules []ShelfLifeRule, name, category string, location StorageLocation, productDays int) ShelfLifeEstimate {
+	if location == "" {
+		location = DefaultStorageLocation(category)
+	}
+	estimate := ShelfLifeEstimate{StorageLocation: location}
+
+	if days := matchShelfLifeRule(rules, location, func(rule ShelfLifeRule) bool {
+		return rule.ProductName != "" && strings.EqualFold(rule.ProductName, strings.TrimSpace(name))
+	}); days > 0 {
+		estimate.Days, estimate.Source = days, ShelfLifeSourceProductRule
+		return estimate
+	}
+
+	if productDays > 0 && location == DefaultStorageLocation(category) {
+		estimate.Days, estimate.Source = productDays, ShelfLifeSourceProduct
+		return estimate
+	}
+
+	if days := matchShelfLifeRule(rules, location, func(rule ShelfLifeRule) bool {
+		return rule.ProductName == "" && strings.EqualFold(rule.Category, strings.TrimSpace(category))
+	}); days > 0 {
+		estimate.Days, estimate.Source = days, ShelfLifeSourceCategoryRule
+		return estimate
+	}
+
+	if days := DefaultShelfLifeDays(category, location); days > 0 {
+		estimate.Days, estimate.Source = days, ShelfLifeSourceDefault
+	}
+	return estimate
+}
+
+// matchShelfLifeRule returns the days of the best matching rule, preferring an exact location
+func matchShelfLifeRule(rules []ShelfLifeRule, location StorageLocation, matches func(ShelfLifeRule) bool) int {
+	anyLocation := 0
+	for _, rule := range rules {
+		if !matches(rule) {
+			continue
+		}
+		if rule.StorageLocation == location {
+			return rule.Days
+		}
+		if rule.StorageLocation == "" && anyLocation == 0 {
+			anyLocation = rule.Days
+		}
+	}
+	return anyLocation
+}
+
+// RescaleExpiration moves an estimated expiration date to a new shelf life,
+// keeping the share of shelf life already used up. Expired dates are left alone.
+func RescaleExpiration(expiration, now time.Time, oldDays, newDays int) time.Time {
+	if expiration.IsZero() || oldDays <= 0 || newDays <= 0 || !expiration.After(now) {
+		return expiration
+	}
+
+	remaining := float64(expiration.Sub(now)) / float64(time.Duration(oldDays)*24*time.Hour)
+	if remaining > 1 {
+		remaining = 1
+	}
+	return now.Add(time.Duration(remaining * float64(time.Duration(newDays)*24*time.Hour)))
+}
+
+// Location returns where the item is kept, falling back to its category's usual place
+func (p *PantryItem) Location() StorageLocation {
+	if p.StorageLocation != "" {
+		return p.StorageLocation
+	}
+	return DefaultStorageLocation(p.Category)
+}
+
+// MoveTo changes where the item is kept. Estimated expiration dates are rescaled
+// to the new location's shelf life; dates entered by hand are kept as they are.
+func (p *PantryItem) MoveTo(location StorageLocation, rules []ShelfLifeRule, productDays int, now time.Time) {
+	p.EnsureBatches()
+
+	oldDays := ResolveShelfLife(rules, p.Name, p.Category, p.Location(), productDays).Days
+	newDays := ResolveShelfLife(rules, p.Name, p.Category, location, productDays).Days
+
+	p.StorageLocation = location
+	for i := range p.Batches {
+		if p.Batches[i].ExpirationEstimated {
+			p.Batches[i].ExpirationDate = RescaleExpiration(p.Batches[i].ExpirationDate, now, oldDays, newDays)
+		}
+	}
+	p.SyncBatches()
+}
@@ -19,6 +19,24 @@ const (
 
 	// CartActivityTypeDelete indicates an item was removed from the shopping cart
 	CartActivityTypeDelete CartActivityType = "delete"
+
+	// CartActivityTypePurchase indicates an item was bought and moved into the pantry
+	CartActivityTypePurchase CartActivityType = "purchase"
+
+	// CartActivityTypeMove indicates an item was moved to another list
+	CartActivityTypeMove CartActivityType = "move"
+
+	// CartActivityTypeListCreate indicates a named list was created
+	CartActivityTypeListCreate CartActivityType = "list_create"
+
+	// CartActivityTypeListRename indicates a named list was renamed
+	CartActivityTypeListRename CartActivityType = "list_rename"
+
+	// CartActivityTypeListArchive indicates a named list was archived
+	CartActivityTypeListArchive CartActivityType = "list_archive"
+
+	// CartActivityTypeListRestore indicates an archived list was restored
+	CartActivityTypeListRestore CartActivityType = "list_restore"
 )
 
 // ShoppingCartActivity represents a record of changes to a shopping cart item
@@ -27,6 +45,8 @@ type ShoppingCartActivity struct {
 	GroupID   primitive.ObjectID   `bson:"group_id" json:"group_id" validate:"required"`
 	ItemID    primitive.ObjectID   `bson:"item_id" json:"item_id" validate:"required"`
 	ItemName  string               `bson:"item_name" json:"item_name" validate:"required"`
+	ListID    primitive.ObjectID   `bson:"list_id,omitempty" json:"list_id,omitempty"`
+	ListName  string               `bson:"list_name,omitempty" json:"list_name,omitempty"`
 	UserID    primitive.ObjectID   `bson:"user_id" json:"user_id" validate:"required"`
 	UserName  string               `bson:"user_name" json:"user_name"`
 	Action    CartActivityType     `bson:"action" json:"action" validate:"required"`
@@ -65,6 +85,21 @@ func CreateShoppingCartActivity(
 	}
 }
 
+// CreateShoppingListActivity creates an activity record for a list-level event.
+// The list stands in for the item.
+func CreateShoppingListActivity(
+	list *ShoppingList,
+	userID primitive.ObjectID,
+	userName string,
+	action CartActivityType,
+	details string,
+) *ShoppingCartActivity {
+	activity := CreateShoppingCartActivity(list.GroupID, list.ID, list.Name, userID, userName, action, 0, details)
+	activity.ListID = list.ID
+	activity.ListName = list.Name
+	return activity
+}
+
 // HasBeenReadBy checks if the activity has been read by a specific user
 func (a *ShoppingCartActivity) HasBeenReadBy(userID primitive.ObjectID) bool {
 	for _, id := range a.ReadBy {
@@ -11,8 +11,10 @@ type ShoppingCartItem struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID   primitive.ObjectID `bson:"user_id" json:"user_id" validate:"required"`
 	GroupID  primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	ListID   primitive.ObjectID `bson:"list_id,omitempty" json:"list_id,omitempty"` // Empty for the group's default list
 	ItemName string             `bson:"item_name" json:"item_name" validate:"required"`
 	Quantity float64            `bson:"quantity" json:"quantity" validate:"required,min=0.1"`
+	Unit     string             `bson:"unit,omitempty" json:"unit,omitempty"`
 	Category string             `bson:"category" json:"category"`
 	AddedAt  time.Time          `bson:"added_at" json:"added_at"`
 }
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"cribb-backend/units"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IngredientNeed is the total amount of one ingredient needed across several recipes
+type IngredientNeed struct {
+	Name     string   `json:"name"`
+	Quantity float64  `json:"quantity"`
+	Unit     string   `json:"unit"`
+	Recipes  []string `json:"recipes"`
+}
+
+// ShoppingGap is an ingredient that has to be bought for planned meals
+type ShoppingGap struct {
+	Name      string             `json:"name"`
+	Required  float64            `json:"required"`
+	Available float64            `json:"available"` // Pantry stock in the needed unit
+	ToBuy     float64            `json:"to_buy"`
+	Unit      string             `json:"unit"`
+	Category  string             `json:"category,omitempty"`
+	ItemID    primitive.ObjectID `json:"item_id,omitempty"`
+	Recipes   []string           `json:"recipes"`
+}
+
+// PlannedRecipe is a recipe cooked for a number of servings
+type PlannedRecipe struct {
+	Recipe   *Recipe
+	Servings int
+}
+
+// AggregateIngredients adds up the scaled ingredients of the planned recipes.
+// The same ingredient in compatible units is summed in the first unit seen;
+// incompatible units (eggs by count and by weight) stay separate needs.
+func AggregateIngredients(planned []PlannedRecipe, includeOptional bool) []IngredientNeed {
+	needs := make([]IngredientNeed, 0)
+	index := make(map[string][]int) // Ingredient key -> positions in needs
+
+	for _, plan := range planned {
+		for _, ingredient := range plan.Recipe.Scaled(plan.Servings) {
+			if ingredient.Optional && !includeOptional {
+				continue
+			}
+
+			key := IngredientKey(ingredient.Name)
+			merged := false
+			for _, i := range index[key] {
+				quantity := ingredient.Quantity
+				if needs[i].Unit != ingredient.Unit {
+					converted, err := units.Convert(quantity, ingredient.Unit, needs[i].Unit)
+					if err != nil {
+						continue
+					}
+					quantity = converted
+				}
+				needs[i].Quantity = units.Round(needs[i].Quantity + quantity)
+				needs[i].Recipes = appendUnique(needs[i].Recipes, plan.Recipe.Name)
+				merged = true
+				break
+			}
+			if merged {
+				continue
+			}
+
+			index[key] = append(index[key], len(needs))
+			needs = append(needs, IngredientNeed{
+				Name:     ingredient.Name,
+				Quantity: ingredient.Quantity,
+				Unit:     ingredient.Unit,
+				Recipes:  []string{plan.Recipe.Name},
+			})
+		}
+	}
+
+	return needs
+}
+
+// ComputeShoppingGap subtracts pantry stock from the needed ingredients and
+// returns what is left to buy, sorted by category and name. Stock measured in
+// a unit that can't be converted counts as nothing available.
+func ComputeShoppingGap(needs []IngredientNeed, items []PantryItem) []ShoppingGap {
+	pantry := IndexPantryItems(items)
+	used := make(map[primitive.ObjectID]float64) // Stock already set against earlier needs, in the item's unit
+
+	gaps := make([]ShoppingGap, 0)
+	for _, need := range needs {
+		gap := ShoppingGap{
+			Name:     need.Name,
+			Required: need.Quantity,
+			Unit:     need.Unit,
+			Recipes:  need.Recipes,
+		}
+
+		if item, found := pantry[IngredientKey(need.Name)]; found {
+			// Use the pantry's name so purchases land on the same item
+			gap.Name = item.Name
+			gap.ItemID = item.ID
+			gap.Category = item.Category
+
+			stock := item.Quantity - used[item.ID]
+			if stock > 0 {
+				if item.Unit == need.Unit {
+					gap.Available = stock
+				} else if converted, err := units.Convert(stock, item.Unit, need.Unit); err == nil {
+					gap.Available = converted
+				}
+			}
+
+			if gap.Available > 0 {
+				taken := gap.Available
+				if taken > gap.Required {
+					taken = gap.Required
+				}
+				if item.Unit == need.Unit {
+					used[item.ID] += taken
+				} else if converted, err := units.Convert(taken, need.Unit, item.Unit); err == nil {
+					used[item.ID] += converted
+				}
+			}
+			gap.Available = units.Round(gap.Available)
+		}
+
+		if gap.Available >= gap.Required {
+			continue
+		}
+		gap.ToBuy = units.Round(gap.Required - gap.Available)
+		gaps = append(gaps, gap)
+	}
+
+	sort.SliceStable(gaps, func(i, j int) bool {
+		if gaps[i].Category != gaps[j].Category {
+			return gaps[i].Category < gaps[j].Category
+		}
+		return gaps[i].Name < gaps[j].Name
+	})
+	return gaps
+}
+
+// appendUnique appends a value to a list if it isn't already there
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultShoppingListName is what the list of cart items without a list ID is called
+const DefaultShoppingListName = "Shopping cart"
+
+// ShoppingList is a named list of shopping cart items within a group, like "Costco run".
+// Cart items without a list ID belong to the group's default list.
+type ShoppingList struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID    primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Name       string             `bson:"name" json:"name" validate:"required"`
+	IsArchived bool               `bson:"is_archived" json:"is_archived"`
+	ArchivedAt *time.Time         `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CreateShoppingList creates a new, active shopping list
+func CreateShoppingList(groupID primitive.ObjectID, name string, createdBy primitive.ObjectID) *ShoppingList {
+	return &ShoppingList{
+		GroupID:    groupID,
+		Name:       strings.TrimSpace(name),
+		IsArchived: false,
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// ValidateShoppingListName checks a list name is usable
+func ValidateShoppingListName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("list name is required")
+	}
+	if len(name) > 60 {
+		return errors.New("list name must be at most 60 characters")
+	}
+	if strings.EqualFold(name, DefaultShoppingListName) {
+		return errors.New("list name is reserved for the default list")
+	}
+	return nil
+}
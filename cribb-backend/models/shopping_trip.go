@@ -0,0 +1,242 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TripStatus represents the state of a shopping trip
+type TripStatus string
+
+const (
+	TripStatusActive    TripStatus = "active"
+	TripStatusCompleted TripStatus = "completed"
+	TripStatusCancelled TripStatus = "cancelled"
+)
+
+// TripItemStatus records what happened to an item during a trip
+type TripItemStatus string
+
+const (
+	// TripItemStatusPending means the shopper hasn't got to the item yet
+	TripItemStatusPending TripItemStatus = "pending"
+
+	// TripItemStatusBought means the item was bought as listed
+	TripItemStatusBought TripItemStatus = "bought"
+
+	// TripItemStatusSkipped means the item wasn't bought
+	TripItemStatusSkipped TripItemStatus = "skipped"
+
+	// TripItemStatusSubstituted means something else was bought instead
+	TripItemStatusSubstituted TripItemStatus = "substituted"
+)
+
+// TripItem is a snapshot of a group list item taken when the trip started
+type TripItem struct {
+	ListItemID     primitive.ObjectID  `bson:"list_item_id" json:"list_item_id"`
+	ItemName       string              `bson:"item_name" json:"item_name"`
+	Quantity       float64             `bson:"quantity" json:"quantity"`
+	Unit           string              `bson:"unit" json:"unit"`
+	Category       string              `bson:"category" json:"category"`
+	Aisle          string              `bson:"aisle" json:"aisle"`
+	AisleOrder     int                 `bson:"aisle_order" json:"aisle_order"`
+	Status         TripItemStatus      `bson:"status" json:"status"`
+	BoughtQuantity float64             `bson:"bought_quantity,omitempty" json:"bought_quantity,omitempty"`
+	Substitute     string              `bson:"substitute,omitempty" json:"substitute,omitempty"`
+	CheckedBy      *primitive.ObjectID `bson:"checked_by,omitempty" json:"checked_by,omitempty"`
+	CheckedAt      *time.Time          `bson:"checked_at,omitempty" json:"checked_at,omitempty"`
+}
+
+// TripSummary counts what happened to a trip's items
+type TripSummary struct {
+	Bought      []TripItem `bson:"bought" json:"bought"`
+	Skipped     []TripItem `bson:"skipped" json:"skipped"` // Includes items never checked off
+	Substituted []TripItem `bson:"substituted" json:"substituted"`
+}
+
+// ShoppingTrip is a member's visit to a store with the group list sorted by its layout
+type ShoppingTrip struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	GroupID     primitive.ObjectID  `bson:"group_id" json:"group_id" validate:"required"`
+	StoreID     *primitive.ObjectID `bson:"store_id,omitempty" json:"store_id,omitempty"`
+	StoreName   string              `bson:"store_name,omitempty" json:"store_name,omitempty"`
+	ShopperID   primitive.ObjectID  `bson:"shopper_id" json:"shopper_id" validate:"required"`
+	ShopperName string              `bson:"shopper_name" json:"shopper_name"`
+	Status      TripStatus          `bson:"status" json:"status"`
+	Items       []TripItem          `bson:"items" json:"items"`
+	Summary     *TripSummary        `bson:"summary,omitempty" json:"summary,omitempty"`
+	Version     int64               `bson:"version" json:"version"`
+	StartedAt   time.Time           `bson:"started_at" json:"started_at"`
+	CompletedAt *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// CreateShoppingTrip starts a trip with a snapshot of the list items, sorted by
+// the store's aisles when a store is given and by category otherwise
+func CreateShoppingTrip(
+	groupID primitive.ObjectID,
+	shopperID primitive.ObjectID,
+	shopperName string,
+	store *Store,
+	listItems []GroupListItem,
+) *ShoppingTrip {
+	trip := &ShoppingTrip{
+		GroupID:     groupID,
+		ShopperID:   shopperID,
+		ShopperName: shopperName,
+		Status:      TripStatusActive,
+		Items:       make([]TripItem, 0, len(listItems)),
+		StartedAt:   time.Now(),
+	}
+	if store != nil {
+		trip.StoreID = &store.ID
+		trip.StoreName = store.Name
+	}
+
+	for _, listItem := range listItems {
+		item := TripItem{
+			ListItemID: listItem.ID,
+			ItemName:   listItem.ItemName,
+			Quantity:   listItem.Quantity,
+			Unit:       listItem.Unit,
+			Category:   listItem.Category,
+			Status:     TripItemStatusPending,
+		}
+		if store != nil {
+			item.Aisle, item.AisleOrder = store.AisleFor(listItem.Category)
+		}
+		trip.Items = append(trip.Items, item)
+	}
+
+	sort.SliceStable(trip.Items, func(i, j int) bool {
+		a, b := trip.Items[i], trip.Items[j]
+		if a.AisleOrder != b.AisleOrder {
+			return a.AisleOrder < b.AisleOrder
+		}
+		if a.Category != b.Category {
+			return strings.ToLower(a.Category) < strings.ToLower(b.Category)
+		}
+		return strings.ToLower(a.ItemName) < strings.ToLower(b.ItemName)
+	})
+	return trip
+}
+
+// ParseTripItemStatus validates a check-off status
+func ParseTripItemStatus(status string) (TripItemStatus, error) {
+	switch TripItemStatus(strings.ToLower(strings.TrimSpace(status))) {
+	case TripItemStatusBought:
+		return TripItemStatusBought, nil
+	case TripItemStatusSkipped:
+		return TripItemStatusSkipped, nil
+	case TripItemStatusSubstituted:
+		return TripItemStatusSubstituted, nil
+	case TripItemStatusPending:
+		return TripItemStatusPending, nil
+	default:
+		return "", errors.New("status must be bought, skipped, substituted or pending")
+	}
+}
+
+// CheckOff records what happened to one of the trip's items. A zero quantity
+// means the listed quantity was bought; pending undoes an earlier check-off.
+func (t *ShoppingTrip) CheckOff(
+	listItemID primitive.ObjectID,
+	status TripItemStatus,
+	quantity float64,
+	substitute string,
+	userID primitive.ObjectID,
+	now time.Time,
+) (*TripItem, error) {
+	if t.Status != TripStatusActive {
+		return nil, errors.New("trip is no longer active")
+	}
+
+	var item *TripItem
+	for idx := range t.Items {
+		if t.Items[idx].ListItemID == listItemID {
+			item = &t.Items[idx]
+			break
+		}
+	}
+	if item == nil {
+		return nil, errors.New("item is not part of this trip")
+	}
+
+	substitute = strings.TrimSpace(substitute)
+	if status == TripItemStatusSubstituted && substitute == "" {
+		return nil, errors.New("substitute name is required")
+	}
+	if quantity < 0 {
+		return nil, errors.New("quantity cannot be negative")
+	}
+
+	item.Status = status
+	item.Substitute = ""
+	item.BoughtQuantity = 0
+	item.CheckedBy = nil
+	item.CheckedAt = nil
+
+	switch status {
+	case TripItemStatusPending:
+	case TripItemStatusSkipped:
+		item.CheckedBy = &userID
+		item.CheckedAt = &now
+	default:
+		if quantity == 0 {
+			quantity = item.Quantity
+		}
+		if status == TripItemStatusSubstituted {
+			item.Substitute = substitute
+		}
+		item.BoughtQuantity = quantity
+		item.CheckedBy = &userID
+		item.CheckedAt = &now
+	}
+
+	t.Version++
+	return item, nil
+}
+
+// Complete ends the trip and summarizes it; items never checked off count as skipped
+func (t *ShoppingTrip) Complete(now time.Time) (*TripSummary, error) {
+	if t.Status != TripStatusActive {
+		return nil, errors.New("trip is no longer active")
+	}
+
+	summary := &TripSummary{
+		Bought:      make([]TripItem, 0),
+		Skipped:     make([]TripItem, 0),
+		Substituted: make([]TripItem, 0),
+	}
+	for _, item := range t.Items {
+		switch item.Status {
+		case TripItemStatusBought:
+			summary.Bought = append(summary.Bought, item)
+		case TripItemStatusSubstituted:
+			summary.Substituted = append(summary.Substituted, item)
+		default:
+			summary.Skipped = append(summary.Skipped, item)
+		}
+	}
+
+	t.Status = TripStatusCompleted
+	t.Summary = summary
+	t.CompletedAt = &now
+	t.Version++
+	return summary, nil
+}
+
+// Cancel abandons the trip, keeping what was checked off for the record
+func (t *ShoppingTrip) Cancel(now time.Time) error {
+	if t.Status != TripStatusActive {
+		return errors.New("trip is no longer active")
+	}
+
+	t.Status = TripStatusCancelled
+	t.CompletedAt = &now
+	t.Version++
+	return nil
+}
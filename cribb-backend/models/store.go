@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UnsortedAisle is where items whose category no aisle lists are placed, after every aisle
+const UnsortedAisle = "Other"
+
+// Aisle is a section of a store and the item categories found there
+type Aisle struct {
+	Name       string   `bson:"name" json:"name"`
+	Categories []string `bson:"categories" json:"categories"`
+}
+
+// Store is a shop a group uses, with its aisles in walking order
+type Store struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"group_id" json:"group_id" validate:"required"`
+	Name      string             `bson:"name" json:"name" validate:"required"`
+	Aisles    []Aisle            `bson:"aisles" json:"aisles"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CreateStore creates a new store; categories are matched case-insensitively
+func CreateStore(groupID primitive.ObjectID, name string, aisles []Aisle, createdBy primitive.ObjectID) *Store {
+	cleaned := make([]Aisle, 0, len(aisles))
+	for _, aisle := range aisles {
+		categories := make([]string, 0, len(aisle.Categories))
+		for _, category := range aisle.Categories {
+			if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+				categories = append(categories, category)
+			}
+		}
+		cleaned = append(cleaned, Aisle{Name: strings.TrimSpace(aisle.Name), Categories: categories})
+	}
+
+	return &Store{
+		GroupID:   groupID,
+		Name:      strings.TrimSpace(name),
+		Aisles:    cleaned,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Validate checks the store has a name and each category is in one aisle only
+func (s *Store) Validate() error {
+	if s.Name == "" {
+		return errors.New("store name is required")
+	}
+
+	seen := make(map[string]string)
+	for _, aisle := range s.Aisles {
+		if aisle.Name == "" {
+			return errors.New("every aisle needs a name")
+		}
+		for _, category := range aisle.Categories {
+			if other, exists := seen[category]; exists {
+				return fmt.Errorf("category %s is in both %s and %s", category, other, aisle.Name)
+			}
+			seen[category] = aisle.Name
+		}
+	}
+	return nil
+}
+
+// AisleFor returns the aisle a category is found in and its position in the
+// walking order. Unknown categories come after every aisle.
+func (s *Store) AisleFor(category string) (string, int) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	for idx, aisle := range s.Aisles {
+		for _, aisleCategory := range aisle.Categories {
+			if aisleCategory == category {
+				return aisle.Name, idx
+			}
+		}
+	}
+	return UnsortedAisle, len(s.Aisles)
+}
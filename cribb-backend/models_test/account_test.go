@@ -0,0 +1,27 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSessionRevocation(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	revocation := models.CreateSessionRevocation(primitive.NewObjectID(), now)
+
+	if !revocation.ExpiresAt.Equal(now.Add(models.SessionLifetime)) {
+		t.Errorf("Expected the revocation to last as long as a session, got %v", revocation.ExpiresAt)
+	}
+	if !revocation.Revokes(now.Add(-time.Minute)) {
+		t.Error("Expected tokens issued before the revocation to be revoked")
+	}
+	if !revocation.Revokes(time.Time{}) {
+		t.Error("Expected tokens without an issue time to be revoked")
+	}
+	if revocation.Revokes(now.Add(time.Minute)) {
+		t.Error("Expected tokens issued afterwards to be accepted")
+	}
+}
@@ -0,0 +1,104 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBillDueDates(t *testing.T) {
+	bill := models.CreateRecurringBill(primitive.NewObjectID(), "Rent", "Rent", 120000, "", 31, 0, 0, primitive.NewObjectID())
+	if err := bill.Validate(); err != nil {
+		t.Fatalf("Expected bill to be valid, got %v", err)
+	}
+	if bill.IntervalMonths != 1 || bill.ReminderDays != models.DefaultBillReminderDays {
+		t.Errorf("Expected monthly defaults, got %+v", bill)
+	}
+
+	// The 31st clamps to the end of shorter months
+	feb := models.DueDateIn(2025, time.February, 31)
+	if feb.Day() != 28 {
+		t.Errorf("Expected Feb 28, got %s", feb.Format("2006-01-02"))
+	}
+	if models.DueDateIn(2024, time.February, 31).Day() != 29 {
+		t.Error("Expected Feb 29 in a leap year")
+	}
+
+	first := bill.FirstDueDate(time.Date(2025, time.January, 31, 15, 0, 0, 0, time.UTC))
+	if first.Format("2006-01-02") != "2025-01-31" {
+		t.Errorf("Expected the bill to be due today, got %s", first.Format("2006-01-02"))
+	}
+
+	// Clamping in February doesn't stick for later months
+	next := bill.DueDateAfter(bill.DueDateAfter(first))
+	if next.Format("2006-01-02") != "2025-03-31" {
+		t.Errorf("Expected Mar 31, got %s", next.Format("2006-01-02"))
+	}
+
+	bill.DueDay = 10
+	if got := bill.FirstDueDate(time.Date(2025, time.December, 15, 0, 0, 0, 0, time.UTC)); got.Format("2006-01-02") != "2026-01-10" {
+		t.Errorf("Expected the next due date to roll into next year, got %s", got.Format("2006-01-02"))
+	}
+}
+
+func TestBillSplitRejectsByItem(t *testing.T) {
+	members, set := expenseMembers(2)
+	bill := models.CreateRecurringBill(primitive.NewObjectID(), "Internet", "internet", 5001, "", 1, 1, 0, members[0])
+
+	if err := bill.Split(models.SplitTypeByItem, models.SplitInput{}, set); err == nil {
+		t.Error("Expected by-item split to be rejected for bills")
+	}
+	if err := bill.Split("", models.SplitInput{Participants: members}, set); err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if bill.SplitType != models.SplitTypeEqual || splitTotal(bill.Splits) != 5001 {
+		t.Errorf("Unexpected split %+v", bill.Splits)
+	}
+}
+
+func TestBillInstancePayments(t *testing.T) {
+	members, set := expenseMembers(2)
+	bill := models.CreateRecurringBill(primitive.NewObjectID(), "Power", "utilities", 8000, "", 15, 1, 3, members[0])
+	bill.Split(models.SplitTypeEqual, models.SplitInput{Participants: members}, set)
+
+	due := models.DueDateIn(2025, time.June, 15)
+	instance := models.CreateBillInstance(bill, due)
+	if len(instance.Shares) != 2 || instance.Status != models.BillStatusPending {
+		t.Fatalf("Unexpected instance %+v", instance)
+	}
+
+	if instance.ReminderDue(time.Date(2025, time.June, 11, 9, 0, 0, 0, time.UTC)) {
+		t.Error("Expected no reminder four days out")
+	}
+	if !instance.ReminderDue(time.Date(2025, time.June, 12, 9, 0, 0, 0, time.UTC)) {
+		t.Error("Expected a reminder three days out")
+	}
+
+	if instance.IsOverdue(time.Date(2025, time.June, 15, 23, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the bill not to be overdue on its due date")
+	}
+	late := time.Date(2025, time.June, 16, 9, 0, 0, 0, time.UTC)
+
+	if err := instance.MarkPaid(members[0], true, late); err != nil {
+		t.Fatalf("MarkPaid failed: %v", err)
+	}
+	if instance.Status != models.BillStatusOverdue {
+		t.Errorf("Expected overdue with one share unpaid, got %s", instance.Status)
+	}
+
+	instance.MarkPaid(members[1], true, late)
+	if instance.Status != models.BillStatusPaid || len(instance.UnpaidShares()) != 0 {
+		t.Errorf("Expected the bill to be paid, got %s", instance.Status)
+	}
+
+	instance.MarkPaid(members[1], false, late)
+	if instance.Status != models.BillStatusOverdue {
+		t.Errorf("Expected undoing a payment to reopen the bill, got %s", instance.Status)
+	}
+
+	if err := instance.MarkPaid(primitive.NewObjectID(), true, late); err == nil {
+		t.Error("Expected a member without a share to be rejected")
+	}
+}
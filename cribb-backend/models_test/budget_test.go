@@ -0,0 +1,109 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEstimateCostAcrossPackSizes(t *testing.T) {
+	groupID, userID := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now()
+
+	// Newest first: 1 kg of rice for 4.00, then an unknown unit
+	records := []models.PriceRecord{
+		*models.CreatePriceRecord(groupID, "Rice", "Aldi", "grains", 1, "kg", 400, "", userID, now),
+		*models.CreatePriceRecord(groupID, "Rice", "Aldi", "grains", 2, "bags", 500, "", userID, now.Add(-time.Hour)),
+	}
+	if records[0].ProductKey != "rice" || records[0].BaseUnit != "g" {
+		t.Fatalf("Unexpected record %+v", records[0])
+	}
+
+	cost, ok := models.EstimateCost(records, 500, "g")
+	if !ok || cost != 200 {
+		t.Errorf("Expected 500 g to cost 200 cents, got %d %v", cost, ok)
+	}
+
+	// Falls back to the older record with a matching unit
+	cost, ok = models.EstimateCost(records, 3, "bags")
+	if !ok || cost != 750 {
+		t.Errorf("Expected 3 bags to cost 750 cents, got %d %v", cost, ok)
+	}
+
+	if _, ok := models.EstimateCost(records, 1, "l"); ok {
+		t.Error("Expected a volume to be unpriceable from weights")
+	}
+}
+
+func TestSummarizePricesByStore(t *testing.T) {
+	groupID, userID := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now()
+
+	records := []models.PriceRecord{
+		*models.CreatePriceRecord(groupID, "Milk", "Lidl", "dairy", 1, "l", 120, "", userID, now),
+		*models.CreatePriceRecord(groupID, "Milk", "Lidl", "dairy", 1, "l", 100, "", userID, now.Add(-48*time.Hour)),
+		*models.CreatePriceRecord(groupID, "Milk", "Corner shop", "dairy", 500, "ml", 90, "", userID, now.Add(-24*time.Hour)),
+	}
+
+	summaries := models.SummarizePrices(records)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected one summary per store, got %+v", summaries)
+	}
+
+	lidl := summaries[0]
+	if lidl.Store != "Lidl" || lidl.Purchases != 2 {
+		t.Fatalf("Expected the cheaper store first, got %+v", summaries)
+	}
+	if lidl.LatestUnitPrice != 0.12 || lidl.MinUnitPrice != 0.1 || lidl.AvgUnitPrice != 0.11 {
+		t.Errorf("Unexpected per-ml prices %+v", lidl)
+	}
+}
+
+func TestBudgetStatusAndAlerts(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	budget := models.CreateGroceryBudget(groupID, 40000, "", map[string]int64{" Dairy": 5000}, 0, primitive.NewObjectID())
+	if err := budget.Validate(); err != nil {
+		t.Fatalf("Expected budget to be valid, got %v", err)
+	}
+	if budget.AlertThreshold != models.DefaultBudgetAlertThreshold || budget.CategoryLimits["dairy"] != 5000 {
+		t.Errorf("Unexpected budget defaults %+v", budget)
+	}
+
+	month := time.Date(2025, time.June, 14, 0, 0, 0, 0, time.UTC)
+	spent := map[string]int64{"dairy": 5200, "produce": 20000}
+	inCart := map[string]int64{"produce": 12000}
+
+	status := models.ComputeBudgetStatus(budget, month, spent, inCart)
+	if status.Month != "2025-06" || status.Total.Spent != 25200 || status.Total.Projected != 37200 {
+		t.Fatalf("Unexpected totals %+v", status.Total)
+	}
+	if len(status.Categories) != 2 || status.Categories[0].Category != "dairy" {
+		t.Fatalf("Unexpected categories %+v", status.Categories)
+	}
+
+	alerts := status.Alerts(groupID, budget.AlertThreshold)
+	if len(alerts) != 2 {
+		t.Fatalf("Expected an overall warning and a dairy alert, got %+v", alerts)
+	}
+	for _, alert := range alerts {
+		switch alert.Category {
+		case "":
+			if alert.Level != models.BudgetAlertLevelWarning {
+				t.Errorf("Expected the cart to push the budget into a warning, got %s", alert.Level)
+			}
+		case "dairy":
+			if alert.Level != models.BudgetAlertLevelExceeded {
+				t.Errorf("Expected dairy to be exceeded, got %s", alert.Level)
+			}
+		default:
+			t.Errorf("Unexpected alert for %s", alert.Category)
+		}
+	}
+
+	start, end := models.BudgetMonth(month)
+	if start.Day() != 1 || end.Month() != time.July {
+		t.Errorf("Unexpected month bounds %s %s", start, end)
+	}
+}
@@ -205,3 +205,76 @@ func TestCreateChoreFromRecurring(t *testing.T) {
 		t.Errorf("Expected due date around %v, got %v (diff: %v)", expectedDueDate, chore.DueDate, timeDiff)
 	}
 }
+
+func TestProjectOccurrences(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	rc := models.CreateRecurringChore("Trash", "Take out the bins", primitive.NewObjectID(), []primitive.ObjectID{alice, bob}, "weekly", 5)
+	rc.CurrentIndex = 1
+	rc.NextAssignment = now.Add(48 * time.Hour)
+
+	occurrences := rc.ProjectOccurrences(now, now.AddDate(0, 0, 28), 10)
+	if len(occurrences) != 4 {
+		t.Fatalf("Expected 4 occurrences within 28 days, got %d", len(occurrences))
+	}
+
+	wantAssignees := []primitive.ObjectID{bob, alice, bob, alice}
+	for i, occurrence := range occurrences {
+		wantAssigned := rc.NextAssignment.Add(time.Duration(i) * 7 * 24 * time.Hour)
+		if !occurrence.AssignedAt.Equal(wantAssigned) {
+			t.Errorf("Occurrence %d: expected assignment at %v, got %v", i, wantAssigned, occurrence.AssignedAt)
+		}
+		if !occurrence.DueDate.Equal(wantAssigned.Add(7 * 24 * time.Hour)) {
+			t.Errorf("Occurrence %d: expected due a week after assignment, got %v", i, occurrence.DueDate)
+		}
+		if occurrence.AssignedTo != wantAssignees[i] {
+			t.Errorf("Occurrence %d: expected assignee %s, got %s", i, wantAssignees[i].Hex(), occurrence.AssignedTo.Hex())
+		}
+	}
+	if rc.CurrentIndex != 1 {
+		t.Errorf("Expected projecting to leave the rotation at 1, got %d", rc.CurrentIndex)
+	}
+
+	if got := rc.ProjectOccurrences(now, now.AddDate(0, 0, 30), 2); len(got) != 2 {
+		t.Errorf("Expected the limit to cap occurrences at 2, got %d", len(got))
+	}
+
+	// Overdue assignments are projected from now
+	rc.NextAssignment = now.Add(-time.Hour)
+	if got := rc.ProjectOccurrences(now, now, 10); len(got) != 1 || !got[0].AssignedAt.Equal(now) {
+		t.Errorf("Expected an overdue assignment to be projected at now, got %+v", got)
+	}
+
+	rc.IsActive = false
+	if got := rc.ProjectOccurrences(now, now.AddDate(0, 0, 30), 10); len(got) != 0 {
+		t.Errorf("Expected no occurrences for an inactive chore, got %d", len(got))
+	}
+}
+
+func TestRecurringChoreRemoveMember(t *testing.T) {
+	alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	rc := models.CreateRecurringChore("Dishes", "", primitive.NewObjectID(), []primitive.ObjectID{alice, bob, carol}, "daily", 3)
+	rc.CurrentIndex = 2 // Carol is up next
+
+	if rc.RemoveMember(primitive.NewObjectID()) {
+		t.Error("Expected removing a non-member to report false")
+	}
+
+	if !rc.RemoveMember(alice) {
+		t.Fatal("Expected removing Alice to report true")
+	}
+	if len(rc.MemberRotation) != 2 || rc.MemberRotation[rc.CurrentIndex] != carol {
+		t.Errorf("Expected Carol to stay up next, got index %d of %v", rc.CurrentIndex, rc.MemberRotation)
+	}
+
+	// Removing whoever is up next passes the turn on, wrapping around
+	rc.RemoveMember(carol)
+	if rc.CurrentIndex != 0 || rc.MemberRotation[0] != bob {
+		t.Errorf("Expected Bob to be up next, got index %d of %v", rc.CurrentIndex, rc.MemberRotation)
+	}
+
+	rc.RemoveMember(bob)
+	if rc.IsActive || len(rc.MemberRotation) != 0 {
+		t.Errorf("Expected an empty rotation to deactivate the chore, got %+v", rc)
+	}
+}
@@ -0,0 +1,90 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+)
+
+func TestDigestSettingsValidate(t *testing.T) {
+	settings := models.DefaultDigestSettings()
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("Expected the default digest to be valid, got %v", err)
+	}
+
+	invalid := []models.DigestSettings{
+		{Frequency: "hourly", Time: "08:00", Channel: models.NotificationChannelEmail},
+		{Frequency: models.DigestDaily, Time: "8am", Channel: models.NotificationChannelEmail},
+		{Frequency: models.DigestWeekly, Time: "08:00", Weekday: 7, Channel: models.NotificationChannelEmail},
+		{Frequency: models.DigestDaily, Time: "08:00", Channel: models.NotificationChannelInApp},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", s)
+		}
+	}
+
+	off := models.DigestSettings{Frequency: models.DigestOff}
+	if err := off.Validate(); err != nil {
+		t.Errorf("Expected a disabled digest to need no schedule, got %v", err)
+	}
+}
+
+func TestDigestSettingsIsDue(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	settings := models.DigestSettings{Frequency: models.DigestDaily, Time: "08:00", Channel: models.NotificationChannelEmail}
+
+	before := time.Date(2025, 3, 12, 7, 45, 0, 0, loc)
+	if got := settings.LastScheduled(before, loc); !got.Equal(time.Date(2025, 3, 11, 8, 0, 0, 0, loc)) {
+		t.Errorf("Expected the previous morning before 8am, got %v", got)
+	}
+
+	after := time.Date(2025, 3, 12, 8, 10, 0, 0, loc)
+	if !settings.IsDue(after, loc) {
+		t.Error("Expected a digest that was never sent to be due at its time")
+	}
+	if settings.IsDue(time.Date(2025, 3, 12, 14, 30, 0, 0, loc), loc) {
+		t.Error("Expected a digest that was never sent to wait for its next scheduled time")
+	}
+	sent := time.Date(2025, 3, 12, 8, 5, 0, 0, loc)
+	settings.LastSentAt = &sent
+	if settings.IsDue(after, loc) {
+		t.Error("Expected a digest sent this morning not to be due again")
+	}
+	if !settings.IsDue(after.AddDate(0, 0, 1), loc) {
+		t.Error("Expected the digest to be due the next morning")
+	}
+
+	// Wednesday March 12th looks back to Monday March 10th
+	settings.Frequency = models.DigestWeekly
+	settings.Weekday = time.Monday
+	if got := settings.LastScheduled(after, loc); !got.Equal(time.Date(2025, 3, 10, 8, 0, 0, 0, loc)) {
+		t.Errorf("Expected the weekly digest to be scheduled for Monday, got %v", got)
+	}
+	if settings.IsDue(after, loc) {
+		t.Error("Expected a weekly digest sent since Monday not to be due")
+	}
+
+	settings.Frequency = models.DigestOff
+	settings.LastSentAt = nil
+	if settings.IsDue(after, loc) {
+		t.Error("Expected a disabled digest never to be due")
+	}
+}
+
+func TestDigestIsEmpty(t *testing.T) {
+	d := &models.Digest{GroupName: "Maple House", Frequency: models.DigestWeekly}
+	if !d.IsEmpty() {
+		t.Error("Expected a digest with no sections to be empty")
+	}
+	d.LowStockItems = append(d.LowStockItems, models.DigestPantryItem{Name: "Milk"})
+	if d.IsEmpty() {
+		t.Error("Expected a digest with a low stock item not to be empty")
+	}
+	if d.Subject() != "Your weekly Maple House digest" {
+		t.Errorf("Unexpected subject %q", d.Subject())
+	}
+}
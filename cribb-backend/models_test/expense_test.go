@@ -0,0 +1,179 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func expenseMembers(n int) ([]primitive.ObjectID, map[primitive.ObjectID]bool) {
+	ids := make([]primitive.ObjectID, n)
+	set := make(map[primitive.ObjectID]bool, n)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+		set[ids[i]] = true
+	}
+	return ids, set
+}
+
+func splitTotal(splits []models.ExpenseShare) int64 {
+	var total int64
+	for _, split := range splits {
+		total += split.Amount
+	}
+	return total
+}
+
+func TestExpenseEqualSplit(t *testing.T) {
+	members, set := expenseMembers(3)
+
+	expense := models.CreateExpense(primitive.NewObjectID(), "Groceries", members[0], 1000, "", models.SplitTypeEqual, models.ExpenseSourceManual, members[0])
+	if err := expense.Validate(); err != nil {
+		t.Fatalf("Expected expense to be valid, got %v", err)
+	}
+	if expense.Currency != models.DefaultCurrency {
+		t.Errorf("Expected default currency, got %s", expense.Currency)
+	}
+
+	if err := expense.Split(models.SplitInput{Participants: members}, set); err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if splitTotal(expense.Splits) != 1000 {
+		t.Errorf("Expected splits to add up to the amount, got %+v", expense.Splits)
+	}
+	for _, split := range expense.Splits {
+		if split.Amount != 333 && split.Amount != 334 {
+			t.Errorf("Expected a third each, got %d", split.Amount)
+		}
+	}
+
+	outsider := primitive.NewObjectID()
+	err := expense.Split(models.SplitInput{Participants: []primitive.ObjectID{members[0], outsider}}, set)
+	if err == nil {
+		t.Error("Expected a non-member participant to be rejected")
+	}
+}
+
+func TestExpenseSharesAndExactSplits(t *testing.T) {
+	members, set := expenseMembers(2)
+	groupID := primitive.NewObjectID()
+
+	shares := models.CreateExpense(groupID, "Rent", members[0], 90000, "eur", models.SplitTypeShares, models.ExpenseSourceManual, members[0])
+	err := shares.Split(models.SplitInput{Shares: map[primitive.ObjectID]float64{members[0]: 2, members[1]: 1}}, set)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if shares.Currency != "EUR" {
+		t.Errorf("Expected currency to be upper-cased, got %s", shares.Currency)
+	}
+	for _, split := range shares.Splits {
+		if split.UserID == members[0] && split.Amount != 60000 {
+			t.Errorf("Expected two thirds for two shares, got %d", split.Amount)
+		}
+	}
+
+	exact := models.CreateExpense(groupID, "Dinner", members[1], 5000, "", models.SplitTypeExact, models.ExpenseSourceManual, members[1])
+	err = exact.Split(models.SplitInput{Exact: map[primitive.ObjectID]int64{members[0]: 3000, members[1]: 1500}}, set)
+	if err == nil {
+		t.Error("Expected exact amounts that don't add up to be rejected")
+	}
+	err = exact.Split(models.SplitInput{Exact: map[primitive.ObjectID]int64{members[0]: 3000, members[1]: 2000}}, set)
+	if err != nil || splitTotal(exact.Splits) != 5000 {
+		t.Errorf("Expected exact split to be accepted, got %v %+v", err, exact.Splits)
+	}
+}
+
+func TestExpenseByItemSplit(t *testing.T) {
+	members, set := expenseMembers(3)
+
+	expense := models.CreateExpense(primitive.NewObjectID(), "Store run", members[0], 1100, "", models.SplitTypeByItem, models.ExpenseSourceManual, members[0])
+	err := expense.Split(models.SplitInput{Items: []models.ExpenseItem{
+		{Name: "Milk", Amount: 300, UserIDs: members},
+		{Name: "Steak", Amount: 800, UserIDs: members[1:2]},
+	}}, set)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+
+	owed := make(map[primitive.ObjectID]int64)
+	for _, split := range expense.Splits {
+		owed[split.UserID] = split.Amount
+	}
+	if owed[members[0]] != 100 || owed[members[1]] != 900 || owed[members[2]] != 100 {
+		t.Errorf("Unexpected by-item split %+v", expense.Splits)
+	}
+	if len(expense.Items) != 2 {
+		t.Error("Expected the items to be kept on the expense")
+	}
+}
+
+func TestBalancesAndSettleUp(t *testing.T) {
+	members, set := expenseMembers(4)
+	groupID := primitive.NewObjectID()
+	a, b, c, d := members[0], members[1], members[2], members[3]
+
+	// A pays 40.00 for everyone, B pays 20.00 for everyone
+	first := models.CreateExpense(groupID, "Groceries", a, 4000, "", models.SplitTypeEqual, models.ExpenseSourceManual, a)
+	first.Split(models.SplitInput{Participants: members}, set)
+	second := models.CreateExpense(groupID, "Cleaning", b, 2000, "", models.SplitTypeEqual, models.ExpenseSourceManual, b)
+	second.Split(models.SplitInput{Participants: members}, set)
+
+	// C has already paid A back 5.00
+	settlement := models.CreateSettlement(groupID, c, a, 500, "", "", c)
+
+	ledgers := models.ComputeBalances([]models.Expense{*first, *second}, []models.Settlement{*settlement})
+	ledger := ledgers[models.DefaultCurrency]
+
+	expected := map[primitive.ObjectID]int64{a: 2000, b: 500, c: -1000, d: -1500}
+	var sum int64
+	for userID, balance := range expected {
+		if ledger[userID] != balance {
+			t.Errorf("Expected balance %d, got %d", balance, ledger[userID])
+		}
+		sum += ledger[userID]
+	}
+	if sum != 0 {
+		t.Errorf("Expected balances to sum to zero, got %d", sum)
+	}
+
+	transfers := models.SettleUp(ledger, models.DefaultCurrency)
+	if len(transfers) > 3 {
+		t.Errorf("Expected at most 3 transfers, got %d", len(transfers))
+	}
+	for _, transfer := range transfers {
+		ledger[transfer.FromUser] += transfer.Amount
+		ledger[transfer.ToUser] -= transfer.Amount
+	}
+	for userID, balance := range ledger {
+		if balance != 0 {
+			t.Errorf("Expected %s to be settled, still has %d", userID.Hex(), balance)
+		}
+	}
+}
+
+func TestSettleUpPairsExactMatches(t *testing.T) {
+	members, _ := expenseMembers(4)
+	a, b, c, d := members[0], members[1], members[2], members[3]
+
+	// Members whose debts and credits match settle with each other directly
+	ledger := map[primitive.ObjectID]int64{a: 700, b: 500, c: -500, d: -700}
+	transfers := models.SettleUp(ledger, "USD")
+	if len(transfers) != 2 {
+		t.Fatalf("Expected two transfers, got %+v", transfers)
+	}
+	for _, transfer := range transfers {
+		if (transfer.FromUser == c && transfer.ToUser != b) || (transfer.FromUser == d && transfer.ToUser != a) {
+			t.Errorf("Expected matching amounts to be paired, got %+v", transfer)
+		}
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	if models.FormatCents(1250) != "12.50" || models.FormatCents(-5) != "-0.05" {
+		t.Errorf("Unexpected formatting %s %s", models.FormatCents(1250), models.FormatCents(-5))
+	}
+	if models.ToCents(19.99) != 1999 {
+		t.Errorf("Expected 1999 cents, got %d", models.ToCents(19.99))
+	}
+}
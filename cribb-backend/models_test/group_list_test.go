@@ -0,0 +1,86 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGroupListMergesRequests(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now()
+
+	item := models.CreateGroupListItem(primitive.NewObjectID(), "Tomatoes", "kg", "produce")
+	if item.ItemKey != models.IngredientKey(" tomatoes ") {
+		t.Errorf("Expected item key to match the ingredient key, got %q", item.ItemKey)
+	}
+
+	if err := item.AddRequest(alice, "Alice", 1, "kg", now); err != nil {
+		t.Fatalf("AddRequest failed: %v", err)
+	}
+	if err := item.AddRequest(bob, "Bob", 500, "g", now); err != nil {
+		t.Fatalf("AddRequest failed: %v", err)
+	}
+	if err := item.AddRequest(alice, "Alice", 0.25, "kg", now); err != nil {
+		t.Fatalf("AddRequest failed: %v", err)
+	}
+
+	if item.Quantity != 1.75 || len(item.Requests) != 2 {
+		t.Errorf("Expected 1.75 kg from two members, got %g from %+v", item.Quantity, item.Requests)
+	}
+	if item.Requests[0].Quantity != 1.25 {
+		t.Errorf("Expected Alice's requests to be summed, got %g", item.Requests[0].Quantity)
+	}
+	if item.Version != 3 {
+		t.Errorf("Expected each change to bump the version, got %d", item.Version)
+	}
+
+	if err := item.AddRequest(bob, "Bob", 1, "l", now); err == nil {
+		t.Error("Expected a volume to be rejected for a weighed item")
+	}
+
+	if !item.RemoveRequest(bob, now) || item.Quantity != 1.25 {
+		t.Errorf("Expected Bob's share to be removed, got %g", item.Quantity)
+	}
+	if item.RemoveRequest(bob, now) {
+		t.Error("Expected removing twice to report no request")
+	}
+
+	if models.ListUnitKey("kg") != models.ListUnitKey("oz") || models.ListUnitKey("") != models.ListUnitKey("pc") {
+		t.Error("Expected units of one dimension to share a key")
+	}
+	if models.ListUnitKey("cans") == models.ListUnitKey("bag") || models.ListUnitKey("cans") == models.ListUnitKey("pc") {
+		t.Error("Expected different containers to keep separate keys")
+	}
+}
+
+func TestGroupListClaims(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now()
+
+	item := models.CreateGroupListItem(primitive.NewObjectID(), "Bread", "", "bakery")
+	item.AddRequest(alice, "Alice", 1, "", now)
+
+	if err := item.Claim(alice, "Alice", now); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if err := item.Claim(alice, "Alice", now); err != nil {
+		t.Errorf("Expected claiming again to be allowed, got %v", err)
+	}
+	if err := item.Claim(bob, "Bob", now); !errors.Is(err, models.ErrListItemClaimed) {
+		t.Errorf("Expected a claim conflict, got %v", err)
+	}
+	if err := item.Unclaim(bob, now); !errors.Is(err, models.ErrListItemNotClaimed) {
+		t.Errorf("Expected Bob not to be able to release Alice's claim, got %v", err)
+	}
+
+	if err := item.Unclaim(alice, now); err != nil || item.IsClaimed() {
+		t.Fatalf("Expected the claim to be released, got %v", err)
+	}
+	if err := item.Claim(bob, "Bob", now); err != nil || item.ClaimedByName != "Bob" {
+		t.Errorf("Expected Bob to claim the released item, got %v", err)
+	}
+}
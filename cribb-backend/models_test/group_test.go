@@ -3,6 +3,8 @@ package models_test
 import (
 	"cribb-backend/models"
 	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestNewGroup(t *testing.T) {
@@ -42,3 +44,20 @@ func TestGenerateGroupCode(t *testing.T) {
 		}
 	}
 }
+
+func TestGroupIsAdmin(t *testing.T) {
+	group := models.NewGroup("Test Apartment")
+	member, admin := primitive.NewObjectID(), primitive.NewObjectID()
+
+	if !group.IsAdmin(member) {
+		t.Error("Expected every member to administer a group without admins")
+	}
+
+	group.Admins = []primitive.ObjectID{admin}
+	if group.IsAdmin(member) {
+		t.Error("Expected a member who isn't an admin to be refused")
+	}
+	if !group.IsAdmin(admin) {
+		t.Error("Expected the admin to be recognized")
+	}
+}
@@ -0,0 +1,77 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateGroupWebhook(t *testing.T) {
+	webhook, err := models.CreateGroupWebhook(primitive.NewObjectID(), "https://example.com/hook", "", []string{"chore.completed"}, primitive.NewObjectID())
+	if err != nil {
+		t.Fatalf("CreateGroupWebhook returned error: %v", err)
+	}
+	if !strings.HasPrefix(webhook.Secret, "whsec_") || len(webhook.Secret) != len("whsec_")+64 {
+		t.Errorf("Unexpected secret %q", webhook.Secret)
+	}
+	other, _ := models.CreateGroupWebhook(webhook.GroupID, webhook.URL, "", webhook.Events, webhook.CreatedBy)
+	if other.Secret == webhook.Secret {
+		t.Error("Expected every webhook to get its own secret")
+	}
+	if err := webhook.Validate(); err != nil {
+		t.Errorf("Expected the webhook to be valid, got %v", err)
+	}
+
+	if !webhook.Wants("chore.completed") || webhook.Wants("pantry.low_stock") {
+		t.Error("Expected the webhook to want only its subscribed events")
+	}
+	webhook.Active = false
+	if webhook.Wants("chore.completed") {
+		t.Error("Expected a disabled webhook to want nothing")
+	}
+
+	webhook.URL = "ftp://example.com"
+	if err := webhook.Validate(); err == nil {
+		t.Error("Expected a non-HTTP URL to be rejected")
+	}
+	for _, internal := range []string{"http://127.0.0.1:27017/", "http://169.254.169.254/latest/meta-data/", "https://10.1.2.3/hook", "http://[::1]/", "http://localhost:8080/"} {
+		webhook.URL = internal
+		if err := webhook.Validate(); !errors.Is(err, models.ErrPrivateHost) {
+			t.Errorf("Expected %s to be rejected as internal, got %v", internal, err)
+		}
+	}
+	webhook.URL = "https://example.com/hook"
+	webhook.Events = nil
+	if err := webhook.Validate(); err == nil {
+		t.Error("Expected a webhook without events to be rejected")
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	webhook := &models.GroupWebhook{ID: primitive.NewObjectID(), GroupID: primitive.NewObjectID()}
+	d := models.CreateWebhookDelivery(webhook, "chore.completed", []byte(`{}`))
+	now := time.Now()
+
+	d.RecordAttempt(models.WebhookAttempt{At: now, StatusCode: 503}, errors.New("webhook responded 503"), false)
+	if d.Status != models.OutboxStatusPending || !d.NextAttemptAt.Equal(now.Add(models.OutboxBackoff(1))) {
+		t.Errorf("Expected a retry after the backoff, got %s at %v", d.Status, d.NextAttemptAt)
+	}
+	if len(d.Log) != 1 || d.Log[0].StatusCode != 503 || d.Log[0].Error == "" {
+		t.Errorf("Expected the attempt to be logged, got %+v", d.Log)
+	}
+
+	d.RecordAttempt(models.WebhookAttempt{At: now, StatusCode: 200}, nil, false)
+	if d.Status != models.OutboxStatusSent || d.DeliveredAt == nil || d.Attempts != 2 {
+		t.Errorf("Expected the delivery to be sent on its second attempt, got %+v", d)
+	}
+
+	failing := models.CreateWebhookDelivery(webhook, "chore.completed", []byte(`{}`))
+	failing.RecordAttempt(models.WebhookAttempt{At: now, StatusCode: 404}, errors.New("webhook responded 404"), true)
+	if failing.Status != models.OutboxStatusFailed {
+		t.Errorf("Expected a permanent failure to stop retries, got %s", failing.Status)
+	}
+}
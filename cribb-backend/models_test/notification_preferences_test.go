@@ -0,0 +1,106 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotificationPreferenceModes(t *testing.T) {
+	prefs := models.DefaultNotificationPreferences(primitive.NewObjectID())
+	if prefs.ModeFor(models.NotificationTypeCartChanged, models.NotificationChannelPush) != models.DeliveryImmediate {
+		t.Error("Expected push to default to immediate")
+	}
+	if prefs.ModeFor(models.NotificationTypeCartChanged, models.NotificationChannelEmail) != models.DeliveryDigest {
+		t.Error("Expected email to default to the digest")
+	}
+
+	prefs.Types[models.NotificationTypeCartChanged] = map[models.NotificationChannel]models.DeliveryMode{
+		models.NotificationChannelInApp: models.DeliveryOff,
+		models.NotificationChannelPush:  models.DeliveryOff,
+	}
+	if !prefs.Wants(models.NotificationTypeCartChanged) {
+		t.Error("Expected the email digest to still want cart changes")
+	}
+
+	prefs.Types[models.NotificationTypeCartChanged][models.NotificationChannelEmail] = models.DeliveryOff
+	if prefs.Wants(models.NotificationTypeCartChanged) {
+		t.Error("Expected cart changes to be fully muted")
+	}
+	if !prefs.Wants(models.NotificationTypeLowStock) {
+		t.Error("Expected other types to keep their defaults")
+	}
+}
+
+func TestNotificationPreferencesValidate(t *testing.T) {
+	invalid := map[string]func(p *models.NotificationPreferences){
+		"timezone": func(p *models.NotificationPreferences) { p.Timezone = "Mars/Olympus" },
+		"quiet hours": func(p *models.NotificationPreferences) {
+			p.QuietHours = &models.QuietHours{Start: "22:00", End: "22:00"}
+		},
+		"clock format": func(p *models.NotificationPreferences) {
+			p.QuietHours = &models.QuietHours{Start: "10pm", End: "07:00"}
+		},
+		"email":        func(p *models.NotificationPreferences) { p.Email = "not an address" },
+		"webhook":      func(p *models.NotificationPreferences) { p.WebhookURL = "ftp://example.com/hook" },
+		"http webhook": func(p *models.NotificationPreferences) { p.WebhookURL = "http://example.com/hook" },
+		"internal webhook": func(p *models.NotificationPreferences) {
+			p.WebhookURL = "https://169.254.169.254/latest/meta-data/"
+		},
+		"type": func(p *models.NotificationPreferences) {
+			p.Types["mystery"] = map[models.NotificationChannel]models.DeliveryMode{models.NotificationChannelPush: models.DeliveryOff}
+		},
+		"channel": func(p *models.NotificationPreferences) {
+			p.Types[models.NotificationTypeLowStock] = map[models.NotificationChannel]models.DeliveryMode{"fax": models.DeliveryOff}
+		},
+		"in-app digest": func(p *models.NotificationPreferences) {
+			p.Types[models.NotificationTypeLowStock] = map[models.NotificationChannel]models.DeliveryMode{models.NotificationChannelInApp: models.DeliveryDigest}
+		},
+	}
+	for name, change := range invalid {
+		prefs := models.DefaultNotificationPreferences(primitive.NewObjectID())
+		change(prefs)
+		if err := prefs.Validate(); err == nil {
+			t.Errorf("Expected an invalid %s to be rejected", name)
+		}
+	}
+
+	prefs := models.DefaultNotificationPreferences(primitive.NewObjectID())
+	prefs.Timezone = "America/New_York"
+	prefs.QuietHours = &models.QuietHours{Start: "22:00", End: "07:00"}
+	if err := prefs.Validate(); err != nil {
+		t.Errorf("Expected valid preferences, got %v", err)
+	}
+}
+
+func TestQuietHoursDeliverAt(t *testing.T) {
+	prefs := models.DefaultNotificationPreferences(primitive.NewObjectID())
+	prefs.Timezone = "America/New_York"
+	prefs.QuietHours = &models.QuietHours{Start: "22:00", End: "07:00"}
+	loc := prefs.Location()
+
+	cases := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"before quiet hours", time.Date(2025, 3, 10, 21, 30, 0, 0, loc), time.Date(2025, 3, 10, 21, 30, 0, 0, loc)},
+		{"late evening", time.Date(2025, 3, 10, 23, 15, 0, 0, loc), time.Date(2025, 3, 11, 7, 0, 0, 0, loc)},
+		{"early morning", time.Date(2025, 3, 11, 3, 0, 0, 0, loc), time.Date(2025, 3, 11, 7, 0, 0, 0, loc)},
+		{"after quiet hours", time.Date(2025, 3, 11, 7, 0, 0, 0, loc), time.Date(2025, 3, 11, 7, 0, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		// Times are passed in UTC; quiet hours apply in the user's timezone
+		if got := prefs.DeliverAt(c.at.UTC()); !got.Equal(c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+
+	prefs.QuietHours = &models.QuietHours{Start: "13:00", End: "15:00"}
+	lunch := time.Date(2025, 3, 11, 14, 0, 0, 0, loc)
+	if got := prefs.DeliverAt(lunch); !got.Equal(time.Date(2025, 3, 11, 15, 0, 0, 0, loc)) {
+		t.Errorf("Expected same-day quiet hours to end at 15:00, got %v", got)
+	}
+}
@@ -0,0 +1,86 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotificationVisibility(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	alice := primitive.NewObjectID()
+	bob := primitive.NewObjectID()
+
+	chore := models.CreateChore("Take out trash", "", groupID, alice, time.Now().Add(24*time.Hour), 5)
+	chore.ID = primitive.NewObjectID()
+
+	assigned := models.CreateChoreAssignedNotification(chore)
+	if assigned.UserID != alice || assigned.SubjectID != chore.ID || assigned.Domain != models.NotificationDomainChores {
+		t.Errorf("Unexpected chore notification %+v", assigned)
+	}
+	if !assigned.IsVisibleTo(alice) || assigned.IsVisibleTo(bob) {
+		t.Error("Expected the chore notification to reach only the assignee")
+	}
+
+	activity := models.CreateShoppingCartActivity(groupID, primitive.NewObjectID(), "Milk", bob, "Bob", models.CartActivityTypeAdd, 2, "")
+	cart := models.NotificationFromCartActivity(activity)
+	if !cart.IsVisibleTo(alice) || cart.IsVisibleTo(bob) {
+		t.Error("Expected cart changes to reach everyone except the member who made them")
+	}
+	cart.HiddenFrom = append(cart.HiddenFrom, alice)
+	if cart.IsVisibleTo(alice) {
+		t.Error("Expected members who muted the type not to see it")
+	}
+	if cart.Message != "Bob changed Milk" {
+		t.Errorf("Expected a fallback message, got %q", cart.Message)
+	}
+}
+
+func TestNotificationReadState(t *testing.T) {
+	pantry := models.CreatePantryNotification(primitive.NewObjectID(), primitive.NewObjectID(), "Eggs", models.NotificationTypeLowStock, "Item is running low")
+	n := models.NotificationFromPantry(pantry)
+	if n.Domain != models.NotificationDomainPantry || n.Type != models.NotificationTypeLowStock || n.SubjectID != pantry.ItemID {
+		t.Errorf("Unexpected pantry notification %+v", n)
+	}
+	if got := n.ExpiresAt.Sub(n.CreatedAt); got != models.NotificationRetention {
+		t.Errorf("Expected notifications to expire after %v, got %v", models.NotificationRetention, got)
+	}
+
+	userID := primitive.NewObjectID()
+	n.MarkAsReadByUser(userID)
+	n.MarkAsReadByUser(userID)
+	if !n.HasBeenReadBy(userID) || len(n.ReadBy) != 1 {
+		t.Errorf("Expected a single read entry, got %v", n.ReadBy)
+	}
+}
+
+func TestMemberNotificationsAreAboutTheMember(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	user := &models.User{ID: primitive.NewObjectID(), Name: "Carol"}
+
+	// Deleting an account removes notifications whose subject is the member,
+	// so every notification about a member must use one of UserSubjectTypes
+	joined := models.CreateMemberJoinedNotification(groupID, user)
+	if joined.SubjectID != user.ID {
+		t.Fatalf("Expected the member to be the subject, got %s", joined.SubjectID.Hex())
+	}
+	found := false
+	for _, notificationType := range models.UserSubjectTypes {
+		if notificationType == joined.Type {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected %q to be listed in UserSubjectTypes", joined.Type)
+	}
+
+	chore := models.CreateChore("Dishes", "", groupID, user.ID, time.Now(), 5)
+	chore.ID = primitive.NewObjectID()
+	for _, notificationType := range models.UserSubjectTypes {
+		if models.CreateChoreAssignedNotification(chore).Type == notificationType {
+			t.Errorf("Expected chore notifications not to be treated as about a member")
+		}
+	}
+}
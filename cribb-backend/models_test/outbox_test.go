@@ -0,0 +1,75 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	expected := []time.Duration{0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for attempts, want := range expected {
+		if got := models.OutboxBackoff(attempts); got != want {
+			t.Errorf("Attempt %d: expected %v, got %v", attempts, want, got)
+		}
+	}
+	if got := models.OutboxBackoff(50); got != 2*time.Hour {
+		t.Errorf("Expected the backoff to be capped at 2h, got %v", got)
+	}
+}
+
+func TestOutboxMessageRetries(t *testing.T) {
+	now := time.Now()
+	target := models.DeliveryTarget{Address: "alice@example.com"}
+	message := models.CreateOutboxMessage(primitive.NewObjectID(), models.NotificationChannelEmail, target, "Low stock", "Milk", now)
+	if message.Status != models.OutboxStatusPending || !message.NextAttemptAt.Equal(now) {
+		t.Errorf("Unexpected new message %+v", message)
+	}
+
+	message.RecordFailure(errors.New("connection refused"), false, now)
+	if message.Status != models.OutboxStatusPending || message.Attempts != 1 {
+		t.Errorf("Expected a retry to be scheduled, got %+v", message)
+	}
+	if !message.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected the next attempt in a minute, got %v", message.NextAttemptAt.Sub(now))
+	}
+
+	for message.Status == models.OutboxStatusPending {
+		message.RecordFailure(errors.New("connection refused"), false, now)
+	}
+	if message.Status != models.OutboxStatusFailed || message.Attempts != models.DefaultOutboxAttempts {
+		t.Errorf("Expected the message to fail after %d attempts, got %+v", models.DefaultOutboxAttempts, message)
+	}
+
+	permanent := models.CreateOutboxMessage(primitive.NewObjectID(), models.NotificationChannelEmail, target, "Low stock", "Milk", now)
+	permanent.RecordFailure(errors.New("mailbox does not exist"), true, now)
+	if permanent.Status != models.OutboxStatusFailed || permanent.LastError != "mailbox does not exist" {
+		t.Errorf("Expected a permanent failure to stop retries, got %+v", permanent)
+	}
+
+	sent := models.CreateOutboxMessage(primitive.NewObjectID(), models.NotificationChannelEmail, target, "Low stock", "Milk", now)
+	sent.MarkSent(now)
+	if sent.Status != models.OutboxStatusSent || sent.SentAt == nil || sent.Attempts != 1 {
+		t.Errorf("Unexpected sent message %+v", sent)
+	}
+}
+
+func TestPushSubscriptionValidate(t *testing.T) {
+	valid := models.CreatePushSubscription(primitive.NewObjectID(), "https://push.example.com/send/abc", "BPk", "c2VjcmV0", "")
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected a valid subscription, got %v", err)
+	}
+
+	insecure := models.CreatePushSubscription(primitive.NewObjectID(), "http://push.example.com/send/abc", "BPk", "c2VjcmV0", "")
+	if err := insecure.Validate(); err == nil {
+		t.Error("Expected plain http endpoints to be rejected")
+	}
+
+	missingKeys := models.CreatePushSubscription(primitive.NewObjectID(), "https://push.example.com/send/abc", "", "", "")
+	if err := missingKeys.Validate(); err == nil {
+		t.Error("Expected subscriptions without keys to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPantryBatchesFIFOConsumption(t *testing.T) {
+	userID := primitive.NewObjectID()
+	now := time.Now()
+
+	milk := models.CreatePantryItem(primitive.NewObjectID(), "Milk", 1, "l", "Dairy", now.AddDate(0, 0, 5), userID)
+	older := models.CreatePantryBatch(1, now.AddDate(0, 0, 2), userID)
+	noExpiry := models.CreatePantryBatch(2, time.Time{}, userID)
+	milk.AddBatch(noExpiry)
+	milk.AddBatch(older)
+
+	if milk.Quantity != 4 {
+		t.Fatalf("Expected total quantity 4, got %f", milk.Quantity)
+	}
+	if !milk.ExpirationDate.Equal(older.ExpirationDate) {
+		t.Errorf("Expected item expiration to be the earliest batch expiration")
+	}
+
+	consumed, err := milk.ConsumeFIFO(1.5)
+	if err != nil {
+		t.Fatalf("Unexpected error consuming milk: %v", err)
+	}
+
+	if len(consumed) != 2 {
+		t.Fatalf("Expected two batches to be touched, got %d", len(consumed))
+	}
+	if consumed[0].BatchID != older.ID || !consumed[0].Emptied || consumed[0].Quantity != 1 {
+		t.Errorf("Expected the first-expiring batch to be emptied first, got %+v", consumed[0])
+	}
+	if consumed[1].Quantity != 0.5 || consumed[1].Emptied {
+		t.Errorf("Expected half a litre from the second batch, got %+v", consumed[1])
+	}
+
+	if milk.Quantity != 2.5 || len(milk.Batches) != 2 {
+		t.Errorf("Expected 2.5 l left in 2 batches, got %f in %d", milk.Quantity, len(milk.Batches))
+	}
+	if milk.Batches[len(milk.Batches)-1].ID != noExpiry.ID {
+		t.Errorf("Expected the batch without an expiration date to be used last")
+	}
+
+	if _, err := milk.ConsumeFIFO(10); !errors.Is(err, models.ErrInsufficientQuantity) {
+		t.Errorf("Expected ErrInsufficientQuantity, got %v", err)
+	}
+}
+
+func TestPantryBatchesLegacyItem(t *testing.T) {
+	item := models.PantryItem{
+		ID:             primitive.NewObjectID(),
+		Quantity:       3,
+		ExpirationDate: time.Now().AddDate(0, 0, -1),
+	}
+
+	item.EnsureBatches()
+	if len(item.Batches) != 1 || item.Batches[0].ID != item.ID {
+		t.Fatalf("Expected a single batch reusing the item ID, got %+v", item.Batches)
+	}
+	if len(item.ExpiredBatches()) != 1 {
+		t.Errorf("Expected the legacy batch to be expired")
+	}
+
+	removed, found := item.RemoveBatch(item.ID)
+	if !found || removed.Quantity != 3 {
+		t.Errorf("Expected to remove the legacy batch, got %+v %v", removed, found)
+	}
+	if item.Quantity != 0 || !item.ExpirationDate.IsZero() {
+		t.Errorf("Expected an empty item without expiration date, got %f %v", item.Quantity, item.ExpirationDate)
+	}
+}
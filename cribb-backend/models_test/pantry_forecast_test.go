@@ -0,0 +1,74 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"math"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func useRecord(itemID primitive.ObjectID, quantity float64, at time.Time) models.PantryHistory {
+	return models.PantryHistory{
+		ItemID:    itemID,
+		Action:    models.ActionTypeUse,
+		Quantity:  quantity,
+		CreatedAt: at,
+	}
+}
+
+func TestForecastConsumption(t *testing.T) {
+	now := time.Now()
+	item := models.PantryItem{ID: primitive.NewObjectID(), Name: "Milk", Quantity: 2, Unit: "l"}
+	otherItem := primitive.NewObjectID()
+
+	history := []models.PantryHistory{
+		useRecord(item.ID, 1, now.AddDate(0, 0, -10)),
+		useRecord(item.ID, 2, now.AddDate(0, 0, -5)),
+		useRecord(item.ID, 2, now.AddDate(0, 0, -1)),
+		useRecord(otherItem, 50, now.AddDate(0, 0, -2)),                                                // Different item
+		useRecord(item.ID, 40, now.AddDate(0, 0, -60)),                                                 // Outside the window
+		{ItemID: item.ID, Action: models.ActionTypeAdd, Quantity: 4, CreatedAt: now.AddDate(0, 0, -3)}, // Not a use
+	}
+
+	forecast := models.ForecastConsumption(item, history, now)
+
+	if !forecast.HasEnoughData {
+		t.Fatalf("Expected enough data to forecast")
+	}
+	if forecast.SampleCount != 3 {
+		t.Errorf("Expected 3 samples, got %d", forecast.SampleCount)
+	}
+	if math.Abs(forecast.DailyRate-0.5) > 0.001 {
+		t.Errorf("Expected daily rate 0.5, got %f", forecast.DailyRate)
+	}
+	if math.Abs(forecast.DaysRemaining-4) > 0.001 {
+		t.Errorf("Expected 4 days remaining, got %f", forecast.DaysRemaining)
+	}
+	if !forecast.RunsOutWithin(5) || forecast.RunsOutWithin(3) {
+		t.Errorf("Expected run-out between 3 and 5 days away")
+	}
+	if math.Abs(forecast.PredictedNeed(7)-1.5) > 0.001 {
+		t.Errorf("Expected predicted need 1.5 for a week, got %f", forecast.PredictedNeed(7))
+	}
+}
+
+func TestForecastConsumptionNotEnoughData(t *testing.T) {
+	now := time.Now()
+	item := models.PantryItem{ID: primitive.NewObjectID(), Quantity: 1}
+
+	forecast := models.ForecastConsumption(item, []models.PantryHistory{
+		useRecord(item.ID, 1, now.AddDate(0, 0, -2)),
+	}, now)
+
+	if forecast.HasEnoughData {
+		t.Errorf("Expected a single use not to be enough data")
+	}
+	if forecast.RunsOutWithin(30) {
+		t.Errorf("Expected no run-out prediction without enough data")
+	}
+	if forecast.PredictedNeed(7) != 0 {
+		t.Errorf("Expected no predicted need without enough data")
+	}
+}
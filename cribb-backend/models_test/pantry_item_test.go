@@ -0,0 +1,61 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+)
+
+func TestPantryItemLowStockDefaults(t *testing.T) {
+	item := models.PantryItem{Quantity: 1}
+
+	if !item.IsLowStock() {
+		t.Errorf("Expected quantity 1 to be low stock with the default threshold")
+	}
+	if item.TargetLevel() != models.DefaultParLevel {
+		t.Errorf("Expected default par level %f, got %f", models.DefaultParLevel, item.TargetLevel())
+	}
+	if item.SuggestedPurchaseQuantity() != 1 {
+		t.Errorf("Expected suggested quantity 1, got %f", item.SuggestedPurchaseQuantity())
+	}
+}
+
+func TestPantryItemCustomThresholds(t *testing.T) {
+	// 1 kg of flour is fine when the threshold is 0.5 kg
+	flour := models.PantryItem{Quantity: 1, MinThreshold: 0.5, ParLevel: 2}
+	if flour.IsLowStock() {
+		t.Errorf("Expected flour above its threshold not to be low stock")
+	}
+
+	// 4 eggs is low when the threshold is 6
+	eggs := models.PantryItem{Quantity: 4, MinThreshold: 6, ParLevel: 12}
+	if !eggs.IsLowStock() {
+		t.Errorf("Expected eggs at or below their threshold to be low stock")
+	}
+	if eggs.SuggestedPurchaseQuantity() != 8 {
+		t.Errorf("Expected suggested quantity 8, got %f", eggs.SuggestedPurchaseQuantity())
+	}
+
+	// Without a par level the target is twice the threshold
+	rice := models.PantryItem{Quantity: 0, MinThreshold: 3}
+	if !rice.IsOutOfStock() || rice.IsLowStock() {
+		t.Errorf("Expected empty item to be out of stock rather than low stock")
+	}
+	if rice.SuggestedPurchaseQuantity() != 6 {
+		t.Errorf("Expected suggested quantity 6, got %f", rice.SuggestedPurchaseQuantity())
+	}
+}
+
+func TestValidateStockLevels(t *testing.T) {
+	if err := models.ValidateStockLevels(2, 10); err != nil {
+		t.Errorf("Expected valid stock levels, got %v", err)
+	}
+	if err := models.ValidateStockLevels(0, 0); err != nil {
+		t.Errorf("Expected unset stock levels to be valid, got %v", err)
+	}
+	if err := models.ValidateStockLevels(5, 2); err == nil {
+		t.Errorf("Expected error when par level is below min threshold")
+	}
+	if err := models.ValidateStockLevels(-1, 0); err == nil {
+		t.Errorf("Expected error for negative threshold")
+	}
+}
@@ -0,0 +1,26 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProductEstimatedExpiration(t *testing.T) {
+	purchasedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	milk := models.CreateProduct("0036000291452", "Whole milk", "Farm", "Dairy", "gal", 1, 7, models.ProductSourceOpenFoodFacts)
+	if expected := purchasedAt.AddDate(0, 0, 7); !milk.EstimatedExpiration(purchasedAt).Equal(expected) {
+		t.Errorf("Expected expiration %v, got %v", expected, milk.EstimatedExpiration(purchasedAt))
+	}
+
+	salt := models.CreateCustomProduct(primitive.NewObjectID(), primitive.NewObjectID(), "96385074", "Salt", "", "Condiments", "kg", 1, 0)
+	if !salt.EstimatedExpiration(purchasedAt).IsZero() {
+		t.Errorf("Expected no expiration for a product without a shelf life")
+	}
+	if salt.Source != models.ProductSourceCustom || salt.GroupID.IsZero() {
+		t.Errorf("Expected a custom product tied to a group, got %+v", salt)
+	}
+}
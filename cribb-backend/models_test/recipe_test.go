@@ -0,0 +1,228 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func pancakeRecipe(groupID, userID primitive.ObjectID) *models.Recipe {
+	return models.CreateRecipe(
+		groupID,
+		"Pancakes",
+		"Fluffy breakfast pancakes",
+		4,
+		[]models.RecipeIngredient{
+			{Name: "Flour", Quantity: 200, Unit: "grams"},
+			{Name: "Milk", Quantity: 300, Unit: "ml"},
+			{Name: "Egg", Quantity: 2, Unit: "pc"},
+			{Name: "Blueberries", Quantity: 100, Unit: "g", Optional: true},
+		},
+		"Mix and fry.",
+		userID,
+	)
+}
+
+func TestRecipeValidate(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	recipe := pancakeRecipe(groupID, userID)
+	if err := recipe.Validate(); err != nil {
+		t.Fatalf("Expected recipe to be valid, got %v", err)
+	}
+	if recipe.Ingredients[0].Unit != "g" {
+		t.Errorf("Expected unit to be normalized to g, got %s", recipe.Ingredients[0].Unit)
+	}
+
+	recipe.Ingredients = append(recipe.Ingredients, models.RecipeIngredient{Name: "Sugar", Quantity: 1, Unit: "handful"})
+	if err := recipe.Validate(); err == nil {
+		t.Error("Expected an unknown unit to be rejected")
+	}
+
+	empty := models.CreateRecipe(groupID, "Nothing", "", 1, nil, "", userID)
+	if err := empty.Validate(); err == nil {
+		t.Error("Expected a recipe without ingredients to be rejected")
+	}
+}
+
+func TestRecipeScaled(t *testing.T) {
+	recipe := pancakeRecipe(primitive.NewObjectID(), primitive.NewObjectID())
+	recipe.Validate()
+
+	scaled := recipe.Scaled(2)
+	if scaled[0].Quantity != 100 || scaled[2].Quantity != 1 {
+		t.Errorf("Expected half quantities, got %+v", scaled)
+	}
+	if recipe.Ingredients[0].Quantity != 200 {
+		t.Error("Expected scaling to leave the recipe unchanged")
+	}
+
+	if same := recipe.Scaled(0); same[1].Quantity != 300 {
+		t.Errorf("Expected zero servings to use the recipe's servings, got %+v", same)
+	}
+}
+
+func TestIngredientKey(t *testing.T) {
+	cases := map[string]string{
+		"Eggs":           "egg",
+		" egg ":          "egg",
+		"Brown   Sugar":  "brown sugar",
+		"Swiss":          "swiss",
+		"Peas":           "pea",
+		"gas":            "gas",
+		"Tomatoes":       "tomatoe",
+		"OLIVE OIL":      "olive oil",
+		"Cherry tomatos": "cherry tomato",
+	}
+	for name, expected := range cases {
+		if key := models.IngredientKey(name); key != expected {
+			t.Errorf("IngredientKey(%q) = %q, expected %q", name, key, expected)
+		}
+	}
+}
+
+func TestMatchRecipe(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	recipe := pancakeRecipe(groupID, userID)
+	recipe.Validate()
+
+	items := []models.PantryItem{
+		*models.CreatePantryItem(groupID, "flour", 1, "kg", "Dry Goods", time.Time{}, userID),
+		*models.CreatePantryItem(groupID, "Milk", 0.2, "l", "Dairy", time.Time{}, userID),
+		*models.CreatePantryItem(groupID, "Eggs", 6, "pc", "Eggs", time.Time{}, userID),
+	}
+	pantry := models.IndexPantryItems(items)
+
+	// 300 ml of milk needed, only 200 ml in stock
+	match := models.MatchRecipe(recipe, 4, pantry)
+	if match.CanCook || match.MissingCount != 1 {
+		t.Fatalf("Expected one missing ingredient, got %+v", match)
+	}
+	if match.Ingredients[0].Available != 1000 {
+		t.Errorf("Expected flour stock converted to 1000 g, got %v", match.Ingredients[0].Available)
+	}
+	if match.Ingredients[1].Missing != 100 {
+		t.Errorf("Expected 100 ml of milk missing, got %v", match.Ingredients[1].Missing)
+	}
+	if match.Ingredients[3].Missing != 100 || !match.Ingredients[3].Optional {
+		t.Errorf("Expected the optional blueberries to be reported missing, got %+v", match.Ingredients[3])
+	}
+	if summary := match.MissingSummary(); !strings.Contains(summary, "Milk") || strings.Contains(summary, "Blueberries") {
+		t.Errorf("Expected only milk in the summary, got %q", summary)
+	}
+
+	// Half the servings fits the milk in stock
+	match = models.MatchRecipe(recipe, 2, pantry)
+	if !match.CanCook {
+		t.Errorf("Expected two servings to be cookable, got %+v", match)
+	}
+}
+
+func TestMatchRecipeSharedItem(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	// Both ingredients come out of the same eggs
+	recipe := models.CreateRecipe(groupID, "Glazed buns", "", 1, []models.RecipeIngredient{
+		{Name: "Eggs", Quantity: 2, Unit: "pc"},
+		{Name: "Egg", Quantity: 1, Unit: "pc"},
+	}, "", userID)
+	recipe.Validate()
+
+	pantry := models.IndexPantryItems([]models.PantryItem{
+		*models.CreatePantryItem(groupID, "Eggs", 2, "pc", "Eggs", time.Time{}, userID),
+	})
+
+	match := models.MatchRecipe(recipe, 1, pantry)
+	if match.CanCook || match.Ingredients[1].Available != 0 || match.Ingredients[1].Missing != 1 {
+		t.Errorf("Expected the second egg ingredient to find no stock left, got %+v", match)
+	}
+
+	pantry = models.IndexPantryItems([]models.PantryItem{
+		*models.CreatePantryItem(groupID, "Eggs", 0.25, "dozen", "Eggs", time.Time{}, userID),
+	})
+	if match := models.MatchRecipe(recipe, 1, pantry); !match.CanCook {
+		t.Errorf("Expected three eggs in stock to cover both ingredients, got %+v", match)
+	}
+}
+
+func TestMatchRecipeIncompatibleUnits(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	recipe := models.CreateRecipe(groupID, "Omelette", "", 1,
+		[]models.RecipeIngredient{{Name: "Eggs", Quantity: 100, Unit: "g"}}, "", userID)
+	recipe.Validate()
+
+	pantry := models.IndexPantryItems([]models.PantryItem{
+		*models.CreatePantryItem(groupID, "Egg", 12, "pc", "Eggs", time.Time{}, userID),
+	})
+
+	match := models.MatchRecipe(recipe, 1, pantry)
+	if match.CanCook {
+		t.Error("Expected a weight ingredient not to match stock counted in pieces")
+	}
+	if match.Ingredients[0].Note == "" {
+		t.Errorf("Expected a note about the pantry unit, got %+v", match.Ingredients[0])
+	}
+}
+
+func TestMatchRecipesOrder(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	recipes := []models.Recipe{
+		*models.CreateRecipe(groupID, "Toast", "", 1,
+			[]models.RecipeIngredient{{Name: "Bread", Quantity: 2, Unit: "pc"}, {Name: "Butter", Quantity: 10, Unit: "g"}}, "", userID),
+		*models.CreateRecipe(groupID, "Salad", "", 1,
+			[]models.RecipeIngredient{{Name: "Lettuce", Quantity: 1, Unit: "pc"}}, "", userID),
+		*models.CreateRecipe(groupID, "Butter toast", "", 1,
+			[]models.RecipeIngredient{{Name: "Bread", Quantity: 1, Unit: "pc"}}, "", userID),
+	}
+	items := []models.PantryItem{
+		*models.CreatePantryItem(groupID, "Bread", 4, "pc", "Bakery", time.Time{}, userID),
+	}
+
+	matches := models.MatchRecipes(recipes, items)
+	if len(matches) != 3 {
+		t.Fatalf("Expected 3 matches, got %d", len(matches))
+	}
+	if matches[0].RecipeName != "Butter toast" || !matches[0].CanCook {
+		t.Errorf("Expected the cookable recipe first, got %+v", matches[0])
+	}
+	if matches[1].RecipeName != "Salad" || matches[2].RecipeName != "Toast" {
+		t.Errorf("Expected ties to be ordered by name, got %s, %s", matches[1].RecipeName, matches[2].RecipeName)
+	}
+}
+
+func TestMealPlanDates(t *testing.T) {
+	// Wednesday evening
+	wednesday := time.Date(2024, time.March, 13, 19, 30, 0, 0, time.UTC)
+	if start := models.WeekStart(wednesday); !start.Equal(time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected week to start on Monday March 11, got %v", start)
+	}
+
+	sunday := time.Date(2024, time.March, 17, 8, 0, 0, 0, time.UTC)
+	if start := models.WeekStart(sunday); !start.Equal(time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected Sunday to belong to the week starting March 11, got %v", start)
+	}
+
+	if _, err := models.ParseMealType("brunch"); err == nil {
+		t.Error("Expected an unknown meal type to be rejected")
+	}
+	if mealType, _ := models.ParseMealType(""); mealType != models.MealTypeDinner {
+		t.Errorf("Expected dinner by default, got %s", mealType)
+	}
+
+	recipe := pancakeRecipe(primitive.NewObjectID(), primitive.NewObjectID())
+	entry := models.CreateMealPlanEntry(recipe.GroupID, recipe, wednesday, models.MealTypeBreakfast, 0, recipe.CreatedBy)
+	if entry.Servings != 4 || !entry.Date.Equal(models.PlanDay(wednesday)) || entry.IsCooked() {
+		t.Errorf("Unexpected meal plan entry %+v", entry)
+	}
+}
@@ -0,0 +1,103 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolveShelfLifePrecedence(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	// Built-in default for the category's usual location
+	estimate := models.ResolveShelfLife(nil, "Chicken thighs", "Meat", "", 0)
+	if estimate.Days != 3 || estimate.Source != models.ShelfLifeSourceDefault || estimate.StorageLocation != models.StorageLocationFridge {
+		t.Errorf("Expected 3 days in the fridge by default, got %+v", estimate)
+	}
+
+	// Catalog value beats the default in the usual location only
+	estimate = models.ResolveShelfLife(nil, "Chicken thighs", "Meat", models.StorageLocationFridge, 5)
+	if estimate.Days != 5 || estimate.Source != models.ShelfLifeSourceProduct {
+		t.Errorf("Expected the catalog shelf life, got %+v", estimate)
+	}
+	estimate = models.ResolveShelfLife(nil, "Chicken thighs", "Meat", models.StorageLocationFreezer, 5)
+	if estimate.Days != 180 || estimate.Source != models.ShelfLifeSourceDefault {
+		t.Errorf("Expected the freezer default, got %+v", estimate)
+	}
+
+	rules := []models.ShelfLifeRule{
+		*models.CreateShelfLifeRule(groupID, "meat", "", "", 2, userID),
+		*models.CreateShelfLifeRule(groupID, "Meat", "", models.StorageLocationFreezer, 120, userID),
+		*models.CreateShelfLifeRule(groupID, "", "chicken thighs", "", 4, userID),
+	}
+
+	// Group product rule beats everything
+	estimate = models.ResolveShelfLife(rules, "Chicken Thighs", "Meat", models.StorageLocationFridge, 5)
+	if estimate.Days != 4 || estimate.Source != models.ShelfLifeSourceProductRule {
+		t.Errorf("Expected the product rule, got %+v", estimate)
+	}
+
+	// Location-specific category rule beats the any-location one
+	estimate = models.ResolveShelfLife(rules, "Steak", "Meat", models.StorageLocationFreezer, 0)
+	if estimate.Days != 120 || estimate.Source != models.ShelfLifeSourceCategoryRule {
+		t.Errorf("Expected the freezer category rule, got %+v", estimate)
+	}
+	estimate = models.ResolveShelfLife(rules, "Steak", "Meat", models.StorageLocationFridge, 0)
+	if estimate.Days != 2 {
+		t.Errorf("Expected the any-location category rule, got %+v", estimate)
+	}
+
+	// Unknown categories have no estimate
+	if estimate := models.ResolveShelfLife(nil, "Thing", "Uncategorized", "", 0); estimate.Days != 0 {
+		t.Errorf("Expected no estimate for an unknown category, got %+v", estimate)
+	}
+}
+
+func TestPantryItemMoveToFreezer(t *testing.T) {
+	userID := primitive.NewObjectID()
+	now := time.Now()
+
+	meat := models.CreatePantryItem(primitive.NewObjectID(), "Ground beef", 1, "kg", "Meat", now.AddDate(0, 0, 3), userID)
+	meat.Batches[0].ExpirationEstimated = true
+	estimatedID := meat.Batches[0].ID
+	labelled := models.CreatePantryBatch(1, now.AddDate(0, 0, 2), userID)
+	meat.AddBatch(labelled)
+
+	meat.MoveTo(models.StorageLocationFreezer, nil, 0, now)
+
+	if meat.Location() != models.StorageLocationFreezer {
+		t.Errorf("Expected the item to be in the freezer, got %s", meat.Location())
+	}
+
+	// The estimated batch had its whole fridge life left, so it gets the whole freezer life
+	estimated, _ := meat.FindBatch(estimatedID)
+	expected := now.AddDate(0, 0, 180)
+	if diff := estimated.ExpirationDate.Sub(expected); diff > time.Minute || diff < -time.Minute {
+		t.Errorf("Expected the estimated expiry to move to %v, got %v", expected, estimated.ExpirationDate)
+	}
+
+	// Dates entered by hand stay as they are
+	if kept, _ := meat.FindBatch(labelled.ID); !kept.ExpirationDate.Equal(labelled.ExpirationDate) {
+		t.Errorf("Expected the labelled expiry to be kept, got %v", kept.ExpirationDate)
+	}
+}
+
+func TestRescaleExpiration(t *testing.T) {
+	now := time.Now()
+
+	// Half of a 4 day fridge life left becomes half of the 90 day freezer life
+	expiration := now.Add(48 * time.Hour)
+	rescaled := models.RescaleExpiration(expiration, now, 4, 90)
+	if expected := now.Add(45 * 24 * time.Hour); !rescaled.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, rescaled)
+	}
+
+	// Expired dates are left alone
+	expired := now.Add(-time.Hour)
+	if !models.RescaleExpiration(expired, now, 4, 90).Equal(expired) {
+		t.Errorf("Expected an expired date to be kept")
+	}
+}
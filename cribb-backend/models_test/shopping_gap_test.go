@@ -0,0 +1,111 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAggregateIngredients(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	pancakes := pancakeRecipe(groupID, userID)
+	pancakes.Validate()
+	omelette := models.CreateRecipe(groupID, "Omelette", "", 1,
+		[]models.RecipeIngredient{
+			{Name: "Eggs", Quantity: 3, Unit: "pc"},
+			{Name: "Milk", Quantity: 0.1, Unit: "l"},
+			{Name: "Egg", Quantity: 50, Unit: "g"}, // Weighed eggs can't be added to counted ones
+		}, "", userID)
+	omelette.Validate()
+
+	needs := models.AggregateIngredients([]models.PlannedRecipe{
+		{Recipe: pancakes, Servings: 8},
+		{Recipe: omelette, Servings: 1},
+	}, false)
+
+	if len(needs) != 4 {
+		t.Fatalf("Expected 4 needs without the optional blueberries, got %+v", needs)
+	}
+
+	byName := make(map[string]models.IngredientNeed)
+	for _, need := range needs {
+		byName[need.Name+"/"+need.Unit] = need
+	}
+
+	if milk := byName["Milk/ml"]; milk.Quantity != 700 || len(milk.Recipes) != 2 {
+		t.Errorf("Expected 700 ml of milk for both recipes, got %+v", milk)
+	}
+	if eggs := byName["Egg/pc"]; eggs.Quantity != 7 {
+		t.Errorf("Expected 7 eggs, got %+v", eggs)
+	}
+	if weighed := byName["Egg/g"]; weighed.Quantity != 50 {
+		t.Errorf("Expected weighed eggs to stay separate, got %+v", weighed)
+	}
+
+	withOptional := models.AggregateIngredients([]models.PlannedRecipe{{Recipe: pancakes}}, true)
+	if len(withOptional) != 4 {
+		t.Errorf("Expected the optional ingredient to be included, got %+v", withOptional)
+	}
+}
+
+func TestComputeShoppingGap(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	flour := models.CreatePantryItem(groupID, "Flour", 0.5, "kg", "Dry Goods", time.Time{}, userID)
+	flour.ID = primitive.NewObjectID()
+	milk := models.CreatePantryItem(groupID, "milk", 2, "l", "Dairy", time.Time{}, userID)
+	milk.ID = primitive.NewObjectID()
+	eggs := models.CreatePantryItem(groupID, "Eggs", 200, "g", "Eggs", time.Time{}, userID)
+	eggs.ID = primitive.NewObjectID()
+
+	needs := []models.IngredientNeed{
+		{Name: "Flour", Quantity: 800, Unit: "g", Recipes: []string{"Bread"}},
+		{Name: "Milk", Quantity: 500, Unit: "ml", Recipes: []string{"Pancakes"}},
+		{Name: "Egg", Quantity: 4, Unit: "pc", Recipes: []string{"Pancakes"}},
+		{Name: "Butter", Quantity: 50, Unit: "g", Recipes: []string{"Bread"}},
+	}
+
+	gaps := models.ComputeShoppingGap(needs, []models.PantryItem{*flour, *milk, *eggs})
+	if len(gaps) != 3 {
+		t.Fatalf("Expected flour, eggs and butter to be bought, got %+v", gaps)
+	}
+
+	// Sorted by category then name: butter has no pantry item and so no category
+	if gaps[0].Name != "Butter" || gaps[0].ToBuy != 50 || !gaps[0].ItemID.IsZero() {
+		t.Errorf("Expected all the butter to be bought, got %+v", gaps[0])
+	}
+	if gaps[1].Name != "Flour" || gaps[1].Available != 500 || gaps[1].ToBuy != 300 || gaps[1].ItemID != flour.ID {
+		t.Errorf("Expected 300 g of flour to be bought, got %+v", gaps[1])
+	}
+	// Eggs are stocked by weight and needed by count, so none count as available
+	if gaps[2].Name != "Eggs" || gaps[2].Available != 0 || gaps[2].ToBuy != 4 {
+		t.Errorf("Expected 4 eggs to be bought under the pantry's name, got %+v", gaps[2])
+	}
+}
+
+func TestComputeShoppingGapSharesStock(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	milk := models.CreatePantryItem(groupID, "Milk", 1, "l", "Dairy", time.Time{}, userID)
+	milk.ID = primitive.NewObjectID()
+
+	// The same milk can't cover two needs in different unit systems twice
+	needs := []models.IngredientNeed{
+		{Name: "Milk", Quantity: 800, Unit: "ml"},
+		{Name: "Milk", Quantity: 2, Unit: "cup"},
+	}
+
+	gaps := models.ComputeShoppingGap(needs, []models.PantryItem{*milk})
+	if len(gaps) != 1 {
+		t.Fatalf("Expected only the second need to be short, got %+v", gaps)
+	}
+	if gaps[0].Unit != "cup" || gaps[0].Available >= 1 {
+		t.Errorf("Expected less than a cup left after the first need, got %+v", gaps[0])
+	}
+}
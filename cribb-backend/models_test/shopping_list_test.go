@@ -0,0 +1,46 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestShoppingListNames(t *testing.T) {
+	valid := []string{"Costco run", "Party supplies", " Hardware store "}
+	for _, name := range valid {
+		if err := models.ValidateShoppingListName(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "   ", "shopping CART", string(make([]byte, 61))}
+	for _, name := range invalid {
+		if err := models.ValidateShoppingListName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+
+	list := models.CreateShoppingList(primitive.NewObjectID(), " Costco run ", primitive.NewObjectID())
+	if list.Name != "Costco run" || list.IsArchived {
+		t.Errorf("Unexpected list %+v", list)
+	}
+}
+
+func TestShoppingListActivity(t *testing.T) {
+	list := models.CreateShoppingList(primitive.NewObjectID(), "Party supplies", primitive.NewObjectID())
+	list.ID = primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	activity := models.CreateShoppingListActivity(list, userID, "Alice", models.CartActivityTypeListArchive, "Archived list Party supplies")
+	if activity.ListID != list.ID || activity.ItemID != list.ID || activity.ListName != "Party supplies" {
+		t.Errorf("Expected the activity to point at the list, got %+v", activity)
+	}
+	if activity.GroupID != list.GroupID || activity.Action != models.CartActivityTypeListArchive {
+		t.Errorf("Unexpected activity %+v", activity)
+	}
+	if activity.ExpiresAt.IsZero() {
+		t.Error("Expected list activity to expire like item activity")
+	}
+}
@@ -0,0 +1,129 @@
+package models_test
+
+import (
+	"cribb-backend/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func listItem(name, category string, quantity float64) models.GroupListItem {
+	item := models.CreateGroupListItem(primitive.NewObjectID(), name, "", category)
+	item.ID = primitive.NewObjectID()
+	item.AddRequest(primitive.NewObjectID(), "Member", quantity, "", time.Now())
+	return *item
+}
+
+func TestStoreAisles(t *testing.T) {
+	store := models.CreateStore(primitive.NewObjectID(), "Corner Market", []models.Aisle{
+		{Name: "Produce", Categories: []string{" Fruit", "vegetables"}},
+		{Name: "Dairy", Categories: []string{"dairy"}},
+	}, primitive.NewObjectID())
+	if err := store.Validate(); err != nil {
+		t.Fatalf("Expected store to be valid, got %v", err)
+	}
+
+	if aisle, order := store.AisleFor("FRUIT"); aisle != "Produce" || order != 0 {
+		t.Errorf("Expected fruit in the first aisle, got %s %d", aisle, order)
+	}
+	if aisle, order := store.AisleFor("cleaning"); aisle != models.UnsortedAisle || order != 2 {
+		t.Errorf("Expected unknown categories last, got %s %d", aisle, order)
+	}
+
+	store.Aisles = append(store.Aisles, models.Aisle{Name: "Cheese", Categories: []string{"dairy"}})
+	if err := store.Validate(); err == nil {
+		t.Error("Expected a category in two aisles to be rejected")
+	}
+}
+
+func TestShoppingTripFollowsStoreLayout(t *testing.T) {
+	store := models.CreateStore(primitive.NewObjectID(), "Market", []models.Aisle{
+		{Name: "Produce", Categories: []string{"fruit"}},
+		{Name: "Dairy", Categories: []string{"dairy"}},
+	}, primitive.NewObjectID())
+
+	items := []models.GroupListItem{
+		listItem("Soap", "cleaning", 1),
+		listItem("Milk", "dairy", 2),
+		listItem("Bananas", "fruit", 6),
+		listItem("Apples", "fruit", 4),
+	}
+	trip := models.CreateShoppingTrip(store.GroupID, primitive.NewObjectID(), "Alice", store, items)
+
+	order := make([]string, 0, len(trip.Items))
+	for _, item := range trip.Items {
+		order = append(order, item.ItemName)
+	}
+	expected := []string{"Apples", "Bananas", "Milk", "Soap"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, order)
+		}
+	}
+	if trip.StoreName != "Market" || trip.Status != models.TripStatusActive {
+		t.Errorf("Unexpected trip %+v", trip)
+	}
+}
+
+func TestShoppingTripCheckOffAndSummary(t *testing.T) {
+	shopper := primitive.NewObjectID()
+	items := []models.GroupListItem{
+		listItem("Milk", "dairy", 2),
+		listItem("Bread", "bakery", 1),
+		listItem("Butter", "dairy", 1),
+		listItem("Eggs", "dairy", 12),
+	}
+	trip := models.CreateShoppingTrip(primitive.NewObjectID(), shopper, "Alice", nil, items)
+	now := time.Now()
+
+	checked, err := trip.CheckOff(items[0].ID, models.TripItemStatusBought, 0, "", shopper, now)
+	if err != nil || checked.BoughtQuantity != 2 {
+		t.Fatalf("Expected the listed quantity to be bought, got %v %+v", err, checked)
+	}
+	if _, err := trip.CheckOff(items[2].ID, models.TripItemStatusSubstituted, 0, "", shopper, now); err == nil {
+		t.Error("Expected a substitution without a substitute to be rejected")
+	}
+	if _, err := trip.CheckOff(items[2].ID, models.TripItemStatusSubstituted, 1, "Margarine", shopper, now); err != nil {
+		t.Fatalf("CheckOff failed: %v", err)
+	}
+	trip.CheckOff(items[1].ID, models.TripItemStatusSkipped, 0, "", shopper, now)
+	if _, err := trip.CheckOff(primitive.NewObjectID(), models.TripItemStatusBought, 0, "", shopper, now); err == nil {
+		t.Error("Expected an item outside the trip to be rejected")
+	}
+
+	summary, err := trip.Complete(now)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if len(summary.Bought) != 1 || len(summary.Substituted) != 1 || len(summary.Skipped) != 2 {
+		t.Errorf("Expected unchecked eggs to count as skipped, got %+v", summary)
+	}
+	if _, err := trip.CheckOff(items[3].ID, models.TripItemStatusBought, 0, "", shopper, now); err == nil {
+		t.Error("Expected check-off after completion to be rejected")
+	}
+}
+
+func TestGroupListFulfil(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now()
+
+	item := models.CreateGroupListItem(primitive.NewObjectID(), "Eggs", "", "dairy")
+	item.AddRequest(alice, "Alice", 6, "", now)
+	item.AddRequest(bob, "Bob", 12, "", now)
+	item.Claim(alice, "Alice", now)
+
+	// A partial purchase covers the oldest request first
+	item.Fulfil(10, now)
+	if len(item.Requests) != 1 || item.Requests[0].UserID != bob || item.Quantity != 8 {
+		t.Errorf("Expected 8 left for Bob, got %+v", item.Requests)
+	}
+	if item.IsClaimed() {
+		t.Error("Expected the claim to be released")
+	}
+
+	item.Fulfil(8, now)
+	if len(item.Requests) != 0 {
+		t.Errorf("Expected everything to be fulfilled, got %+v", item.Requests)
+	}
+}
@@ -0,0 +1,218 @@
+// notifications/notifications.go
+package notifications
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/models"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds every user's inbox
+const Collection = "notifications"
+
+// PreferencesCollection holds each user's notification preferences
+const PreferencesCollection = "notification_preferences"
+
+// OutboxCollection holds messages waiting to go out on outbound channels
+const OutboxCollection = "notification_outbox"
+
+// PushSubscriptionsCollection holds the browsers registered for Web Push
+const PushSubscriptionsCollection = "push_subscriptions"
+
+// outboundChannels are the channels that go through the outbox
+var outboundChannels = []models.NotificationChannel{
+	models.NotificationChannelPush,
+	models.NotificationChannelEmail,
+	models.NotificationChannelSMS,
+	models.NotificationChannelWebhook,
+}
+
+// Recipients returns who a notification is for: its addressee, or every
+// group member except the one who caused it
+func Recipients(ctx context.Context, n *models.Notification) ([]models.User, error) {
+	filter := bson.M{"group_id": n.GroupID, "_id": bson.M{"$ne": n.ActorID}}
+	if !n.UserID.IsZero() {
+		filter = bson.M{"_id": n.UserID}
+	}
+
+	cursor, err := config.DB.Collection("users").Find(
+		ctx,
+		filter,
+		options.Find().SetProjection(bson.M{"_id": 1, "name": 1, "phone_number": 1, "group_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var members []models.User
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// LoadPreferences returns the notification preferences of each user,
+// using the defaults for users who haven't saved any
+func LoadPreferences(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID]*models.NotificationPreferences, error) {
+	preferences := make(map[primitive.ObjectID]*models.NotificationPreferences, len(userIDs))
+	for _, userID := range userIDs {
+		preferences[userID] = models.DefaultNotificationPreferences(userID)
+	}
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+
+	cursor, err := config.DB.Collection(PreferencesCollection).Find(ctx, bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var saved []models.NotificationPreferences
+	if err = cursor.All(ctx, &saved); err != nil {
+		return nil, err
+	}
+	for i := range saved {
+		// Preferences saved before digests existed get the default schedule
+		if saved[i].Digest.Frequency == "" {
+			saved[i].Digest = models.DefaultDigestSettings()
+		}
+		preferences[saved[i].UserID] = &saved[i]
+	}
+	return preferences, nil
+}
+
+// Targets returns where a user's messages go on an outbound channel.
+// Push goes to every registered browser; users without an address get none.
+func Targets(ctx context.Context, user models.User, prefs *models.NotificationPreferences, channel models.NotificationChannel) ([]models.DeliveryTarget, error) {
+	switch channel {
+	case models.NotificationChannelEmail:
+		if prefs.Email != "" {
+			return []models.DeliveryTarget{{Address: prefs.Email}}, nil
+		}
+	case models.NotificationChannelSMS:
+		if user.PhoneNumber != "" {
+			return []models.DeliveryTarget{{Address: user.PhoneNumber}}, nil
+		}
+	case models.NotificationChannelWebhook:
+		if prefs.WebhookURL != "" {
+			return []models.DeliveryTarget{{Address: prefs.WebhookURL}}, nil
+		}
+	case models.NotificationChannelPush:
+		cursor, err := config.DB.Collection(PushSubscriptionsCollection).Find(ctx, bson.M{"user_id": user.ID})
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+
+		var subscriptions []models.PushSubscription
+		if err = cursor.All(ctx, &subscriptions); err != nil {
+			return nil, err
+		}
+		targets := make([]models.DeliveryTarget, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			targets = append(targets, subscription.Target())
+		}
+		return targets, nil
+	}
+	return nil, nil
+}
+
+// Send stores a notification in its recipients' inboxes and queues it on
+// the outbound channels they want it on immediately, following their
+// preferences. Members who turned the type off in the inbox don't see it
+// there, and a notification none of its recipients want at all isn't stored.
+// Types set to digest are summarized by the digest job instead.
+func Send(ctx context.Context, n *models.Notification) error {
+	recipients, err := Recipients(ctx, n)
+	if err != nil {
+		return err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(recipients))
+	for _, recipient := range recipients {
+		userIDs = append(userIDs, recipient.ID)
+	}
+	preferences, err := LoadPreferences(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	wanted := false
+	for _, userID := range userIDs {
+		prefs := preferences[userID]
+		if prefs.Wants(n.Type) {
+			wanted = true
+		}
+		if prefs.ModeFor(n.Type, models.NotificationChannelInApp) == models.DeliveryOff {
+			n.HiddenFrom = append(n.HiddenFrom, userID)
+		}
+	}
+	if !wanted {
+		return nil
+	}
+
+	result, err := config.DB.Collection(Collection).InsertOne(ctx, n)
+	if err != nil {
+		return err
+	}
+	n.ID = result.InsertedID.(primitive.ObjectID)
+
+	var outbox []interface{}
+	for _, recipient := range recipients {
+		prefs := preferences[recipient.ID]
+		sendAt := prefs.DeliverAt(n.CreatedAt)
+		for _, channel := range outboundChannels {
+			if prefs.ModeFor(n.Type, channel) != models.DeliveryImmediate {
+				continue
+			}
+			targets, err := Targets(ctx, recipient, prefs, channel)
+			if err != nil {
+				return err
+			}
+			for _, target := range targets {
+				message := models.CreateOutboxMessage(recipient.ID, channel, target, n.Title(), n.Message, sendAt)
+				message.NotificationID = n.ID
+				outbox = append(outbox, message)
+			}
+		}
+	}
+	if len(outbox) > 0 {
+		if _, err := config.DB.Collection(OutboxCollection).InsertMany(ctx, outbox); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notify sends a notification in the background, logging failures. Callers
+// use it where a missing notification shouldn't fail the change itself.
+func Notify(n *models.Notification) {
+	go func() {
+		if err := Send(context.Background(), n); err != nil {
+			log.Printf("Failed to send %s notification: %v", n.Type, err)
+		}
+	}()
+}
+
+// InboxFilter matches the notifications a user should see in their group
+func InboxFilter(groupID, userID primitive.ObjectID) bson.M {
+	return bson.M{
+		"group_id":    groupID,
+		"user_id":     bson.M{"$in": bson.A{nil, userID}},
+		"actor_id":    bson.M{"$ne": userID},
+		"hidden_from": bson.M{"$ne": userID},
+	}
+}
+
+// UnreadFilter narrows an inbox filter to notifications the user hasn't read
+func UnreadFilter(groupID, userID primitive.ObjectID) bson.M {
+	filter := InboxFilter(groupID, userID)
+	filter["read_by"] = bson.M{"$ne": userID}
+	return filter
+}
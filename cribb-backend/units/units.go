@@ -0,0 +1,231 @@
+// units/units.go
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dimension is the physical quantity a unit measures
+type Dimension string
+
+const (
+	// Mass units convert through grams
+	Mass Dimension = "mass"
+
+	// Volume units convert through millilitres
+	Volume Dimension = "volume"
+
+	// Count units convert through single pieces
+	Count Dimension = "count"
+
+	// Container units hold different amounts of different things, so each
+	// only converts to itself
+	Container Dimension = "container"
+)
+
+var (
+	// ErrUnknownUnit is returned for units that aren't in the registry
+	ErrUnknownUnit = errors.New("unknown unit")
+
+	// ErrIncompatibleUnits is returned when converting between different dimensions
+	ErrIncompatibleUnits = errors.New("incompatible units")
+)
+
+// Unit describes a unit of measure
+type Unit struct {
+	Symbol    string    `json:"symbol"`
+	Name      string    `json:"name"`
+	Dimension Dimension `json:"dimension"`
+	Factor    float64   `json:"factor"` // Size of one unit in the dimension's base unit
+}
+
+// registry holds every supported unit with the spellings it's known by
+var registry = []struct {
+	Unit
+	aliases []string
+}{
+	// Mass
+	{Unit{"mg", "milligram", Mass, 0.001}, []string{"milligram", "milligrams", "milligramme", "milligrammes"}},
+	{Unit{"g", "gram", Mass, 1}, []string{"gram", "grams", "gramme", "grammes", "gr", "grs"}},
+	{Unit{"kg", "kilogram", Mass, 1000}, []string{"kilogram", "kilograms", "kilogramme", "kilogrammes", "kilo", "kilos", "kgs"}},
+	{Unit{"oz", "ounce", Mass, 28.349523125}, []string{"ounce", "ounces", "ozs"}},
+	{Unit{"lb", "pound", Mass, 453.59237}, []string{"pound", "pounds", "lbs"}},
+
+	// Volume
+	{Unit{"ml", "millilitre", Volume, 1}, []string{"milliliter", "milliliters", "millilitre", "millilitres", "mls", "cc"}},
+	{Unit{"cl", "centilitre", Volume, 10}, []string{"centiliter", "centiliters", "centilitre", "centilitres"}},
+	{Unit{"dl", "decilitre", Volume, 100}, []string{"deciliter", "deciliters", "decilitre", "decilitres"}},
+	{Unit{"l", "litre", Volume, 1000}, []string{"liter", "liters", "litre", "litres", "ltr", "ltrs", "lt"}},
+	{Unit{"tsp", "teaspoon", Volume, 4.92892159375}, []string{"teaspoon", "teaspoons", "tsps"}},
+	{Unit{"tbsp", "tablespoon", Volume, 14.78676478125}, []string{"tablespoon", "tablespoons", "tbsps", "tbs", "tbl"}},
+	{Unit{"fl oz", "fluid ounce", Volume, 29.5735295625}, []string{"fluid ounce", "fluid ounces", "floz", "fl. oz", "fl.oz"}},
+	{Unit{"cup", "cup", Volume, 236.5882365}, []string{"cups"}},
+	{Unit{"pt", "pint", Volume, 473.176473}, []string{"pint", "pints", "pts"}},
+	{Unit{"qt", "quart", Volume, 946.352946}, []string{"quart", "quarts", "qts"}},
+	{Unit{"gal", "gallon", Volume, 3785.411784}, []string{"gallon", "gallons", "gals"}},
+
+	// Count
+	{Unit{"pc", "piece", Count, 1}, []string{"piece", "pieces", "pcs", "each", "ea", "item", "items", "unit", "units"}},
+	{Unit{"dozen", "dozen", Count, 12}, []string{"dozens", "doz", "dz"}},
+
+	// Container
+	{Unit{"can", "can", Container, 1}, []string{"cans", "tin", "tins"}},
+	{Unit{"bottle", "bottle", Container, 1}, []string{"bottles", "btl"}},
+	{Unit{"jar", "jar", Container, 1}, []string{"jars"}},
+	{Unit{"box", "box", Container, 1}, []string{"boxes"}},
+	{Unit{"pack", "pack", Container, 1}, []string{"packs", "packet", "packets", "pkg", "package", "packages"}},
+	{Unit{"bag", "bag", Container, 1}, []string{"bags"}},
+	{Unit{"carton", "carton", Container, 1}, []string{"cartons"}},
+	{Unit{"loaf", "loaf", Container, 1}, []string{"loaves"}},
+}
+
+// lookup maps every normalized spelling to its unit
+var lookup = func() map[string]Unit {
+	m := make(map[string]Unit)
+	for _, entry := range registry {
+		m[entry.Symbol] = entry.Unit
+		for _, alias := range entry.aliases {
+			m[alias] = entry.Unit
+		}
+	}
+	return m
+}()
+
+// baseUnits are the symbols quantities are converted through for each
+// dimension. Containers have none; each is its own base.
+var baseUnits = map[Dimension]string{
+	Mass:   "g",
+	Volume: "ml",
+	Count:  "pc",
+}
+
+// clean lowercases a unit name and strips punctuation and extra spaces
+func clean(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimSuffix(name, ".")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Lookup finds a unit by any of its spellings
+func Lookup(name string) (Unit, bool) {
+	unit, ok := lookup[clean(name)]
+	return unit, ok
+}
+
+// Normalize returns the canonical symbol for a unit name
+func Normalize(name string) (string, error) {
+	unit, ok := Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownUnit, name)
+	}
+	return unit.Symbol, nil
+}
+
+// base returns the symbol the unit's quantities are converted through
+func (u Unit) base() string {
+	if u.Dimension == Container {
+		return u.Symbol
+	}
+	return baseUnits[u.Dimension]
+}
+
+// Compatible checks if quantities in the two units can be converted into each other
+func Compatible(from, to string) bool {
+	fromUnit, ok := Lookup(from)
+	if !ok {
+		return false
+	}
+	toUnit, ok := Lookup(to)
+	if !ok {
+		return false
+	}
+	return fromUnit.base() == toUnit.base()
+}
+
+// Convert converts a quantity from one unit to another of the same dimension
+func Convert(quantity float64, from, to string) (float64, error) {
+	fromUnit, ok := Lookup(from)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownUnit, from)
+	}
+	toUnit, ok := Lookup(to)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownUnit, to)
+	}
+	if fromUnit.base() != toUnit.base() {
+		return 0, fmt.Errorf("%w: cannot convert %s (%s) to %s (%s)",
+			ErrIncompatibleUnits, fromUnit.Symbol, fromUnit.Dimension, toUnit.Symbol, toUnit.Dimension)
+	}
+	if fromUnit.Symbol == toUnit.Symbol {
+		return quantity, nil
+	}
+	return Round(quantity * fromUnit.Factor / toUnit.Factor), nil
+}
+
+// ToBase converts a quantity into its dimension's base unit (g, ml or pc).
+// Container quantities stay in their own unit.
+func ToBase(quantity float64, unit string) (float64, string, error) {
+	u, ok := Lookup(unit)
+	if !ok {
+		return 0, "", fmt.Errorf("%w: %q", ErrUnknownUnit, unit)
+	}
+	return Round(quantity * u.Factor), u.base(), nil
+}
+
+// BaseUnit returns the symbol quantities of a dimension are converted through,
+// or an empty string for containers
+func BaseUnit(dimension Dimension) string {
+	return baseUnits[dimension]
+}
+
+// Parse splits a quantity string like "500 g" or "1.5kg" into its amount and unit symbol
+func Parse(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return !(unicode.IsDigit(r) || r == '.' || r == ',')
+	})
+	if split <= 0 {
+		return 0, "", fmt.Errorf("invalid quantity %q", s)
+	}
+
+	quantity, err := strconv.ParseFloat(strings.ReplaceAll(s[:split], ",", "."), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid quantity %q", s)
+	}
+
+	symbol, err := Normalize(s[split:])
+	if err != nil {
+		return 0, "", err
+	}
+	return quantity, symbol, nil
+}
+
+// Round trims floating point noise from converted quantities
+func Round(quantity float64) float64 {
+	return math.Round(quantity*1e6) / 1e6
+}
+
+// Symbols returns the canonical symbols of all supported units, sorted
+func Symbols() []string {
+	symbols := make([]string, 0, len(registry))
+	for _, entry := range registry {
+		symbols = append(symbols, entry.Symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// All returns every supported unit grouped in registry order
+func All() []Unit {
+	all := make([]Unit, 0, len(registry))
+	for _, entry := range registry {
+		all = append(all, entry.Unit)
+	}
+	return all
+}
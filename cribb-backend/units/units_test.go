@@ -0,0 +1,121 @@
+package units_test
+
+import (
+	"cribb-backend/units"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"grams":        "g",
+		" KG ":         "kg",
+		"Tablespoons":  "tbsp",
+		"fl. oz":       "fl oz",
+		"fluid ounces": "fl oz",
+		"oz.":          "oz",
+		"Litres":       "l",
+		"each":         "pc",
+		"gallons":      "gal",
+	}
+
+	for input, expected := range cases {
+		symbol, err := units.Normalize(input)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned error: %v", input, err)
+			continue
+		}
+		if symbol != expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", input, symbol, expected)
+		}
+	}
+
+	if _, err := units.Normalize("handful"); !errors.Is(err, units.ErrUnknownUnit) {
+		t.Errorf("Expected ErrUnknownUnit for an unknown unit, got %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		quantity float64
+		from     string
+		to       string
+		expected float64
+	}{
+		{200, "g", "kg", 0.2},
+		{1, "kg", "g", 1000},
+		{1, "lb", "oz", 16},
+		{3, "tsp", "tbsp", 1},
+		{1, "cup", "ml", 236.588237},
+		{1, "gal", "qt", 4},
+		{2, "dozen", "pc", 24},
+		{5, "g", "grams", 5},
+	}
+
+	for _, c := range cases {
+		got, err := units.Convert(c.quantity, c.from, c.to)
+		if err != nil {
+			t.Errorf("Convert(%v, %q, %q) returned error: %v", c.quantity, c.from, c.to, err)
+			continue
+		}
+		if math.Abs(got-c.expected) > 1e-6 {
+			t.Errorf("Convert(%v, %q, %q) = %v, expected %v", c.quantity, c.from, c.to, got, c.expected)
+		}
+	}
+}
+
+func TestConvertIncompatible(t *testing.T) {
+	if _, err := units.Convert(1, "kg", "l"); !errors.Is(err, units.ErrIncompatibleUnits) {
+		t.Errorf("Expected ErrIncompatibleUnits converting mass to volume, got %v", err)
+	}
+	if units.Compatible("g", "ml") {
+		t.Errorf("Expected grams and millilitres to be incompatible")
+	}
+	if !units.Compatible("cups", "tbsp") {
+		t.Errorf("Expected cups and tablespoons to be compatible")
+	}
+}
+
+func TestContainersOnlyConvertToThemselves(t *testing.T) {
+	if _, err := units.Convert(1, "bag", "can"); !errors.Is(err, units.ErrIncompatibleUnits) {
+		t.Errorf("Expected ErrIncompatibleUnits converting bags to cans, got %v", err)
+	}
+	if _, err := units.Convert(2, "cans", "pc"); !errors.Is(err, units.ErrIncompatibleUnits) {
+		t.Errorf("Expected ErrIncompatibleUnits converting cans to pieces, got %v", err)
+	}
+	if got, err := units.Convert(2, "tins", "can"); err != nil || got != 2 {
+		t.Errorf("Convert(2, \"tins\", \"can\") = %v %v", got, err)
+	}
+	if quantity, base, err := units.ToBase(3, "jars"); err != nil || quantity != 3 || base != "jar" {
+		t.Errorf("ToBase(3, \"jars\") = %v %q %v", quantity, base, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	quantity, unit, err := units.Parse("1.5kg")
+	if err != nil || quantity != 1.5 || unit != "kg" {
+		t.Errorf("Parse(\"1.5kg\") = %v %q %v", quantity, unit, err)
+	}
+
+	quantity, unit, err = units.Parse("500 g")
+	if err != nil || quantity != 500 || unit != "g" {
+		t.Errorf("Parse(\"500 g\") = %v %q %v", quantity, unit, err)
+	}
+
+	quantity, unit, err = units.Parse("33,5 cl")
+	if err != nil || quantity != 33.5 || unit != "cl" {
+		t.Errorf("Parse(\"33,5 cl\") = %v %q %v", quantity, unit, err)
+	}
+
+	if _, _, err := units.Parse("some flour"); err == nil {
+		t.Errorf("Expected error parsing a quantity without a number")
+	}
+}
+
+func TestToBase(t *testing.T) {
+	quantity, base, err := units.ToBase(2, "l")
+	if err != nil || quantity != 2000 || base != "ml" {
+		t.Errorf("ToBase(2, \"l\") = %v %q %v", quantity, base, err)
+	}
+}
@@ -0,0 +1,101 @@
+// Package webhooks posts group events to the URLs group admins register.
+package webhooks
+
+import (
+	"context"
+	"cribb-backend/config"
+	"cribb-backend/delivery"
+	"cribb-backend/events"
+	"cribb-backend/models"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// Collection holds the groups' registered webhooks
+	Collection = "group_webhooks"
+
+	// DeliveriesCollection holds queued deliveries and their logs
+	DeliveriesCollection = "webhook_deliveries"
+)
+
+// Enqueue queues a delivery of the event for each of its group's webhooks
+// subscribed to it. It's registered as an event bus hook, so it encodes the
+// payload right away and leaves the database work to a goroutine.
+func Enqueue(event events.Event) {
+	if !event.Type.IsKnown() {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding %s event for webhooks: %v", event.Type, err)
+		return
+	}
+
+	go func() {
+		if err := enqueue(context.Background(), event, payload); err != nil {
+			log.Printf("Error queueing %s webhooks: %v", event.Type, err)
+		}
+	}()
+}
+
+// enqueue stores a delivery per subscribed webhook
+func enqueue(ctx context.Context, event events.Event, payload []byte) error {
+	cursor, err := config.DB.Collection(Collection).Find(ctx, bson.M{
+		"group_id": event.GroupID,
+		"active":   true,
+		"events":   string(event.Type),
+	})
+	if err != nil {
+		return err
+	}
+	var hooks []models.GroupWebhook
+	if err = cursor.All(ctx, &hooks); err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	deliveries := make([]interface{}, 0, len(hooks))
+	for i := range hooks {
+		deliveries = append(deliveries, models.CreateWebhookDelivery(&hooks[i], string(event.Type), payload))
+	}
+	_, err = config.DB.Collection(DeliveriesCollection).InsertMany(ctx, deliveries)
+	return err
+}
+
+// TestPayload builds the event sent by a test delivery
+func TestPayload(webhook *models.GroupWebhook, user models.User) ([]byte, error) {
+	return json.Marshal(events.Event{
+		Type:    events.WebhookTest,
+		GroupID: webhook.GroupID,
+		ActorID: user.ID,
+		Data: map[string]string{
+			"webhook_id": webhook.ID.Hex(),
+			"message":    user.Name + " sent a test delivery",
+		},
+		Time: time.Now(),
+	})
+}
+
+// Attempt posts a delivery to its webhook and records the result on the delivery
+func Attempt(ctx context.Context, sender *delivery.SignedWebhook, webhook *models.GroupWebhook, d *models.WebhookDelivery) error {
+	started := time.Now()
+	statusCode, err := sender.Post(ctx, delivery.SignedRequest{
+		URL:        webhook.URL,
+		Secret:     webhook.Secret,
+		Event:      d.EventType,
+		DeliveryID: d.ID.Hex(),
+		Payload:    []byte(d.Payload),
+	})
+	d.RecordAttempt(models.WebhookAttempt{
+		At:         started,
+		StatusCode: statusCode,
+		DurationMs: time.Since(started).Milliseconds(),
+	}, err, delivery.IsPermanent(err))
+	return err
+}